@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// soundEffectPattern matches bracketed or parenthesized SDH annotations such as
+// "[door slams]", "(LAUGHS)" or "[NARRATOR]"
+var soundEffectPattern = regexp.MustCompile(`\[[^\]]*\]|\([^)]*\)`)
+
+// AnnotationLanguageMismatchWarning reports SDH annotations whose language differs
+// from the dialogue's expected language, typically English sound effects and speaker
+// labels left in a localized track
+type AnnotationLanguageMismatchWarning struct {
+	Type         string        `json:"type"`
+	Rule         string        `json:"rule"`
+	Severity     string        `json:"severity"`
+	DetectedLang string        `json:"detected_language"`
+	ExpectedLang string        `json:"expected_language"`
+	Annotations  int           `json:"annotations"`
+	Description  string        `json:"description"`
+	SuggestedFix *SuggestedFix `json:"suggested_fix,omitempty"`
+}
+
+// splitAnnotations separates a cue's SDH annotations, bracketed sound effects and
+// upper-case speaker labels, from its dialogue. Annotations are returned without
+// brackets, colons or markup.
+func splitAnnotations(text string) (dialogue string, annotations []string) {
+	add := func(annotation string) {
+		if annotation = strings.TrimSpace(stripMarkup(annotation)); annotation != "" {
+			annotations = append(annotations, annotation)
+		}
+	}
+	text = soundEffectPattern.ReplaceAllStringFunc(text, func(match string) string {
+		add(match[1 : len(match)-1])
+		return " "
+	})
+	text = speakerPrefixPattern.ReplaceAllStringFunc(text, func(match string) string {
+		add(speakerPrefixPattern.FindStringSubmatch(match)[1])
+		return " "
+	})
+	return strings.Join(strings.Fields(text), " "), annotations
+}
+
+// validateAnnotationLanguage sends the annotations of all cues to the detector as
+// one sample and reports them when they are in another language than expected.
+// Files with too few annotation words to detect, and detector failures, which the
+// dialogue check already reports, are skipped.
+func (cv *CaptionValidator) validateAnnotationLanguage(ctx context.Context, captions []Caption) *AnnotationLanguageMismatchWarning {
+	var annotations []string
+	var cues []int
+	for i, caption := range captions {
+		_, found := splitAnnotations(withoutForeignSpans(caption.Text, cv.expectedLanguage))
+		if len(found) > 0 {
+			annotations = append(annotations, found...)
+			cues = append(cues, i+1)
+		}
+	}
+	text := redactText(sampleText(annotations, cv.sampleChars), cv.redactMode)
+	if len(textWords(text)) < minLanguageSpanWords {
+		return nil
+	}
+	detected, err := cv.detectLanguage(ctx, text)
+	if err != nil || sameBaseLanguage(detected, cv.expectedLanguage) {
+		return nil
+	}
+
+	return &AnnotationLanguageMismatchWarning{
+		Type:         "annotation_language_mismatch",
+		Severity:     SeverityWarning,
+		DetectedLang: detected,
+		ExpectedLang: cv.expectedLanguage,
+		Annotations:  len(annotations),
+		Description:  fmt.Sprintf("SDH annotations in %d cue(s) were detected as '%s', not the expected '%s'", len(cues), detected, cv.expectedLanguage),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixTranslateSDH,
+			Cues:        cues,
+			Language:    cv.expectedLanguage,
+			Description: fmt.Sprintf("Translate the sound effects and speaker labels in %s to %s", cueRange(cues), cv.expectedLanguage),
+		},
+	}
+}
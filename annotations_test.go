@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitAnnotations(t *testing.T) {
+	dialogue, annotations := splitAnnotations("[door slams] - NARRATOR: <i>Nadie sabía</i> (laughs) la verdad")
+	if dialogue != "<i>Nadie sabía</i> la verdad" {
+		t.Errorf("unexpected dialogue %q", dialogue)
+	}
+	if !slices.Equal(annotations, []string{"door slams", "laughs", "NARRATOR"}) {
+		t.Errorf("unexpected annotations %q", annotations)
+	}
+	if dialogue, annotations = splitAnnotations("Sin anotaciones"); dialogue != "Sin anotaciones" || annotations != nil {
+		t.Errorf("expected plain dialogue unchanged, got %q %q", dialogue, annotations)
+	}
+}
+
+func TestValidateAnnotationLanguage(t *testing.T) {
+	// The detector answers English only for the English sound effects
+	var sent []string
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sent = append(sent, string(body))
+		lang := "es-ES"
+		if strings.Contains(string(body), "door") {
+			lang = "en-US"
+		}
+		json.NewEncoder(w).Encode(map[string]string{"lang": lang})
+	}))
+	defer detector.Close()
+
+	cv := NewCaptionValidator(detector.URL)
+	cv.expectedLanguage = "es-ES"
+	cv.sdhCheck = true
+	captions := []Caption{
+		{StartTime: 0, EndTime: 2, Text: "[door slams] ¿Quién está ahí?"},
+		{StartTime: 2, EndTime: 4, Text: "Nadie, vuelve a dormir."},
+		{StartTime: 4, EndTime: 6, Text: "(footsteps approaching) No puedo dormir."},
+	}
+
+	if err := cv.validateLanguage(context.Background(), captions); err != nil || strings.Contains(sent[0], "door") {
+		t.Errorf("expected dialogue detected without annotations, sent %q: %+v", sent[0], err)
+	}
+	warning := cv.validateAnnotationLanguage(context.Background(), captions)
+	if warning == nil {
+		t.Fatal("expected an annotation_language_mismatch warning")
+	}
+	if warning.DetectedLang != "en-US" || warning.Annotations != 2 || !slices.Equal(warning.SuggestedFix.Cues, []int{1, 3}) || warning.SuggestedFix.Action != FixTranslateSDH {
+		t.Errorf("unexpected warning: %+v %+v", warning, warning.SuggestedFix)
+	}
+
+	// Translated annotations match the dialogue
+	captions[0].Text = "[portazo] ¿Quién está ahí?"
+	captions[2].Text = "(pasos que se acercan) No puedo dormir."
+	if warning := cv.validateAnnotationLanguage(context.Background(), captions); warning != nil {
+		t.Errorf("expected no warning for translated annotations, got %+v", warning)
+	}
+	// A single short annotation is too little to detect
+	if warning := cv.validateAnnotationLanguage(context.Background(), []Caption{{StartTime: 0, EndTime: 2, Text: "[door] Hola"}}); warning != nil {
+		t.Errorf("expected no warning for one short annotation, got %+v", warning)
+	}
+}
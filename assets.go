@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Asset is one entry of an -asset_list: the run parameters a MAM export gives the
+// caption files its pattern matches
+type Asset struct {
+	Pattern  string  `json:"pattern"` // glob matched against the end of the file's path
+	ID       string  `json:"asset_id,omitempty"`
+	Duration float64 `json:"duration,omitempty"` // seconds; the window ends here
+	Language string  `json:"language,omitempty"` // expected language, instead of -language
+	Coverage float64 `json:"coverage,omitempty"` // required coverage, instead of -coverage
+}
+
+// AssetList is an -asset_list in the order it was written; the first match wins
+type AssetList []Asset
+
+// assetColumns maps the column, attribute and element names MAM exports use to
+// the asset field they fill, after lower-casing and turning spaces and hyphens
+// into underscores
+var assetColumns = map[string]string{
+	"pattern": "pattern", "file": "pattern", "filename": "pattern", "file_name": "pattern", "path": "pattern", "caption_file": "pattern",
+	"asset_id": "id", "assetid": "id", "asset": "id", "id": "id", "house_id": "id",
+	"duration": "duration", "runtime": "duration", "length": "duration",
+	"language": "language", "lang": "language", "language_code": "language", "locale": "language",
+	"coverage": "coverage", "required_coverage": "coverage",
+}
+
+// assetColumn returns the asset field a column name fills, or ""
+func assetColumn(name string) string {
+	name = strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(name)))
+	return assetColumns[name]
+}
+
+// loadAssetList reads an -asset_list: XML, recognized by its leading '<', or CSV
+// with a header row, delimited by commas, semicolons or tabs. Durations in SMPTE
+// timecode are read at rate.
+func loadAssetList(path string, rate FrameRate) (AssetList, error) {
+	content, err := os.ReadFile(longPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read asset list: %w", err)
+	}
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	var records []map[string]string
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("<")) {
+		records, err = assetXMLRecords(content)
+	} else {
+		records, err = assetCSVRecords(content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var assets AssetList
+	for i, record := range records {
+		asset, err := parseAsset(record, rate)
+		if err != nil {
+			return nil, fmt.Errorf("%s: asset %d: %w", path, i+1, err)
+		}
+		assets = append(assets, asset)
+	}
+	if len(assets) == 0 {
+		return nil, fmt.Errorf("%s: no assets with a file name or pattern", path)
+	}
+	return assets, nil
+}
+
+// assetCSVRecords reads the rows of a CSV asset list as asset field values
+func assetCSVRecords(content []byte) ([]map[string]string, error) {
+	header, _, _ := bytes.Cut(content, []byte("\n"))
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	for _, delimiter := range []rune{';', '\t'} {
+		if bytes.ContainsRune(header, delimiter) && !bytes.ContainsRune(header, ',') {
+			reader.Comma = delimiter
+		}
+	}
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	fields := make([]string, len(rows[0]))
+	for i, name := range rows[0] {
+		fields[i] = assetColumn(name)
+	}
+	var records []map[string]string
+	for _, row := range rows[1:] {
+		record := map[string]string{}
+		for i, value := range row {
+			if i < len(fields) && fields[i] != "" {
+				record[fields[i]] = strings.TrimSpace(value)
+			}
+		}
+		if record["pattern"] != "" {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// assetNode is any XML element of an asset list
+type assetNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr  `xml:",any,attr"`
+	Text     string      `xml:",chardata"`
+	Children []assetNode `xml:",any"`
+}
+
+// assetXMLRecords reads an XML asset list. Exports differ in their element names,
+// so every element whose attributes or child elements name a file is an asset,
+// e.g. <Asset id="A1"><FileName>ep1.srt</FileName><Language>es-ES</Language></Asset>.
+func assetXMLRecords(content []byte) ([]map[string]string, error) {
+	var root assetNode
+	if err := xml.Unmarshal(content, &root); err != nil {
+		return nil, err
+	}
+	var records []map[string]string
+	var walk func(node assetNode)
+	walk = func(node assetNode) {
+		record := map[string]string{}
+		for _, attr := range node.Attrs {
+			if field := assetColumn(attr.Name.Local); field != "" {
+				record[field] = strings.TrimSpace(attr.Value)
+			}
+		}
+		for _, child := range node.Children {
+			if field := assetColumn(child.XMLName.Local); field != "" && len(child.Children) == 0 {
+				record[field] = strings.TrimSpace(child.Text)
+			}
+		}
+		if record["pattern"] != "" {
+			records = append(records, record)
+			return
+		}
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+	return records, nil
+}
+
+// parseAsset checks and converts one asset's values
+func parseAsset(record map[string]string, rate FrameRate) (Asset, error) {
+	asset := Asset{Pattern: filepath.ToSlash(record["pattern"]), ID: record["id"], Language: record["language"]}
+	if _, err := filepath.Match(asset.Pattern, ""); err != nil {
+		return Asset{}, fmt.Errorf("invalid pattern %q", asset.Pattern)
+	}
+	if value := record["duration"]; value != "" {
+		duration, err := parseAssetDuration(value, rate)
+		if err != nil {
+			return Asset{}, err
+		}
+		asset.Duration = duration
+	}
+	if asset.Language != "" && !languageTagPattern.MatchString(asset.Language) {
+		return Asset{}, fmt.Errorf("invalid language tag %q", asset.Language)
+	}
+	if value := record["coverage"]; value != "" {
+		coverage, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil || coverage <= 0 || coverage > 100 {
+			return Asset{}, fmt.Errorf("coverage must be a percentage between 0 and 100, got %q", value)
+		}
+		asset.Coverage = coverage
+	}
+	return asset, nil
+}
+
+// parseAssetDuration reads a duration as -t_end does, or as SMPTE timecode
+func parseAssetDuration(value string, rate FrameRate) (float64, error) {
+	seconds, err := parseTimestamp(value)
+	if err != nil {
+		frames, tcErr := timecodeFrames(value, rate, false)
+		if tcErr != nil {
+			return 0, fmt.Errorf("invalid duration %q: expected seconds, HH:MM:SS.mmm, HH:MM:SS:FF or a duration like 44m", value)
+		}
+		seconds = rate.seconds(frames)
+	}
+	if seconds <= 0 {
+		return 0, fmt.Errorf("invalid duration %q: must be positive", value)
+	}
+	return seconds, nil
+}
+
+// lookup returns the first asset whose pattern matches the end of the file's path:
+// "EP101_*.srt" matches the base name, "season1/*.srt" the last two elements
+func (assets AssetList) lookup(path string) *Asset {
+	elements := strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+	for i := range assets {
+		depth := strings.Count(assets[i].Pattern, "/") + 1
+		if depth > len(elements) {
+			continue
+		}
+		if ok, _ := filepath.Match(assets[i].Pattern, strings.Join(elements[len(elements)-depth:], "/")); ok {
+			return &assets[i]
+		}
+	}
+	return nil
+}
+
+// withAsset returns a copy of the validator, the window and the coverage threshold
+// with the file's -asset_list entry applied, and the entry. The duration ends the
+// window unless -program sets the timeline.
+func (cv *CaptionValidator) withAsset(path string, window Window, requiredCoverage float64) (*CaptionValidator, Window, float64, *Asset) {
+	asset := cv.assets.lookup(path)
+	if asset == nil {
+		return cv, window, requiredCoverage, nil
+	}
+	fileCV := *cv
+	if asset.Duration > 0 && len(cv.program) == 0 {
+		window.End = asset.Duration
+	}
+	if asset.Language != "" {
+		fileCV.expectedLanguage = asset.Language
+	}
+	if asset.Coverage > 0 {
+		requiredCoverage = asset.Coverage
+	}
+	return &fileCV, window, requiredCoverage, asset
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadAssetListCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "assets.csv")
+	os.WriteFile(path, []byte("\xef\xbb\xbfAsset ID;File Name;Duration;Language;Coverage;Title\n"+
+		"A101;EP101_*.srt;00:44:00:12;es-ES;75%;Pilot\n"+
+		"A102;season1/EP102.vtt;1h2m;;;\n"+
+		";;;;;\n"), 0o644)
+	assets, err := loadAssetList(path, FrameRate{Num: 24, Den: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := AssetList{
+		{Pattern: "EP101_*.srt", ID: "A101", Duration: 2640.5, Language: "es-ES", Coverage: 75},
+		{Pattern: "season1/EP102.vtt", ID: "A102", Duration: 3720},
+	}
+	if len(assets) != len(expected) {
+		t.Fatalf("expected %d assets, got %+v", len(expected), assets)
+	}
+	for i := range expected {
+		if assets[i] != expected[i] {
+			t.Errorf("asset %d: expected %+v, got %+v", i+1, expected[i], assets[i])
+		}
+	}
+}
+
+func TestLoadAssetListXML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "assets.xml")
+	os.WriteFile(path, []byte(`<?xml version="1.0"?>
+<Delivery>
+  <Assets>
+    <Asset AssetId="A101"><FileName>EP101_es.srt</FileName><Duration>2640</Duration><Language>es-ES</Language></Asset>
+    <Asset><Id>A102</Id><Path>EP102_*.vtt</Path><Runtime>00:45:00.000</Runtime></Asset>
+  </Assets>
+</Delivery>`), 0o644)
+	assets, err := loadAssetList(path, FrameRate{Num: 24, Den: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 2 || assets[0] != (Asset{Pattern: "EP101_es.srt", ID: "A101", Duration: 2640, Language: "es-ES"}) || assets[1] != (Asset{Pattern: "EP102_*.vtt", ID: "A102", Duration: 2700}) {
+		t.Errorf("unexpected assets %+v", assets)
+	}
+}
+
+func TestLoadAssetListErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"duration.csv": "file,duration\nep1.srt,soon\n",
+		"language.csv": "file,language\nep1.srt,english please\n",
+		"coverage.csv": "file,coverage\nep1.srt,120\n",
+		"pattern.csv":  "file\n[ep1.srt\n",
+		"empty.csv":    "asset_id,duration\nA1,10\n",
+		"broken.xml":   "<Assets><Asset>",
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0o644)
+		if _, err := loadAssetList(path, FrameRate{Num: 24, Den: 1}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestAssetListLookup(t *testing.T) {
+	assets := AssetList{
+		{Pattern: "season1/*.srt", ID: "S1"},
+		{Pattern: "EP1*.srt", ID: "EP"},
+		{Pattern: "*", ID: "ANY"},
+	}
+	tests := []struct {
+		path, expected string
+	}{
+		{"/mnt/catalog/season1/EP101.srt", "S1"},
+		{"/mnt/catalog/season2/EP101.srt", "EP"},
+		{"EP102.srt", "EP"},
+		{"notes.vtt", "ANY"},
+	}
+	for _, tt := range tests {
+		if asset := assets.lookup(tt.path); asset == nil || asset.ID != tt.expected {
+			t.Errorf("%s: expected %s, got %+v", tt.path, tt.expected, asset)
+		}
+	}
+	if asset := assets[:1].lookup("season1.srt"); asset != nil {
+		t.Errorf("expected no match, got %+v", asset)
+	}
+}
+
+func TestValidateWithAsset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "es-ES"})
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	listed := filepath.Join(dir, "EP101_es.srt")
+	os.WriteFile(listed, []byte("1\n00:00:01,000 --> 00:00:09,000\nHola\n"), 0o644)
+	unlisted := filepath.Join(dir, "other.srt")
+	os.WriteFile(unlisted, []byte("1\n00:00:01,000 --> 00:00:09,000\nHola\n"), 0o644)
+
+	cv := NewCaptionValidator(server.URL)
+	cv.assets = AssetList{{Pattern: "EP101_*.srt", ID: "A101", Duration: 10, Language: "es-ES", Coverage: 75}}
+	report, err := cv.Validate(listed, Window{}, 90)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Window != "00:00:00.000-00:00:10.000" || len(report.Errors) != 0 || report.Asset == nil || report.Asset.ID != "A101" {
+		t.Errorf("expected the asset's window, language and coverage to apply, got %+v", report)
+	}
+	if _, err := cv.Validate(unlisted, Window{}, 90); err == nil || !strings.Contains(err.Error(), "-asset_list") {
+		t.Errorf("expected a missing window error, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Attestation is appended to the report when it is signed with an embedded JWS
+type Attestation struct {
+	Type string `json:"type"`
+	Alg  string `json:"alg"`
+	JWS  string `json:"jws"`
+}
+
+// Signer produces an Ed25519 signature over data
+type Signer interface {
+	Sign(data []byte) ([]byte, error)
+}
+
+// keySigner signs with a local Ed25519 private key
+type keySigner struct {
+	key ed25519.PrivateKey
+}
+
+func (s keySigner) Sign(data []byte) ([]byte, error) {
+	return ed25519.Sign(s.key, data), nil
+}
+
+// commandSigner delegates signing to an external command (e.g. a KMS CLI wrapper).
+// The command receives the signing input on stdin and prints a base64 signature.
+type commandSigner struct {
+	command []string
+}
+
+func (s commandSigner) Sign(data []byte) ([]byte, error) {
+	cmd := exec.Command(s.command[0], s.command[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("signing command failed: %w", err)
+	}
+
+	encoded := strings.TrimSpace(string(out))
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		if signature, err = base64.RawURLEncoding.DecodeString(encoded); err != nil {
+			return nil, fmt.Errorf("signing command returned invalid base64: %w", err)
+		}
+	}
+	if len(signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("signing command returned %d-byte signature, expected %d", len(signature), ed25519.SignatureSize)
+	}
+	return signature, nil
+}
+
+// newSigner builds a signer from a PKCS#8 PEM key file or an external signing command
+func newSigner(keyPath, command string) (Signer, error) {
+	if command != "" {
+		fields := strings.Fields(command)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("empty signing command")
+		}
+		return commandSigner{command: fields}, nil
+	}
+
+	content, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("signing key is not PEM encoded")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key is not an Ed25519 key")
+	}
+	return keySigner{key: edKey}, nil
+}
+
+// signReport returns a compact JWS (RFC 7515) over the report bytes. When detached
+// is true the payload segment is left empty, so verifiers supply the report themselves.
+func signReport(signer Signer, report []byte, keyID string, detached bool) (string, error) {
+	header := map[string]string{"alg": "EdDSA", "cty": "application/x-ndjson"}
+	if keyID != "" {
+		header["kid"] = keyID
+	}
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+
+	encodedHeader := base64.RawURLEncoding.EncodeToString(headerJSON)
+	encodedPayload := base64.RawURLEncoding.EncodeToString(report)
+	signature, err := signer.Sign([]byte(encodedHeader + "." + encodedPayload))
+	if err != nil {
+		return "", err
+	}
+
+	if detached {
+		encodedPayload = ""
+	}
+	return encodedHeader + "." + encodedPayload + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSignReport(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile, err := os.CreateTemp("", "test_key_*.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(keyFile.Name())
+	pem.Encode(keyFile, &pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	keyFile.Close()
+
+	signer, err := newSigner(keyFile.Name(), "")
+	if err != nil {
+		t.Fatalf("newSigner failed: %v", err)
+	}
+
+	report := []byte(`{"type":"caption_coverage"}` + "\n")
+	for _, detached := range []bool{false, true} {
+		jws, err := signReport(signer, report, "qc-key-1", detached)
+		if err != nil {
+			t.Fatalf("signReport failed: %v", err)
+		}
+
+		parts := strings.Split(jws, ".")
+		if len(parts) != 3 {
+			t.Fatalf("expected compact JWS with 3 parts, got %q", jws)
+		}
+		if detached != (parts[1] == "") {
+			t.Errorf("detached=%v but payload segment is %q", detached, parts[1])
+		}
+
+		// Verifiers rebuild the signing input from the report they received
+		signingInput := parts[0] + "." + base64.RawURLEncoding.EncodeToString(report)
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ed25519.Verify(public, []byte(signingInput), signature) {
+			t.Error("signature does not verify against the report")
+		}
+		if ed25519.Verify(public, []byte(parts[0]+"."+base64.RawURLEncoding.EncodeToString([]byte("tampered"))), signature) {
+			t.Error("signature should not verify against a tampered report")
+		}
+	}
+}
+
+func TestNewSignerRejectsBadKey(t *testing.T) {
+	keyFile, err := os.CreateTemp("", "test_key_*.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(keyFile.Name())
+	keyFile.WriteString("not a key")
+	keyFile.Close()
+
+	if _, err := newSigner(keyFile.Name(), ""); err == nil {
+		t.Error("expected error for invalid key, got none")
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// baselineVersion is written to new baseline files and is the only version read
+const baselineVersion = 1
+
+// BaselineEntry is one violation a file already had when the baseline was recorded
+type BaselineEntry struct {
+	Rule        string `json:"rule"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// Baseline records the violations a catalog already has, keyed by file path, so runs
+// with -baseline only report new ones. With update set, Validate records each file's
+// current violations instead of filtering them.
+type Baseline struct {
+	Version int                        `json:"version"`
+	Files   map[string][]BaselineEntry `json:"files"`
+
+	update bool
+	mu     sync.Mutex
+}
+
+// loadBaseline reads a baseline file. A missing file is an empty baseline when it
+// is about to be recorded, and an error otherwise.
+func loadBaseline(path string, update bool) (*Baseline, error) {
+	baseline := &Baseline{Version: baselineVersion, Files: map[string][]BaselineEntry{}, update: update}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && update {
+		return baseline, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	if err := json.Unmarshal(content, baseline); err != nil {
+		return nil, fmt.Errorf("failed to decode baseline: %w", err)
+	}
+	if baseline.Version != baselineVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d", baseline.Version)
+	}
+	// Keys edited by hand may use another spelling of the same path
+	files := map[string][]BaselineEntry{}
+	for path, entries := range baseline.Files {
+		files[baselineKey(path)] = append(files[baselineKey(path)], entries...)
+	}
+	baseline.Files = files
+	return baseline, nil
+}
+
+// save writes the baseline through a temporary file so an interrupted write never
+// leaves a truncated baseline behind
+func (b *Baseline) save(path string) error {
+	b.mu.Lock()
+	content, err := json.MarshalIndent(b, "", "  ")
+	b.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// baselineKey is the path a file is recorded under
+func baselineKey(path string) string {
+	return filepath.ToSlash(normalizePath(path))
+}
+
+// issueFingerprint hashes an issue without its descriptions, so the fingerprint
+// does not depend on -locale. Any other change, such as a further cue joining an
+// issue, makes it a new violation.
+func issueFingerprint(issue interface{}) string {
+	data, _ := json.Marshal(issue)
+	var fields interface{}
+	json.Unmarshal(data, &fields)
+	data, _ = json.Marshal(withoutDescriptions(fields))
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// withoutDescriptions drops "description" keys from decoded JSON at any depth
+func withoutDescriptions(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		delete(v, "description")
+		for key, value := range v {
+			v[key] = withoutDescriptions(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = withoutDescriptions(value)
+		}
+	}
+	return v
+}
+
+// apply records a file's issues when the baseline is being updated, and otherwise
+// drops the issues it already holds. Each entry matches at most one issue. It
+// returns the kept issues and the rule IDs of those dropped.
+func (b *Baseline) apply(path string, issues []interface{}) ([]interface{}, []string) {
+	if b == nil {
+		return issues, nil
+	}
+	key := baselineKey(path)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.update {
+		var entries []BaselineEntry
+		for _, issue := range issues {
+			entries = append(entries, BaselineEntry{Rule: issueRule(issue), Fingerprint: issueFingerprint(issue)})
+		}
+		if len(entries) == 0 {
+			delete(b.Files, key)
+		} else {
+			b.Files[key] = entries
+		}
+		return issues, nil
+	}
+
+	known := map[string]int{}
+	for _, entry := range b.Files[key] {
+		known[entry.Fingerprint]++
+	}
+	kept := issues[:0]
+	var baselined []string
+	for _, issue := range issues {
+		if fingerprint := issueFingerprint(issue); known[fingerprint] > 0 {
+			known[fingerprint]--
+			baselined = append(baselined, issueRule(issue))
+			continue
+		}
+		kept = append(kept, issue)
+	}
+	sort.Strings(baselined)
+	return kept, baselined
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBaselineReportsOnlyNewViolations(t *testing.T) {
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	defer detector.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "captions.vtt")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	validate := func(baseline *Baseline) *FileReport {
+		t.Helper()
+		cv := NewCaptionValidator(detector.URL)
+		cv.punctuation = PunctuationStyle{Dash: DashEm}
+		cv.baseline = baseline
+		report, err := cv.Validate(path, Window{Start: 0, End: 4}, 80)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+	baselinePath := filepath.Join(dir, "baseline.json")
+
+	// Recording keeps the report unchanged and writes the file's violations
+	write("WEBVTT\n\n00:00:00.000 --> 00:00:02.000\nWait -- what\n")
+	recording, err := loadBaseline(baselinePath, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report := validate(recording); len(report.Errors) != 2 || report.Baselined != nil {
+		t.Fatalf("expected coverage and punctuation issues while recording, got %+v", report.Errors)
+	}
+	if err := recording.save(baselinePath); err != nil {
+		t.Fatal(err)
+	}
+
+	baseline, err := loadBaseline(baselinePath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := baseline.Files[baselineKey(path)]
+	if len(entries) != 2 || entries[0].Rule != "CV0201" || entries[1].Rule != "CV0403" {
+		t.Fatalf("unexpected baseline entries %+v", entries)
+	}
+	report := validate(baseline)
+	if len(report.Errors) != 0 || !reflect.DeepEqual(report.Baselined, []string{"CV0201", "CV0403"}) {
+		t.Errorf("expected every issue to be baselined, got %+v, baselined %v", report.Errors, report.Baselined)
+	}
+
+	// A further cue with the same problem changes the issue, so it is new
+	write("WEBVTT\n\n00:00:00.000 --> 00:00:02.000\nWait -- what\n\n00:00:02.000 --> 00:00:04.000\nFine -- go on\n")
+	report = validate(baseline)
+	if len(report.Errors) != 1 || issueRule(report.Errors[0]) != "CV0403" {
+		t.Errorf("expected the changed punctuation issue to be reported, got %+v", report.Errors)
+	}
+}
+
+func TestBaselineFingerprintIgnoresDescriptions(t *testing.T) {
+	english := &PunctuationStyleWarning{Type: "punctuation_style", Description: "Punctuation does not follow the house style", SuggestedFix: &SuggestedFix{Action: FixRestylePunctuation, Cues: []int{1}, Description: "Rewrite cue 1"}}
+	spanish := &PunctuationStyleWarning{Type: "punctuation_style", Description: "La puntuación no sigue el estilo de la casa", SuggestedFix: &SuggestedFix{Action: FixRestylePunctuation, Cues: []int{1}, Description: "Reescriba cue 1"}}
+	if issueFingerprint(english) != issueFingerprint(spanish) {
+		t.Error("expected descriptions not to affect the fingerprint")
+	}
+	spanish.SuggestedFix.Cues = []int{2}
+	if issueFingerprint(english) == issueFingerprint(spanish) {
+		t.Error("expected different cues to change the fingerprint")
+	}
+}
+
+func TestLoadBaseline(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.json")
+	if _, err := loadBaseline(missing, false); err == nil {
+		t.Error("expected an error for a missing baseline")
+	}
+	if baseline, err := loadBaseline(missing, true); err != nil || len(baseline.Files) != 0 {
+		t.Errorf("expected an empty baseline to record into, got %+v, %v", baseline, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	os.WriteFile(path, []byte(`{"version": 2, "files": {}}`), 0644)
+	if _, err := loadBaseline(path, false); err == nil {
+		t.Error("expected an error for an unknown version")
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WalkOptions controls how caption files are discovered under directory roots
+type WalkOptions struct {
+	Include        []string // glob patterns a file must match (base name or relative path); empty matches all
+	Exclude        []string // glob patterns that skip files and prune directories
+	FollowSymlinks bool     // descend into symlinked directories and include symlinked files
+}
+
+// BatchOptions configures batch validation of many caption files
+type BatchOptions struct {
+	Workers  int // maximum in-flight validations
+	Walk     WalkOptions
+	Manifest string                   // run manifest to write, one entry per validated file
+	Resume   map[string]ManifestEntry // a previous run's manifest; files it finished are not re-run
+	Shard    Shard                    // the slice of the files this run validates; the zero value is all of them
+}
+
+// ErrInterrupted reports that a batch stopped dispatching files because its context was cancelled
+var ErrInterrupted = errors.New("batch interrupted")
+
+// batchQueueDepth is how many finished reports per worker may wait on a slower earlier file
+// before dispatch pauses, so memory stays bounded when results must be emitted in order
+const batchQueueDepth = 4
+
+// collectFiles expands roots into a sorted, de-duplicated list of caption files.
+// Explicit file roots are always included; directories are walked recursively in parallel.
+func collectFiles(roots []string, opts WalkOptions, workers int, handles semaphore) ([]string, error) {
+	w := &walker{
+		opts:    opts,
+		sem:     newSemaphore(max(workers, 1)),
+		handles: handles,
+		seen:    make(map[string]bool),
+		visited: make(map[string]bool),
+	}
+
+	for _, root := range roots {
+		info, err := os.Stat(longPath(root))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		}
+		if !info.IsDir() {
+			w.add(root)
+			continue
+		}
+		if !w.enterDir(root) {
+			continue
+		}
+		w.wg.Add(1)
+		go w.walkDir(root, root)
+	}
+	w.wg.Wait()
+
+	if len(w.errs) > 0 {
+		return nil, w.errs[0]
+	}
+	sort.Strings(w.files)
+	return w.files, nil
+}
+
+// walker walks directory trees concurrently, bounding concurrent directory reads
+type walker struct {
+	opts    WalkOptions
+	sem     semaphore // bounds concurrent directory reads
+	handles semaphore // shared open file handle limit
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex
+	files   []string
+	seen    map[string]bool // files already collected
+	visited map[string]bool // resolved directories already walked, guards symlink cycles
+	errs    []error
+}
+
+func (w *walker) walkDir(root, dir string) {
+	defer w.wg.Done()
+
+	w.sem.acquire()
+	w.handles.acquire()
+	entries, err := os.ReadDir(longPath(dir))
+	w.handles.release()
+	w.sem.release()
+	if err != nil {
+		w.fail(fmt.Errorf("failed to read directory: %w", err))
+		return
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		rel, _ := filepath.Rel(root, path)
+		if matchAny(w.opts.Exclude, entry.Name(), rel) {
+			continue
+		}
+
+		isDir := entry.IsDir()
+		if entry.Type()&os.ModeSymlink != 0 {
+			if !w.opts.FollowSymlinks {
+				continue
+			}
+			info, err := os.Stat(longPath(path))
+			if err != nil {
+				continue // dangling symlink
+			}
+			isDir = info.IsDir()
+		}
+
+		if isDir {
+			if w.enterDir(path) {
+				w.wg.Add(1)
+				go w.walkDir(root, path)
+			}
+			continue
+		}
+
+		if len(w.opts.Include) == 0 || matchAny(w.opts.Include, entry.Name(), rel) {
+			w.add(path)
+		}
+	}
+}
+
+// enterDir records a directory as visited, returning false if it was already walked
+func (w *walker) enterDir(dir string) bool {
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		resolved = dir
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.visited[resolved] {
+		return false
+	}
+	w.visited[resolved] = true
+	return true
+}
+
+func (w *walker) add(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if key := normalizePath(path); !w.seen[key] {
+		w.seen[key] = true
+		w.files = append(w.files, path)
+	}
+}
+
+func (w *walker) fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.errs = append(w.errs, err)
+}
+
+// matchAny reports whether the base name or slash-separated relative path matches any pattern
+func matchAny(patterns []string, name, rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateBatch validates every caption file under roots in parallel and prints one
+// FileReport per file as a JSON line, in sorted path order. It returns true if any
+// file could not be validated. Cancelling ctx stops new files from starting; reports
+// for files already in flight are still printed, and ErrInterrupted is returned.
+// Files the resumed manifest records as finished are skipped and carried over into
+// the new manifest without printing a report; files outside opts.Shard are left
+// out altogether.
+func (cv *CaptionValidator) ValidateBatch(ctx context.Context, roots []string, window Window, requiredCoverage float64, opts BatchOptions) (bool, error) {
+	workers := max(opts.Workers, 1)
+	files, err := collectFiles(roots, opts.Walk, workers, cv.openFiles)
+	if err != nil {
+		return false, err
+	}
+	if opts.Shard.Count > 0 {
+		total := len(files)
+		files = opts.Shard.filter(files)
+		log.Printf("Shard %s: %d of %d files", opts.Shard, len(files), total)
+	}
+
+	var manifest *json.Encoder
+	if opts.Manifest != "" {
+		file, err := os.Create(opts.Manifest)
+		if err != nil {
+			return false, fmt.Errorf("failed to write manifest: %w", err)
+		}
+		defer file.Close()
+		manifest = json.NewEncoder(file)
+	}
+	if opts.Resume != nil {
+		remaining := files[:0]
+		for _, file := range files {
+			entry, ok := opts.Resume[normalizePath(file)]
+			if !ok || !entry.final() {
+				remaining = append(remaining, file)
+			} else if manifest != nil {
+				manifest.Encode(entry)
+			}
+		}
+		log.Printf("Resuming: %d of %d files already finished", len(files)-len(remaining), len(files))
+		files = remaining
+	}
+
+	reports := make([]FileReport, len(files))
+	skipped := make([]bool, len(files))
+	done := make([]chan struct{}, len(files))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	// Dispatch blocks once too many reports are waiting to be printed (backpressure)
+	pending := newSemaphore(workers * batchQueueDepth)
+	jobs := make(chan int)
+	for range workers {
+		go func() {
+			for i := range jobs {
+				reports[i] = cv.validateForReport(files[i], window, requiredCoverage)
+				close(done[i])
+			}
+		}()
+	}
+	// Once ctx is cancelled no new files are started; in-flight ones still finish
+	go func() {
+		defer close(jobs)
+		for i := range files {
+			pending.acquire()
+			if ctx.Err() == nil {
+				select {
+				case jobs <- i:
+					continue
+				case <-ctx.Done():
+				}
+			}
+			for j := i; j < len(files); j++ {
+				skipped[j] = true
+				close(done[j])
+			}
+			pending.release()
+			return
+		}
+	}()
+
+	// Emit reports in path order as soon as each one (and all before it) is ready
+	failed := false
+	notRun := 0
+	for i := range files {
+		<-done[i]
+		if skipped[i] {
+			notRun++
+			continue
+		}
+		if reports[i].ProgramError != "" {
+			failed = true
+		}
+		cv.printReport(&reports[i])
+		if manifest != nil {
+			manifest.Encode(manifestEntry(reports[i]))
+		}
+		reports[i] = FileReport{}
+		pending.release()
+	}
+	if notRun > 0 {
+		return failed, fmt.Errorf("%w: %d of %d files not validated", ErrInterrupted, notRun, len(files))
+	}
+	return failed, nil
+}
+
+// validateForReport validates one file, capturing program errors in the report instead of aborting
+func (cv *CaptionValidator) validateForReport(filepath string, window Window, requiredCoverage float64) FileReport {
+	started := time.Now()
+	report, err := cv.validateAndSummarize(filepath, window, requiredCoverage)
+	if err != nil {
+		_, window, _, asset := cv.withAsset(filepath, window, requiredCoverage)
+		return FileReport{File: filepath, Window: window.String(), Errors: []interface{}{}, Asset: asset, ProgramError: err.Error(), ErrorType: programErrorType(err), Version: buildVersion(), RunID: cv.runID,
+			StartedAt: reportTime(started), FinishedAt: reportTime(time.Now())}
+	}
+	return *report
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCollectFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"b.srt", "a.webvtt", "notes.txt", "season1/ep1.srt", "season1/ep2.webvtt", "drafts/ep3.srt"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("WEBVTT\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "linked.srt"), []byte("1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "linked")); err != nil {
+		t.Fatal(err)
+	}
+	// A cycle back to the root must not be walked twice
+	if err := os.Symlink(root, filepath.Join(root, "season1", "loop")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     WalkOptions
+		expected []string
+	}{
+		{
+			name:     "include and exclude patterns",
+			opts:     WalkOptions{Include: []string{"*.srt", "*.webvtt"}, Exclude: []string{"drafts"}},
+			expected: []string{"a.webvtt", "b.srt", "season1/ep1.srt", "season1/ep2.webvtt"},
+		},
+		{
+			name:     "relative path include",
+			opts:     WalkOptions{Include: []string{"season1/*"}},
+			expected: []string{"season1/ep1.srt", "season1/ep2.webvtt"},
+		},
+		{
+			name:     "follow symlinks",
+			opts:     WalkOptions{Include: []string{"*.srt"}, Exclude: []string{"drafts"}, FollowSymlinks: true},
+			expected: []string{"b.srt", "linked/linked.srt", "season1/ep1.srt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := collectFiles([]string{root}, tt.opts, 4, newSemaphore(2))
+			if err != nil {
+				t.Fatalf("collectFiles failed: %v", err)
+			}
+			var rel []string
+			for _, file := range files {
+				r, _ := filepath.Rel(root, file)
+				rel = append(rel, filepath.ToSlash(r))
+			}
+			if !reflect.DeepEqual(rel, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, rel)
+			}
+		})
+	}
+}
+
+func TestValidateForReportProgramError(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test_unsupported_*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpFile.Name())
+	tmpFile.WriteString("This is just plain text, not a caption file")
+	tmpFile.Close()
+
+	cv := NewCaptionValidator("http://test.com")
+	report := cv.validateForReport(tmpFile.Name(), Window{Start: 0, End: 30}, 80)
+	if report.ProgramError == "" {
+		t.Error("expected program error for unsupported format, got none")
+	}
+	if report.File != tmpFile.Name() {
+		t.Errorf("expected file %s, got %s", tmpFile.Name(), report.File)
+	}
+}
+
+func TestValidateBatchInterrupted(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.srt", "b.srt"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("1\n00:00:00,000 --> 00:00:01,000\nHi\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cv := NewCaptionValidator("http://test.com")
+	_, err := cv.ValidateBatch(ctx, []string{root}, Window{End: 10}, 80, BatchOptions{Workers: 2})
+	if !errors.Is(err, ErrInterrupted) || !strings.Contains(err.Error(), "2 of 2") {
+		t.Errorf("expected ErrInterrupted with no files validated, got %v", err)
+	}
+}
+
+func TestValidateBatchResume(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.srt", "b.srt", "c.txt"} {
+		content := "1\n00:00:00,000 --> 00:00:10,000\n" + name + "\n"
+		if name == "c.txt" {
+			content = "Not a caption file\n"
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The detector hiccups on b.srt during the first run only
+	hiccup := true
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body bytes.Buffer
+		body.ReadFrom(r.Body)
+		if hiccup && strings.Contains(body.String(), "b.srt") {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	defer detector.Close()
+
+	var output bytes.Buffer
+	resultOutput = &output
+	defer func() { resultOutput = os.Stdout }()
+
+	manifestPath := filepath.Join(t.TempDir(), "run.jsonl")
+	cv := NewCaptionValidator(detector.URL)
+	if _, err := cv.ValidateBatch(context.Background(), []string{root}, Window{End: 10}, 80, BatchOptions{Workers: 2, Manifest: manifestPath}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := loadManifest(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := map[string]string{}
+	for file, entry := range entries {
+		statuses[filepath.Base(file)] = entry.Status
+	}
+	expected := map[string]string{"a.srt": ManifestPassed, "b.srt": ManifestTransient, "c.txt": ManifestError}
+	if !reflect.DeepEqual(statuses, expected) {
+		t.Fatalf("expected statuses %v, got %v", expected, statuses)
+	}
+
+	hiccup = false
+	output.Reset()
+	if _, err := cv.ValidateBatch(context.Background(), []string{root}, Window{End: 10}, 80, BatchOptions{Workers: 2, Manifest: manifestPath, Resume: entries}); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(output.String()), "\n"); len(lines) != 1 || !strings.Contains(lines[0], "b.srt") {
+		t.Errorf("expected only b.srt to be re-run, got %q", output.String())
+	}
+	if entries, _ = loadManifest(manifestPath); len(entries) != 3 || entries[filepath.Join(root, "b.srt")].Status != ManifestPassed {
+		t.Errorf("expected updated manifest with all files, got %+v", entries)
+	}
+}
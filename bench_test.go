@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// benchmarkCueCounts are the file sizes parsing is measured at
+var benchmarkCueCounts = []int{10_000, 50_000, 100_000}
+
+// benchmarkFile renders n two-line cues, two seconds apart, in format
+func benchmarkFile(format string, n int) string {
+	captions := make([]Caption, n)
+	for i := range captions {
+		captions[i] = Caption{
+			StartTime: float64(i) * 2,
+			EndTime:   float64(i)*2 + 1.5,
+			Text:      fmt.Sprintf("Line %d of the <i>benchmark</i> dialogue,\nspoken by someone on screen.", i+1),
+		}
+	}
+	var b strings.Builder
+	WriteCues(&b, captions, format)
+	return b.String()
+}
+
+func benchmarkParse(b *testing.B, format string, parse func(*CaptionValidator, string) ([]Caption, []ParseFailure, error)) {
+	for _, n := range benchmarkCueCounts {
+		content := benchmarkFile(format, n)
+		b.Run(fmt.Sprintf("%dk", n/1000), func(b *testing.B) {
+			cv := NewCaptionValidator("")
+			b.SetBytes(int64(len(content)))
+			b.ReportAllocs()
+			for b.Loop() {
+				captions, _, err := parse(cv, content)
+				if err != nil || len(captions) != n {
+					b.Fatalf("parsed %d cues: %v", len(captions), err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseWebVTT(b *testing.B) {
+	benchmarkParse(b, "webvtt", (*CaptionValidator).parseWebVTT)
+}
+
+func BenchmarkParseSRT(b *testing.B) {
+	benchmarkParse(b, "srt", (*CaptionValidator).parseSRT)
+}
+
+func BenchmarkMeasureCoverage(b *testing.B) {
+	captions, _, err := NewCaptionValidator("").parseWebVTT(benchmarkFile("webvtt", 100_000))
+	if err != nil {
+		b.Fatal(err)
+	}
+	window := Window{Start: 0, End: 200_000}
+	b.ReportAllocs()
+	for b.Loop() {
+		measureCoverage(captions, window, 1.0, CoverageDialogueWeighted)
+	}
+}
+
+func BenchmarkValidate(b *testing.B) {
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	defer detector.Close()
+
+	path := filepath.Join(b.TempDir(), "benchmark.vtt")
+	content := benchmarkFile("webvtt", 10_000)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		b.Fatal(err)
+	}
+	cv := NewCaptionValidator(detector.URL)
+	window := Window{Start: 0, End: 20_000}
+	b.SetBytes(int64(len(content)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := cv.Validate(path, window, 70); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"log"
+	"math"
+)
+
+// languageChunks groups cues into consecutive chunks of seconds of program time,
+// counted from the first cue, so each chunk can be detected on its own
+func languageChunks(captions []Caption, seconds float64) [][]Caption {
+	var chunks [][]Caption
+	last := -1
+	for _, caption := range captions {
+		chunk := int((caption.StartTime - captions[0].StartTime) / seconds)
+		if chunk != last {
+			chunks = append(chunks, nil)
+			last = chunk
+		}
+		chunks[len(chunks)-1] = append(chunks[len(chunks)-1], caption)
+	}
+	return chunks
+}
+
+// languageBreakdown detects the track -detect_chunk seconds at a time and returns
+// the percentage of captioned time detected as each language, so a partly
+// localized track can be told from a wholly wrong one. Chunks the detector fails
+// on are left out; nil means the breakdown is disabled or nothing was detected.
+// -sample_chars bounds the whole breakdown, split evenly across the chunks, so
+// chunking never sends more of the track than a single detection would.
+func (cv *CaptionValidator) languageBreakdown(ctx context.Context, captions []Caption) map[string]float64 {
+	if cv.detectChunk <= 0 || len(captions) == 0 {
+		return nil
+	}
+	seconds := map[string]float64{}
+	total := 0.0
+	chunks := languageChunks(captions, cv.detectChunk)
+	for i, chunk := range chunks {
+		limit := 0
+		if cv.sampleChars > 0 {
+			if limit = chunkSampleChars(cv.sampleChars, len(chunks), i); limit == 0 {
+				continue
+			}
+		}
+		text := cv.sampledDetectionText(chunk, limit)
+		if text == "" {
+			continue
+		}
+		lang, err := cv.detectLanguage(ctx, text)
+		if err != nil {
+			log.Printf("Language breakdown: skipping chunk at %.3fs: %v", chunk[0].StartTime, err)
+			continue
+		}
+		for _, caption := range chunk {
+			seconds[lang] += caption.EndTime - caption.StartTime
+			total += caption.EndTime - caption.StartTime
+		}
+	}
+	if total <= 0 {
+		return nil
+	}
+	breakdown := make(map[string]float64, len(seconds))
+	for lang, s := range seconds {
+		breakdown[lang] = math.Round(s/total*1000) / 10
+	}
+	return breakdown
+}
+
+// chunkSampleChars is chunk i's share of a budget of chars split across n chunks;
+// the first chars%n chunks get one more, and chunks past the budget get none
+func chunkSampleChars(chars, n, i int) int {
+	share := chars / n
+	if i < chars%n {
+		share++
+	}
+	return share
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLanguageChunks(t *testing.T) {
+	captions := []Caption{
+		{StartTime: 10, EndTime: 12}, {StartTime: 30, EndTime: 32},
+		{StartTime: 75, EndTime: 77}, {StartTime: 200, EndTime: 202},
+	}
+	chunks := languageChunks(captions, 60)
+	if len(chunks) != 3 || len(chunks[0]) != 2 || len(chunks[1]) != 1 || len(chunks[2]) != 1 {
+		t.Errorf("expected chunks of 2, 1 and 1 cues, got %v", chunks)
+	}
+}
+
+func TestLanguageBreakdownOnPartlyLocalizedTrack(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "Hola") {
+			w.Write([]byte(`{"lang": "es-419"}`))
+			return
+		}
+		w.Write([]byte(`{"lang": "en-US"}`))
+	}))
+	defer server.Close()
+
+	cv := NewCaptionValidator(server.URL)
+	cv.expectedLanguage = "en-US"
+	cv.detectChunk = 60
+	captions := []Caption{
+		{StartTime: 0, EndTime: 4, Text: "Hello there"},
+		{StartTime: 10, EndTime: 12, Text: "How are you"},
+		{StartTime: 60, EndTime: 64, Text: "Hola amigo"},
+	}
+	breakdown := cv.languageBreakdown(context.Background(), captions)
+	if breakdown["en-US"] != 60 || breakdown["es-419"] != 40 {
+		t.Errorf("expected 60%% en-US and 40%% es-419, got %v", breakdown)
+	}
+
+	cv.detectChunk = 0
+	if breakdown := cv.languageBreakdown(context.Background(), captions); breakdown != nil {
+		t.Errorf("expected no breakdown without -detect_chunk, got %v", breakdown)
+	}
+}
+
+func TestLanguageBreakdownSharesSampleChars(t *testing.T) {
+	sent := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sent += len(body)
+		w.Write([]byte(`{"lang": "en-US"}`))
+	}))
+	defer server.Close()
+
+	cv := NewCaptionValidator(server.URL)
+	cv.expectedLanguage = "en-US"
+	cv.detectChunk = 10
+	cv.sampleChars = 40
+	var captions []Caption
+	for i := range 8 {
+		start := float64(i) * 10
+		captions = append(captions, Caption{StartTime: start, EndTime: start + 5, Text: "Some words that go on and on for a while"})
+	}
+	cv.languageBreakdown(context.Background(), captions)
+	if sent == 0 || sent > cv.sampleChars {
+		t.Errorf("expected at most %d characters across all chunks, sent %d", cv.sampleChars, sent)
+	}
+
+	if shares := []int{chunkSampleChars(10, 4, 0), chunkSampleChars(10, 4, 1), chunkSampleChars(10, 4, 2), chunkSampleChars(10, 4, 3)}; shares[0] != 3 || shares[1] != 3 || shares[2] != 2 || shares[3] != 2 {
+		t.Errorf("expected shares 3, 3, 2 and 2, got %v", shares)
+	}
+	if share := chunkSampleChars(2, 4, 3); share != 0 {
+		t.Errorf("expected no share past the budget, got %d", share)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+)
+
+// DetectorCapabilityError reports that the detector does not list the expected
+// language, so every file would come back as a misleading incorrect_language
+type DetectorCapabilityError struct {
+	Type         string        `json:"type"`
+	Rule         string        `json:"rule"`
+	Endpoint     string        `json:"endpoint"`
+	ExpectedLang string        `json:"expected_language"`
+	Supported    []string      `json:"supported_languages"`
+	Description  string        `json:"description"`
+	SuggestedFix *SuggestedFix `json:"suggested_fix,omitempty"`
+}
+
+// LanguagesResponse is a detector's language inventory, e.g. {"languages": ["en", "es"]}
+type LanguagesResponse struct {
+	Languages []string `json:"languages"`
+}
+
+// languagesURL is the detector's inventory endpoint, beside its detection path:
+// http://host/v1/detect lists its languages at http://host/v1/languages
+func languagesURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	u.Path = path.Join(path.Dir(u.Path), "languages")
+	u.RawPath = ""
+	return u.String(), nil
+}
+
+// detectorLanguages fetches the detector's language inventory. Detectors without a
+// /languages endpoint answer 404 or 405, or answer every path as if it were a
+// detection call; ok is false for those.
+func (cv *CaptionValidator) detectorLanguages(ctx context.Context) (languages []string, ok bool, err error) {
+	inventory, err := languagesURL(cv.endpoint)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid language detection endpoint: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, inventory, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	cv.setDetectorHeaders(req)
+	resp, err := cv.client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list detector languages: %w", err)
+	}
+	defer drainClose(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, false, nil
+	default:
+		return nil, false, fmt.Errorf("language inventory endpoint returned status: %d", resp.StatusCode)
+	}
+	var listing LanguagesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return nil, false, fmt.Errorf("failed to decode language inventory: %w", err)
+	}
+	return listing.Languages, listing.Languages != nil, nil
+}
+
+// checkDetectorCapability asks the detector which languages it supports and returns
+// a detector_capability error when the expected language is not among them. A
+// detector that does not publish an inventory is assumed capable.
+func (cv *CaptionValidator) checkDetectorCapability(ctx context.Context) (*DetectorCapabilityError, error) {
+	languages, ok, err := cv.detectorLanguages(ctx)
+	if err != nil || !ok {
+		return nil, err
+	}
+	if slices.ContainsFunc(languages, func(lang string) bool { return sameBaseLanguage(lang, cv.expectedLanguage) }) {
+		return nil, nil
+	}
+	capabilityErr := &DetectorCapabilityError{
+		Type:         "detector_capability",
+		Endpoint:     cv.endpoint,
+		ExpectedLang: cv.expectedLanguage,
+		Supported:    languages,
+		Description:  fmt.Sprintf("The language detector does not support the expected language '%s'", cv.expectedLanguage),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixChooseDetector,
+			Language:    cv.expectedLanguage,
+			Description: fmt.Sprintf("Point -endpoint at a detector that supports %s, or correct -language", cv.expectedLanguage),
+		},
+	}
+	issueRule(capabilityErr)
+	cv.locale.localize(capabilityErr)
+	return capabilityErr, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestLanguagesURL(t *testing.T) {
+	for endpoint, expected := range map[string]string{
+		"http://localhost:8081/detect":          "http://localhost:8081/languages",
+		"https://lid.example.com/v1/detect?k=1": "https://lid.example.com/v1/languages?k=1",
+	} {
+		if got, err := languagesURL(endpoint); err != nil || got != expected {
+			t.Errorf("%s: expected %s, got %s (%v)", endpoint, expected, got, err)
+		}
+	}
+}
+
+func TestDetectorCapability(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/languages", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"languages": ["en", "es-419", "pt"]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cv := NewCaptionValidator(server.URL + "/v1/detect")
+	cv.expectedLanguage = "es-ES"
+	if capabilityErr, err := cv.checkDetectorCapability(context.Background()); capabilityErr != nil || err != nil {
+		t.Errorf("expected es-ES to match es-419, got %+v (%v)", capabilityErr, err)
+	}
+
+	cv.expectedLanguage = "ja-JP"
+	capabilityErr, err := cv.checkDetectorCapability(context.Background())
+	if err != nil || capabilityErr == nil {
+		t.Fatalf("expected detector_capability for ja-JP, got %v", err)
+	}
+	if capabilityErr.Rule != "CV0306" || !slices.Equal(capabilityErr.Supported, []string{"en", "es-419", "pt"}) || capabilityErr.SuggestedFix.Action != FixChooseDetector {
+		t.Errorf("unexpected error %+v", capabilityErr)
+	}
+
+	// Detectors without an inventory are assumed to support the language
+	cv.endpoint = server.URL + "/detect"
+	if capabilityErr, err := cv.checkDetectorCapability(context.Background()); capabilityErr != nil || err != nil {
+		t.Errorf("expected no check without /languages, got %+v (%v)", capabilityErr, err)
+	}
+}
+
+func TestDetectorCapabilityIgnoresCatchAllDetector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"lang": "es-ES"}`))
+	}))
+	defer server.Close()
+
+	cv := NewCaptionValidator(server.URL)
+	cv.expectedLanguage = "en-US"
+	if capabilityErr, err := cv.checkDetectorCapability(context.Background()); capabilityErr != nil || err != nil {
+		t.Errorf("expected a detector answering every path to be assumed capable, got %+v (%v)", capabilityErr, err)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// endpointSamples are texts any working detector identifies, by expected language
+var endpointSamples = []struct {
+	lang string
+	text string
+}{
+	{"en", "Where are you going? I told you to wait for me at the station. We have to leave before the storm gets worse, and the last train is at nine."},
+	{"es", "¿Adónde vas? Te dije que me esperaras en la estación. Tenemos que irnos antes de que empeore la tormenta, y el último tren sale a las nueve."},
+}
+
+// EndpointCheck is the detector's answer for one known sample
+type EndpointCheck struct {
+	Expected  string  `json:"expected_language"`
+	Detected  string  `json:"detected_language,omitempty"`
+	LatencyMS float64 `json:"latency_ms"`
+	Passed    bool    `json:"passed"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// EndpointCheckReport is the result of check-endpoint
+type EndpointCheckReport struct {
+	Type     string          `json:"type"`
+	Endpoint string          `json:"endpoint"`
+	Passed   bool            `json:"passed"`
+	Checks   []EndpointCheck `json:"checks"`
+}
+
+// runCheckEndpoint implements the check-endpoint subcommand: it prints the detector's
+// answers to the known samples and exits 1 unless all of them are right
+func runCheckEndpoint(args []string) {
+	fs := flag.NewFlagSet("check-endpoint", flag.ExitOnError)
+	endpoint := fs.String("endpoint", "", "Language detection endpoint URL")
+	connectTimeout := fs.Duration("connect_timeout", defaultDetectorTimeouts.Connect, "Timeout for connecting to the language detection endpoint")
+	requestTimeout := fs.Duration("request_timeout", defaultDetectorTimeouts.Request, "Timeout for one language detection call")
+	detectorHeaders := headerFlag{}
+	fs.Var(detectorHeaders, "detector_header", "Header sent with every language detection call as \"Name: value\" (repeatable)")
+	resultsFD := resultsFDFlag(fs)
+	fs.Usage = commandUsage(fs, "check-endpoint", "check-endpoint -endpoint URL")
+	fs.Parse(args)
+	if err := setResultsFD(*resultsFD); err != nil {
+		log.Fatal(err)
+	}
+	if *endpoint == "" {
+		log.Fatal("Language detection endpoint is required (use -endpoint flag)")
+	}
+
+	cv := NewCaptionValidator(*endpoint)
+	cv.setTimeouts(DetectorTimeouts{Connect: *connectTimeout, Request: *requestTimeout})
+	cv.headers = http.Header(detectorHeaders)
+	report := cv.CheckEndpoint()
+	printJSON(report)
+	if !report.Passed {
+		os.Exit(1)
+	}
+}
+
+// CheckEndpoint sends an English and a Spanish sample to the detector and checks that
+// each comes back as its own language. A detector that is down, answers garbage or
+// names one language for everything fails.
+func (cv *CaptionValidator) CheckEndpoint() *EndpointCheckReport {
+	report := &EndpointCheckReport{Type: "endpoint_check", Endpoint: cv.endpoint, Passed: true}
+	for _, sample := range endpointSamples {
+		check := EndpointCheck{Expected: sample.lang}
+		start := time.Now()
+		detected, err := cv.detectLanguage(context.Background(), sample.text)
+		check.LatencyMS = milliseconds(time.Since(start))
+		switch {
+		case err != nil:
+			check.Error = err.Error()
+		default:
+			check.Detected = detected
+			check.Passed = sameBaseLanguage(detected, sample.lang)
+		}
+		report.Passed = report.Passed && check.Passed
+		report.Checks = append(report.Checks, check)
+	}
+	return report
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckEndpoint(t *testing.T) {
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		lang := "en-US"
+		if strings.Contains(string(body), "¿") {
+			lang = "es-MX"
+		}
+		json.NewEncoder(w).Encode(map[string]string{"lang": lang})
+	}))
+	defer detector.Close()
+
+	report := NewCaptionValidator(detector.URL).CheckEndpoint()
+	if !report.Passed || len(report.Checks) != 2 {
+		t.Fatalf("expected both samples to pass, got %+v", report)
+	}
+	if report.Checks[1].Expected != "es" || report.Checks[1].Detected != "es-MX" {
+		t.Errorf("unexpected Spanish check: %+v", report.Checks[1])
+	}
+}
+
+func TestCheckEndpointFailures(t *testing.T) {
+	// A detector stuck on one language fails the other sample
+	stuck := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "es-ES"})
+	}))
+	defer stuck.Close()
+	report := NewCaptionValidator(stuck.URL).CheckEndpoint()
+	if report.Passed || report.Checks[0].Passed || !report.Checks[1].Passed {
+		t.Errorf("expected only the English sample to fail, got %+v", report)
+	}
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer broken.Close()
+	report = NewCaptionValidator(broken.URL).CheckEndpoint()
+	if report.Passed || !strings.Contains(report.Checks[0].Error, "unrecognized language detector response") {
+		t.Errorf("expected an empty response to fail, got %+v", report)
+	}
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	report = NewCaptionValidator(down.URL).CheckEndpoint()
+	if report.Passed || !strings.Contains(report.Checks[1].Error, "status: 503") {
+		t.Errorf("expected a 503 to fail, got %+v", report)
+	}
+}
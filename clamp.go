@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Bounds -clamp_window cuts an overlong window at
+const (
+	ClampMedia   = "media"    // the media duration the file declares
+	ClampLastCue = "last_cue" // the end of the last cue, or the media duration if sooner
+)
+
+// mediaDurationFields are the WebVTT header fields that declare the media's duration
+var mediaDurationFields = []string{"duration", "x-duration", "media-duration"}
+
+// WindowClamp records that -clamp_window cut the window short, since an end time
+// past the media, usually a wrong duration on the ticket, would otherwise fail
+// coverage for a tail no captions could cover
+type WindowClamp struct {
+	Requested string  `json:"requested_window"`
+	ClampedTo string  `json:"clamped_to"` // media_duration or last_cue
+	EndTime   float64 `json:"end_time"`
+}
+
+// parseClampWindow checks the -clamp_window flag
+func parseClampWindow(mode string) (string, error) {
+	switch mode {
+	case "", ClampMedia, ClampLastCue:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid -clamp_window %q: use %s or %s", mode, ClampMedia, ClampLastCue)
+}
+
+// mediaDuration returns the media duration a file's header declares, in seconds, or 0
+func mediaDuration(metadata *FileMetadata) float64 {
+	if metadata == nil {
+		return 0
+	}
+	for key, value := range metadata.Fields {
+		for _, field := range mediaDurationFields {
+			if strings.EqualFold(key, field) {
+				if seconds, err := parseTimestamp(value); err == nil && seconds > 0 {
+					return seconds
+				}
+			}
+		}
+	}
+	return 0
+}
+
+// clampWindow cuts the window's end back to the media duration, and with last_cue to
+// the end of the last (padded) cue, when it runs past them. A window that would be
+// left empty or under 1ms, as when it starts after the last cue, is kept as it is.
+func clampWindow(window Window, mode string, captions []Caption, metadata *FileMetadata) (Window, *WindowClamp) {
+	end, clampedTo := window.End, ""
+	if media := mediaDuration(metadata); media > 0 && media < end {
+		end, clampedTo = media, "media_duration"
+	}
+	if mode == ClampLastCue && len(captions) > 0 {
+		lastEnd := 0.0
+		for _, caption := range captions {
+			lastEnd = max(lastEnd, caption.EndTime)
+		}
+		if lastEnd < end {
+			end, clampedTo = lastEnd, ClampLastCue
+		}
+	}
+	if clampedTo == "" || (Window{Start: window.Start, End: end}).Validate() != nil {
+		return window, nil
+	}
+	clamp := &WindowClamp{Requested: window.String(), ClampedTo: clampedTo, EndTime: end}
+	window.End = end
+	return window, clamp
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClampWindow(t *testing.T) {
+	captions := []Caption{{StartTime: 1, EndTime: 4}, {StartTime: 5, EndTime: 8}}
+	header := &FileMetadata{Fields: map[string]string{"Duration": "00:00:09.500"}}
+	tests := []struct {
+		name      string
+		window    Window
+		mode      string
+		metadata  *FileMetadata
+		end       float64
+		clampedTo string
+	}{
+		{"media", Window{End: 30}, ClampMedia, header, 9.5, "media_duration"},
+		{"media without a duration", Window{End: 30}, ClampMedia, nil, 30, ""},
+		{"last cue", Window{End: 30}, ClampLastCue, nil, 8, ClampLastCue},
+		{"last cue before the media end", Window{End: 30}, ClampLastCue, header, 8, ClampLastCue},
+		{"window inside the media", Window{End: 6}, ClampLastCue, header, 6, ""},
+		{"window after the last cue", Window{Start: 20, End: 30}, ClampLastCue, nil, 30, ""},
+	}
+	for _, tt := range tests {
+		window, clamp := clampWindow(tt.window, tt.mode, captions, tt.metadata)
+		if window.End != tt.end || window.Start != tt.window.Start {
+			t.Errorf("%s: expected the window to end at %g, got %s", tt.name, tt.end, window)
+		}
+		switch {
+		case tt.clampedTo == "" && clamp != nil:
+			t.Errorf("%s: expected no clamp, got %+v", tt.name, clamp)
+		case tt.clampedTo != "" && (clamp == nil || clamp.ClampedTo != tt.clampedTo || clamp.EndTime != tt.end || clamp.Requested != tt.window.String()):
+			t.Errorf("%s: expected a clamp to %s, got %+v", tt.name, tt.clampedTo, clamp)
+		}
+	}
+
+	if _, err := parseClampWindow("ticket"); err == nil {
+		t.Error("expected an unknown -clamp_window mode to be rejected")
+	}
+}
+
+func TestClampWindowReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ep1.vtt")
+	os.WriteFile(path, []byte("WEBVTT\nDuration: 10\n\n00:00:01.000 --> 00:00:09.000\nHello there\n"), 0o644)
+
+	cv := NewCaptionValidator("")
+	cv.clampWindow = ClampMedia
+	report, err := cv.Validate(path, Window{End: 60}, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Window != "00:00:00.000-00:00:10.000" || report.WindowClamped == nil || report.WindowClamped.Requested != "00:00:00.000-00:01:00.000" {
+		t.Errorf("expected the window clamped to the media duration, got %s, %+v", report.Window, report.WindowClamped)
+	}
+	if len(report.Errors) != 0 || report.Coverage.WallClock != 80 {
+		t.Errorf("expected coverage to pass over the clamped window, got %+v", report.Errors)
+	}
+
+	cv.clampWindow = ""
+	if report, _ := cv.Validate(path, Window{End: 60}, 80); report.WindowClamped != nil || len(report.Errors) != 1 {
+		t.Errorf("expected the window kept without -clamp_window, got %+v", report.Errors)
+	}
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// classifierChunk is how many seconds of captions are classified at a time when
+// -detect_chunk does not say, so a finding points at a stretch of the track
+const classifierChunk = 60.0
+
+// Classifier is a -classifier: an endpoint that scores cue text for something other
+// than its language, such as toxicity or personal data, and how its answers map to
+// issues
+type Classifier struct {
+	Name      string
+	Endpoint  string
+	ErrorType string            // issue type of flagged text; classifier_flagged by default
+	Labels    map[string]string // issue type per label the endpoint answers, overriding ErrorType
+	Threshold float64           // score from which text is flagged
+	Severity  string            // warning by default, so flagged text goes to review without failing the file
+}
+
+// ClassifierLabel is one score in a classifier's answer. Label is empty when the
+// endpoint answers a single score.
+type ClassifierLabel struct {
+	Label string
+	Score float64
+}
+
+// ClassifiedText is one stretch of cues a classifier scored at or over its threshold
+type ClassifiedText struct {
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+	Label     string  `json:"label,omitempty"`
+	Score     float64 `json:"score"`
+}
+
+// ClassifierError reports cue text a -classifier flagged. Its type is the one the
+// classifier's mapping gives, so toxicity and personal data findings can be told
+// apart, disabled and baselined on their own; its rule is always CV0902.
+type ClassifierError struct {
+	Type         string           `json:"type"`
+	Rule         string           `json:"rule"`
+	Severity     string           `json:"severity"`
+	Classifier   string           `json:"classifier"`
+	Threshold    float64          `json:"threshold"`
+	MaxScore     float64          `json:"max_score"`
+	StartTime    float64          `json:"start_time"`
+	EndTime      float64          `json:"end_time"`
+	Flagged      []ClassifiedText `json:"flagged"`
+	Description  string           `json:"description"`
+	SuggestedFix *SuggestedFix    `json:"suggested_fix,omitempty"`
+}
+
+// ClassifierFailedError reports a classifier that could not be reached, answered with
+// an error or answered no score, so the text it checks is unchecked
+type ClassifierFailedError struct {
+	Type         string        `json:"type"`
+	Rule         string        `json:"rule"`
+	Classifier   string        `json:"classifier"`
+	Endpoint     string        `json:"endpoint"`
+	Cause        string        `json:"cause"`
+	StatusCode   int           `json:"status_code,omitempty"`
+	Timeout      bool          `json:"timeout"`
+	Retries      int           `json:"retries"`
+	Description  string        `json:"description"`
+	SuggestedFix *SuggestedFix `json:"suggested_fix,omitempty"`
+}
+
+// parseClassifier reads a -classifier flag:
+//
+//	NAME=URL[,type=ISSUE_TYPE][,threshold=SCORE][,severity=error|warning][,label.LABEL=ISSUE_TYPE]
+//
+// e.g. "toxicity=http://classify:8080/toxicity,type=toxic_content,threshold=0.8"
+func parseClassifier(spec string) (Classifier, error) {
+	parts := strings.Split(spec, ",")
+	name, endpoint, ok := strings.Cut(parts[0], "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || endpoint == "" {
+		return Classifier{}, fmt.Errorf("invalid -classifier %q: expected NAME=URL", spec)
+	}
+	classifier := Classifier{
+		Name:      name,
+		Endpoint:  strings.TrimSpace(endpoint),
+		ErrorType: "classifier_flagged",
+		Threshold: 0.5,
+		Severity:  SeverityWarning,
+	}
+	for _, option := range parts[1:] {
+		key, value, ok := strings.Cut(strings.TrimSpace(option), "=")
+		if !ok || value == "" {
+			return Classifier{}, fmt.Errorf("invalid -classifier %s option %q: expected key=value", name, option)
+		}
+		switch {
+		case key == "type":
+			classifier.ErrorType = value
+		case key == "threshold":
+			threshold, err := strconv.ParseFloat(value, 64)
+			if err != nil || threshold < 0 {
+				return Classifier{}, fmt.Errorf("invalid -classifier %s threshold %q", name, value)
+			}
+			classifier.Threshold = threshold
+		case key == "severity":
+			if value != SeverityError && value != SeverityWarning {
+				return Classifier{}, fmt.Errorf("invalid -classifier %s severity %q: use %s or %s", name, value, SeverityError, SeverityWarning)
+			}
+			classifier.Severity = value
+		case strings.HasPrefix(key, "label.") && len(key) > len("label."):
+			if classifier.Labels == nil {
+				classifier.Labels = map[string]string{}
+			}
+			classifier.Labels[strings.ToLower(strings.TrimPrefix(key, "label."))] = value
+		default:
+			return Classifier{}, fmt.Errorf("unknown -classifier %s option %q: use type, threshold, severity or label.LABEL", name, key)
+		}
+	}
+	return classifier, nil
+}
+
+// issueType is the issue type text flagged under label is reported as
+func (c Classifier) issueType(label string) string {
+	if issueType, ok := c.Labels[strings.ToLower(label)]; ok {
+		return issueType
+	}
+	return c.ErrorType
+}
+
+// labelKeys name the label of one score in a classifier's answer
+var labelKeys = []string{"label", "category", "entity", "type", "name"}
+
+// parseClassifierResponse reads the scores in a classifier's answer. Classifiers
+// answer in several shapes:
+//
+//	0.93, as a bare body or JSON number, or true and false for 1 and 0
+//	{"score": 0.93}, with any of the score keys, or {"flagged": true}
+//	{"labels": [{"label": "insult", "score": 0.9}, ...]}, under any of the candidate keys
+//	[{"label": "email", "score": 0.99}, ...]
+//	{"toxicity": 0.9, "insult": 0.2}, a score per label
+//
+// ok is false when the answer holds no score.
+func parseClassifierResponse(body []byte) (labels []ClassifierLabel, ok bool) {
+	body = bytes.TrimSpace(body)
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		score, err := strconv.ParseFloat(string(body), 64)
+		if err != nil {
+			return nil, false
+		}
+		return []ClassifierLabel{{Score: score}}, true
+	}
+	labels = labelsFromJSON(decoded)
+	return labels, len(labels) > 0
+}
+
+// labelsFromJSON finds the scores in a decoded JSON value
+func labelsFromJSON(value interface{}) []ClassifierLabel {
+	switch v := value.(type) {
+	case float64:
+		return []ClassifierLabel{{Score: v}}
+	case bool:
+		return []ClassifierLabel{{Score: boolScore(v)}}
+	case []interface{}:
+		var labels []ClassifierLabel
+		for _, item := range v {
+			labels = append(labels, labelsFromJSON(item)...)
+		}
+		return labels
+	case map[string]interface{}:
+		fields := foldKeys(v)
+		for _, key := range append(slices.Clone(candidateKeys), "labels", "entities", "categories") {
+			if list, ok := fields[key].([]interface{}); ok {
+				return labelsFromJSON(list)
+			}
+		}
+		label := ""
+		for _, key := range labelKeys {
+			if name, ok := fields[key].(string); ok {
+				label = name
+				break
+			}
+		}
+		for _, key := range scoreKeys {
+			if score, ok := fields[key].(float64); ok {
+				return []ClassifierLabel{{Label: label, Score: score}}
+			}
+		}
+		if flagged, ok := fields["flagged"].(bool); ok {
+			return []ClassifierLabel{{Label: label, Score: boolScore(flagged)}}
+		}
+		if label != "" {
+			return []ClassifierLabel{{Label: label, Score: 1}} // a bare label, as PII detectors list the entities found
+		}
+		var labels []ClassifierLabel
+		for name, field := range v {
+			if score, ok := field.(float64); ok {
+				labels = append(labels, ClassifierLabel{Label: name, Score: score})
+			}
+		}
+		slices.SortFunc(labels, func(a, b ClassifierLabel) int { return strings.Compare(a.Label, b.Label) })
+		return labels
+	}
+	return nil
+}
+
+func boolScore(flagged bool) float64 {
+	if flagged {
+		return 1
+	}
+	return 0
+}
+
+// classificationCache keeps each classifier's scores by the text they were for, for
+// the whole run, so text repeated across files, such as credits and disclaimers, is
+// sent once
+type classificationCache struct {
+	mu     sync.Mutex
+	labels map[string][]ClassifierLabel
+}
+
+func newClassificationCache() *classificationCache {
+	return &classificationCache{labels: map[string][]ClassifierLabel{}}
+}
+
+func classificationKey(classifier, text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return classifier + ":" + hex.EncodeToString(sum[:])
+}
+
+// get returns cached scores; a nil cache holds nothing
+func (c *classificationCache) get(classifier, text string) ([]ClassifierLabel, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	labels, ok := c.labels[classificationKey(classifier, text)]
+	return labels, ok
+}
+
+func (c *classificationCache) put(classifier, text string, labels []ClassifierLabel) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.labels[classificationKey(classifier, text)] = labels
+}
+
+// classify scores text with a classifier, through the cache, retrying as language
+// detection calls are. Once every attempt has failed the error is a *DetectionError.
+func (cv *CaptionValidator) classify(ctx context.Context, classifier Classifier, text string) ([]ClassifierLabel, error) {
+	if labels, ok := cv.classifications.get(classifier.Name, text); ok {
+		return labels, nil
+	}
+	var labels []ClassifierLabel
+	retries, status, err := cv.retryCall(ctx, func() (int, error) {
+		body, _, status, err := cv.postText(ctx, classifier.Endpoint, classifier.Name+" classifier", text)
+		if err != nil {
+			return status, err
+		}
+		var ok bool
+		if labels, ok = parseClassifierResponse(body); !ok {
+			sample := bytes.TrimSpace(body)
+			if len(sample) > detectorBodySample {
+				sample = sample[:detectorBodySample]
+			}
+			return status, fmt.Errorf("unrecognized %s classifier response: %q", classifier.Name, strings.ToValidUTF8(string(sample), ""))
+		}
+		return status, nil
+	})
+	if err != nil {
+		return nil, &DetectionError{StatusCode: status, Retries: retries, Err: err}
+	}
+	cv.classifications.put(classifier.Name, text, labels)
+	return labels, nil
+}
+
+// classifierText is the cue text sent to classifiers: all of it, since toxicity and
+// personal data hide in any cue, redacted with -redact as detection text is
+func (cv *CaptionValidator) classifierText(captions []Caption) string {
+	var parts []string
+	for _, caption := range captions {
+		if caption.Text != "" {
+			parts = append(parts, caption.Text)
+		}
+	}
+	return redactText(strings.Join(parts, " "), cv.redactMode)
+}
+
+// runClassifiers scores the track with every -classifier, -detect_chunk seconds (or
+// classifierChunk) at a time, and reports the text scored at or over each one's
+// threshold as one issue per issue type. A classifier that fails is reported as
+// classifier_failed and skipped for the rest of the file.
+func (cv *CaptionValidator) runClassifiers(ctx context.Context, captions []Caption) []interface{} {
+	if len(captions) == 0 {
+		return nil
+	}
+	seconds := cv.detectChunk
+	if seconds <= 0 {
+		seconds = classifierChunk
+	}
+	chunks := languageChunks(captions, seconds)
+
+	var issues []interface{}
+	for _, classifier := range cv.classifiers {
+		found := map[string]*ClassifierError{}
+		var order []string
+		first := 0 // index of the chunk's first cue
+		for _, chunk := range chunks {
+			cues := make([]int, len(chunk))
+			for i := range chunk {
+				cues[i] = first + i + 1
+			}
+			first += len(chunk)
+			text := cv.classifierText(chunk)
+			if text == "" {
+				continue
+			}
+			labels, err := cv.classify(ctx, classifier, text)
+			if err != nil {
+				log.Printf("Classifier %s: giving up on the file at %.3fs: %v", classifier.Name, chunk[0].StartTime, err)
+				issues = append(issues, cv.classifierFailed(classifier, err))
+				break
+			}
+			end := chunk[len(chunk)-1].EndTime
+			for _, label := range labels {
+				if label.Score < classifier.Threshold {
+					continue
+				}
+				issueType := classifier.issueType(label.Label)
+				issue, ok := found[issueType]
+				if !ok {
+					issue = &ClassifierError{
+						Type:         issueType,
+						Rule:         ruleIDs["classifier_flagged"],
+						Severity:     classifier.Severity,
+						Classifier:   classifier.Name,
+						Threshold:    classifier.Threshold,
+						StartTime:    chunk[0].StartTime,
+						SuggestedFix: &SuggestedFix{Action: FixReviewText},
+					}
+					found[issueType] = issue
+					order = append(order, issueType)
+				}
+				issue.MaxScore = max(issue.MaxScore, label.Score)
+				issue.EndTime = max(issue.EndTime, end)
+				issue.Flagged = append(issue.Flagged, ClassifiedText{StartTime: chunk[0].StartTime, EndTime: end, Label: label.Label, Score: label.Score})
+				for _, cue := range cues {
+					if !slices.Contains(issue.SuggestedFix.Cues, cue) {
+						issue.SuggestedFix.Cues = append(issue.SuggestedFix.Cues, cue)
+					}
+				}
+			}
+		}
+		for _, issueType := range order {
+			issue := found[issueType]
+			issue.Description = fmt.Sprintf("The %s classifier flagged %d stretch(es) of cue text, scoring up to %.2f against a threshold of %.2f", classifier.Name, len(issue.Flagged), issue.MaxScore, classifier.Threshold)
+			issue.SuggestedFix.Description = fmt.Sprintf("Review %s and edit or sign off the flagged text", cueRange(issue.SuggestedFix.Cues))
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// classifierFailed describes a classifier call that failed after its retries
+func (cv *CaptionValidator) classifierFailed(classifier Classifier, err error) *ClassifierFailedError {
+	failure := &ClassifierFailedError{
+		Type:        "classifier_failed",
+		Classifier:  classifier.Name,
+		Endpoint:    classifier.Endpoint,
+		Cause:       err.Error(),
+		Timeout:     isTimeout(err),
+		Description: fmt.Sprintf("The %s classifier failed: %v", classifier.Name, err),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixRetryDetection,
+			Description: fmt.Sprintf("Check the %s classifier endpoint and re-run validation", classifier.Name),
+		},
+	}
+	var detectionErr *DetectionError
+	if errors.As(err, &detectionErr) {
+		failure.Cause = detectionErr.Err.Error()
+		failure.StatusCode = detectionErr.StatusCode
+		failure.Retries = detectionErr.Retries
+	}
+	return failure
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseClassifier(t *testing.T) {
+	classifier, err := parseClassifier("pii=http://classify/pii,threshold=0.9,severity=error,label.EMAIL=pii_email")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Classifier{
+		Name:      "pii",
+		Endpoint:  "http://classify/pii",
+		ErrorType: "classifier_flagged",
+		Labels:    map[string]string{"email": "pii_email"},
+		Threshold: 0.9,
+		Severity:  SeverityError,
+	}
+	if !reflect.DeepEqual(classifier, want) {
+		t.Errorf("expected %+v, got %+v", want, classifier)
+	}
+	if classifier.issueType("Email") != "pii_email" || classifier.issueType("phone") != "classifier_flagged" {
+		t.Errorf("unexpected label mapping %v", classifier.Labels)
+	}
+
+	for _, spec := range []string{"http://classify", "toxicity=", "toxicity=http://c,threshold=high", "toxicity=http://c,severity=info", "toxicity=http://c,colour=red"} {
+		if _, err := parseClassifier(spec); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
+
+func TestParseClassifierResponse(t *testing.T) {
+	tests := []struct {
+		body string
+		want []ClassifierLabel
+	}{
+		{"0.93", []ClassifierLabel{{Score: 0.93}}},
+		{"true", []ClassifierLabel{{Score: 1}}},
+		{`{"Score": 0.4}`, []ClassifierLabel{{Score: 0.4}}},
+		{`{"flagged": false}`, []ClassifierLabel{{Score: 0}}},
+		{`{"labels": [{"label": "insult", "score": 0.9}, {"label": "threat", "score": 0.1}]}`, []ClassifierLabel{{"insult", 0.9}, {"threat", 0.1}}},
+		{`{"entities": [{"entity": "email", "start": 4}]}`, []ClassifierLabel{{"email", 1}}},
+		{`{"toxicity": 0.8, "insult": 0.2}`, []ClassifierLabel{{"insult", 0.2}, {"toxicity", 0.8}}},
+	}
+	for _, test := range tests {
+		labels, ok := parseClassifierResponse([]byte(test.body))
+		if !ok || !reflect.DeepEqual(labels, test.want) {
+			t.Errorf("%s: expected %v, got %v (ok %v)", test.body, test.want, labels, ok)
+		}
+	}
+	for _, body := range []string{"toxic", `{"status": "ok"}`, "[]"} {
+		if labels, ok := parseClassifierResponse([]byte(body)); ok {
+			t.Errorf("%s: expected no scores, got %v", body, labels)
+		}
+	}
+}
+
+func TestRunClassifiers(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if strings.Contains(string(body), "idiot") {
+			w.Write([]byte(`{"labels": [{"label": "insult", "score": 0.92}, {"label": "threat", "score": 0.1}]}`))
+			return
+		}
+		w.Write([]byte(`{"labels": [{"label": "insult", "score": 0.05}]}`))
+	}))
+	defer server.Close()
+
+	cv := NewCaptionValidator("")
+	cv.classifications = newClassificationCache()
+	cv.classifiers = []Classifier{
+		{Name: "toxicity", Endpoint: server.URL + "/toxicity", ErrorType: "toxic_content", Threshold: 0.8, Severity: SeverityWarning},
+		{Name: "pii", Endpoint: server.URL + "/broken", ErrorType: "classifier_flagged", Threshold: 0.5, Severity: SeverityWarning},
+	}
+	captions := []Caption{
+		{StartTime: 0, EndTime: 4, Text: "Good morning"},
+		{StartTime: 70, EndTime: 73, Text: "You idiot"},
+		{StartTime: 80, EndTime: 82, Text: "Sorry"},
+	}
+	issues := cv.runClassifiers(context.Background(), captions)
+	if len(issues) != 2 {
+		t.Fatalf("expected a finding and a failure, got %v", issues)
+	}
+	toxic, ok := issues[0].(*ClassifierError)
+	if !ok || toxic.Type != "toxic_content" || toxic.Rule != "CV0902" || toxic.MaxScore != 0.92 || toxic.StartTime != 70 || toxic.EndTime != 82 {
+		t.Fatalf("unexpected finding %+v", issues[0])
+	}
+	if !reflect.DeepEqual(toxic.SuggestedFix.Cues, []int{2, 3}) || toxic.SuggestedFix.Action != FixReviewText {
+		t.Errorf("expected a review of cues 2-3, got %+v", toxic.SuggestedFix)
+	}
+	failed, ok := issues[1].(*ClassifierFailedError)
+	if !ok || failed.Classifier != "pii" || failed.StatusCode != http.StatusBadRequest {
+		t.Errorf("unexpected failure %+v", issues[1])
+	}
+	if issueRule(failed) != "CV0903" {
+		t.Errorf("expected rule CV0903, got %s", failed.Rule)
+	}
+
+	// The pii classifier gave up after its first chunk; a second pass is served from the cache
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 calls, got %d", calls.Load())
+	}
+	cv.classifiers = cv.classifiers[:1]
+	cv.runClassifiers(context.Background(), captions)
+	if calls.Load() != 3 {
+		t.Errorf("expected cached scores to be reused, got %d calls", calls.Load())
+	}
+}
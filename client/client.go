@@ -0,0 +1,261 @@
+// Package client is a Go client for the caption validator's server-mode HTTP API,
+// as described by the OpenAPI document the server publishes at /openapi.json.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Job states
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// Client calls a caption validator server
+type Client struct {
+	BaseURL    string
+	APIKey     string // sent as X-API-Key when the server has tenants configured
+	HTTPClient *http.Client
+}
+
+// New returns a client for the server at baseURL, e.g. "http://validator:8080".
+// apiKey may be empty when the server does not require authentication.
+func New(baseURL, apiKey string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), APIKey: apiKey, HTTPClient: http.DefaultClient}
+}
+
+// Options are the validation settings sent with an upload. Window takes precedence
+// over TStart/TEnd; a nil Coverage uses the server default.
+type Options struct {
+	Window   string
+	TStart   string
+	TEnd     string
+	Coverage *float64
+}
+
+// Job is an asynchronous validation
+type Job struct {
+	ID       string      `json:"id"`
+	Status   string      `json:"status"`
+	Created  time.Time   `json:"created"`
+	Finished *time.Time  `json:"finished,omitempty"`
+	Report   *FileReport `json:"report,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// FileReport is the validation result for one file; it passed if Errors is empty
+type FileReport struct {
+	File          string            `json:"file"`
+	Window        string            `json:"window"`
+	Coverage      *CoverageMetrics  `json:"coverage,omitempty"`
+	Speakers      []SpeakerStats    `json:"speakers,omitempty"`
+	ParseFailures []ParseFailure    `json:"parse_failures,omitempty"`
+	Errors        []ValidationError `json:"errors"`
+	ProgramError  string            `json:"program_error,omitempty"`
+}
+
+type CoverageMetrics struct {
+	WallClock        float64 `json:"wall_clock"`
+	DialogueWeighted float64 `json:"dialogue_weighted"`
+	MinReadable      float64 `json:"min_readable_seconds"`
+	Gating           string  `json:"gating_metric"`
+}
+
+type SpeakerStats struct {
+	Speaker          string  `json:"speaker"`
+	Cues             int     `json:"cues"`
+	CaptionedSeconds float64 `json:"captioned_seconds"`
+}
+
+type ParseFailure struct {
+	Line   int    `json:"line"`
+	Kind   string `json:"kind"`
+	Text   string `json:"text"`
+	Reason string `json:"reason"`
+}
+
+// ValidationError is one reported error. Fields specific to the error type are kept
+// in Raw for callers that need them.
+type ValidationError struct {
+	Type         string          `json:"type"`
+	Description  string          `json:"description"`
+	SuggestedFix *SuggestedFix   `json:"suggested_fix,omitempty"`
+	Raw          json.RawMessage `json:"-"`
+}
+
+func (e *ValidationError) UnmarshalJSON(data []byte) error {
+	type plain ValidationError
+	if err := json.Unmarshal(data, (*plain)(e)); err != nil {
+		return err
+	}
+	e.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+type SuggestedFix struct {
+	Action      string   `json:"action"`
+	Gaps        []Window `json:"gaps,omitempty"`
+	Cues        []int    `json:"cues,omitempty"`
+	Lines       []int    `json:"lines,omitempty"`
+	Shift       float64  `json:"shift_seconds,omitempty"`
+	Language    string   `json:"language,omitempty"`
+	Speakers    []string `json:"speakers,omitempty"`
+	Description string   `json:"description"`
+}
+
+type Window struct {
+	Start float64 `json:"start_time"`
+	End   float64 `json:"end_time"`
+}
+
+// Usage counts a tenant's server activity since startup
+type Usage struct {
+	Tenant      string `json:"tenant"`
+	Requests    int64  `json:"requests"`
+	Validations int64  `json:"validations"`
+	Passed      int64  `json:"passed"`
+	Failed      int64  `json:"failed"`
+	Errors      int64  `json:"errors"`
+	BytesIn     int64  `json:"bytes_in"`
+}
+
+// APIError is a non-success response from the server
+type APIError struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration // set when the job queue is full
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("caption validator: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Validate uploads content and waits for its report
+func (c *Client) Validate(ctx context.Context, name string, content io.Reader, opts Options) (*FileReport, error) {
+	var report FileReport
+	if err := c.upload(ctx, "/validate", name, content, opts, http.StatusOK, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// SubmitJob queues content for asynchronous validation
+func (c *Client) SubmitJob(ctx context.Context, name string, content io.Reader, opts Options) (*Job, error) {
+	var job Job
+	if err := c.upload(ctx, "/jobs", name, content, opts, http.StatusAccepted, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetJob fetches a job's status, and its report once finished
+func (c *Client) GetJob(ctx context.Context, id string) (*Job, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/jobs/"+id, nil)
+	if err != nil {
+		return nil, err
+	}
+	var job Job
+	if err := c.do(req, http.StatusOK, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Usage fetches the calling tenant's usage counters
+func (c *Client) Usage(ctx context.Context) (*Usage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/usage", nil)
+	if err != nil {
+		return nil, err
+	}
+	var usage Usage
+	if err := c.do(req, http.StatusOK, &usage); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// WaitJob polls a job every interval until it is done or failed
+func (c *Client) WaitJob(ctx context.Context, id string, interval time.Duration) (*Job, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		job, err := c.GetJob(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if job.Status == JobDone || job.Status == JobFailed {
+			return job, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// upload posts content as a multipart form with the validation options
+func (c *Client) upload(ctx context.Context, path, name string, content io.Reader, opts Options, want int, out interface{}) error {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	fields := map[string]string{"window": opts.Window, "t_start": opts.TStart, "t_end": opts.TEnd}
+	if opts.Coverage != nil {
+		fields["coverage"] = strconv.FormatFloat(*opts.Coverage, 'f', -1, 64)
+	}
+	for key, value := range fields {
+		if value != "" {
+			writer.WriteField(key, value)
+		}
+	}
+	part, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, content); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return c.do(req, want, out)
+}
+
+// do sends req and decodes the response into out, or returns an *APIError
+func (c *Client) do(req *http.Request, want int, out interface{}) error {
+	if c.APIKey != "" {
+		req.Header.Set("X-API-Key", c.APIKey)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != want {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return &APIError{StatusCode: resp.StatusCode, Message: apiErr.Error, RetryAfter: time.Duration(retryAfter) * time.Second}
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+)
+
+// command is one subcommand of the CLI. help and completion have no run function:
+// they read this table, so main runs them itself.
+type command struct {
+	name    string
+	aliases []string
+	summary string
+	run     func(args []string)
+}
+
+// commands are listed in the order help shows them. Arguments that do not start
+// with a command name are validated, as before subcommands existed.
+var commands = []command{
+	{name: "validate", summary: "Validate caption coverage, language and quality (the default command)", run: runValidate},
+	{name: "probe", summary: "Print the format, encoding, cue count and timing range of files", run: runProbe},
+	{name: "conform", aliases: []string{"convert"}, summary: "Rewrite a file's cues to a delivery profile, optionally in another format", run: runConform},
+	{name: "suggest", summary: "Print edit lists that split long cues and merge short ones", run: runSuggest},
+	{name: "check-endpoint", summary: "Check that a language detector answers known samples correctly", run: runCheckEndpoint},
+	{name: "follow", summary: "Poll a live WebVTT stream and alert when its coverage drops", run: func(args []string) { runFollow(shutdownContext(), args) }},
+	{name: "merge-reports", summary: "Combine the reports of sharded batch runs into one, with totals", run: runMergeReports},
+	{name: "serve", summary: "Serve validation over HTTP", run: func(args []string) { runServe(shutdownContext(), args) }},
+	{name: "lsp", summary: "Send live diagnostics to caption editors as a language server on stdin and stdout", run: runLSP},
+	{name: "help", summary: "Show the commands, or a command's flags and examples"},
+	{name: "completion", summary: "Print a bash, zsh or fish completion script"},
+}
+
+// commandExamples are printed under each command's flags by -h
+var commandExamples = map[string][]string{
+	"validate": {
+		"caption-validator -endpoint http://localhost:8081/detect -t_end 30 -language en-US episode.srt",
+		"caption-validator validate -endpoint http://localhost:8081/detect -coverage 90 -fail_on fail episodes/",
+	},
+	"probe": {
+		"caption-validator probe episode.srt episode.vtt",
+		"caption-validator probe -cues episode.srt",
+	},
+	"conform": {
+		"caption-validator conform -profile bbc -format webvtt -o episode.vtt episode.srt",
+		"caption-validator convert -language ko-KR episode.srt",
+	},
+	"suggest": {
+		"caption-validator suggest -max_cps 17 episode.srt",
+		"caption-validator suggest -language ja-JP episode.vtt",
+	},
+	"check-endpoint": {
+		"caption-validator check-endpoint -endpoint http://localhost:8081/detect",
+	},
+	"follow": {
+		"caption-validator follow -endpoint http://localhost:8081/detect -trailing 2m https://live.example.com/captions.vtt",
+	},
+	"merge-reports": {
+		"caption-validator merge-reports shard-*.jsonl > catalog.jsonl",
+	},
+	"serve": {
+		"caption-validator serve -addr :8080 -endpoint http://localhost:8081/detect",
+	},
+	"lsp": {
+		"caption-validator lsp -profile bbc -language en-GB",
+	},
+	"completion": {
+		"source <(caption-validator completion bash)",
+		"caption-validator completion fish > ~/.config/fish/completions/caption-validator.fish",
+	},
+}
+
+// findCommand returns the command called name, or nil
+func findCommand(name string) *command {
+	for i, cmd := range commands {
+		if cmd.name == name || slices.Contains(cmd.aliases, name) {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// commandUsage returns a usage function for a command's flag set: the usage line,
+// the flags and the command's examples
+func commandUsage(fs *flag.FlagSet, name, usage string) func() {
+	return func() {
+		w := fs.Output()
+		fmt.Fprintf(w, "Usage: caption-validator %s\n", usage)
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(w, "\nFlags:")
+			fs.PrintDefaults()
+		}
+		if examples := commandExamples[name]; len(examples) > 0 {
+			fmt.Fprintln(w, "\nExamples:")
+			for _, example := range examples {
+				fmt.Fprintf(w, "  %s\n", example)
+			}
+		}
+	}
+}
+
+// printCommands lists the commands with their summaries
+func printCommands(w io.Writer) {
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		name := cmd.name
+		if len(cmd.aliases) > 0 {
+			name += " (" + strings.Join(cmd.aliases, ", ") + ")"
+		}
+		fmt.Fprintf(w, "  %-26s %s\n", name, cmd.summary)
+	}
+	fmt.Fprintln(w, "\nRun 'caption-validator help COMMAND' for a command's flags and examples.")
+}
+
+// runHelp implements the help command: the command list, or one command's usage
+func runHelp(args []string) {
+	if len(args) == 0 {
+		printCommands(os.Stdout)
+		return
+	}
+	cmd := findCommand(args[0])
+	switch {
+	case cmd == nil:
+		log.Fatalf("unknown command %q", args[0])
+	case cmd.name == "help":
+		printCommands(os.Stdout)
+	case cmd.name == "completion":
+		fs := flag.NewFlagSet("completion", flag.ExitOnError)
+		fs.SetOutput(os.Stdout)
+		commandUsage(fs, "completion", "completion bash|zsh|fish")()
+	default:
+		cmd.run([]string{"-h"})
+	}
+}
+
+// runCompletion implements the completion command. The scripts complete command
+// names and paths, and read a command's flags from its -h output when asked, so
+// they never go stale as flags are added.
+func runCompletion(args []string) {
+	if len(args) != 1 {
+		log.Fatal("Usage: caption-validator completion bash|zsh|fish")
+	}
+	var names []string
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+		names = append(names, cmd.aliases...)
+	}
+	switch args[0] {
+	case "bash":
+		fmt.Printf(bashCompletion, strings.Join(names, " "))
+	case "zsh":
+		fmt.Print("autoload -U +X bashcompinit && bashcompinit\n")
+		fmt.Printf(bashCompletion, strings.Join(names, " "))
+	case "fish":
+		fmt.Printf(fishCompletion, strings.Join(names, " "))
+	default:
+		log.Fatalf("unknown shell %q (use bash, zsh or fish)", args[0])
+	}
+}
+
+// bashCompletion is the bash (and, through bashcompinit, zsh) completion script;
+// %[1]s is the command names
+const bashCompletion = `_caption_validator() {
+    local cur=${COMP_WORDS[COMP_CWORD]} cmd=validate
+    if [[ $COMP_CWORD -gt 1 && " %[1]s " == *" ${COMP_WORDS[1]} "* ]]; then
+        cmd=${COMP_WORDS[1]}
+    elif [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then
+        COMPREPLY=($(compgen -W "%[1]s" -- "$cur") $(compgen -f -- "$cur"))
+        return
+    fi
+    if [[ $cur == -* ]]; then
+        COMPREPLY=($(compgen -W "$(caption-validator "$cmd" -h 2>&1 | sed -n 's/^  \(-[A-Za-z0-9_-]*\).*/\1/p')" -- "$cur"))
+    else
+        COMPREPLY=($(compgen -f -- "$cur"))
+    fi
+}
+complete -o filenames -F _caption_validator caption-validator
+`
+
+// fishCompletion is the fish completion script; %[1]s is the command names
+const fishCompletion = `function __caption_validator_flags
+    set -l words (commandline -opc)
+    set -l cmd validate
+    if test (count $words) -ge 2; and contains -- $words[2] %[1]s
+        set cmd $words[2]
+    end
+    caption-validator $cmd -h 2>&1 | string replace -rf '^  (-[A-Za-z0-9_-]+).*' '$1'
+end
+complete -c caption-validator -n __fish_use_subcommand -a '%[1]s'
+complete -c caption-validator -n 'string match -q -- "-*" (commandline -ct)' -f -a '(__caption_validator_flags)'
+`
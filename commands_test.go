@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFindCommand(t *testing.T) {
+	if cmd := findCommand("convert"); cmd == nil || cmd.name != "conform" {
+		t.Errorf("expected convert to be an alias of conform, got %+v", cmd)
+	}
+	if cmd := findCommand("episode.srt"); cmd != nil {
+		t.Errorf("expected a file path not to be a command, got %+v", cmd)
+	}
+	for _, cmd := range commands {
+		if cmd.run == nil && cmd.name != "help" && cmd.name != "completion" {
+			t.Errorf("command %s has nothing to run", cmd.name)
+		}
+	}
+}
+
+func TestCommandUsageListsFlagsAndExamples(t *testing.T) {
+	var out bytes.Buffer
+	fs := flag.NewFlagSet("suggest", flag.ContinueOnError)
+	fs.SetOutput(&out)
+	fs.Float64("max_cps", 20, "Characters per second")
+	commandUsage(fs, "suggest", "suggest [flags] captions-filepath")()
+	for _, expected := range []string{"Usage: caption-validator suggest [flags]", "-max_cps", "Examples:", "suggest -language ja-JP"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected usage to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	bash := fmt.Sprintf(bashCompletion, "validate probe")
+	if !strings.Contains(bash, `compgen -W "validate probe"`) || !strings.Contains(bash, "complete -o filenames -F _caption_validator caption-validator") {
+		t.Errorf("unexpected bash script:\n%s", bash)
+	}
+	fish := fmt.Sprintf(fishCompletion, "validate probe")
+	if !strings.Contains(fish, "-a 'validate probe'") || strings.Contains(fish, "%!") {
+		t.Errorf("unexpected fish script:\n%s", fish)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// lowConfidenceShare is the share of a stretch's scored cue time low-confidence cues
+// must pass for low_asr_confidence to flag it
+const lowConfidenceShare = 0.5
+
+// ConfidenceWindow is a stretch of the track dominated by low-confidence cues
+type ConfidenceWindow struct {
+	StartTime      float64 `json:"start_time"`
+	EndTime        float64 `json:"end_time"`
+	LowShare       float64 `json:"low_confidence_share"` // percent of scored cue time under the minimum
+	MeanConfidence float64 `json:"mean_confidence"`      // time-weighted over the scored cues
+}
+
+// LowASRConfidenceWarning reports stretches of an auto-caption track where the
+// recognizer was unsure of most of what it captioned, so a person should check them
+// before delivery
+type LowASRConfidenceWarning struct {
+	Type          string             `json:"type"`
+	Rule          string             `json:"rule"`
+	Severity      string             `json:"severity"`
+	MinConfidence float64            `json:"min_confidence"`
+	WindowSeconds float64            `json:"window_seconds"`
+	Windows       []ConfidenceWindow `json:"windows"`
+	StartTime     float64            `json:"start_time"`
+	Description   string             `json:"description"`
+	SuggestedFix  *SuggestedFix      `json:"suggested_fix,omitempty"`
+}
+
+// applyASRConfidence sets each cue's Confidence to the mean confidence of the ASR
+// words spoken during it, judged by their midpoints. Cues without scored words keep
+// a nil Confidence.
+func applyASRConfidence(captions []Caption, words []ASRWord) {
+	for i := range captions {
+		sum, n := 0.0, 0
+		for _, word := range words {
+			if word.Confidence == nil {
+				continue
+			}
+			if mid := (word.Start + word.End) / 2; mid >= captions[i].StartTime && mid < captions[i].EndTime {
+				sum += *word.Confidence
+				n++
+			}
+		}
+		captions[i].Confidence = nil
+		if n > 0 {
+			confidence := math.Round(sum/float64(n)*1000) / 1000
+			captions[i].Confidence = &confidence
+		}
+	}
+}
+
+// validateASRConfidence measures the window -asr_confidence_window seconds at a
+// time and warns about the stretches where cues under minConfidence make up more
+// than lowConfidenceShare of the cue time with a confidence. Flagged stretches that
+// touch are reported as one.
+func (cv *CaptionValidator) validateASRConfidence(captions []Caption, window Window, minConfidence, seconds float64) *LowASRConfidenceWarning {
+	if seconds <= 0 || window.Duration() <= 0 {
+		return nil
+	}
+	var windows []ConfidenceWindow
+	var cues []int
+	for start := window.Start; start < window.End; start += seconds {
+		stretch := Window{Start: start, End: min(start+seconds, window.End)}
+		scored, low, weighted := 0.0, 0.0, 0.0
+		var lowCues []int
+		for i, caption := range captions {
+			if caption.Confidence == nil {
+				continue
+			}
+			overlap, ok := stretch.Intersect(Window{Start: caption.StartTime, End: caption.EndTime})
+			if !ok {
+				continue
+			}
+			scored += overlap.Duration()
+			weighted += overlap.Duration() * *caption.Confidence
+			if *caption.Confidence < minConfidence {
+				low += overlap.Duration()
+				lowCues = append(lowCues, i+1)
+			}
+		}
+		if scored <= 0 || low/scored <= lowConfidenceShare {
+			continue
+		}
+		for _, cue := range lowCues {
+			if len(cues) == 0 || cues[len(cues)-1] < cue {
+				cues = append(cues, cue)
+			}
+		}
+		flagged := ConfidenceWindow{
+			StartTime:      stretch.Start,
+			EndTime:        stretch.End,
+			LowShare:       math.Round(low/scored*1000) / 10,
+			MeanConfidence: math.Round(weighted/scored*1000) / 1000,
+		}
+		if n := len(windows); n > 0 && windows[n-1].EndTime == flagged.StartTime {
+			// Merge into the previous stretch, weighting by duration
+			previous := windows[n-1]
+			a, b := previous.EndTime-previous.StartTime, flagged.EndTime-flagged.StartTime
+			previous.LowShare = math.Round((previous.LowShare*a+flagged.LowShare*b)/(a+b)*10) / 10
+			previous.MeanConfidence = math.Round((previous.MeanConfidence*a+flagged.MeanConfidence*b)/(a+b)*1000) / 1000
+			previous.EndTime = flagged.EndTime
+			windows[n-1] = previous
+			continue
+		}
+		windows = append(windows, flagged)
+	}
+	if len(windows) == 0 {
+		return nil
+	}
+	flaggedSeconds := 0.0
+	for _, flagged := range windows {
+		flaggedSeconds += flagged.EndTime - flagged.StartTime
+	}
+	return &LowASRConfidenceWarning{
+		Type:          "low_asr_confidence",
+		Severity:      SeverityWarning,
+		MinConfidence: minConfidence,
+		WindowSeconds: seconds,
+		Windows:       windows,
+		StartTime:     windows[0].StartTime,
+		Description:   fmt.Sprintf("%d stretch(es) totaling %.2fs are dominated by cues recognized with confidence under %g", len(windows), flaggedSeconds, minConfidence),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixReviewText,
+			Cues:        cues,
+			Description: fmt.Sprintf("Have a person check %s against the audio before delivery", cueRange(cues)),
+		},
+	}
+}
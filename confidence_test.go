@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadASRWordConfidence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "asr.json")
+	content := `[{"word": "hello", "start": 1, "end": 1.4, "confidence": 0.9},
+		{"word": "there", "start": 1.5, "end": 1.9, "probability": 0.4},
+		{"word": "friend", "start": 2, "end": 2.5}]`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	words, err := loadASRWords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(words) != 3 || *words[0].Confidence != 0.9 || *words[1].Confidence != 0.4 || words[2].Confidence != nil {
+		t.Errorf("unexpected words %+v", words)
+	}
+}
+
+func TestValidateASRConfidence(t *testing.T) {
+	score := func(confidence float64) *float64 { return &confidence }
+	words := []ASRWord{
+		{Word: "good", Start: 1, End: 2, Confidence: score(0.95)},
+		{Word: "morning", Start: 2, End: 3, Confidence: score(0.85)},
+		{Word: "mumble", Start: 61, End: 62, Confidence: score(0.3)},
+		{Word: "mumble", Start: 63, End: 64, Confidence: score(0.5)},
+		{Word: "static", Start: 125, End: 126, Confidence: score(0.2)},
+		{Word: "unscored", Start: 200, End: 201},
+	}
+	captions := []Caption{
+		{StartTime: 0, EndTime: 4, Text: "Good morning"},
+		{StartTime: 60, EndTime: 65, Text: "Something something"},
+		{StartTime: 124, EndTime: 128, Text: "Static"},
+		{StartTime: 199, EndTime: 202, Text: "Unscored"},
+	}
+	applyASRConfidence(captions, words)
+	if *captions[0].Confidence != 0.9 || *captions[1].Confidence != 0.4 || captions[3].Confidence != nil {
+		t.Fatalf("unexpected confidences %v, %v, %v", captions[0].Confidence, captions[1].Confidence, captions[3].Confidence)
+	}
+
+	cv := NewCaptionValidator("")
+	warn := cv.validateASRConfidence(captions, Window{Start: 0, End: 240}, 0.6, 60)
+	if warn == nil {
+		t.Fatal("expected low_asr_confidence")
+	}
+	want := []ConfidenceWindow{{StartTime: 60, EndTime: 180, LowShare: 100, MeanConfidence: 0.3}}
+	if !reflect.DeepEqual(warn.Windows, want) {
+		t.Errorf("expected the touching stretches merged as %+v, got %+v", want, warn.Windows)
+	}
+	if !reflect.DeepEqual(warn.SuggestedFix.Cues, []int{2, 3}) || warn.Severity != SeverityWarning {
+		t.Errorf("unexpected warning %+v", warn)
+	}
+
+	if warn := cv.validateASRConfidence(captions, Window{Start: 0, End: 240}, 0.1, 60); warn != nil {
+		t.Errorf("expected no warning with a lower minimum, got %+v", warn)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Coverage metrics that can gate delivery
+const (
+	CoverageWallClock        = "wall_clock"        // share of the window with any caption on screen
+	CoverageDialogueWeighted = "dialogue_weighted" // discounts cues shown too briefly to read
+)
+
+// coverageDecimals is the precision coverage percentages are rounded to before they
+// are compared or reported, so float noise in the last bits cannot flip a result
+const coverageDecimals = 2
+
+// coverageRounding documents the rounding rule in reports
+const coverageRounding = "percentages rounded half away from zero to 2 decimals before comparison; durations in whole milliseconds"
+
+// CoverageMetrics reports both coverage measures so policy can choose the gating one
+type CoverageMetrics struct {
+	WallClock        float64 `json:"wall_clock"`
+	DialogueWeighted float64 `json:"dialogue_weighted"`
+	MinReadable      float64 `json:"min_readable_seconds"`
+	EndPadding       float64 `json:"end_padding_seconds,omitempty"` // added to every cue's end before measuring
+	Gating           string  `json:"gating_metric"`
+	CoveredMs        int64   `json:"covered_ms"` // window milliseconds with a caption on screen, overlaps counted once
+	WindowMs         int64   `json:"window_ms"`
+	CoveredSeconds   float64 `json:"covered_seconds"`
+	WindowSeconds    float64 `json:"window_seconds"`
+	Rounding         string  `json:"rounding"`
+}
+
+// roundCoverage rounds a percentage to coverageDecimals, half away from zero
+func roundCoverage(percent float64) float64 {
+	scale := math.Pow10(coverageDecimals)
+	return math.Round(percent*scale) / scale
+}
+
+// coveragePasses reports whether actual meets required, allowing tolerance
+// percentage points of shortfall. Both sides are rounded first.
+func coveragePasses(actual, required, tolerance float64) bool {
+	return roundCoverage(actual) >= roundCoverage(required-tolerance)
+}
+
+// validCoverageMetric reports whether name is a supported gating metric
+func validCoverageMetric(name string) bool {
+	return name == CoverageWallClock || name == CoverageDialogueWeighted
+}
+
+// gatingValue returns the coverage percentage used to pass or fail the file
+func (m CoverageMetrics) gatingValue() float64 {
+	if m.Gating == CoverageDialogueWeighted {
+		return m.DialogueWeighted
+	}
+	return m.WallClock
+}
+
+// toMillis converts seconds to whole milliseconds, the resolution of caption timestamps
+func toMillis(seconds float64) int64 {
+	return int64(math.Round(seconds * 1000))
+}
+
+// padCueEnds returns captions with each end extended by padding seconds, as players
+// keep a cue's text on screen briefly after it ends and some specs measure coverage
+// that way. Captions are returned unchanged when padding is 0.
+func padCueEnds(captions []Caption, padding float64) []Caption {
+	if padding <= 0 {
+		return captions
+	}
+	padded := make([]Caption, len(captions))
+	for i, caption := range captions {
+		caption.EndTime += padding
+		padded[i] = caption
+	}
+	return padded
+}
+
+// coverageEdge is a cue starting or ending on the sweep line
+type coverageEdge struct {
+	at     int64 // milliseconds
+	weight int64 // readable milliseconds the cue counts for, out of the readable duration
+	start  bool
+}
+
+// measureCoverage computes wall-clock coverage and a dialogue-weighted variant where
+// each cue shorter than minReadable only counts in proportion to its duration/minReadable.
+// Cues are merged so overlaps count once, at the weight of the most readable cue on
+// screen, and all sums are integer milliseconds so the result does not depend on cue
+// order or platform.
+func measureCoverage(captions []Caption, window Window, minReadable float64, gating string) CoverageMetrics {
+	windowStart, windowEnd := toMillis(window.Start), toMillis(window.End)
+	readable := toMillis(minReadable)
+	if readable <= 0 {
+		readable = 1
+	}
+
+	edges := make([]coverageEdge, 0, 2*len(captions))
+	for _, caption := range captions {
+		start, end := toMillis(caption.StartTime), toMillis(caption.EndTime)
+		clippedStart, clippedEnd := max(start, windowStart), min(end, windowEnd)
+		if clippedEnd <= clippedStart {
+			continue
+		}
+		weight := readable
+		if minReadable > 0 {
+			weight = min(end-start, readable)
+		}
+		edges = append(edges,
+			coverageEdge{at: clippedStart, weight: weight, start: true},
+			coverageEdge{at: clippedEnd, weight: weight})
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].at < edges[j].at })
+
+	// Sweep the edges, counting each stretch once while any cue is active
+	var coveredMs, weightedUnits int64
+	active := map[int64]int{}
+	for i, edge := range edges {
+		if edge.start {
+			active[edge.weight]++
+		} else if active[edge.weight]--; active[edge.weight] == 0 {
+			delete(active, edge.weight)
+		}
+		if len(active) == 0 || i+1 == len(edges) {
+			continue
+		}
+		stretch := edges[i+1].at - edge.at
+		heaviest := int64(0)
+		for weight := range active {
+			heaviest = max(heaviest, weight)
+		}
+		coveredMs += stretch
+		weightedUnits += stretch * heaviest
+	}
+
+	if gating == "" {
+		gating = CoverageWallClock
+	}
+	windowMs := windowEnd - windowStart
+	return CoverageMetrics{
+		WallClock:        roundCoverage(float64(coveredMs*100) / float64(windowMs)),
+		DialogueWeighted: roundCoverage(float64(weightedUnits*100) / float64(windowMs*readable)),
+		MinReadable:      minReadable,
+		Gating:           gating,
+		CoveredMs:        coveredMs,
+		WindowMs:         windowMs,
+		CoveredSeconds:   float64(coveredMs) / 1000,
+		WindowSeconds:    float64(windowMs) / 1000,
+		Rounding:         coverageRounding,
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Coverage image layout in pixels
+const (
+	coverageImageWidth  = 1000
+	coverageImageHeight = 80
+	coverageBarTop      = 28
+	coverageBarHeight   = 24
+)
+
+// Coverage image colors
+var (
+	coveredColor  = color.RGBA{0x2e, 0x7d, 0x32, 0xff} // captioned
+	gapColor      = color.RGBA{0xc6, 0x28, 0x28, 0xff} // uncovered program time
+	excludedColor = color.RGBA{0xbd, 0xbd, 0xbd, 0xff} // outside the -program segments
+	axisColor     = color.RGBA{0x42, 0x42, 0x42, 0xff}
+)
+
+// coverageTimeline is the captioned and uncovered time of a window, ready to draw
+type coverageTimeline struct {
+	title    string
+	window   Window
+	covered  []Window
+	gaps     []Window
+	excluded []Window
+}
+
+// coverageTimeline splits the window into captioned ranges, gaps, and with -program
+// the time between program segments
+func (cv *CaptionValidator) coverageTimeline(file string, captions []Caption, window Window, coverage CoverageMetrics) coverageTimeline {
+	timeline := coverageTimeline{
+		title:  fmt.Sprintf("%s: %.2f%% %s coverage", filepath.Base(file), coverage.gatingValue(), coverage.Gating),
+		window: window,
+	}
+	if len(cv.program) == 0 {
+		timeline.gaps = coverageGaps(captions, window)
+	} else {
+		var segments []Window
+		for _, segment := range cv.program {
+			segments = append(segments, segment.Window)
+			timeline.gaps = append(timeline.gaps, coverageGaps(captions, segment.Window)...)
+		}
+		timeline.excluded = subtractWindows(window, segments)
+	}
+	timeline.covered = subtractWindows(window, append(append([]Window{}, timeline.gaps...), timeline.excluded...))
+	return timeline
+}
+
+// x maps a time to a horizontal pixel position on the bar
+func (t coverageTimeline) x(seconds float64) float64 {
+	return (seconds - t.window.Start) / t.window.Duration() * coverageImageWidth
+}
+
+// tickStep picks a round tick interval giving at most ten ticks across the window
+func (t coverageTimeline) tickStep() float64 {
+	for _, step := range []float64{1, 5, 10, 30, 60, 300, 600, 1800, 3600} {
+		if t.window.Duration()/step <= 10 {
+			return step
+		}
+	}
+	return 7200
+}
+
+// ticks returns the tick times from the first round time in the window
+func (t coverageTimeline) ticks() []float64 {
+	step := t.tickStep()
+	var ticks []float64
+	for at := float64(int(t.window.Start/step)) * step; at <= t.window.End; at += step {
+		if at >= t.window.Start {
+			ticks = append(ticks, at)
+		}
+	}
+	return ticks
+}
+
+// svg draws the timeline with a title and labeled ticks
+func (t coverageTimeline) svg() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n",
+		coverageImageWidth, coverageImageHeight, coverageImageWidth, coverageImageHeight)
+	fmt.Fprintf(&b, `<title>%s</title>`+"\n", html.EscapeString(t.title))
+	fmt.Fprintf(&b, `<text x="0" y="16" fill="%s">%s</text>`+"\n", hexColor(axisColor), html.EscapeString(t.title))
+	for _, layer := range []struct {
+		class  string
+		fill   color.RGBA
+		ranges []Window
+	}{{"covered", coveredColor, t.covered}, {"gap", gapColor, t.gaps}, {"excluded", excludedColor, t.excluded}} {
+		for _, r := range layer.ranges {
+			fmt.Fprintf(&b, `<rect class="%s" x="%.2f" y="%d" width="%.2f" height="%d" fill="%s"><title>%s</title></rect>`+"\n",
+				layer.class, t.x(r.Start), coverageBarTop, t.x(r.End)-t.x(r.Start), coverageBarHeight, hexColor(layer.fill), r)
+		}
+	}
+	for _, at := range t.ticks() {
+		x := t.x(at)
+		anchor := "middle"
+		switch {
+		case x < 30:
+			anchor = "start"
+		case x > coverageImageWidth-30:
+			anchor = "end"
+		}
+		fmt.Fprintf(&b, `<line x1="%.2f" y1="%d" x2="%.2f" y2="%d" stroke="%s"/>`+"\n", x, coverageBarTop+coverageBarHeight, x, coverageBarTop+coverageBarHeight+5, hexColor(axisColor))
+		fmt.Fprintf(&b, `<text x="%.2f" y="%d" text-anchor="%s" fill="%s">%s</text>`+"\n", x, coverageImageHeight-4, anchor, hexColor(axisColor), formatTimestamp(at)[:8])
+	}
+	b.WriteString("</svg>\n")
+	return b.Bytes()
+}
+
+// png draws the same bar and ticks without text, which the standard library cannot render
+func (t coverageTimeline) png() ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, coverageImageWidth, coverageImageHeight))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	fill := func(r Window, c color.RGBA) {
+		rect := image.Rect(int(t.x(r.Start)+0.5), coverageBarTop, int(t.x(r.End)+0.5), coverageBarTop+coverageBarHeight)
+		draw.Draw(img, rect, image.NewUniform(c), image.Point{}, draw.Src)
+	}
+	for _, r := range t.covered {
+		fill(r, coveredColor)
+	}
+	for _, r := range t.gaps {
+		fill(r, gapColor)
+	}
+	for _, r := range t.excluded {
+		fill(r, excludedColor)
+	}
+	for _, at := range t.ticks() {
+		x := min(int(t.x(at)+0.5), coverageImageWidth-1)
+		draw.Draw(img, image.Rect(x, coverageBarTop+coverageBarHeight, x+1, coverageBarTop+coverageBarHeight+5), image.NewUniform(axisColor), image.Point{}, draw.Src)
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// hexColor formats a color as #rrggbb
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// coverageImagePath fills the -coverage_image pattern for a caption file: {name} is
+// its base name without the extension
+func coverageImagePath(pattern, file string) string {
+	name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	return strings.ReplaceAll(pattern, "{name}", name)
+}
+
+// writeCoverageImage renders the coverage timeline of a file as SVG, or as PNG when
+// the -coverage_image path ends in .png
+func (cv *CaptionValidator) writeCoverageImage(file string, captions []Caption, window Window, coverage CoverageMetrics) error {
+	path := coverageImagePath(cv.coverageImage, file)
+	timeline := cv.coverageTimeline(file, captions, window, coverage)
+	content := timeline.svg()
+	if strings.EqualFold(filepath.Ext(path), ".png") {
+		var err error
+		if content, err = timeline.png(); err != nil {
+			return fmt.Errorf("failed to render coverage image: %w", err)
+		}
+	}
+	if err := os.WriteFile(longPath(path), content, 0644); err != nil {
+		return fmt.Errorf("failed to write coverage image: %w", err)
+	}
+	return nil
+}
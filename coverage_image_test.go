@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCoverageTimeline(t *testing.T) {
+	captions := []Caption{{StartTime: 0, EndTime: 10, Text: "One"}, {StartTime: 12, EndTime: 20, Text: "Two"}}
+	cv := NewCaptionValidator("")
+	timeline := cv.coverageTimeline("ep1.srt", captions, Window{Start: 0, End: 30}, CoverageMetrics{WallClock: 60, Gating: CoverageWallClock})
+	if len(timeline.covered) != 2 || len(timeline.gaps) != 2 || len(timeline.excluded) != 0 {
+		t.Fatalf("unexpected timeline: %+v", timeline)
+	}
+
+	// Time between program segments is neither covered nor a gap
+	cv.program = []ProgramSegment{{Name: "act 1", Window: Window{Start: 0, End: 10}}, {Name: "act 2", Window: Window{Start: 15, End: 30}}}
+	timeline = cv.coverageTimeline("ep1.srt", captions, Window{Start: 0, End: 30}, CoverageMetrics{WallClock: 20, Gating: CoverageWallClock})
+	if len(timeline.excluded) != 1 || timeline.excluded[0] != (Window{Start: 10, End: 15}) {
+		t.Errorf("expected the break between acts to be excluded, got %v", timeline.excluded)
+	}
+	if len(timeline.gaps) != 1 || timeline.gaps[0] != (Window{Start: 20, End: 30}) {
+		t.Errorf("expected one gap in act 2, got %v", timeline.gaps)
+	}
+	if len(timeline.covered) != 2 || timeline.covered[1] != (Window{Start: 15, End: 20}) {
+		t.Errorf("unexpected covered ranges: %v", timeline.covered)
+	}
+
+	svg := string(timeline.svg())
+	for _, want := range []string{`class="excluded" x="333.33"`, `class="gap" x="666.67"`, "ep1.srt: 20.00% wall_clock coverage", ">00:00:30<"} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG is missing %q:\n%s", want, svg)
+		}
+	}
+}
+
+func TestWriteCoverageImage(t *testing.T) {
+	dir := t.TempDir()
+	cv := NewCaptionValidator("")
+	cv.coverageImage = filepath.Join(dir, "{name}.png")
+	captions := []Caption{{StartTime: 0, EndTime: 5, Text: "One"}}
+	if err := cv.writeCoverageImage("episodes/ep1.srt", captions, Window{Start: 0, End: 10}, CoverageMetrics{WallClock: 50}); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "ep1.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.At(100, coverageBarTop+1); got != coveredColor {
+		t.Errorf("expected the first half to be covered, got %v", got)
+	}
+	if got := img.At(900, coverageBarTop+1); got != gapColor {
+		t.Errorf("expected the second half to be a gap, got %v", got)
+	}
+}
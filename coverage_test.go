@@ -0,0 +1,180 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMeasureCoverage(t *testing.T) {
+	captions := []Caption{
+		{StartTime: 0.0, EndTime: 4.0, Text: "A readable cue"},
+		{StartTime: 5.0, EndTime: 5.5, Text: "Flash"},
+	}
+
+	metrics := measureCoverage(captions, Window{Start: 0, End: 10}, 1.0, CoverageDialogueWeighted)
+	if metrics.WallClock != 45 {
+		t.Errorf("expected wall-clock coverage 45, got %f", metrics.WallClock)
+	}
+	// The half-second cue only counts for half of its duration
+	if metrics.DialogueWeighted != 42.5 {
+		t.Errorf("expected dialogue-weighted coverage 42.5, got %f", metrics.DialogueWeighted)
+	}
+	if metrics.gatingValue() != 42.5 {
+		t.Errorf("expected gating value 42.5, got %f", metrics.gatingValue())
+	}
+}
+
+func TestValidateCoverageGatingMetric(t *testing.T) {
+	captions := []Caption{
+		{StartTime: 0.0, EndTime: 4.0, Text: "A readable cue"},
+		{StartTime: 5.0, EndTime: 5.5, Text: "Flash"},
+	}
+	window := Window{Start: 0, End: 10}
+
+	cv := NewCaptionValidator("http://test.com")
+	if err := cv.validateCoverage(captions, window, 44); err != nil {
+		t.Errorf("unexpected wall-clock coverage error: %v", err)
+	}
+
+	cv.coverageMetric = CoverageDialogueWeighted
+	err := cv.validateCoverage(captions, window, 44)
+	if err == nil {
+		t.Fatal("expected dialogue-weighted coverage error, got none")
+	}
+	if err.GatingMetric != CoverageDialogueWeighted || err.ActualCoverage != 42.5 || err.WallClock != 45 {
+		t.Errorf("unexpected coverage error: %+v", err)
+	}
+}
+
+func TestCoverageToleranceAndRounding(t *testing.T) {
+	// 7.996 of 10 seconds is 79.96%
+	captions := []Caption{{StartTime: 0, EndTime: 7.996, Text: "Almost there"}}
+	window := Window{Start: 0, End: 10}
+
+	metrics := measureCoverage(captions, window, 1.0, CoverageWallClock)
+	if metrics.WallClock != 79.96 || metrics.CoveredSeconds != 7.996 || metrics.WindowSeconds != 10 || metrics.Rounding == "" {
+		t.Errorf("unexpected metrics: %+v", metrics)
+	}
+
+	cv := NewCaptionValidator("http://test.com")
+	err := cv.validateCoverage(captions, window, 80)
+	if err == nil {
+		t.Fatal("expected coverage error without tolerance")
+	}
+	if err.CoveredSeconds != 7.996 {
+		t.Errorf("expected raw covered seconds in the error, got %+v", err)
+	}
+
+	cv.tolerance = 0.05
+	if err := cv.validateCoverage(captions, window, 80); err != nil {
+		t.Errorf("expected 79.96%% to pass at 80%% with 0.05 tolerance, got %+v", err)
+	}
+	cv.tolerance = 0.03
+	if err := cv.validateCoverage(captions, window, 80); err == nil {
+		t.Error("expected 79.96% to fail at 80% with 0.03 tolerance")
+	}
+}
+
+func TestCoverageWarnThreshold(t *testing.T) {
+	// 8.5 of 10 seconds is 85%
+	captions := []Caption{{StartTime: 0, EndTime: 8.5, Text: "Mostly covered"}}
+	window := Window{Start: 0, End: 10}
+
+	cv := NewCaptionValidator("http://test.com")
+	cv.coverageWarn = 90
+	err := cv.validateCoverage(captions, window, 80)
+	if err == nil {
+		t.Fatal("expected a coverage warning under 90%")
+	}
+	if err.Severity != SeverityWarning || err.RequiredCoverage != 90 || err.Description != "Caption coverage of 85.00% is below the 90.00% warning threshold" {
+		t.Errorf("unexpected coverage warning: %+v", err)
+	}
+
+	err = cv.validateCoverage(captions, window, 86)
+	if err == nil || err.Severity != SeverityError || err.RequiredCoverage != 86 {
+		t.Errorf("expected a coverage error under the required 86%%, got %+v", err)
+	}
+
+	cv.coverageWarn = 85
+	if err := cv.validateCoverage(captions, window, 80); err != nil {
+		t.Errorf("expected 85%% to clear an 85%% warning threshold, got %+v", err)
+	}
+}
+
+func TestCoveragePassesRoundsBothSides(t *testing.T) {
+	// 80-0.05 is 79.95000000000000284 in floating point
+	if !coveragePasses(79.95, 80, 0.05) {
+		t.Error("expected 79.95 to pass at 80 with 0.05 tolerance")
+	}
+	if !coveragePasses(79.999999999, 80, 0) {
+		t.Error("expected 79.999999999 to round up to 80")
+	}
+	if coveragePasses(79.994, 80, 0) {
+		t.Error("expected 79.994 to round to 79.99 and fail")
+	}
+}
+
+func TestMeasureCoverageMergesOverlaps(t *testing.T) {
+	captions := []Caption{
+		{StartTime: 0, EndTime: 4, Text: "Speaker one"},
+		{StartTime: 2, EndTime: 2.5, Text: "Flash"},
+		{StartTime: 3, EndTime: 6, Text: "Speaker two"},
+	}
+
+	metrics := measureCoverage(captions, Window{Start: 0, End: 10}, 1.0, CoverageWallClock)
+	if metrics.CoveredMs != 6000 || metrics.WindowMs != 10000 || metrics.WallClock != 60 {
+		t.Errorf("expected 6000 of 10000ms covered once, got %+v", metrics)
+	}
+	// The flash overlaps a readable cue, so it does not lower the weighted coverage
+	if metrics.DialogueWeighted != 60 {
+		t.Errorf("expected dialogue-weighted 60, got %f", metrics.DialogueWeighted)
+	}
+}
+
+func TestMeasureCoverageIsOrderIndependent(t *testing.T) {
+	var captions []Caption
+	start := 0.0
+	for i := 0; i < 5000; i++ {
+		duration := 0.1 + float64(i%37)*0.113
+		captions = append(captions, Caption{StartTime: start, EndTime: start + duration, Text: "cue"})
+		start += duration*0.9 + float64(i%5)*0.07
+	}
+	window := Window{Start: 0, End: start}
+	expected := measureCoverage(captions, window, 1.0, CoverageWallClock)
+
+	shuffled := append([]Caption(nil), captions...)
+	rand.New(rand.NewSource(1)).Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	if got := measureCoverage(shuffled, window, 1.0, CoverageWallClock); got != expected {
+		t.Errorf("coverage depends on cue order: %+v vs %+v", got, expected)
+	}
+}
+
+func TestEndPadding(t *testing.T) {
+	captions := []Caption{
+		{StartTime: 0, EndTime: 2, Text: "First"},
+		{StartTime: 2.25, EndTime: 4, Text: "Second"},
+		{StartTime: 9.9, EndTime: 10, Text: "Last"},
+	}
+	window := Window{Start: 0, End: 10}
+
+	if metrics := measureCoverage(padCueEnds(captions, 0), window, 1.0, CoverageWallClock); metrics.WallClock != 38.5 {
+		t.Errorf("expected 38.5%% without padding, got %f", metrics.WallClock)
+	}
+	// Padding closes the short gap, overlaps count once and nothing counts past the window
+	metrics := measureCoverage(padCueEnds(captions, 0.25), window, 1.0, CoverageWallClock)
+	if metrics.WallClock != 43.5 {
+		t.Errorf("expected 43.5%% with 0.25s padding, got %f", metrics.WallClock)
+	}
+	if captions[0].EndTime != 2 {
+		t.Errorf("expected the captions to be left alone, got %+v", captions[0])
+	}
+
+	cv := NewCaptionValidator("http://test.com")
+	cv.endPadding = 0.25
+	if err := cv.validateCoverage(padCueEnds(captions, cv.endPadding), window, 42); err != nil {
+		t.Errorf("expected padded coverage to pass 42%%, got %+v", err)
+	}
+	if err := cv.validateCoverage(padCueEnds(captions, cv.endPadding), window, 50); err == nil || err.EndPadding != 0.25 {
+		t.Errorf("expected a coverage error recording the padding, got %+v", err)
+	}
+}
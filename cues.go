@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+	"sync"
+)
+
+// cueBlock is a run of non-blank lines from a caption file
+type cueBlock struct {
+	Line      int      // 1-based line number of the first line
+	Offset    int64    // byte offset of the first line
+	EndLine   int      // 1-based line number of the last line
+	End       int64    // byte offset just past the last line's line break
+	Lines     []string // trimmed lines; the slice is reused for the next block
+	Offsets   []int64  // byte offset of each line; reused like Lines
+	First     bool     // first block in the file (the WebVTT header)
+	Truncated bool     // the file ends inside this block without a final newline
+}
+
+// SourceSpan locates a cue in its source file: the bytes and lines of its block,
+// from the cue identifier or timing line to the line break after its last text
+// line. Replacing those bytes patches the cue in place.
+type SourceSpan struct {
+	Offset    int64 `json:"byte_offset"`
+	Length    int64 `json:"byte_length"`
+	StartLine int   `json:"start_line"`
+	EndLine   int   `json:"end_line"`
+}
+
+// span is where the block sits in its file
+func (b cueBlock) span() SourceSpan {
+	return SourceSpan{Offset: b.Offset, Length: b.End - b.Offset, StartLine: b.Line, EndLine: b.EndLine}
+}
+
+// part is the i-th part of a block cut at starts, a list of line indexes
+func (b cueBlock) part(starts []int, i int) cueBlock {
+	first, last := starts[i], len(b.Lines)
+	part := b
+	if i+1 < len(starts) {
+		last = starts[i+1]
+		part.End, part.Truncated = b.Offsets[last], false
+	}
+	part.Lines, part.Offsets = b.Lines[first:last], b.Offsets[first:last]
+	part.Line, part.Offset, part.EndLine = b.Line+first, b.Offsets[first], b.Line+last-1
+	part.First = b.First && i == 0
+	return part
+}
+
+// srtCueStarts returns the line index each cue of an SRT block starts at when the
+// block holds several cues not separated by blank lines, or nil when it holds one.
+// A cue starts at an index line followed by a timing line, or at a timing line
+// after cue text.
+func (cv *CaptionValidator) srtCueStarts(lines []string) []int {
+	var starts []int
+	timing := -1 // the current cue's timing line
+	for i := 0; i < len(lines); i++ {
+		switch {
+		case timing >= 0 && i+1 < len(lines) && isIndexLine(lines[i]) && strings.Contains(lines[i+1], "-->"):
+			starts = append(starts, i)
+			timing = i + 1
+			i++
+		case strings.Contains(lines[i], "-->") && (timing < 0 || cv.isSRTTimingLine(lines[i])):
+			if timing >= 0 {
+				starts = append(starts, i)
+			}
+			timing = i
+		}
+	}
+	if starts == nil {
+		return nil
+	}
+	return append([]int{0}, starts...)
+}
+
+// isIndexLine reports whether line is an SRT cue number
+func isIndexLine(line string) bool {
+	return line != "" && strings.Trim(line, "0123456789") == ""
+}
+
+// isSRTTimingLine reports whether line is a timing line srtCue would accept, rather
+// than cue text that happens to contain an arrow
+func (cv *CaptionValidator) isSRTTimingLine(line string) bool {
+	times, ok := splitTiming(line)
+	if !ok {
+		return false
+	}
+	_, _, _, err := parseCueTimes(times, cv.parseSRTTime, cv.parseWebVTTTime)
+	return err == nil
+}
+
+// blockReaders pools the read buffers of scanBlocks across files
+var blockReaders = sync.Pool{New: func() any { return bufio.NewReaderSize(nil, 64<<10) }}
+
+// scanBlocks streams the blank-line separated blocks of source. Each block's lines
+// share one string, and the Lines slice is reused, so a block costs one allocation.
+func scanBlocks(source io.Reader) iter.Seq2[cueBlock, error] {
+	return func(yield func(cueBlock, error) bool) {
+		reader := blockReaders.Get().(*bufio.Reader)
+		reader.Reset(source)
+		defer func() {
+			reader.Reset(nil)
+			blockReaders.Put(reader)
+		}()
+		var block cueBlock
+		var text, long []byte // the block's trimmed lines back to back; overflow for long lines
+		var ends []int        // end of each line in text
+		first := true
+		var offset int64
+
+		// flush turns the buffered lines into the block's Lines
+		flush := func() {
+			joined := string(text)
+			block.Lines = block.Lines[:0]
+			start := 0
+			for _, end := range ends {
+				block.Lines = append(block.Lines, joined[start:end])
+				start = end
+			}
+			text, ends = text[:0], ends[:0]
+		}
+
+		for lineNo := 1; ; lineNo++ {
+			var line []byte
+			var err error
+			line, long, err = readLine(reader, long)
+			if err != nil && err != io.EOF {
+				yield(cueBlock{}, err)
+				return
+			}
+			lineOffset := offset
+			offset += int64(len(line))
+
+			trimmed := bytes.TrimSpace(line)
+			if len(trimmed) > 0 {
+				if len(ends) == 0 {
+					block.Line, block.Offset = lineNo, lineOffset
+				}
+				block.EndLine, block.End = lineNo, offset
+				block.Offsets = append(block.Offsets, lineOffset)
+				text = append(text, trimmed...)
+				ends = append(ends, len(text))
+			} else if len(ends) > 0 {
+				flush()
+				block.First, first = first, false
+				if !yield(block, nil) {
+					return
+				}
+				block = cueBlock{Lines: block.Lines, Offsets: block.Offsets[:0]}
+			}
+
+			if err == io.EOF {
+				// A non-blank last line without a newline means the file may have been cut off
+				block.Truncated = len(trimmed) > 0
+				break
+			}
+		}
+		if len(ends) > 0 {
+			flush()
+			block.First = first
+			yield(block, nil)
+		}
+	}
+}
+
+// readLine returns the next line of reader, newline included. The line is only
+// valid until the next read; lines longer than the reader's buffer are gathered
+// in long, which is returned for reuse.
+func readLine(reader *bufio.Reader, long []byte) (line, grown []byte, err error) {
+	line, err = reader.ReadSlice('\n')
+	if err != bufio.ErrBufferFull {
+		return line, long, err
+	}
+	long = append(long[:0], line...)
+	for err == bufio.ErrBufferFull {
+		line, err = reader.ReadSlice('\n')
+		long = append(long, line...)
+	}
+	return long, long, err
+}
+
+// Cues streams the cues of a WebVTT, SRT, OCR JSON, TTML or SCC source; text formats
+// are read without loading the whole file.
+// Blocks that cannot be decoded are yielded as *ParseFailure errors and iteration
+// continues; a repaired hybrid timestamp is yielded the same way just before its cue.
+// Rules disabled by a "NOTE cv-disable ... next-cue" comment are set on the next cue.
+// SRT cues run together without blank lines are split at their index lines.
+// Any other error ends the sequence.
+func (cv *CaptionValidator) Cues(source io.Reader, format string) iter.Seq2[Caption, error] {
+	return func(yield func(Caption, error) bool) {
+		var decode func(cueBlock) (*Caption, *ParseFailure)
+		switch format {
+		case "webvtt":
+			decode = cv.webVTTCue
+		case "srt":
+			decode = cv.srtCue
+		case FormatOCRJSON, FormatTTML, FormatSCC:
+			cues := cv.ocrCues
+			switch format {
+			case FormatTTML:
+				cues = cv.ttmlCues
+			case FormatSCC:
+				cues = cv.sccCues
+			}
+			for caption, err := range cues(source) {
+				if !yield(caption, err) {
+					return
+				}
+			}
+			return
+		default:
+			yield(Caption{}, fmt.Errorf("unsupported format: %s", format))
+			return
+		}
+
+		var pending []string // rules disabled for the next cue
+		for block, err := range scanBlocks(source) {
+			if err != nil {
+				yield(Caption{}, fmt.Errorf("failed to read file: %w", err))
+				return
+			}
+			if format == "webvtt" {
+				if rules, scope, ok := parseSuppression(block.Lines); ok {
+					if scope == ScopeNextCue {
+						pending = append(pending, rules...)
+					}
+					continue
+				}
+			}
+
+			// Some SRT authoring tools run cues together without blank lines
+			var starts []int
+			if format == "srt" {
+				starts = cv.srtCueStarts(block.Lines)
+			}
+			for i := range max(len(starts), 1) {
+				cue := block
+				if starts != nil {
+					cue = block.part(starts, i)
+				}
+
+				// A repaired block yields its note first, then the cue
+				caption, failure := decode(cue)
+				if failure != nil && !yield(Caption{}, failure) {
+					return
+				}
+				if caption != nil {
+					caption.Suppressed, pending = strings.Join(pending, " "), nil
+					if !yield(*caption, nil) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// estimatedCueBytes is a typical size of one cue in a caption file, used to size
+// the cue slice before parsing
+const estimatedCueBytes = 64
+
+// collectCues drains a cue sequence into cues and parse failures. expected sizes
+// the cue slice up front; an estimate is fine, and 0 grows it as needed.
+func collectCues(cues iter.Seq2[Caption, error], expected int) ([]Caption, []ParseFailure, error) {
+	captions := make([]Caption, 0, expected)
+	var failures []ParseFailure
+	for caption, err := range cues {
+		var failure *ParseFailure
+		switch {
+		case errors.As(err, &failure):
+			failures = append(failures, *failure)
+		case err != nil:
+			return nil, nil, err
+		default:
+			captions = append(captions, caption)
+		}
+	}
+	return captions, failures, nil
+}
+
+// Visitor consumes cues during a Walk, e.g. to gather statistics, run a custom
+// check or export the cues, without the caller holding them in a slice
+type Visitor interface {
+	Visit(index int, cue Caption) error
+}
+
+// VisitorFunc adapts a function to the Visitor interface
+type VisitorFunc func(index int, cue Caption) error
+
+func (f VisitorFunc) Visit(index int, cue Caption) error {
+	return f(index, cue)
+}
+
+// Walk reads source once and feeds each cue, with its 0-based index, to every
+// visitor in order. It stops at the first visitor or read error; parse failures
+// do not stop the walk and are returned instead.
+func (cv *CaptionValidator) Walk(source io.Reader, format string, visitors ...Visitor) ([]ParseFailure, error) {
+	var failures []ParseFailure
+	index := 0
+	for caption, err := range cv.Cues(source, format) {
+		var failure *ParseFailure
+		if errors.As(err, &failure) {
+			failures = append(failures, *failure)
+			continue
+		}
+		if err != nil {
+			return failures, err
+		}
+
+		for _, visitor := range visitors {
+			if err := visitor.Visit(index, caption); err != nil {
+				return failures, err
+			}
+		}
+		index++
+	}
+	return failures, nil
+}
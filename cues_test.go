@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWalkFeedsEveryVisitor(t *testing.T) {
+	cv := NewCaptionValidator("http://test.com")
+	srt := "1\n00:00:01,000 --> 00:00:02,000\nOne\n\nbroken block\n\n2\n00:00:03,000 --> 00:00:05,000\nTwo\n"
+
+	duration := 0.0
+	var texts []string
+	stats := VisitorFunc(func(index int, cue Caption) error {
+		duration += cue.EndTime - cue.StartTime
+		return nil
+	})
+	export := VisitorFunc(func(index int, cue Caption) error {
+		texts = append(texts, cue.Text)
+		return nil
+	})
+
+	failures, err := cv.Walk(strings.NewReader(srt), "srt", stats, export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if duration != 3 || strings.Join(texts, ",") != "One,Two" {
+		t.Errorf("expected both visitors to see both cues, got duration %f and texts %q", duration, texts)
+	}
+	if len(failures) != 1 || failures[0].Kind != FailureMissingTiming || failures[0].Line != 5 {
+		t.Errorf("expected missing_timing failure on line 5, got %+v", failures)
+	}
+}
+
+func TestWalkStopsOnVisitorError(t *testing.T) {
+	cv := NewCaptionValidator("http://test.com")
+	vtt := "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nOne\n\n00:00:03.000 --> 00:00:04.000\nTwo\n"
+
+	stop := errors.New("stop")
+	visited := 0
+	_, err := cv.Walk(strings.NewReader(vtt), "webvtt", VisitorFunc(func(index int, cue Caption) error {
+		visited++
+		return stop
+	}))
+	if !errors.Is(err, stop) || visited != 1 {
+		t.Errorf("expected walk to stop after first cue, got err %v after %d cue(s)", err, visited)
+	}
+}
+
+func TestCuesEarlyBreak(t *testing.T) {
+	cv := NewCaptionValidator("http://test.com")
+	vtt := "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nOne\n\n00:00:03.000 --> 00:00:04.000\nTwo\n"
+
+	for cue, err := range cv.Cues(strings.NewReader(vtt), "webvtt") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cue.Text != "One" {
+			t.Errorf("expected first cue, got %+v", cue)
+		}
+		break
+	}
+
+	for _, err := range cv.Cues(strings.NewReader(vtt), "ttml") {
+		if err == nil {
+			t.Error("expected unsupported format error")
+		}
+	}
+}
+
+func TestScanBlocksLongLinesAndReuse(t *testing.T) {
+	long := strings.Repeat("word ", 30_000) // longer than the read buffer
+	source := "first line\n  second line  \n\n\n" + long + "\n\nlast"
+
+	var blocks []cueBlock
+	for block, err := range scanBlocks(strings.NewReader(source)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Lines is reused for the next block, so keep a copy
+		block.Lines = append([]string(nil), block.Lines...)
+		blocks = append(blocks, block)
+	}
+
+	if len(blocks) != 3 {
+		t.Fatalf("expected 3 blocks, got %d", len(blocks))
+	}
+	if strings.Join(blocks[0].Lines, "|") != "first line|second line" || !blocks[0].First {
+		t.Errorf("unexpected first block %+v", blocks[0])
+	}
+	if blocks[1].Line != 5 || len(blocks[1].Lines) != 1 || blocks[1].Lines[0] != strings.TrimSpace(long) {
+		t.Errorf("expected the long line intact at line 5, got line %d with %d line(s)", blocks[1].Line, len(blocks[1].Lines))
+	}
+	if blocks[2].Lines[0] != "last" || !blocks[2].Truncated {
+		t.Errorf("expected a truncated last block, got %+v", blocks[2])
+	}
+}
+
+func TestCuesSplitSRTWithoutBlankLines(t *testing.T) {
+	cv := NewCaptionValidator("http://test.com")
+	srt := "1\r\n00:00:01,000 --> 00:00:02,000\r\nOne\r\n2\r\n00:00:03,000 --> 00:00:05,000\r\nTwo\r\nlines\r\n00:00:06,000 --> 00:00:07,000\r\nA --> B\r\n4\r\n00:00:08,000 --> 00:00:09,000\r\n4\r\n"
+
+	var cues []Caption
+	for cue, err := range cv.Cues(strings.NewReader(srt), "srt") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		cues = append(cues, cue)
+	}
+	var texts []string
+	for _, cue := range cues {
+		texts = append(texts, cue.Text)
+	}
+	if strings.Join(texts, "|") != "One|Two lines|A --> B|4" {
+		t.Fatalf("expected four cues, got %q", texts)
+	}
+	second := cues[1].Source
+	if second.StartLine != 4 || second.EndLine != 7 || srt[second.Offset:second.Offset+second.Length] != "2\r\n00:00:03,000 --> 00:00:05,000\r\nTwo\r\nlines\r\n" {
+		t.Errorf("unexpected span of the second cue %+v", second)
+	}
+	if last := cues[3].Source; srt[last.Offset:last.Offset+last.Length] != "4\r\n00:00:08,000 --> 00:00:09,000\r\n4\r\n" {
+		t.Errorf("unexpected span of the last cue %+v", last)
+	}
+}
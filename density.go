@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// LowDialogueDensityWarning reports a track with far fewer words than its window's
+// dialogue should need, such as sporadic placeholder lines held on screen long enough
+// to pass coverage
+type LowDialogueDensityWarning struct {
+	Type          string        `json:"type"`
+	Rule          string        `json:"rule"`
+	Severity      string        `json:"severity"`
+	MinWPM        float64       `json:"min_words_per_minute"`
+	WPM           float64       `json:"words_per_minute"`
+	Words         int           `json:"words"`
+	WindowSeconds float64       `json:"window_seconds"`
+	Description   string        `json:"description"`
+	SuggestedFix  *SuggestedFix `json:"suggested_fix,omitempty"`
+}
+
+// dialogueWords counts the words of every cue shown in the window
+func dialogueWords(captions []Caption, window Window) int {
+	words := 0
+	for _, caption := range captions {
+		if _, ok := window.Intersect(Window{Start: caption.StartTime, End: caption.EndTime}); ok {
+			words += len(captionWords(caption.Text))
+		}
+	}
+	return words
+}
+
+// validateDialogueDensity warns when the window averages fewer than minWPM words
+// per minute
+func (cv *CaptionValidator) validateDialogueDensity(captions []Caption, window Window, minWPM float64) *LowDialogueDensityWarning {
+	minutes := window.Duration() / 60
+	if minutes <= 0 {
+		return nil
+	}
+	words := dialogueWords(captions, window)
+	wpm := math.Round(float64(words)/minutes*100) / 100
+	if wpm >= minWPM {
+		return nil
+	}
+	return &LowDialogueDensityWarning{
+		Type:          "low_dialogue_density",
+		Severity:      SeverityWarning,
+		MinWPM:        minWPM,
+		WPM:           wpm,
+		Words:         words,
+		WindowSeconds: window.Duration(),
+		Description:   fmt.Sprintf("Only %.2f words per minute over the window (%d words), below the plausible minimum of %g", wpm, words, minWPM),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixReplaceTrack,
+			Language:    cv.expectedLanguage,
+			Description: "Check the track captions all dialogue and is not a placeholder; replace it with a complete track if not",
+		},
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestDialogueDensityFlagsPlaceholderTrack(t *testing.T) {
+	cv := NewCaptionValidator("")
+	cv.expectedLanguage = "en-US"
+	window := Window{Start: 0, End: 600}
+
+	// Ten long cues cover the whole window with two words each: 2 words per minute
+	var placeholder []Caption
+	for i := 0; i < 10; i++ {
+		placeholder = append(placeholder, Caption{StartTime: float64(i * 60), EndTime: float64(i*60 + 60), Text: "[Dialogue continues]"})
+	}
+	warn := cv.validateDialogueDensity(placeholder, window, 20)
+	if warn == nil {
+		t.Fatal("expected low_dialogue_density for a placeholder track")
+	}
+	if warn.WPM != 2 || warn.Words != 20 || warn.Severity != SeverityWarning || warn.SuggestedFix.Action != FixReplaceTrack {
+		t.Errorf("unexpected warning %+v", warn)
+	}
+
+	// Cues outside the window do not count
+	if words := dialogueWords(append(placeholder, Caption{StartTime: 700, EndTime: 702, Text: "one two three"}), window); words != 20 {
+		t.Errorf("expected 20 words in the window, got %d", words)
+	}
+
+	dialogue := []Caption{{StartTime: 0, EndTime: 4, Text: "I told you to wait for me at the station, didn't I?"}}
+	if warn := cv.validateDialogueDensity(dialogue, Window{Start: 0, End: 30}, 20); warn != nil {
+		t.Errorf("expected no warning for dense dialogue, got %+v", warn)
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DetectorProtocolError reports a detector response that names no language in any
+// shape the validator understands. It is also the error detectLanguage returns for
+// such responses.
+type DetectorProtocolError struct {
+	Type         string        `json:"type"`
+	Rule         string        `json:"rule"`
+	Endpoint     string        `json:"endpoint"`
+	ContentType  string        `json:"content_type,omitempty"`
+	Body         string        `json:"body"` // the start of the response, for spotting the detector's format
+	Description  string        `json:"description"`
+	SuggestedFix *SuggestedFix `json:"suggested_fix,omitempty"`
+}
+
+func (e *DetectorProtocolError) Error() string {
+	return fmt.Sprintf("unrecognized language detector response (%s): %q", e.ContentType, e.Body)
+}
+
+// LanguageDetectionFailedError reports that the detector could not be reached or
+// answered with an error, so the track's language is unknown. It is kept apart from
+// incorrect_language, which is about the file, since a detector outage is not.
+type LanguageDetectionFailedError struct {
+	Type         string        `json:"type"`
+	Rule         string        `json:"rule"`
+	Endpoint     string        `json:"endpoint"`
+	ExpectedLang string        `json:"expected_language"`
+	Cause        string        `json:"cause"`
+	StatusCode   int           `json:"status_code,omitempty"` // the detector's HTTP status, when it answered
+	Timeout      bool          `json:"timeout"`
+	Retries      int           `json:"retries"`
+	Description  string        `json:"description"`
+	SuggestedFix *SuggestedFix `json:"suggested_fix,omitempty"`
+}
+
+// DetectionError is the error detectLanguage returns when every attempt failed
+type DetectionError struct {
+	StatusCode int // HTTP status of the last attempt, 0 when there was no answer
+	Retries    int // attempts made after the first
+	Err        error
+}
+
+func (e *DetectionError) Error() string {
+	if e.Retries > 0 {
+		return fmt.Sprintf("%v (after %d retry(s))", e.Err, e.Retries)
+	}
+	return e.Err.Error()
+}
+
+func (e *DetectionError) Unwrap() error { return e.Err }
+
+// detectorRetryDelay is the wait before the first retry; it doubles for each one after
+const detectorRetryDelay = 500 * time.Millisecond
+
+// retryableDetection reports whether a failed detection call is worth repeating: the
+// detector timed out, was unreachable, or answered 429 or 5xx. Other statuses will
+// not change, and nothing is retried past the validation deadline.
+func retryableDetection(ctx context.Context, status int) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
+
+// waitRetry waits out the backoff before retry number retries+1, returning false
+// if ctx ends first
+func waitRetry(ctx context.Context, retries int) bool {
+	timer := time.NewTimer(detectorRetryDelay << retries)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// retryCall makes a call to an external endpoint, repeating it up to cv.retries
+// times with a backoff while it fails in a way retryableDetection allows. It returns
+// the retries made and the last call's status and error.
+func (cv *CaptionValidator) retryCall(ctx context.Context, call func() (status int, err error)) (retries, status int, err error) {
+	for retries = 0; ; retries++ {
+		status, err = call()
+		if err == nil || retries >= cv.retries || !retryableDetection(ctx, status) || !waitRetry(ctx, retries) {
+			return retries, status, err
+		}
+	}
+}
+
+// postText posts cue text to an endpoint with the -detector_header headers and reads
+// the answer; status is the HTTP status when one came back. what names the endpoint
+// in errors, e.g. "language detection".
+func (cv *CaptionValidator) postText(ctx context.Context, endpoint, what, text string) (body []byte, contentType string, status int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(text))
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("failed to call %s endpoint: %w", what, err)
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	cv.setDetectorHeaders(req)
+	resp, err := cv.client.Do(req)
+	if context.Cause(ctx) == errValidationDeadline {
+		return nil, "", 0, fmt.Errorf("%w after %s", errValidationDeadline, cv.timeouts.Validation)
+	}
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("failed to call %s endpoint: %w", what, err)
+	}
+	defer drainClose(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", resp.StatusCode, fmt.Errorf("%s endpoint returned status: %d", what, resp.StatusCode)
+	}
+	body, err = io.ReadAll(io.LimitReader(resp.Body, maxDetectorResponse))
+	if err != nil {
+		return nil, "", resp.StatusCode, fmt.Errorf("failed to read %s response: %w", what, err)
+	}
+	return body, resp.Header.Get("Content-Type"), resp.StatusCode, nil
+}
+
+// detectionFailed describes a detection call that failed after its retries
+func (cv *CaptionValidator) detectionFailed(err error) *LanguageDetectionFailedError {
+	failure := &LanguageDetectionFailedError{
+		Type:         "language_detection_failed",
+		Endpoint:     cv.endpoint,
+		ExpectedLang: cv.expectedLanguage,
+		Cause:        err.Error(),
+		Timeout:      isTimeout(err),
+		Description:  fmt.Sprintf("Failed to detect language: %v", err),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixRetryDetection,
+			Description: "Check the language detection endpoint and re-run validation",
+		},
+	}
+	var detectionErr *DetectionError
+	if errors.As(err, &detectionErr) {
+		failure.Cause = detectionErr.Err.Error()
+		failure.StatusCode = detectionErr.StatusCode
+		failure.Retries = detectionErr.Retries
+	}
+	return failure
+}
+
+// maxDetectorResponse caps how much of a detection response is read
+const maxDetectorResponse = 1 << 20
+
+// detectorBodySample is how much of an unparsable response a protocol error quotes
+const detectorBodySample = 200
+
+// languageKeys name a detector's answer; candidateKeys name its list of candidates.
+// Keys are matched without regard to case.
+var (
+	languageKeys  = []string{"lang", "language"}
+	candidateKeys = []string{"candidates", "languages", "predictions", "results"}
+	scoreKeys     = []string{"score", "confidence", "probability"}
+)
+
+// languageTagPattern matches a bare BCP 47 style tag, such as a text/plain answer
+var languageTagPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(?:[-_][A-Za-z0-9]{1,8})*$`)
+
+// protocolError describes a response parseDetectorResponse could not read
+func (cv *CaptionValidator) protocolError(contentType string, body []byte) *DetectorProtocolError {
+	sample := bytes.TrimSpace(body)
+	if len(sample) > detectorBodySample {
+		sample = sample[:detectorBodySample]
+	}
+	return &DetectorProtocolError{
+		Type:        "detector_protocol_error",
+		Endpoint:    cv.endpoint,
+		ContentType: contentType,
+		Body:        strings.ToValidUTF8(string(sample), ""),
+		Description: "The language detector's response does not name a language",
+		SuggestedFix: &SuggestedFix{
+			Action:      FixCheckDetector,
+			Description: "Check that -endpoint is a language detector that answers with a language tag",
+		},
+	}
+}
+
+// parseDetectorResponse finds the language in a detection response. Detectors
+// answer in several shapes, all accepted whatever the Content-Type:
+//
+//	{"lang": "en-US"}, with any casing of lang or language, and extra fields ignored
+//	{"candidates": [{"language": "en", "score": 0.9}, ...]}, taking the highest score
+//	["en", "es"], taking the first
+//	en-US, as a bare text/plain body, or a language name such as English
+//
+// ok is false when no language can be found.
+func parseDetectorResponse(body []byte) (lang string, ok bool) {
+	body = bytes.TrimSpace(body)
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err == nil {
+		lang = languageFromJSON(decoded, false)
+		return lang, lang != ""
+	}
+	if word := strings.ToLower(string(body)); languageTagPattern.Match(body) || languageAliases[word] != "" || undeterminedLanguages[word] {
+		return string(body), true
+	}
+	return "", false
+}
+
+// languageFromJSON finds the language in a decoded JSON value. Strings under a
+// language key are taken as they are; other strings only when they look like a tag.
+func languageFromJSON(value interface{}, named bool) string {
+	switch v := value.(type) {
+	case string:
+		if named || languageTagPattern.MatchString(v) {
+			return strings.TrimSpace(v)
+		}
+	case map[string]interface{}:
+		fields := foldKeys(v)
+		for _, key := range languageKeys {
+			if lang := languageFromJSON(fields[key], true); lang != "" {
+				return lang
+			}
+		}
+		for _, key := range candidateKeys {
+			if lang := languageFromJSON(fields[key], false); lang != "" {
+				return lang
+			}
+		}
+	case []interface{}:
+		best, bestScore := "", math.Inf(-1)
+		for _, candidate := range v {
+			lang := languageFromJSON(candidate, named)
+			if lang == "" {
+				continue
+			}
+			if score := candidateScore(candidate); best == "" || score > bestScore {
+				best, bestScore = lang, score
+			}
+		}
+		return best
+	}
+	return ""
+}
+
+// candidateScore is a candidate's score, confidence or probability, or -Inf
+func candidateScore(candidate interface{}) float64 {
+	fields, ok := candidate.(map[string]interface{})
+	if !ok {
+		return math.Inf(-1)
+	}
+	fields = foldKeys(fields)
+	for _, key := range scoreKeys {
+		if score, ok := fields[key].(float64); ok {
+			return score
+		}
+	}
+	return math.Inf(-1)
+}
+
+// foldKeys lowercases an object's keys; a key already in lower case wins a clash
+func foldKeys(fields map[string]interface{}) map[string]interface{} {
+	folded := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		lower := strings.ToLower(key)
+		if _, clash := folded[lower]; !clash || key == lower {
+			folded[lower] = value
+		}
+	}
+	return folded
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseDetectorResponse(t *testing.T) {
+	tests := []struct {
+		body     string
+		expected string
+		ok       bool
+	}{
+		{`{"lang": "en-US"}`, "en-US", true},
+		{`{"Language": "en-US", "model": "v3", "latency_ms": 12}`, "en-US", true},
+		{`{"LANG": "pt-BR"}`, "pt-BR", true},
+		{`{"candidates": [{"language": "es", "score": 0.2}, {"language": "en", "score": 0.7}]}`, "en", true},
+		{`{"results": [{"lang": "fr", "Confidence": 0.4}, {"lang": "de", "confidence": 0.3}]}`, "fr", true},
+		{`[{"language": "it", "probability": 0.1}, {"language": "en", "probability": 0.9}]`, "en", true},
+		{`["ja", "ko"]`, "ja", true},
+		{`"en-GB"`, "en-GB", true},
+		{"en-US\n", "en-US", true},
+		{"en_US", "en_US", true},
+		{`{"lang": ""}`, "", false},
+		{`{"status": "ok"}`, "", false},
+		{`{"candidates": []}`, "", false},
+		{`"internal server error"`, "", false},
+		{"<html><body>Bad Gateway</body></html>", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		lang, ok := parseDetectorResponse([]byte(tt.body))
+		if lang != tt.expected || ok != tt.ok {
+			t.Errorf("%q: expected %q, %v; got %q, %v", tt.body, tt.expected, tt.ok, lang, ok)
+		}
+	}
+}
+
+func TestDetectorProtocolError(t *testing.T) {
+	body := `<html><body>Bad Gateway</body></html>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	cv := NewCaptionValidator(server.URL)
+	issue := cv.validateLanguage(context.Background(), []Caption{{StartTime: 1, EndTime: 3, Text: "Hello world"}})
+	protocolErr, ok := issue.(*DetectorProtocolError)
+	if !ok {
+		t.Fatalf("expected a detector_protocol_error, got %+v", issue)
+	}
+	if protocolErr.ContentType != "text/html" || protocolErr.Body != body || protocolErr.Endpoint != server.URL || protocolErr.SuggestedFix.Action != FixCheckDetector {
+		t.Errorf("unexpected error %+v", protocolErr)
+	}
+
+	path := filepath.Join(t.TempDir(), "sample.srt")
+	if err := os.WriteFile(path, []byte("1\n00:00:01,000 --> 00:00:03,000\nHello world\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report, err := cv.Validate(path, Window{Start: 1, End: 3}, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Errors) != 1 || issueRule(report.Errors[0]) != "CV0307" {
+		t.Errorf("expected one CV0307 issue, got %+v", report.Errors)
+	}
+}
+
+func TestDetectorPlainTextResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("es-ES\n"))
+	}))
+	defer server.Close()
+
+	lang, err := NewCaptionValidator(server.URL).detectLanguage(context.Background(), "Hola mundo")
+	if err != nil || lang != "es-ES" {
+		t.Errorf("expected es-ES, got %q (%v)", lang, err)
+	}
+}
+
+func TestLanguageDetectionFailed(t *testing.T) {
+	var calls atomic.Int32
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// While status is 200, the first call after a reset still fails with a 502
+		switch n := calls.Add(1); {
+		case status != http.StatusOK:
+			w.WriteHeader(status)
+		case n == 1:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte(`{"lang": "en-US"}`))
+		}
+	}))
+	defer server.Close()
+
+	cv := NewCaptionValidator(server.URL)
+	cv.retries = 1
+	captions := []Caption{{StartTime: 1, EndTime: 3, Text: "Hello world"}}
+	failure, ok := cv.validateLanguage(context.Background(), captions).(*LanguageDetectionFailedError)
+	if !ok {
+		t.Fatal("expected a language_detection_failed error")
+	}
+	if failure.StatusCode != 503 || failure.Retries != 1 || failure.Timeout || calls.Load() != 2 {
+		t.Errorf("unexpected failure %+v after %d calls", failure, calls.Load())
+	}
+	if failure.Cause != "language detection endpoint returned status: 503" || failure.SuggestedFix.Action != FixRetryDetection {
+		t.Errorf("unexpected failure %+v", failure)
+	}
+
+	// A client error is not retried
+	calls.Store(0)
+	status = http.StatusBadRequest
+	if failure, ok := cv.validateLanguage(context.Background(), captions).(*LanguageDetectionFailedError); !ok || failure.Retries != 0 || calls.Load() != 1 {
+		t.Errorf("expected one call, got %d: %+v", calls.Load(), failure)
+	}
+
+	// A retry that succeeds hides the failure
+	calls.Store(0)
+	status = http.StatusOK
+	if issue := cv.validateLanguage(context.Background(), captions); issue != nil || calls.Load() != 2 {
+		t.Errorf("expected the retry to detect en-US, got %+v after %d calls", issue, calls.Load())
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net"
+	"net/smtp"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxDigestFiles caps the failed files listed in a digest email; the counts cover all
+const maxDigestFiles = 50
+
+// EmailConfig is the -email file: where to send the digest of a run and how to link
+// to its reports
+type EmailConfig struct {
+	Host        string   `json:"host"`
+	Port        int      `json:"port,omitempty"` // defaults to 587
+	Username    string   `json:"username,omitempty"`
+	PasswordEnv string   `json:"password_env,omitempty"` // environment variable holding the SMTP password
+	From        string   `json:"from"`
+	To          []string `json:"to"`
+	Subject     string   `json:"subject,omitempty"`    // defaults to a pass/fail count; {files}, {failed} and {errors} are replaced
+	ReportURL   string   `json:"report_url,omitempty"` // link to the full run report, e.g. a pipeline artifact
+	FileURL     string   `json:"file_url,omitempty"`   // link to one file's report; {file} is replaced with its path, each segment escaped
+}
+
+// loadEmailConfig reads and checks an -email file
+func loadEmailConfig(path string) (*EmailConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email config: %w", err)
+	}
+	var config EmailConfig
+	if err := json.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("failed to decode email config: %w", err)
+	}
+	if config.Host == "" || config.From == "" || len(config.To) == 0 {
+		return nil, fmt.Errorf("email config needs host, from and at least one to address")
+	}
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	if config.PasswordEnv != "" && os.Getenv(config.PasswordEnv) == "" {
+		return nil, fmt.Errorf("email config: %s is not set", config.PasswordEnv)
+	}
+	return &config, nil
+}
+
+// runDigest collects the summaries of a run for the email digest, notifications and
+// -fail_on; a nil *runDigest collects nothing
+type runDigest struct {
+	mu        sync.Mutex
+	summaries []ValidationSummary
+}
+
+func (rd *runDigest) record(summary ValidationSummary) {
+	if rd == nil {
+		return
+	}
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+	rd.summaries = append(rd.summaries, summary)
+}
+
+// DigestCounts totals the results of a run
+type DigestCounts struct {
+	Files  int
+	Passed int
+	Warned int // passed with warnings only
+	Failed int
+	Errors int            // files that could not be validated
+	Types  map[string]int // failed and warned files per error type
+}
+
+// counts totals everything recorded so far
+func (rd *runDigest) counts() DigestCounts {
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+	counts := DigestCounts{Files: len(rd.summaries), Types: map[string]int{}}
+	for _, summary := range rd.summaries {
+		switch summary.Result {
+		case SummaryPass:
+			counts.Passed++
+		case SummaryWarn:
+			counts.Warned++
+		case SummaryFail:
+			counts.Failed++
+		default:
+			counts.Errors++
+		}
+		for _, errType := range summary.Types {
+			counts.Types[errType]++
+		}
+	}
+	return counts
+}
+
+// fileLink returns the report link for a file, or "" without a file_url
+func (config *EmailConfig) fileLink(file string) string {
+	if config.FileURL == "" {
+		return ""
+	}
+	segments := strings.Split(filepath.ToSlash(file), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.ReplaceAll(config.FileURL, "{file}", strings.Join(segments, "/"))
+}
+
+// subject returns the digest subject line
+func (config *EmailConfig) subject(counts DigestCounts) string {
+	subject := config.Subject
+	if subject == "" {
+		subject = "Caption validation: {failed} of {files} files failed"
+	}
+	return strings.NewReplacer(
+		"{files}", strconv.Itoa(counts.Files),
+		"{failed}", strconv.Itoa(counts.Failed),
+		"{errors}", strconv.Itoa(counts.Errors),
+	).Replace(subject)
+}
+
+// body writes the plain-text digest: totals, failed files per error type, then the
+// failed files and those that could not be validated, with links to their reports
+func (rd *runDigest) body(config *EmailConfig) string {
+	counts := rd.counts()
+	var b strings.Builder
+	fmt.Fprintf(&b, "Files validated: %d\nPassed: %d\nWarnings only: %d\nFailed: %d\nCould not be validated: %d\n", counts.Files, counts.Passed, counts.Warned, counts.Failed, counts.Errors)
+	if config.ReportURL != "" {
+		fmt.Fprintf(&b, "Full report: %s\n", config.ReportURL)
+	}
+	for _, key := range slices.Sorted(maps.Keys(resultMeta)) {
+		fmt.Fprintf(&b, "%s: %s\n", key, resultMeta[key])
+	}
+
+	if len(counts.Types) > 0 {
+		b.WriteString("\nFiles by error type:\n")
+		for _, errType := range counts.topTypes(len(counts.Types)) {
+			fmt.Fprintf(&b, "  %-28s %d\n", errType, counts.Types[errType])
+		}
+	}
+
+	rd.mu.Lock()
+	summaries := slices.Clone(rd.summaries)
+	rd.mu.Unlock()
+	slices.SortFunc(summaries, func(a, b ValidationSummary) int { return strings.Compare(a.File, b.File) })
+	listed := 0
+	for _, summary := range summaries {
+		if summary.Result == SummaryPass {
+			continue
+		}
+		if listed == 0 {
+			b.WriteString("\nFiles needing attention:\n")
+		}
+		if listed++; listed > maxDigestFiles {
+			continue
+		}
+		if summary.Result == SummaryError {
+			fmt.Fprintf(&b, "  %s: could not be validated (%s)\n", summary.File, summary.Reason)
+		} else {
+			fmt.Fprintf(&b, "  %s: %s\n", summary.File, strings.Join(summary.Types, ", "))
+		}
+		if link := config.fileLink(summary.File); link != "" {
+			fmt.Fprintf(&b, "    %s\n", link)
+		}
+	}
+	if listed > maxDigestFiles {
+		fmt.Fprintf(&b, "  ... and %d more\n", listed-maxDigestFiles)
+	}
+	return b.String()
+}
+
+// sendMail delivers a message; tests replace it
+var sendMail = smtp.SendMail
+
+// send emails the digest. smtp.SendMail upgrades to TLS with STARTTLS when the
+// server offers it, and refuses to send credentials over a plain connection.
+func (rd *runDigest) send(config *EmailConfig) error {
+	header := []string{
+		"From: " + config.From,
+		"To: " + strings.Join(config.To, ", "),
+		"Subject: " + config.subject(rd.counts()),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+	}
+	body := strings.ReplaceAll(rd.body(config), "\n", "\r\n")
+	message := strings.Join(header, "\r\n") + "\r\n\r\n" + body
+
+	var auth smtp.Auth
+	if config.Username != "" {
+		auth = smtp.PlainAuth("", config.Username, os.Getenv(config.PasswordEnv), config.Host)
+	}
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+	if err := sendMail(addr, auth, config.From, config.To, []byte(message)); err != nil {
+		return fmt.Errorf("failed to send email digest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDigestBody(t *testing.T) {
+	digest := &runDigest{}
+	digest.record(ValidationSummary{File: "b/ep2.srt", Result: SummaryFail, Errors: 2, Types: []string{"caption_coverage", "incorrect_language"}})
+	digest.record(ValidationSummary{File: "a/ep1.srt", Result: SummaryFail, Errors: 1, Types: []string{"caption_coverage"}})
+	digest.record(ValidationSummary{File: "c/notes.txt", Result: SummaryError, Reason: "unsupported caption format: unknown"})
+	digest.record(ValidationSummary{File: "d/ep 3.srt", Result: SummaryFail, Types: []string{"markup_error"}})
+
+	config := &EmailConfig{ReportURL: "https://ci.example.com/run/42", FileURL: "https://reports.example.com/{file}.json"}
+	expected := `Files validated: 4
+Passed: 0
+Warnings only: 0
+Failed: 3
+Could not be validated: 1
+Full report: https://ci.example.com/run/42
+
+Files by error type:
+  caption_coverage             2
+  incorrect_language           1
+  markup_error                 1
+
+Files needing attention:
+  a/ep1.srt: caption_coverage
+    https://reports.example.com/a/ep1.srt.json
+  b/ep2.srt: caption_coverage, incorrect_language
+    https://reports.example.com/b/ep2.srt.json
+  c/notes.txt: could not be validated (unsupported caption format: unknown)
+    https://reports.example.com/c/notes.txt.json
+  d/ep 3.srt: markup_error
+    https://reports.example.com/d/ep%203.srt.json
+`
+	if body := digest.body(config); body != expected {
+		t.Errorf("unexpected digest body:\n%s", body)
+	}
+	if subject := config.subject(digest.counts()); subject != "Caption validation: 3 of 4 files failed" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+}
+
+func TestDigestListsAtMostMaxFiles(t *testing.T) {
+	digest := &runDigest{}
+	for i := range maxDigestFiles + 3 {
+		digest.record(ValidationSummary{File: strings.Repeat("x", i+1), Result: SummaryFail, Types: []string{"caption_coverage"}})
+	}
+	body := digest.body(&EmailConfig{})
+	if !strings.Contains(body, "caption_coverage             53") || !strings.HasSuffix(body, "  ... and 3 more\n") {
+		t.Errorf("expected all files counted and 3 left unlisted, got:\n%s", body)
+	}
+}
+
+func TestDigestSend(t *testing.T) {
+	var addr, from string
+	var to []string
+	var message string
+	sendMail = func(a string, auth smtp.Auth, f string, t []string, msg []byte) error {
+		addr, from, to, message = a, f, t, string(msg)
+		return nil
+	}
+	defer func() { sendMail = smtp.SendMail }()
+
+	digest := &runDigest{}
+	digest.record(ValidationSummary{File: "ep1.srt", Result: SummaryPass})
+	config := &EmailConfig{Host: "smtp.example.com", Port: 587, From: "qc@example.com", To: []string{"ops@example.com", "lead@example.com"}, Subject: "Sweep: {failed}/{files} failed"}
+	if err := digest.send(config); err != nil {
+		t.Fatal(err)
+	}
+	if addr != "smtp.example.com:587" || from != "qc@example.com" || len(to) != 2 {
+		t.Errorf("unexpected envelope %s %s %v", addr, from, to)
+	}
+	if !strings.Contains(message, "To: ops@example.com, lead@example.com\r\n") || !strings.Contains(message, "Subject: Sweep: 0/1 failed\r\n") || !strings.Contains(message, "\r\n\r\nFiles validated: 1\r\n") {
+		t.Errorf("unexpected message:\n%s", message)
+	}
+
+	sendMail = func(string, smtp.Auth, string, []string, []byte) error { return errors.New("connection refused") }
+	if err := digest.send(config); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("expected the delivery error, got %v", err)
+	}
+}
+
+func TestLoadEmailConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "email.json")
+		os.WriteFile(path, []byte(content), 0644)
+		return path
+	}
+
+	config, err := loadEmailConfig(write(`{"host": "smtp.example.com", "from": "qc@example.com", "to": ["ops@example.com"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Port != 587 {
+		t.Errorf("expected the submission port by default, got %d", config.Port)
+	}
+	if _, err := loadEmailConfig(write(`{"host": "smtp.example.com", "from": "qc@example.com"}`)); err == nil {
+		t.Error("expected an error without recipients")
+	}
+	t.Setenv("CV_TEST_SMTP_PASSWORD", "")
+	if _, err := loadEmailConfig(write(`{"host": "smtp.example.com", "from": "qc@example.com", "to": ["ops@example.com"], "password_env": "CV_TEST_SMTP_PASSWORD"}`)); err == nil {
+		t.Error("expected an error for an unset password variable")
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// QC directives a WebVTT file can carry in NOTE blocks, e.g. "NOTE cv-expect-lang: fr-FR"
+const (
+	DirectiveExpectLang = "cv-expect-lang" // expected language, instead of -language
+	DirectiveExclude    = "cv-exclude"     // a range left out of coverage; repeatable
+	DirectiveCoverage   = "cv-coverage"    // required coverage percentage, when above -coverage
+)
+
+// fileDirectives are the run parameters a file overrides for itself
+type fileDirectives struct {
+	expectedLanguage string
+	excluded         []Window
+	coverage         float64  // 0 keeps the run's threshold
+	applied          []string // the directives as written, for the report
+}
+
+// parseDirective adds a WebVTT NOTE block holding a "cv-name: value" directive to
+// directives. Other blocks, cv-disable suppressions included, are skipped; a
+// directive with an unknown name or a bad value is an error.
+func parseDirective(lines []string, directives *fileDirectives) error {
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "NOTE") || !isWebVTTMetadataBlock(lines[0]) {
+		return nil
+	}
+	text := strings.TrimSpace(strings.TrimPrefix(strings.Join(lines, " "), "NOTE"))
+	name, value, found := strings.Cut(text, ":")
+	if !found || !strings.HasPrefix(name, "cv-") || strings.ContainsAny(name, " \t") {
+		return nil
+	}
+	value = strings.TrimSpace(value)
+
+	switch name {
+	case DirectiveExpectLang:
+		if !languageTagPattern.MatchString(value) {
+			return fmt.Errorf("%s: invalid language tag %q", name, value)
+		}
+		directives.expectedLanguage = value
+	case DirectiveExclude:
+		window, err := ParseWindow(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		directives.excluded = append(directives.excluded, window)
+	case DirectiveCoverage:
+		coverage, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil || coverage <= 0 || coverage > 100 {
+			return fmt.Errorf("%s: coverage must be a percentage between 0 and 100, got %q", name, value)
+		}
+		directives.coverage = coverage
+	default:
+		return fmt.Errorf("unknown directive %s (use %s, %s or %s)", name, DirectiveExpectLang, DirectiveExclude, DirectiveCoverage)
+	}
+	directives.applied = append(directives.applied, name+": "+value)
+	return nil
+}
+
+// readDirectives returns the QC directives of a WebVTT file, or nil when it has none
+// or -directives is off
+func (cv *CaptionValidator) readDirectives(filepath, format string) (*fileDirectives, error) {
+	if !cv.directives || format != "webvtt" {
+		return nil, nil
+	}
+	cv.openFiles.acquire()
+	defer cv.openFiles.release()
+	file, err := cv.openCaption(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	var directives fileDirectives
+	for block, err := range scanBlocks(file) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		if err := parseDirective(block.Lines, &directives); err != nil {
+			return nil, fmt.Errorf("line %d: %w", block.Line, err)
+		}
+	}
+	if len(directives.applied) == 0 {
+		return nil, nil
+	}
+	return &directives, nil
+}
+
+// withDirectives returns a copy of the validator and the coverage threshold with a
+// file's directives applied. Excluded ranges are cut out of the -program segments,
+// or out of the window when there is no program, and coverage is measured over the
+// pieces that remain. cv-coverage can only raise the threshold; one at or below it
+// is dropped from the applied directives.
+func (cv *CaptionValidator) withDirectives(directives *fileDirectives, window Window, requiredCoverage float64) (*CaptionValidator, float64, error) {
+	if directives == nil {
+		return cv, requiredCoverage, nil
+	}
+	fileCV := *cv
+	if directives.expectedLanguage != "" {
+		fileCV.expectedLanguage = directives.expectedLanguage
+	}
+	if directives.coverage > requiredCoverage {
+		requiredCoverage = directives.coverage
+	} else if directives.coverage > 0 {
+		directives.applied = slices.DeleteFunc(slices.Clone(directives.applied), func(applied string) bool {
+			return strings.HasPrefix(applied, DirectiveCoverage+":")
+		})
+	}
+	if len(directives.excluded) > 0 {
+		segments := cv.program
+		if len(segments) == 0 {
+			segments = []ProgramSegment{{Name: "window", Window: window}}
+		}
+		fileCV.program = nil
+		for _, segment := range segments {
+			for _, piece := range subtractWindows(segment.Window, directives.excluded) {
+				fileCV.program = append(fileCV.program, ProgramSegment{Name: segment.Name, Window: piece})
+			}
+		}
+		if len(fileCV.program) == 0 {
+			return nil, 0, fmt.Errorf("%s directives leave nothing of %s to measure", DirectiveExclude, window)
+		}
+	}
+	return &fileCV, requiredCoverage, nil
+}
+
+// list is the directives as written, or nil
+func (d *fileDirectives) list() []string {
+	if d == nil {
+		return nil
+	}
+	return d.applied
+}
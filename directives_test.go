@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseDirective(t *testing.T) {
+	var directives fileDirectives
+	for _, block := range []string{
+		"NOTE cv-expect-lang: fr-FR",
+		"NOTE\ncv-exclude: 00:10:00-00:12:00",
+		"NOTE cv-coverage: 95%",
+		"NOTE cv-disable CV0403 file",
+		"NOTE Translated by: the Paris team",
+	} {
+		if err := parseDirective(strings.Split(block, "\n"), &directives); err != nil {
+			t.Fatalf("%q: %v", block, err)
+		}
+	}
+	if directives.expectedLanguage != "fr-FR" || directives.coverage != 95 || !slices.Equal(directives.excluded, []Window{{Start: 600, End: 720}}) {
+		t.Errorf("unexpected directives %+v", directives)
+	}
+	if !slices.Equal(directives.applied, []string{"cv-expect-lang: fr-FR", "cv-exclude: 00:10:00-00:12:00", "cv-coverage: 95%"}) {
+		t.Errorf("unexpected applied directives %q", directives.applied)
+	}
+
+	for _, block := range []string{
+		"NOTE cv-expect-lang: French",
+		"NOTE cv-exclude: 00:12:00-00:10:00",
+		"NOTE cv-coverage: 120",
+		"NOTE cv-expect-language: fr-FR",
+	} {
+		if err := parseDirective([]string{block}, &fileDirectives{}); err == nil {
+			t.Errorf("%q: expected an error", block)
+		}
+	}
+}
+
+func TestValidateDirectives(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "fr-FR"})
+	}))
+	defer server.Close()
+
+	// Cues cover 0-10s of a 20s window; excluding 10-20s leaves full coverage
+	content := "WEBVTT\n\nNOTE cv-expect-lang: fr-FR\n\nNOTE cv-exclude: 10-20\n\n00:00:00.000 --> 00:00:05.000\nBonjour\n\n00:00:05.000 --> 00:00:10.000\nAu revoir\n"
+	path := filepath.Join(t.TempDir(), "directives.vtt")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Directives are ignored unless the run opts in with -directives
+	cv := NewCaptionValidator(server.URL)
+	report, err := cv.Validate(path, Window{Start: 0, End: 20}, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Directives != nil || len(report.Errors) != 2 {
+		t.Errorf("expected the directives to be ignored, got %q %+v", report.Directives, report.Errors)
+	}
+
+	cv.directives = true
+	report, err = cv.Validate(path, Window{Start: 0, End: 20}, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Errors) != 0 || report.Coverage.WallClock != 100 {
+		t.Errorf("expected the directives to pass the file, got %+v %+v", report.Errors, report.Coverage)
+	}
+	if !slices.Equal(report.Directives, []string{"cv-expect-lang: fr-FR", "cv-exclude: 10-20"}) {
+		t.Errorf("unexpected directives %q", report.Directives)
+	}
+	if cv.expectedLanguage != "en-US" || cv.program != nil {
+		t.Errorf("expected the run's validator to be left alone, got %s %+v", cv.expectedLanguage, cv.program)
+	}
+
+	// cv-coverage raises the run's threshold but never lowers it
+	os.WriteFile(path, []byte("WEBVTT\n\nNOTE cv-coverage: 40\n\n00:00:00.000 --> 00:00:10.000\nBonjour\n"), 0o644)
+	cv.expectedLanguage = "fr-FR"
+	if report, err := cv.Validate(path, Window{Start: 0, End: 20}, 80); err != nil || len(report.Errors) != 1 || len(report.Directives) != 0 {
+		t.Errorf("expected cv-coverage below -coverage to be ignored, got %+v %q: %v", report.Errors, report.Directives, err)
+	}
+	os.WriteFile(path, []byte("WEBVTT\n\nNOTE cv-coverage: 90\n\n00:00:00.000 --> 00:00:17.000\nBonjour\n"), 0o644)
+	if report, err := cv.Validate(path, Window{Start: 0, End: 20}, 80); err != nil || len(report.Errors) != 1 || !slices.Equal(report.Directives, []string{"cv-coverage: 90"}) {
+		t.Errorf("expected cv-coverage to raise the threshold, got %+v %q: %v", report.Errors, report.Directives, err)
+	}
+
+	// Excluding the whole window leaves nothing to measure
+	os.WriteFile(path, []byte("WEBVTT\n\nNOTE cv-exclude: 0-30\n\n00:00:00.000 --> 00:00:05.000\nBonjour\n"), 0o644)
+	if _, err := cv.Validate(path, Window{Start: 0, End: 20}, 80); err == nil || !strings.Contains(err.Error(), "nothing") {
+		t.Errorf("expected an error, got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import "fmt"
+
+// DuplicateCue is a cue with the same times and text as an earlier cue
+type DuplicateCue struct {
+	Cue         int `json:"cue"`
+	DuplicateOf int `json:"duplicate_of"`
+}
+
+// DuplicateCueWarning reports cues repeated exactly, usually from concatenating a
+// file with itself or with an overlapping part of itself
+type DuplicateCueWarning struct {
+	Type         string         `json:"type"`
+	Rule         string         `json:"rule"`
+	Severity     string         `json:"severity"`
+	Duplicates   []DuplicateCue `json:"duplicates"`
+	Description  string         `json:"description"`
+	SuggestedFix *SuggestedFix  `json:"suggested_fix,omitempty"`
+}
+
+// cueKey identifies a cue by millisecond times and text
+type cueKey struct {
+	start, end int64
+	text       string
+}
+
+// findDuplicates returns cues whose times and text exactly match an earlier cue,
+// with 1-based numbers
+func findDuplicates(captions []Caption) []DuplicateCue {
+	var duplicates []DuplicateCue
+	first := map[cueKey]int{}
+	for i, caption := range captions {
+		key := cueKey{toMillis(caption.StartTime), toMillis(caption.EndTime), caption.Text}
+		if original, ok := first[key]; ok {
+			duplicates = append(duplicates, DuplicateCue{Cue: i + 1, DuplicateOf: original})
+			continue
+		}
+		first[key] = i + 1
+	}
+	return duplicates
+}
+
+// removeDuplicates returns captions without the duplicate cues
+func removeDuplicates(captions []Caption, duplicates []DuplicateCue) []Caption {
+	if len(duplicates) == 0 {
+		return captions
+	}
+	drop := make(map[int]bool, len(duplicates))
+	for _, duplicate := range duplicates {
+		drop[duplicate.Cue] = true
+	}
+	kept := make([]Caption, 0, len(captions)-len(duplicates))
+	for i, caption := range captions {
+		if !drop[i+1] {
+			kept = append(kept, caption)
+		}
+	}
+	return kept
+}
+
+// keptCueNumber maps a cue number from before duplicates were removed to the number
+// of the cue that remains for it: its own, or for a duplicate the cue it repeats
+func keptCueNumber(cue int, duplicates []DuplicateCue) int {
+	for _, duplicate := range duplicates {
+		if duplicate.Cue == cue {
+			cue = duplicate.DuplicateOf
+			break
+		}
+	}
+	kept := cue
+	for _, duplicate := range duplicates {
+		if duplicate.Cue < cue {
+			kept--
+		}
+	}
+	return kept
+}
+
+// newDuplicateCueWarning reports duplicates, or returns nil when there are none
+func newDuplicateCueWarning(duplicates []DuplicateCue) *DuplicateCueWarning {
+	if len(duplicates) == 0 {
+		return nil
+	}
+	cues := make([]int, len(duplicates))
+	for i, duplicate := range duplicates {
+		cues[i] = duplicate.Cue
+	}
+	return &DuplicateCueWarning{
+		Type:        "duplicate_cue",
+		Severity:    SeverityWarning,
+		Duplicates:  duplicates,
+		Description: fmt.Sprintf("%d cue(s) repeat an earlier cue's times and text exactly and were left out of the other checks", len(duplicates)),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixRemoveDuplicates,
+			Cues:        cues,
+			Description: fmt.Sprintf("Delete %s, or run conform -dedupe", cueRange(cues)),
+		},
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	captions := []Caption{
+		{StartTime: 1, EndTime: 3, Text: "Hello"},
+		{StartTime: 4, EndTime: 6, Text: "World"},
+		{StartTime: 1, EndTime: 3, Text: "Hello"},
+		// Same text at other times is a repeated line, not a duplicate
+		{StartTime: 7, EndTime: 9, Text: "Hello"},
+		{StartTime: 4, EndTime: 6, Text: "World"},
+	}
+
+	duplicates := findDuplicates(captions)
+	expected := []DuplicateCue{{Cue: 3, DuplicateOf: 1}, {Cue: 5, DuplicateOf: 2}}
+	if !reflect.DeepEqual(duplicates, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, duplicates)
+	}
+	if kept := removeDuplicates(captions, duplicates); len(kept) != 3 || kept[2].StartTime != 7 {
+		t.Errorf("expected duplicates removed, got %+v", kept)
+	}
+
+	warning := newDuplicateCueWarning(duplicates)
+	if warning == nil || warning.Type != "duplicate_cue" || !reflect.DeepEqual(warning.SuggestedFix.Cues, []int{3, 5}) {
+		t.Errorf("unexpected warning: %+v", warning)
+	}
+	if newDuplicateCueWarning(nil) != nil {
+		t.Error("expected no warning without duplicates")
+	}
+}
+
+func TestValidateExcludesDuplicates(t *testing.T) {
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	defer detector.Close()
+
+	path := filepath.Join(t.TempDir(), "doubled.srt")
+	content := "1\n00:00:00,000 --> 00:00:02,000\nANNA: Hello there\n\n2\n00:00:00,000 --> 00:00:02,000\nANNA: Hello there\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := NewCaptionValidator(detector.URL).Validate(path, Window{Start: 0, End: 2}, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Errors) != 1 {
+		t.Fatalf("expected only the duplicate_cue warning, got %+v", report.Errors)
+	}
+	if _, ok := report.Errors[0].(*DuplicateCueWarning); !ok {
+		t.Errorf("expected duplicate_cue, got %+v", report.Errors[0])
+	}
+	if len(report.Speakers) != 1 || report.Speakers[0].Cues != 1 {
+		t.Errorf("expected the duplicate left out of speaker stats, got %+v", report.Speakers)
+	}
+}
+
+func TestDuplicatesKeepLaterCueNumbers(t *testing.T) {
+	duplicates := []DuplicateCue{{Cue: 2, DuplicateOf: 1}, {Cue: 4, DuplicateOf: 3}}
+	for cue, want := range map[int]int{1: 1, 2: 1, 3: 2, 4: 2, 5: 3} {
+		if got := keptCueNumber(cue, duplicates); got != want {
+			t.Errorf("keptCueNumber(%d) = %d, want %d", cue, got, want)
+		}
+	}
+
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	defer detector.Close()
+
+	// Cue 3 follows a duplicate, so it is the second remaining cue; cue 1 starts 1s
+	// before the -offset and is clamped
+	path := filepath.Join(t.TempDir(), "doubled.srt")
+	content := "1\n00:00:01,000 --> 00:00:04,000\nHello there\n\n2\n00:00:01,000 --> 00:00:04,000\nHello there\n\n3\n00:00:04,000 --> 00:00:06,000\nGood\u200bbye\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cv := NewCaptionValidator(detector.URL)
+	cv.invisibleCheck = true
+	cv.offset = -2
+	report, err := cv.Validate(path, Window{Start: 0, End: 4}, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var invisible *InvisibleCharacterWarning
+	var offset *TimestampRangeError
+	for _, issue := range report.Errors {
+		switch issue := issue.(type) {
+		case *InvisibleCharacterWarning:
+			invisible = issue
+		case *TimestampRangeError:
+			offset = issue
+		}
+	}
+	if invisible == nil || invisible.Characters[0].Cue != 2 || len(invisible.SuggestedFix.Spans) != 1 || invisible.SuggestedFix.Spans[0].StartLine != 9 {
+		t.Errorf("expected the invisible character in remaining cue 2 on line 9, got %+v", invisible)
+	}
+	if offset == nil || len(offset.SuggestedFix.Cues) != 1 || offset.SuggestedFix.Cues[0] != 1 {
+		t.Errorf("expected the clamped cue to be remaining cue 1, got %+v", offset)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+)
+
+// Cue edit actions
+const (
+	EditSplit = "split"
+	EditMerge = "merge"
+)
+
+// CueEdit replaces the numbered cues of the original file with Result. Edits in
+// one EditList never share a cue, so an editor can apply them in any order.
+type CueEdit struct {
+	Action string    `json:"action"`
+	Cues   []int     `json:"cues"` // 1-based cue numbers in the original file
+	Reason string    `json:"reason"`
+	Result []Caption `json:"result"`
+}
+
+// EditList is the output of the suggest subcommand for one file
+type EditList struct {
+	File  string    `json:"file"`
+	Edits []CueEdit `json:"edits"`
+}
+
+// EditThresholds decide which cues are split or merged
+type EditThresholds struct {
+	MaxDuration float64 // cues on screen longer than this many seconds are split
+	MaxChars    int     // cues with more characters than this are split
+	MinDuration float64 // consecutive cues shorter than this are merged...
+	MaxMergeGap float64 // ...when no more than this many seconds apart
+	MaxCPS      float64 // ...and the merged cue reads no faster than this many characters per second
+}
+
+// runSuggest implements the suggest subcommand, printing one EditList per file
+func runSuggest(args []string) {
+	fs := flag.NewFlagSet("suggest", flag.ExitOnError)
+	var thresholds EditThresholds
+	fs.Float64Var(&thresholds.MaxDuration, "max_duration", 7, "Split cues longer than this many seconds")
+	fs.IntVar(&thresholds.MaxChars, "max_chars", 84, "Split cues with more characters than this")
+	fs.Float64Var(&thresholds.MinDuration, "min_duration", 1, "Merge consecutive cues shorter than this many seconds")
+	fs.Float64Var(&thresholds.MaxMergeGap, "max_merge_gap", 0.5, "Only merge cues at most this many seconds apart")
+	fs.Float64Var(&thresholds.MaxCPS, "max_cps", 20, "Maximum characters per second of a merged cue")
+	language := fs.String("language", "", "Caption language, e.g. ja-JP; its defaults replace -max_cps and -max_chars unless they are given")
+	resultsFD := resultsFDFlag(fs)
+	fs.Usage = commandUsage(fs, "suggest", "suggest [flags] captions-filepath [more paths...]")
+	fs.Parse(args)
+	if err := setResultsFD(*resultsFD); err != nil {
+		log.Fatal(err)
+	}
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	rules, set := languageRulesFor(*language), setFlags(fs)
+	if !set["max_cps"] {
+		thresholds.MaxCPS = rules.MaxCPS
+	}
+	if !set["max_chars"] {
+		thresholds.MaxChars = rules.MaxChars
+	}
+
+	cv := NewCaptionValidator("")
+	for _, path := range fs.Args() {
+		format, err := cv.detectFormat(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		captions, _, err := cv.parseFile(path, format)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printJSON(EditList{File: path, Edits: SuggestEdits(captions, thresholds)})
+	}
+}
+
+// SuggestEdits proposes splits for cues that are too long, on screen or in text, and
+// merges for runs of rapid-fire fragments that stay readable once combined
+func SuggestEdits(captions []Caption, thresholds EditThresholds) []CueEdit {
+	edits := []CueEdit{}
+	for i := 0; i < len(captions); i++ {
+		if reason := splitReason(captions[i], thresholds); reason != "" {
+			if parts := splitCue(captions[i], thresholds, 0); len(parts) > 1 {
+				edits = append(edits, CueEdit{Action: EditSplit, Cues: []int{i + 1}, Reason: reason, Result: parts})
+			}
+			continue
+		}
+
+		// Grow a run of short cues while the merged cue stays readable
+		merged, end := captions[i], i
+		for end+1 < len(captions) && isFragment(captions[end], thresholds) {
+			next := captions[end+1]
+			if !isFragment(next, thresholds) || next.StartTime-merged.EndTime > thresholds.MaxMergeGap {
+				break
+			}
+			candidate := Caption{StartTime: merged.StartTime, EndTime: next.EndTime, Text: merged.Text + " " + next.Text}
+			if cueCPS(candidate) > thresholds.MaxCPS || splitReason(candidate, thresholds) != "" {
+				break
+			}
+			merged, end = candidate, end+1
+		}
+		if end > i {
+			cues := make([]int, 0, end-i+1)
+			for n := i; n <= end; n++ {
+				cues = append(cues, n+1)
+			}
+			edits = append(edits, CueEdit{
+				Action: EditMerge,
+				Cues:   cues,
+				Reason: fmt.Sprintf("%d consecutive cues shorter than %gs", len(cues), thresholds.MinDuration),
+				Result: []Caption{merged},
+			})
+			i = end
+		}
+	}
+	return edits
+}
+
+// cueCPS returns a cue's reading speed in characters per second, fullwidth
+// characters counting as two
+func cueCPS(caption Caption) float64 {
+	duration := caption.EndTime - caption.StartTime
+	if duration <= 0 {
+		return math.Inf(1)
+	}
+	return float64(textWidth(caption.Text)) / duration
+}
+
+// splitReason describes why a cue should be split, or returns "" if it is fine
+func splitReason(caption Caption, thresholds EditThresholds) string {
+	if duration := caption.EndTime - caption.StartTime; thresholds.MaxDuration > 0 && duration > thresholds.MaxDuration {
+		return fmt.Sprintf("on screen for %.2fs (max %gs)", duration, thresholds.MaxDuration)
+	}
+	if chars := textWidth(caption.Text); thresholds.MaxChars > 0 && chars > thresholds.MaxChars {
+		return fmt.Sprintf("%d characters (max %d)", chars, thresholds.MaxChars)
+	}
+	return ""
+}
+
+// isFragment reports whether a cue is short enough to be merged with its neighbours
+func isFragment(caption Caption, thresholds EditThresholds) bool {
+	return caption.EndTime-caption.StartTime < thresholds.MinDuration
+}
+
+// maxSplitDepth bounds recursive splitting, i.e. a cue becomes at most 8 cues
+const maxSplitDepth = 3
+
+// splitCue splits a cue in two at the word boundary that best balances the halves,
+// preferring sentence and clause ends, and recurses while a half still needs
+// splitting. Time is divided in proportion to the characters on each side.
+// Unspaced scripts can be split between any two characters.
+func splitCue(caption Caption, thresholds EditThresholds, depth int) []Caption {
+	words := breakTokens(caption.Text)
+	if depth == maxSplitDepth || len(words) < 2 || splitReason(caption, thresholds) == "" {
+		return []Caption{caption}
+	}
+
+	total := textWidth(joinTokens(words))
+	best, bestScore := 1, math.Inf(1)
+	left := 0
+	for i := 1; i < len(words); i++ {
+		left += textWidth(words[i-1].text)
+		if words[i].spaced {
+			left++
+		}
+		score := math.Abs(float64(2*left - total))
+		_, last := splitLastRune(words[i-1].text)
+		switch {
+		case strings.ContainsRune(sentenceEndPunctuation, last):
+			score -= float64(total) / 2
+		case strings.ContainsRune(clausePunctuation, last):
+			score -= float64(total) / 4
+		}
+		if score < bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	first := joinTokens(words[:best])
+	at := caption.StartTime + (caption.EndTime-caption.StartTime)*float64(textWidth(first))/float64(total)
+	at = math.Round(at*1000) / 1000
+	head := Caption{StartTime: caption.StartTime, EndTime: at, Text: first}
+	tail := Caption{StartTime: at, EndTime: caption.EndTime, Text: joinTokens(words[best:])}
+	return append(splitCue(head, thresholds, depth+1), splitCue(tail, thresholds, depth+1)...)
+}
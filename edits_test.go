@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuggestEdits(t *testing.T) {
+	thresholds := EditThresholds{MaxCPS: 20, MaxDuration: 7, MinDuration: 1, MaxMergeGap: 0.5, MaxChars: 84}
+	captions := []Caption{
+		// Too many characters: split at the sentence end rather than the exact middle
+		{StartTime: 0, EndTime: 6, Text: "We need to leave right now before it gets dark. The storm is coming in fast from the west"},
+		{StartTime: 3, EndTime: 3.5, Text: "Wait."},
+		{StartTime: 3.6, EndTime: 4.2, Text: "What?"},
+		{StartTime: 4.3, EndTime: 4.9, Text: "Go!"},
+		{StartTime: 10, EndTime: 12, Text: "Fine"},
+		// Too long on screen
+		{StartTime: 20, EndTime: 30, Text: "Hello there"},
+	}
+
+	edits := SuggestEdits(captions, thresholds)
+	if len(edits) != 3 {
+		t.Fatalf("expected 3 edits, got %+v", edits)
+	}
+
+	split := edits[0]
+	if split.Action != EditSplit || !reflect.DeepEqual(split.Cues, []int{1}) || len(split.Result) != 2 {
+		t.Fatalf("expected split of cue 1, got %+v", split)
+	}
+	if split.Result[0].Text != "We need to leave right now before it gets dark." {
+		t.Errorf("expected split at sentence end, got %q", split.Result[0].Text)
+	}
+	if split.Result[0].EndTime != split.Result[1].StartTime || split.Result[1].EndTime != 6 {
+		t.Errorf("expected split parts to share the cue's time, got %+v", split.Result)
+	}
+
+	merge := edits[1]
+	if merge.Action != EditMerge || !reflect.DeepEqual(merge.Cues, []int{2, 3, 4}) {
+		t.Fatalf("expected merge of cues 2-4, got %+v", merge)
+	}
+	expected := Caption{StartTime: 3, EndTime: 4.9, Text: "Wait. What? Go!"}
+	if len(merge.Result) != 1 || merge.Result[0] != expected {
+		t.Errorf("expected merged cue %+v, got %+v", expected, merge.Result)
+	}
+
+	long := edits[2]
+	if long.Action != EditSplit || !reflect.DeepEqual(long.Cues, []int{6}) || len(long.Result) != 2 || long.Result[1].EndTime != 30 {
+		t.Errorf("expected two-way split of cue 6, got %+v", long)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SuggestedFix is a machine-readable remediation hint attached to a validation error.
+// Action says what to do; the remaining fields carry whatever targets that action needs.
+type SuggestedFix struct {
+	Action      string    `json:"action"`
+	Gaps        []Window  `json:"gaps,omitempty"`          // uncovered ranges to caption
+	Cues        []int     `json:"cues,omitempty"`          // 1-based cue numbers to edit
+	Lines       []int     `json:"lines,omitempty"`         // 1-based source lines to edit
+	Shift       float64   `json:"shift_seconds,omitempty"` // seconds to add to every cue
+	Language    string    `json:"language,omitempty"`      // language the track should be in
+	Speakers    []string  `json:"speakers,omitempty"`      // speakers to caption
+	Spans       []CueSpan `json:"spans,omitempty"`         // where each of Cues sits in the source file
+	Description string    `json:"description"`
+}
+
+// Suggested fix actions
+const (
+	FixCaptionGaps        = "caption_gaps"
+	FixReplaceTrack       = "replace_track"
+	FixRetryDetection     = "retry_detection"
+	FixChooseDetector     = "choose_detector"
+	FixCheckDetector      = "check_detector"
+	FixShiftCues          = "shift_cues"
+	FixResegmentCues      = "resegment_cues"
+	FixCorrectTimestamp   = "correct_timestamp"
+	FixAdjustOffset       = "adjust_offset"
+	FixRepairBlocks       = "repair_blocks"
+	FixBalanceTags        = "balance_tags"
+	FixConvertTimestamps  = "convert_timestamps"
+	FixRelabelFormat      = "relabel_format"
+	FixCaptionSpeakers    = "caption_speakers"
+	FixCleanText          = "clean_text"
+	FixRestylePunctuation = "restyle_punctuation"
+	FixLocalizeNumbers    = "localize_numbers"
+	FixReviewTranslation  = "review_translation"
+	FixRemoveDuplicates   = "remove_duplicates"
+	FixRetagLanguage      = "retag_language"
+	FixSetHeaderLanguage  = "set_header_language"
+	FixTranslateSDH       = "translate_annotations"
+	FixRepositionCues     = "reposition_cues"
+	FixSplitFile          = "split_file"
+	FixRewrapLines        = "rewrap_lines"
+	FixMergeCues          = "merge_cues"
+	FixReplaceStuck       = "replace_stuck_text"
+	FixCheckPlugin        = "check_plugin"
+	FixRetimeCues         = "retime_cues"
+	FixReviewText         = "review_flagged_text"
+)
+
+// CueSpan is where a cue a fix targets sits in its source file
+type CueSpan struct {
+	Cue int `json:"cue"`
+	SourceSpan
+}
+
+// addSpans locates the cues each issue's fix targets, so an editor can patch them in
+// place. Cue numbers index cues, except duplicate_cue's, which index parsed; cues
+// from formats without source positions get no spans.
+func addSpans(issues []interface{}, cues, parsed []Caption) {
+	for _, issue := range issues {
+		fix := issueFix(issue)
+		if fix == nil || len(fix.Cues) == 0 {
+			continue
+		}
+		source := cues
+		if _, ok := issue.(*DuplicateCueWarning); ok {
+			source = parsed
+		}
+		fix.Spans = nil
+		for _, cue := range fix.Cues {
+			if cue >= 1 && cue <= len(source) && source[cue-1].Source.EndLine > 0 {
+				fix.Spans = append(fix.Spans, CueSpan{Cue: cue, SourceSpan: source[cue-1].Source})
+			}
+		}
+	}
+}
+
+// coverageGaps returns the uncovered ranges of window in chronological order
+func coverageGaps(captions []Caption, window Window) []Window {
+	var covered []Window
+	for _, caption := range captions {
+		if overlap, ok := window.Intersect(Window{Start: caption.StartTime, End: caption.EndTime}); ok {
+			covered = append(covered, overlap)
+		}
+	}
+	sort.Slice(covered, func(i, j int) bool { return covered[i].Start < covered[j].Start })
+
+	var gaps []Window
+	cursor := window.Start
+	for _, c := range covered {
+		if c.Start > cursor {
+			gaps = append(gaps, Window{Start: cursor, End: c.Start})
+		}
+		cursor = max(cursor, c.End)
+	}
+	if cursor < window.End {
+		gaps = append(gaps, Window{Start: cursor, End: window.End})
+	}
+	return gaps
+}
+
+// coverageFix suggests captioning the uncovered gaps of the window
+func coverageFix(captions []Caption, window Window) *SuggestedFix {
+	gaps := coverageGaps(captions, window)
+	total := 0.0
+	for _, gap := range gaps {
+		total += gap.Duration()
+	}
+	return &SuggestedFix{
+		Action:      FixCaptionGaps,
+		Gaps:        gaps,
+		Description: fmt.Sprintf("Caption %d uncovered range(s) totaling %.2fs", len(gaps), total),
+	}
+}
+
+// cueRange formats sorted 1-based cue numbers for descriptions, e.g. "cues 3, 7-9"
+func cueRange(cues []int) string {
+	if len(cues) == 1 {
+		return fmt.Sprintf("cue %d", cues[0])
+	}
+	text := "cues "
+	for i := 0; i < len(cues); {
+		j := i
+		for j+1 < len(cues) && cues[j+1] == cues[j]+1 {
+			j++
+		}
+		if i > 0 {
+			text += ", "
+		}
+		if j > i {
+			text += fmt.Sprintf("%d-%d", cues[i], cues[j])
+		} else {
+			text += fmt.Sprintf("%d", cues[i])
+		}
+		i = j + 1
+	}
+	return text
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCoverageGaps(t *testing.T) {
+	captions := []Caption{
+		{StartTime: 5.0, EndTime: 7.0, Text: "World"},
+		{StartTime: 1.0, EndTime: 3.0, Text: "Hello"},
+		{StartTime: 2.0, EndTime: 4.0, Text: "Overlapping"},
+		{StartTime: 9.0, EndTime: 12.0, Text: "Past the window"},
+	}
+
+	gaps := coverageGaps(captions, Window{Start: 0, End: 10})
+	expected := []Window{{Start: 0, End: 1}, {Start: 4, End: 5}, {Start: 7, End: 9}}
+	if !reflect.DeepEqual(gaps, expected) {
+		t.Errorf("expected gaps %v, got %v", expected, gaps)
+	}
+
+	cv := NewCaptionValidator("http://test.com")
+	coverageErr := cv.validateCoverage(captions, Window{Start: 0, End: 10}, 90)
+	if coverageErr == nil || coverageErr.SuggestedFix == nil {
+		t.Fatal("expected coverage error with suggested fix")
+	}
+	if coverageErr.SuggestedFix.Action != FixCaptionGaps || !reflect.DeepEqual(coverageErr.SuggestedFix.Gaps, expected) {
+		t.Errorf("unexpected suggested fix: %+v", coverageErr.SuggestedFix)
+	}
+}
+
+func TestCueRange(t *testing.T) {
+	tests := []struct {
+		cues     []int
+		expected string
+	}{
+		{[]int{4}, "cue 4"},
+		{[]int{41, 42}, "cues 41-42"},
+		{[]int{1, 3, 4, 5, 9}, "cues 1, 3-5, 9"},
+	}
+	for _, tt := range tests {
+		if result := cueRange(tt.cues); result != tt.expected {
+			t.Errorf("for %v expected %q, got %q", tt.cues, tt.expected, result)
+		}
+	}
+}
+
+func TestAddSpans(t *testing.T) {
+	first := SourceSpan{Offset: 8, Length: 40, StartLine: 3, EndLine: 4}
+	repeat := SourceSpan{Offset: 49, Length: 40, StartLine: 6, EndLine: 7}
+	last := SourceSpan{Offset: 90, Length: 30, StartLine: 9, EndLine: 10}
+	parsed := []Caption{{Source: first}, {Source: repeat}, {Source: last}}
+	cues := []Caption{{Source: first}, {Source: last}} // the repeat was removed
+
+	markup := &MarkupError{Type: "markup_error", SuggestedFix: &SuggestedFix{Action: FixBalanceTags, Cues: []int{2}}}
+	duplicate := &DuplicateCueWarning{Type: "duplicate_cue", SuggestedFix: &SuggestedFix{Action: FixRemoveDuplicates, Cues: []int{2}}}
+	ocr := &MarkupError{Type: "markup_error", SuggestedFix: &SuggestedFix{Action: FixBalanceTags, Cues: []int{1}}}
+	addSpans([]interface{}{markup, duplicate}, cues, parsed)
+	addSpans([]interface{}{ocr}, []Caption{{}}, nil)
+
+	if expected := []CueSpan{{Cue: 2, SourceSpan: last}}; !reflect.DeepEqual(markup.SuggestedFix.Spans, expected) {
+		t.Errorf("expected %+v, got %+v", expected, markup.SuggestedFix.Spans)
+	}
+	if expected := []CueSpan{{Cue: 2, SourceSpan: repeat}}; !reflect.DeepEqual(duplicate.SuggestedFix.Spans, expected) {
+		t.Errorf("expected duplicate_cue to index the parsed cues, got %+v", duplicate.SuggestedFix.Spans)
+	}
+	if ocr.SuggestedFix.Spans != nil {
+		t.Errorf("expected no spans for cues without a source position, got %+v", ocr.SuggestedFix.Spans)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+// FlashLimits bounds how fast cues may change: no span of Window seconds may hold
+// more than Rate cues per second. A zero rate disables the check.
+type FlashLimits struct {
+	Rate   float64
+	Window float64
+}
+
+// enabled reports whether a cue rate limit is configured
+func (fl FlashLimits) enabled() bool {
+	return fl.Rate > 0 && fl.Window > 0
+}
+
+// CueBurst is a stretch of the timeline where cues appear and disappear faster than
+// the limit allows
+type CueBurst struct {
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+	Window    string  `json:"window"`
+	Cues      []int   `json:"cues"`
+	PeakRate  float64 `json:"peak_cues_per_second"`
+}
+
+// CueFlashWarning reports bursts of short cues that flash by too fast to read
+type CueFlashWarning struct {
+	Type          string        `json:"type"`
+	Rule          string        `json:"rule"`
+	Severity      string        `json:"severity"`
+	MaxRate       float64       `json:"max_cues_per_second"`
+	WindowSeconds float64       `json:"window_seconds"`
+	Bursts        []CueBurst    `json:"bursts"`
+	Description   string        `json:"description"`
+	SuggestedFix  *SuggestedFix `json:"suggested_fix,omitempty"`
+}
+
+// findCueBursts slides a window of limits.Window seconds from each cue's start and
+// counts the cues starting inside it. Windows over the rate that share cues are
+// merged into one burst, which runs from its first cue's start to its last cue's end.
+func findCueBursts(captions []Caption, limits FlashLimits) []CueBurst {
+	order := make([]int, len(captions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return captions[order[a]].StartTime < captions[order[b]].StartTime })
+
+	var bursts []CueBurst
+	lastIncluded := -1 // position in order of the last cue in the current burst
+	end := 0
+	for start := range order {
+		for end < len(order) && captions[order[end]].StartTime < captions[order[start]].StartTime+limits.Window {
+			end++
+		}
+		rate := float64(end-start) / limits.Window
+		if rate <= limits.Rate {
+			continue
+		}
+		if len(bursts) == 0 || start > lastIncluded {
+			bursts = append(bursts, CueBurst{StartTime: captions[order[start]].StartTime})
+		}
+		burst := &bursts[len(bursts)-1]
+		for _, i := range order[max(start, lastIncluded+1):end] {
+			burst.Cues = append(burst.Cues, i+1)
+			burst.EndTime = max(burst.EndTime, captions[i].EndTime)
+		}
+		burst.PeakRate = max(burst.PeakRate, math.Round(rate*100)/100)
+		lastIncluded = end - 1
+	}
+	for i := range bursts {
+		slices.Sort(bursts[i].Cues)
+		bursts[i].Window = Window{Start: bursts[i].StartTime, End: bursts[i].EndTime}.String()
+	}
+	return bursts
+}
+
+// validateCueFlash reports cue bursts over the configured rate
+func (cv *CaptionValidator) validateCueFlash(captions []Caption, limits FlashLimits) *CueFlashWarning {
+	bursts := findCueBursts(captions, limits)
+	if len(bursts) == 0 {
+		return nil
+	}
+
+	var cues []int
+	for _, burst := range bursts {
+		cues = append(cues, burst.Cues...)
+	}
+	slices.Sort(cues)
+	return &CueFlashWarning{
+		Type:          "cue_flash",
+		Severity:      SeverityWarning,
+		MaxRate:       limits.Rate,
+		WindowSeconds: limits.Window,
+		Bursts:        bursts,
+		Description:   fmt.Sprintf("%d burst(s) of more than %g cues per second over %gs", len(bursts), limits.Rate, limits.Window),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixMergeCues,
+			Cues:        cues,
+			Description: fmt.Sprintf("Merge or retime %s so cues stay on screen long enough to read", cueRange(cues)),
+		},
+	}
+}
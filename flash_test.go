@@ -0,0 +1,63 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFindCueBursts(t *testing.T) {
+	captions := []Caption{
+		{StartTime: 0, EndTime: 3, Text: "A calm opening line."},
+		{StartTime: 10, EndTime: 10.4, Text: "Go!"},
+		{StartTime: 10.5, EndTime: 10.9, Text: "Now!"},
+		{StartTime: 11, EndTime: 11.4, Text: "Run!"},
+		{StartTime: 11.5, EndTime: 11.9, Text: "Left!"},
+		{StartTime: 12, EndTime: 12.4, Text: "Right!"},
+		{StartTime: 12.5, EndTime: 13, Text: "Down!"},
+		{StartTime: 12.8, EndTime: 13.2, Text: "Up!"},
+		{StartTime: 20, EndTime: 23, Text: "Quiet again."},
+	}
+	limits := FlashLimits{Rate: 2, Window: 3}
+
+	bursts := findCueBursts(captions, limits)
+	if len(bursts) != 1 {
+		t.Fatalf("expected one burst, got %+v", bursts)
+	}
+	burst := bursts[0]
+	if burst.StartTime != 10 || burst.EndTime != 13.2 || burst.Window != "00:00:10.000-00:00:13.200" {
+		t.Errorf("unexpected burst span: %+v", burst)
+	}
+	if !slices.Equal(burst.Cues, []int{2, 3, 4, 5, 6, 7, 8}) || burst.PeakRate != 2.33 {
+		t.Errorf("unexpected burst cues or rate: %+v", burst)
+	}
+
+	// Unsorted input is measured in time order and reported by cue number
+	swapped := slices.Clone(captions)
+	swapped[1], swapped[8] = swapped[8], swapped[1]
+	if bursts := findCueBursts(swapped, limits); len(bursts) != 1 || !slices.Equal(bursts[0].Cues, []int{3, 4, 5, 6, 7, 8, 9}) {
+		t.Errorf("unexpected bursts for unsorted cues: %+v", bursts)
+	}
+
+	if bursts := findCueBursts(captions, FlashLimits{Rate: 2.5, Window: 3}); bursts != nil {
+		t.Errorf("expected no burst at 2.5 cues per second, got %+v", bursts)
+	}
+}
+
+func TestValidateCueFlash(t *testing.T) {
+	cv := NewCaptionValidator("http://test.com")
+	var captions []Caption
+	for i := range 5 {
+		start := float64(i) * 0.5
+		captions = append(captions, Caption{StartTime: start, EndTime: start + 0.4, Text: "Hey"})
+	}
+	warning := cv.validateCueFlash(captions, FlashLimits{Rate: 1, Window: 2})
+	if warning == nil {
+		t.Fatal("expected a cue_flash warning")
+	}
+	if warning.Description != "1 burst(s) of more than 1 cues per second over 2s" || warning.SuggestedFix.Action != FixMergeCues || warning.SuggestedFix.Description != "Merge or retime cues 1-5 so cues stay on screen long enough to read" {
+		t.Errorf("unexpected warning: %+v %+v", warning, warning.SuggestedFix)
+	}
+	if warning.Severity != SeverityWarning || issuesResult([]interface{}{warning}) != SummaryWarn {
+		t.Errorf("expected cue_flash to warn without failing the file, got severity %q", warning.Severity)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Live caption states; an alert is printed each time a followed source changes state
+const (
+	LiveOK          = "ok"          // coverage over the trailing window meets -coverage
+	LiveDropout     = "dropout"     // coverage over the trailing window is below -coverage
+	LiveUnreachable = "unreachable" // the source could not be read or parsed
+)
+
+// liveResults maps live states onto the run results -notify webhooks subscribe to
+var liveResults = map[string]string{
+	LiveOK:          SummaryPass,
+	LiveDropout:     SummaryFail,
+	LiveUnreachable: SummaryError,
+}
+
+// LiveAlert is printed when a followed source changes state
+type LiveAlert struct {
+	Type             string  `json:"type"`
+	Source           string  `json:"source"`
+	State            string  `json:"state"`
+	Previous         string  `json:"previous_state"`
+	Window           string  `json:"window,omitempty"`
+	Coverage         float64 `json:"coverage"`
+	RequiredCoverage float64 `json:"required_coverage"`
+	LastCueEnd       string  `json:"last_cue_end,omitempty"`
+	Error            string  `json:"error,omitempty"`
+	Description      string  `json:"description"`
+}
+
+// liveFollower polls a live WebVTT source and tracks coverage over the trailing
+// window. The stream's clock is taken from the cues: when following starts it is the
+// latest cue end, then it advances with wall-clock time, so a stream whose captions
+// stop keeps moving its window over empty time. Cues ending past that estimate (or a
+// timeline that restarts) re-anchor it.
+type liveFollower struct {
+	cv       *CaptionValidator
+	source   string
+	trailing time.Duration
+	required float64
+	client   *http.Client
+
+	state      string
+	anchored   bool
+	anchorWall time.Time
+	anchorTime float64 // stream seconds at anchorWall
+	lastEnd    float64 // latest cue end seen on the previous poll
+}
+
+// runFollow implements the follow subcommand: it polls a live WebVTT URL or a file
+// being appended to until interrupted, printing a LiveAlert whenever captions drop
+// out, come back, or the source becomes unreachable
+func runFollow(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("follow", flag.ExitOnError)
+	trailing := fs.Duration("trailing", 5*time.Minute, "Trailing window of the stream coverage is measured over")
+	interval := fs.Duration("interval", 10*time.Second, "How often the source is read")
+	coverage := fs.Float64("coverage", 80, "Required coverage percentage over the trailing window")
+	notifyPath := fs.String("notify", "", "JSON file of Slack or Teams webhooks alerts are posted to (on: fail for dropouts, error for an unreachable source, pass for recoveries)")
+	resultsFD := resultsFDFlag(fs)
+	fs.Usage = commandUsage(fs, "follow", "follow [flags] URL-or-filepath")
+	fs.Parse(args)
+	if err := setResultsFD(*resultsFD); err != nil {
+		log.Fatal(err)
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *trailing <= 0 || *interval <= 0 {
+		log.Fatal("-trailing and -interval must be positive")
+	}
+	var webhooks []Webhook
+	if *notifyPath != "" {
+		var err error
+		if webhooks, err = loadWebhooks(*notifyPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	follower := newLiveFollower(NewCaptionValidator(""), fs.Arg(0), *trailing, *coverage)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		if alert := follower.poll(ctx, time.Now()); alert != nil {
+			printJSON(alert)
+			for _, err := range alert.notify(webhooks) {
+				log.Print(err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func newLiveFollower(cv *CaptionValidator, source string, trailing time.Duration, required float64) *liveFollower {
+	return &liveFollower{
+		cv:       cv,
+		source:   source,
+		trailing: trailing,
+		required: required,
+		client:   &http.Client{Timeout: defaultDetectorTimeouts.Request},
+		state:    LiveOK,
+	}
+}
+
+// read returns the source's current content
+func (f *liveFollower) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(f.source, "http://") && !strings.HasPrefix(f.source, "https://") {
+		return os.ReadFile(longPath(f.source))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// poll reads the source once at wall-clock time now and returns an alert when its
+// state changed, or nil
+func (f *liveFollower) poll(ctx context.Context, now time.Time) *LiveAlert {
+	content, err := f.read(ctx)
+	var captions []Caption
+	if err == nil {
+		// A cue still being written is a parse failure and simply not counted yet
+		captions, _, err = f.cv.parseWebVTT(string(content))
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return f.transition(&LiveAlert{State: LiveUnreachable, Error: err.Error(),
+			Description: fmt.Sprintf("Live captions at %s could not be read: %v", f.source, err)})
+	}
+
+	lastEnd := 0.0
+	for _, caption := range captions {
+		lastEnd = max(lastEnd, caption.EndTime)
+	}
+	streamNow := f.streamTime(now, lastEnd)
+	window := Window{Start: max(0, streamNow-f.trailing.Seconds()), End: streamNow}
+	if window.Duration() <= 0 {
+		// Nothing has been captioned yet, so there is no stream time to measure
+		return nil
+	}
+	metrics := measureCoverage(captions, window, 0, CoverageWallClock)
+	alert := &LiveAlert{State: LiveOK, Window: window.String(), Coverage: metrics.WallClock, LastCueEnd: formatTimestamp(lastEnd)}
+	if coveragePasses(metrics.WallClock, f.required, 0) {
+		alert.Description = fmt.Sprintf("Live captions at %s recovered: %.2f%% coverage over the last %s", f.source, metrics.WallClock, f.trailing)
+	} else {
+		alert.State = LiveDropout
+		alert.Description = fmt.Sprintf("Live captions at %s dropped out: %.2f%% coverage over the last %s, %.2f%% required; last cue ended at %s",
+			f.source, metrics.WallClock, f.trailing, f.required, alert.LastCueEnd)
+	}
+	return f.transition(alert)
+}
+
+// streamTime estimates the stream's current time from the latest cue end, anchoring
+// the estimate on the first poll and whenever the cues disagree with it
+func (f *liveFollower) streamTime(now time.Time, lastEnd float64) float64 {
+	estimate := f.anchorTime + now.Sub(f.anchorWall).Seconds()
+	if !f.anchored || lastEnd > estimate || lastEnd < f.lastEnd {
+		f.anchored, f.anchorWall, f.anchorTime, estimate = true, now, lastEnd, lastEnd
+	}
+	f.lastEnd = lastEnd
+	return estimate
+}
+
+// transition completes an alert and records its state, returning nil when the state
+// did not change
+func (f *liveFollower) transition(alert *LiveAlert) *LiveAlert {
+	if alert.State == f.state {
+		return nil
+	}
+	alert.Type = "live_alert"
+	alert.Source = f.source
+	alert.Previous = f.state
+	alert.RequiredCoverage = f.required
+	f.state = alert.State
+	return alert
+}
+
+// notify posts the alert to every webhook configured for its state's result and
+// returns the errors of those that failed
+func (a *LiveAlert) notify(webhooks []Webhook) []error {
+	if len(webhooks) == 0 {
+		return nil
+	}
+	n := notification{
+		title: a.Description,
+		facts: [][2]string{
+			{"Source", a.Source},
+			{"State", a.State},
+			{"Coverage", fmt.Sprintf("%.2f%%", a.Coverage)},
+			{"Required", fmt.Sprintf("%.2f%%", a.RequiredCoverage)},
+		},
+	}
+	if a.Window != "" {
+		n.facts = append(n.facts, [2]string{"Window", a.Window})
+	}
+	var matching []Webhook
+	for _, webhook := range webhooks {
+		if slices.Contains(webhook.On, liveResults[a.State]) {
+			matching = append(matching, webhook)
+		}
+	}
+	return n.post(matching)
+}
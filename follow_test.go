@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// liveCues writes back-to-back two-second cues from start to end seconds
+func liveCues(start, end int) string {
+	var b strings.Builder
+	for t := start; t < end; t += 2 {
+		fmt.Fprintf(&b, "%s --> %s\nLive line\n\n", formatTimestamp(float64(t)), formatTimestamp(float64(t+2)))
+	}
+	return b.String()
+}
+
+func TestFollowAlertsOnDropoutAndRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "live.vtt")
+	if err := os.WriteFile(path, []byte("WEBVTT\n\n"+liveCues(0, 60)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f := newLiveFollower(NewCaptionValidator(""), path, time.Minute, 80)
+	ctx := context.Background()
+	start := time.Now()
+
+	if alert := f.poll(ctx, start); alert != nil {
+		t.Fatalf("expected no alert while captions flow, got %+v", alert)
+	}
+	// Nothing appended for 30 seconds: half the trailing minute is uncovered
+	alert := f.poll(ctx, start.Add(30*time.Second))
+	if alert == nil || alert.State != LiveDropout || alert.Previous != LiveOK || alert.Coverage != 50 {
+		t.Fatalf("expected a dropout at 50%% coverage, got %+v", alert)
+	}
+	if alert.Window != "00:00:30.000-00:01:30.000" || alert.LastCueEnd != "00:01:00.000" {
+		t.Errorf("unexpected window %s or last cue end %s", alert.Window, alert.LastCueEnd)
+	}
+	if again := f.poll(ctx, start.Add(40*time.Second)); again != nil {
+		t.Errorf("expected one alert per dropout, got %+v", again)
+	}
+
+	// Captioning resumes and catches up with the stream
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	file.WriteString(liveCues(60, 150))
+	file.Close()
+	alert = f.poll(ctx, start.Add(90*time.Second))
+	if alert == nil || alert.State != LiveOK || alert.Previous != LiveDropout {
+		t.Fatalf("expected recovery, got %+v", alert)
+	}
+}
+
+func TestFollowAlertsWhenSourceIsUnreachable(t *testing.T) {
+	f := newLiveFollower(NewCaptionValidator(""), filepath.Join(t.TempDir(), "missing.vtt"), time.Minute, 80)
+	alert := f.poll(context.Background(), time.Now())
+	if alert == nil || alert.State != LiveUnreachable || alert.Error == "" {
+		t.Errorf("expected an unreachable alert, got %+v", alert)
+	}
+}
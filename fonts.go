@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// FontMetrics are the advance widths of a typeface in thousandths of an em
+type FontMetrics struct {
+	Name  string
+	ascii [95]int16      // printable ASCII, space to '~'
+	extra map[rune]int16 // punctuation and symbols beyond ASCII
+
+	upper, lower int16 // letters without an entry, e.g. accented Latin
+	wide         int16 // East Asian wide characters, drawn from a fallback font
+}
+
+// helveticaASCII are the Adobe Helvetica widths; Arial was drawn to the same metrics
+var helveticaASCII = [95]int16{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 to ?
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ to O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P to _
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` to o
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p to ~
+}
+
+// arialMetrics also serve for Helvetica
+var arialMetrics = &FontMetrics{
+	Name:  "arial",
+	ascii: helveticaASCII,
+	extra: map[rune]int16{
+		'\u00a0': 278, '¡': 333, '¿': 611, '«': 556, '»': 556, '€': 556, '£': 556, '°': 400,
+		'–': 556, '—': 1000, '‘': 222, '’': 222, '“': 333, '”': 333, '…': 1000, '♪': 600,
+	},
+	upper: 722,
+	lower: 556,
+	wide:  1000,
+}
+
+// fonts are the typefaces -font accepts
+var fonts = map[string]*FontMetrics{
+	"arial":     arialMetrics,
+	"helvetica": arialMetrics,
+	"courier":   {Name: "courier", ascii: monospace(600), upper: 600, lower: 600, wide: 1000},
+}
+
+// monospace returns ASCII widths for a typeface whose characters all advance the same
+func monospace(width int16) (ascii [95]int16) {
+	for i := range ascii {
+		ascii[i] = width
+	}
+	return ascii
+}
+
+// lookupFont returns the metrics for a -font name
+func lookupFont(name string) (*FontMetrics, error) {
+	if font, ok := fonts[strings.ToLower(name)]; ok {
+		return font, nil
+	}
+	return nil, fmt.Errorf("unknown font %q (use %s)", name, strings.Join(slices.Sorted(maps.Keys(fonts)), ", "))
+}
+
+// advance returns the width of one character in thousandths of an em. Combining
+// marks take no space; other characters without metrics are estimated.
+func (fm *FontMetrics) advance(r rune) int16 {
+	switch {
+	case r >= ' ' && r <= '~':
+		return fm.ascii[r-' ']
+	case unicode.Is(unicode.Mn, r):
+		return 0
+	case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+		return fm.wide
+	}
+	if width, ok := fm.extra[r]; ok {
+		return width
+	}
+	if unicode.IsUpper(r) {
+		return fm.upper
+	}
+	return fm.lower
+}
+
+// width returns the rendered width of a line in pixels at a font size in pixels
+func (fm *FontMetrics) width(line string, size float64) float64 {
+	total := 0
+	for _, r := range line {
+		total += int(fm.advance(r))
+	}
+	return float64(total) * size / 1000
+}
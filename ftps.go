@@ -0,0 +1,302 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ftpsImplicitPort is where FTPS servers speak TLS from the first byte; on any other
+// port the session starts in plain FTP and is upgraded with AUTH TLS
+const ftpsImplicitPort = "990"
+
+// ftpsConn is a logged-in FTPS control connection. Every data connection is
+// protected with TLS too, resuming the control connection's session as most
+// servers require.
+type ftpsConn struct {
+	text     *textproto.Conn
+	host     string
+	config   *tls.Config
+	dialer   *net.Dialer
+	deadline time.Time
+}
+
+// ftpsConfig is the TLS configuration for a server, with the -remote_cert client
+// certificate when one is given
+func ftpsConfig(host string, opts RemoteOptions) (*tls.Config, error) {
+	config := &tls.Config{ServerName: host, ClientSessionCache: tls.NewLRUClientSessionCache(4)}
+	if opts.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(opts.Cert, opts.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// checkFTPSArg refuses a command argument with a line break or NUL, which would end
+// the command early and let the rest of the value run as a command of its own
+func checkFTPSArg(value string) error {
+	if strings.ContainsAny(value, "\r\n\x00") {
+		return fmt.Errorf("unsupported FTPS argument %q: line breaks and NUL are not allowed", value)
+	}
+	return nil
+}
+
+// dialFTPS connects, secures the control connection and logs in as the URL's user
+// (anonymous without one) with the password in opts.PasswordEnv
+func dialFTPS(u *url.URL, config *tls.Config, opts RemoteOptions) (*ftpsConn, error) {
+	user, password := "anonymous", ""
+	if u.User != nil {
+		user = u.User.Username()
+	}
+	if opts.PasswordEnv != "" {
+		password = os.Getenv(opts.PasswordEnv)
+	}
+	if err := checkFTPSArg(user); err != nil {
+		return nil, err
+	}
+	if err := checkFTPSArg(password); err != nil {
+		return nil, fmt.Errorf("unsupported FTPS password in $%s: line breaks and NUL are not allowed", opts.PasswordEnv)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = ftpsImplicitPort
+	}
+	addr := net.JoinHostPort(u.Hostname(), port)
+	c := &ftpsConn{host: u.Hostname(), config: config, dialer: &net.Dialer{Timeout: opts.ConnectTimeout}, deadline: time.Now().Add(remoteTimeout)}
+
+	var conn net.Conn
+	var err error
+	if port == ftpsImplicitPort {
+		conn, err = tls.DialWithDialer(c.dialer, "tcp", addr, config)
+	} else {
+		conn, err = c.dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(c.deadline)
+	c.text = textproto.NewConn(conn)
+	if _, _, err := c.text.ReadResponse(220); err != nil {
+		c.text.Close()
+		return nil, err
+	}
+	if port != ftpsImplicitPort {
+		if _, _, err := c.cmd(234, "AUTH TLS"); err != nil {
+			c.text.Close()
+			return nil, fmt.Errorf("server does not support AUTH TLS: %w", err)
+		}
+		secure := tls.Client(conn, config)
+		if err := secure.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		c.text = textproto.NewConn(secure)
+	}
+
+	if err := c.login(user, password); err != nil {
+		c.text.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// login authenticates and switches to protected binary transfers
+func (c *ftpsConn) login(user, password string) error {
+	code, _, err := c.cmd(0, "USER %s", user)
+	if err != nil {
+		return err
+	}
+	switch code {
+	case 230:
+	case 331:
+		if _, _, err := c.cmd(230, "PASS %s", password); err != nil {
+			return fmt.Errorf("login failed: %w", err)
+		}
+	default:
+		return fmt.Errorf("login failed: unexpected reply %d to USER", code)
+	}
+	for _, command := range []string{"PBSZ 0", "PROT P", "TYPE I"} {
+		if _, _, err := c.cmd(200, "%s", command); err != nil {
+			return fmt.Errorf("%s: %w", command, err)
+		}
+	}
+	return nil
+}
+
+// cmd sends a command and reads its reply, checking the code against expect as
+// textproto does (0 accepts any code)
+func (c *ftpsConn) cmd(expect int, format string, args ...any) (int, string, error) {
+	if err := checkFTPSArgs(args); err != nil {
+		return 0, "", err
+	}
+	id, err := c.text.Cmd(format, args...)
+	if err != nil {
+		return 0, "", err
+	}
+	c.text.StartResponse(id)
+	defer c.text.EndResponse(id)
+	return c.text.ReadResponse(expect)
+}
+
+// dataConn opens a protected data connection in extended passive mode
+func (c *ftpsConn) dataConn() (net.Conn, error) {
+	_, message, err := c.cmd(229, "EPSV")
+	if err != nil {
+		return nil, fmt.Errorf("EPSV: %w", err)
+	}
+	// 229 Entering Extended Passive Mode (|||6446|)
+	start, end := strings.Index(message, "(|||"), strings.LastIndex(message, "|)")
+	if start < 0 || end < start+4 {
+		return nil, fmt.Errorf("EPSV: unexpected reply %q", message)
+	}
+	conn, err := c.dialer.Dial("tcp", net.JoinHostPort(c.host, message[start+4:end]))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(c.deadline)
+	return tls.Client(conn, c.config), nil
+}
+
+// checkFTPSArgs checks the string arguments of a command, which include names read
+// from a server's listings
+func checkFTPSArgs(args []any) error {
+	for _, arg := range args {
+		if value, ok := arg.(string); ok {
+			if err := checkFTPSArg(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// transfer runs a command that sends its result over a data connection into w
+func (c *ftpsConn) transfer(w io.Writer, format string, args ...any) error {
+	if err := checkFTPSArgs(args); err != nil {
+		return err
+	}
+	data, err := c.dataConn()
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+
+	id, err := c.text.Cmd(format, args...)
+	if err != nil {
+		return err
+	}
+	c.text.StartResponse(id)
+	defer c.text.EndResponse(id)
+	if _, _, err := c.text.ReadResponse(1); err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, data); err != nil {
+		return err
+	}
+	data.Close()
+	_, _, err = c.text.ReadResponse(2)
+	return err
+}
+
+// ftpsEntry is one name in a directory listing
+type ftpsEntry struct {
+	name string
+	dir  bool
+}
+
+// list reads a directory's files and subdirectories with MLSD
+func (c *ftpsConn) list(dir string) ([]ftpsEntry, error) {
+	var listing strings.Builder
+	if err := c.transfer(&listing, "MLSD %s", dir); err != nil {
+		return nil, fmt.Errorf("MLSD %s: %w", dir, err)
+	}
+	var entries []ftpsEntry
+	for _, line := range strings.Split(listing.String(), "\n") {
+		// type=file;size=5123;modify=20240102030405; ep1.srt
+		facts, name, ok := strings.Cut(strings.TrimRight(line, "\r"), " ")
+		if !ok || name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+			continue
+		}
+		for _, fact := range strings.Split(facts, ";") {
+			switch strings.ToLower(fact) {
+			case "type=file":
+				entries = append(entries, ftpsEntry{name: name})
+			case "type=dir":
+				entries = append(entries, ftpsEntry{name: name, dir: true})
+			}
+		}
+	}
+	return entries, nil
+}
+
+// fetchFile downloads one remote file to local
+func (c *ftpsConn) fetchFile(remote, local string) error {
+	file, err := os.Create(longPath(local))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := c.transfer(file, "RETR %s", remote); err != nil {
+		os.Remove(longPath(local))
+		return fmt.Errorf("RETR %s: %w", remote, err)
+	}
+	return file.Close()
+}
+
+// fetchDir downloads a remote directory tree to local
+func (c *ftpsConn) fetchDir(remote, local string) error {
+	entries, err := c.list(remote)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(longPath(local), 0755); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		fetch := c.fetchFile
+		if entry.dir {
+			fetch = c.fetchDir
+		}
+		if err := fetch(path.Join(remote, entry.name), filepath.Join(local, entry.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchFTPS downloads a remote file or directory tree to target
+func fetchFTPS(u *url.URL, target string, opts RemoteOptions) error {
+	remote := path.Clean("/" + u.Path)
+	if err := checkFTPSArg(remote); err != nil {
+		return err
+	}
+	config, err := ftpsConfig(u.Hostname(), opts)
+	if err != nil {
+		return err
+	}
+	c, err := dialFTPS(u, config, opts)
+	if err != nil {
+		return err
+	}
+	defer c.text.Close()
+
+	fetch := c.fetchFile
+	if _, _, err := c.cmd(250, "CWD %s", remote); err == nil {
+		fetch = c.fetchDir
+	}
+	err = fetch(remote, target)
+	c.cmd(0, "QUIT")
+	return err
+}
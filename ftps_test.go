@@ -0,0 +1,268 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// selfSignedTLS returns a server certificate for 127.0.0.1 and a client config trusting it
+func selfSignedTLS(t *testing.T) (*tls.Config, *tls.Config) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	server := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	return server, &tls.Config{ServerName: "127.0.0.1", RootCAs: roots, ClientSessionCache: tls.NewLRUClientSessionCache(4)}
+}
+
+// serveFTPS answers one explicit-TLS FTP session over files, which maps absolute
+// paths to contents; every directory above a file exists
+func serveFTPS(ln net.Listener, config *tls.Config, password string, files map[string]string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 test server ready")
+
+	var passive net.Listener
+	isDir := func(dir string) bool {
+		for name := range files {
+			if strings.HasPrefix(name, strings.TrimSuffix(dir, "/")+"/") {
+				return true
+			}
+		}
+		return false
+	}
+	send := func(content string) {
+		data, err := passive.Accept()
+		passive.Close()
+		if err != nil {
+			return
+		}
+		secure := tls.Server(data, config)
+		secure.Write([]byte(content))
+		secure.Close()
+	}
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command, arg, _ := strings.Cut(line, " ")
+		switch command {
+		case "AUTH":
+			text.PrintfLine("234 AUTH TLS ok")
+			text = textproto.NewConn(tls.Server(conn, config))
+		case "USER":
+			text.PrintfLine("331 password please")
+		case "PASS":
+			if arg != password {
+				text.PrintfLine("530 login incorrect")
+				continue
+			}
+			text.PrintfLine("230 logged in")
+		case "PBSZ", "PROT", "TYPE":
+			text.PrintfLine("200 ok")
+		case "CWD":
+			if isDir(arg) {
+				text.PrintfLine("250 ok")
+			} else {
+				text.PrintfLine("550 not a directory")
+			}
+		case "EPSV":
+			passive, _ = net.Listen("tcp", "127.0.0.1:0")
+			text.PrintfLine("229 Entering Extended Passive Mode (|||%d|)", passive.Addr().(*net.TCPAddr).Port)
+		case "MLSD":
+			seen := map[string]bool{}
+			var lines []string
+			for name := range files {
+				rest, ok := strings.CutPrefix(name, arg+"/")
+				if !ok {
+					continue
+				}
+				entry, _, nested := strings.Cut(rest, "/")
+				if seen[entry] {
+					continue
+				}
+				seen[entry] = true
+				kind := "file"
+				if nested {
+					kind = "dir"
+				}
+				lines = append(lines, "type="+kind+";perm=r; "+entry+"\r\n")
+			}
+			sort.Strings(lines)
+			text.PrintfLine("150 listing")
+			send("type=cdir; " + arg + "\r\n" + strings.Join(lines, ""))
+			text.PrintfLine("226 done")
+		case "RETR":
+			content, ok := files[arg]
+			if !ok {
+				passive.Close()
+				text.PrintfLine("550 no such file")
+				continue
+			}
+			text.PrintfLine("150 sending")
+			send(content)
+			text.PrintfLine("226 done")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func TestFetchFTPSDirectory(t *testing.T) {
+	serverConfig, clientConfig := selfSignedTLS(t)
+	files := map[string]string{
+		"/drops/ep1.srt":           "1\n00:00:01,000 --> 00:00:02,000\nHola\n",
+		"/drops/season2/ep2.vtt":   "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nAdiós\n",
+		"/elsewhere/unrelated.srt": "not fetched",
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go serveFTPS(ln, serverConfig, "secret", files)
+
+	t.Setenv("VENDOR_FTPS_PASSWORD", "secret")
+	u, _ := url.Parse("ftps://vendor@" + ln.Addr().String() + "/drops")
+	opts := RemoteOptions{PasswordEnv: "VENDOR_FTPS_PASSWORD", ConnectTimeout: 5 * time.Second}
+	c, err := dialFTPS(u, clientConfig, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.text.Close()
+
+	target := filepath.Join(t.TempDir(), "drops")
+	if err := c.fetchDir("/drops", target); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"/drops/ep1.srt", "/drops/season2/ep2.vtt"} {
+		got, err := os.ReadFile(filepath.Join(target, filepath.FromSlash(strings.TrimPrefix(name, "/drops/"))))
+		if err != nil || string(got) != files[name] {
+			t.Errorf("expected %s downloaded, got %q: %v", path.Base(name), got, err)
+		}
+	}
+	if err := c.fetchFile("/drops/missing.srt", filepath.Join(target, "missing.srt")); err == nil || !strings.Contains(err.Error(), "550") {
+		t.Errorf("expected a 550 for a missing file, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(target, "missing.srt")); !os.IsNotExist(err) {
+		t.Error("expected no file left behind for a failed download")
+	}
+}
+
+func TestFetchFTPSRejectsWrongPassword(t *testing.T) {
+	serverConfig, clientConfig := selfSignedTLS(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go serveFTPS(ln, serverConfig, "secret", nil)
+
+	u, _ := url.Parse("ftps://vendor@" + ln.Addr().String() + "/drops")
+	if _, err := dialFTPS(u, clientConfig, RemoteOptions{ConnectTimeout: 5 * time.Second}); err == nil || !strings.Contains(err.Error(), "login failed") {
+		t.Errorf("expected login to fail without the password, got %v", err)
+	}
+}
+
+func TestFetchFTPSRejectsLineBreaks(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	connected := make(chan bool, 1)
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			connected <- true
+			conn.Close()
+		}
+	}()
+
+	t.Setenv("VENDOR_FTPS_PASSWORD", "secret\r\nDELE /drops/ep1.srt")
+	addr := ln.Addr().String()
+	for _, tc := range []struct {
+		name, url, passwordEnv string
+	}{
+		{"user", "ftps://vendor%0D%0ADELE%20x@" + addr + "/drops", ""},
+		{"password", "ftps://vendor@" + addr + "/drops", "VENDOR_FTPS_PASSWORD"},
+		{"path", "ftps://vendor@" + addr + "/drops%0D%0ADELE%20ep1.srt", ""},
+		{"NUL in path", "ftps://vendor@" + addr + "/drops%00.srt", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.Parse(tc.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := RemoteOptions{PasswordEnv: tc.passwordEnv, ConnectTimeout: 5 * time.Second}
+			if err := fetchFTPS(u, t.TempDir(), opts); err == nil || !strings.Contains(err.Error(), "line breaks and NUL") {
+				t.Errorf("expected the value refused, got %v", err)
+			}
+		})
+	}
+	select {
+	case <-connected:
+		t.Error("expected no connection before the values were checked")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestFetchFTPSRefusesListedNamesWithLineBreaks(t *testing.T) {
+	serverConfig, clientConfig := selfSignedTLS(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go serveFTPS(ln, serverConfig, "secret", map[string]string{"/drops/ep1\rDELE x.srt": "1\n"})
+
+	t.Setenv("VENDOR_FTPS_PASSWORD", "secret")
+	u, _ := url.Parse("ftps://vendor@" + ln.Addr().String() + "/drops")
+	c, err := dialFTPS(u, clientConfig, RemoteOptions{PasswordEnv: "VENDOR_FTPS_PASSWORD", ConnectTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.text.Close()
+	if err := c.fetchDir("/drops", t.TempDir()); err == nil || !strings.Contains(err.Error(), "line breaks and NUL") {
+		t.Errorf("expected the listed name refused, got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Grapheme cluster break properties, after Unicode's UAX #29
+const (
+	gcbOther = iota
+	gcbCR
+	gcbLF
+	gcbControl
+	gcbExtend
+	gcbZWJ
+	gcbSpacingMark
+	gcbRegional
+	gcbL
+	gcbV
+	gcbT
+	gcbLV
+	gcbLVT
+)
+
+// graphemeBreak returns r's grapheme cluster break property
+func graphemeBreak(r rune) int {
+	switch {
+	case r == '\r':
+		return gcbCR
+	case r == '\n':
+		return gcbLF
+	case r == '‍':
+		return gcbZWJ
+	case r == '‌' || unicode.In(r, unicode.Mn, unicode.Me) || r >= 0x1F3FB && r <= 0x1F3FF || r >= 0xE0020 && r <= 0xE007F:
+		// Nonspacing marks, ZWNJ, emoji skin tones and tag characters
+		return gcbExtend
+	case unicode.In(r, unicode.Cc, unicode.Cf, unicode.Zl, unicode.Zp):
+		return gcbControl
+	case unicode.Is(unicode.Mc, r):
+		return gcbSpacingMark
+	case r >= 0x1F1E6 && r <= 0x1F1FF:
+		return gcbRegional
+	case r >= 0x1100 && r <= 0x115F || r >= 0xA960 && r <= 0xA97C:
+		return gcbL
+	case r >= 0x1160 && r <= 0x11A7 || r >= 0xD7B0 && r <= 0xD7C6:
+		return gcbV
+	case r >= 0x11A8 && r <= 0x11FF || r >= 0xD7CB && r <= 0xD7FB:
+		return gcbT
+	case r >= 0xAC00 && r <= 0xD7A3:
+		if (r-0xAC00)%28 == 0 {
+			return gcbLV
+		}
+		return gcbLVT
+	}
+	return gcbOther
+}
+
+// isPictographic approximates Extended_Pictographic: the emoji and symbol blocks
+// ZWJ sequences such as 👩‍👧 are built from
+func isPictographic(r rune) bool {
+	return r >= 0x1F000 && r <= 0x1FAFF || r >= 0x2600 && r <= 0x27BF || r == 0x00A9 || r == 0x00AE
+}
+
+// isIndicLinker reports whether r is a virama that joins the consonants around it
+// into one conjunct, as in Devanagari क्ष
+func isIndicLinker(r rune) bool {
+	switch r {
+	case '्', '্', '્', '୍', '్', '്':
+		return true
+	}
+	return false
+}
+
+// isIndicConsonant reports whether r is a letter of the Indic scripts that form
+// conjuncts across a virama
+func isIndicConsonant(r rune) bool {
+	return r >= 0x0900 && r <= 0x0D7F && unicode.Is(unicode.Lo, r)
+}
+
+// graphemeBoundary reports whether a cluster ends between prev and next. linked is
+// whether a virama has followed the cluster's last Indic consonant, and pictographic
+// whether the cluster holds an emoji that a ZWJ may extend; regional counts the
+// regional indicators in a row so flags pair up.
+func graphemeBoundary(prev, next rune, linked, pictographic bool, regional int) bool {
+	p, n := graphemeBreak(prev), graphemeBreak(next)
+	switch {
+	case p == gcbCR && n == gcbLF:
+		return false
+	case p == gcbCR || p == gcbLF || p == gcbControl || n == gcbCR || n == gcbLF || n == gcbControl:
+		return true
+	case p == gcbL && (n == gcbL || n == gcbV || n == gcbLV || n == gcbLVT),
+		(p == gcbLV || p == gcbV) && (n == gcbV || n == gcbT),
+		(p == gcbLVT || p == gcbT) && n == gcbT:
+		return false
+	case n == gcbExtend || n == gcbZWJ || n == gcbSpacingMark:
+		return false
+	case linked && isIndicConsonant(next):
+		return false
+	case p == gcbZWJ && pictographic && isPictographic(next):
+		return false
+	case p == gcbRegional && n == gcbRegional:
+		return regional%2 == 0
+	}
+	return true
+}
+
+// graphemes splits text into grapheme clusters, the units a reader sees as one
+// character: a letter with its combining marks, an Arabic letter with its harakat,
+// a Devanagari conjunct, a Hangul syllable from jamo, or an emoji sequence
+func graphemes(text string) []string {
+	var clusters []string
+	start := 0
+	var prev rune
+	linked, pictographic, regional := false, false, 0
+	for i, r := range text {
+		if i > 0 && graphemeBoundary(prev, r, linked, pictographic, regional) {
+			clusters = append(clusters, text[start:i])
+			start, linked, pictographic, regional = i, false, false, 0
+		}
+		switch {
+		case isIndicLinker(r):
+			linked = linked || isIndicConsonant(prev) || graphemeBreak(prev) == gcbExtend
+		case isIndicConsonant(r):
+			linked = false
+		}
+		pictographic = pictographic || isPictographic(r)
+		if graphemeBreak(r) == gcbRegional {
+			regional++
+		}
+		prev = r
+	}
+	if start < len(text) {
+		clusters = append(clusters, text[start:])
+	}
+	return clusters
+}
+
+// firstRune returns the first character of a cluster
+func firstRune(cluster string) rune {
+	r, _ := utf8.DecodeRuneInString(cluster)
+	return r
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGraphemes(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{"latin with combining mark", "Café!", []string{"C", "a", "f", "é", "!"}},
+		{"arabic harakat", "كَتَبَ", []string{"كَ", "تَ", "بَ"}},
+		{"devanagari conjunct and vowel signs", "क्षत्रिय", []string{"क्ष", "त्रि", "य"}},
+		{"devanagari virama at word end", "क्", []string{"क्"}},
+		{"hangul jamo", "한글", []string{"한", "글"}},
+		{"emoji zwj and skin tone", "👩‍👧👍🏽", []string{"👩‍👧", "👍🏽"}},
+		{"flags", "🇯🇵🇫🇷", []string{"🇯🇵", "🇫🇷"}},
+		{"crlf", "a\r\nb", []string{"a", "\r\n", "b"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if clusters := graphemes(tc.text); !slices.Equal(clusters, tc.expected) {
+				t.Errorf("expected %q, got %q", tc.expected, clusters)
+			}
+		})
+	}
+}
+
+func TestWidthCountsClusters(t *testing.T) {
+	for text, expected := range map[string]int{
+		"नमस्ते":    3, // न म स्ते
+		"مَرْحَبًا": 5,
+		"👩‍👧":       1, // one family, not two people
+	} {
+		if width := textWidth(text); width != expected {
+			t.Errorf("%q: expected width %d, got %d", text, expected, width)
+		}
+	}
+}
+
+func TestBreakTokensKeepsMarksOnUnspacedText(t *testing.T) {
+	// A combining mark after a Han character must not start a token of its own
+	for _, token := range breakTokens("漢́字") {
+		if strings.HasPrefix(token.text, "́") {
+			t.Errorf("token %q starts with a combining mark", token.text)
+		}
+	}
+}
+
+func TestTruncateAtWordKeepsClusters(t *testing.T) {
+	// Each cluster of क्षत्रिय is several bytes; a cut must fall between them
+	if cut := truncateAtWord("क्षत्रिय", 10); cut != "क्ष" {
+		t.Errorf("expected a whole conjunct, got %q", cut)
+	}
+}
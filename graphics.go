@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// GraphicRegion is an on-screen graphic captions must stay clear of, such as a score
+// bug or a scheduled lower third. Edges are percentages of the frame from the top
+// and left; Times lists windows like "00:10:00-00:10:30" and is empty for a graphic
+// that is always on screen.
+type GraphicRegion struct {
+	Name   string   `json:"name"`
+	Top    float64  `json:"top"`
+	Bottom float64  `json:"bottom"`
+	Left   float64  `json:"left"`
+	Right  float64  `json:"right"`
+	Times  []string `json:"times,omitempty"`
+
+	windows []Window
+}
+
+// GraphicCollision is one cue shown over a graphic
+type GraphicCollision struct {
+	Cue     int     `json:"cue"`
+	Graphic string  `json:"graphic"`
+	Start   float64 `json:"start_time"` // when the overlap begins
+	End     float64 `json:"end_time"`
+}
+
+// GraphicCollisionWarning reports cues rendered over on-screen graphics
+type GraphicCollisionWarning struct {
+	Type         string             `json:"type"`
+	Rule         string             `json:"rule"`
+	Severity     string             `json:"severity"`
+	Collisions   []GraphicCollision `json:"collisions"`
+	Description  string             `json:"description"`
+	SuggestedFix *SuggestedFix      `json:"suggested_fix,omitempty"`
+}
+
+// loadGraphics reads a JSON array of GraphicRegion and parses their times
+func loadGraphics(path string) ([]GraphicRegion, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graphics file: %w", err)
+	}
+	var graphics []GraphicRegion
+	if err := json.Unmarshal(content, &graphics); err != nil {
+		return nil, fmt.Errorf("failed to decode graphics file: %w", err)
+	}
+	for i := range graphics {
+		graphic := &graphics[i]
+		if graphic.Name == "" {
+			graphic.Name = fmt.Sprintf("graphic %d", i+1)
+		}
+		if graphic.Top >= graphic.Bottom || graphic.Left >= graphic.Right {
+			return nil, fmt.Errorf("%s: top and left must be less than bottom and right", graphic.Name)
+		}
+		for _, times := range graphic.Times {
+			window, err := ParseWindow(times)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", graphic.Name, err)
+			}
+			graphic.windows = append(graphic.windows, window)
+		}
+	}
+	return graphics, nil
+}
+
+// onScreen returns the part of a cue's time the graphic is shown for
+func (g GraphicRegion) onScreen(caption Caption) (Window, bool) {
+	cue := Window{Start: caption.StartTime, End: caption.EndTime}
+	if len(g.windows) == 0 {
+		return cue, cue.End > cue.Start
+	}
+	for _, window := range g.windows {
+		if overlap, ok := window.Intersect(cue); ok && overlap.End > overlap.Start {
+			return overlap, true
+		}
+	}
+	return Window{}, false
+}
+
+// validateGraphics flags cues whose estimated box overlaps a graphic while it is on screen
+func (cv *CaptionValidator) validateGraphics(captions []Caption) *GraphicCollisionWarning {
+	var collisions []GraphicCollision
+	var cues []int
+	for i, caption := range captions {
+		top, bottom, left, right := cueBox(caption)
+		collided := false
+		for _, graphic := range cv.graphics {
+			if top >= graphic.Bottom || bottom <= graphic.Top || left >= graphic.Right || right <= graphic.Left {
+				continue
+			}
+			if overlap, ok := graphic.onScreen(caption); ok {
+				collisions = append(collisions, GraphicCollision{Cue: i + 1, Graphic: graphic.Name, Start: overlap.Start, End: overlap.End})
+				collided = true
+			}
+		}
+		if collided {
+			cues = append(cues, i+1)
+		}
+	}
+	if len(collisions) == 0 {
+		return nil
+	}
+
+	return &GraphicCollisionWarning{
+		Type:        "graphic_collision",
+		Severity:    SeverityWarning,
+		Collisions:  collisions,
+		Description: fmt.Sprintf("%d cue(s) are shown over on-screen graphics", len(cues)),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixRepositionCues,
+			Cues:        cues,
+			Description: fmt.Sprintf("Move %s clear of the graphics, e.g. with a line setting above them", cueRange(cues)),
+		},
+	}
+}
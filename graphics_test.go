@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadGraphics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "graphics.json")
+	content := `[{"name": "score bug", "top": 85, "bottom": 95, "left": 70, "right": 95},
+		{"top": 75, "bottom": 90, "left": 5, "right": 60, "times": ["10-20", "00:01:00-00:01:05"]}]`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	graphics, err := loadGraphics(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if graphics[1].Name != "graphic 2" || !reflect.DeepEqual(graphics[1].windows, []Window{{Start: 10, End: 20}, {Start: 60, End: 65}}) {
+		t.Errorf("expected a default name and parsed windows, got %+v", graphics[1])
+	}
+
+	if err := os.WriteFile(path, []byte(`[{"name": "upside down", "top": 90, "bottom": 80, "left": 0, "right": 10}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadGraphics(path); err == nil {
+		t.Error("expected an error for a region with top below bottom")
+	}
+}
+
+func TestValidateGraphics(t *testing.T) {
+	cv := NewCaptionValidator("")
+	cv.graphics = []GraphicRegion{
+		{Name: "score bug", Top: 85, Bottom: 95, Left: 70, Right: 95},
+		{Name: "lower third", Top: 80, Bottom: 95, Left: 5, Right: 60, windows: []Window{{Start: 10, End: 20}}},
+	}
+	captions := []Caption{
+		// Bottom row, long enough to reach under the score bug
+		{StartTime: 0, EndTime: 2, Lines: 1, Text: "A long line of dialogue that runs nearly the full width of the frame here"},
+		// Short and centered, clear of the corner graphic
+		{StartTime: 2, EndTime: 4, Lines: 1, Text: "Short"},
+		// Centered during the lower third
+		{StartTime: 18, EndTime: 22, Lines: 1, Text: "Over the lower third"},
+		// Moved to the top during the lower third
+		{StartTime: 12, EndTime: 14, Lines: 1, Text: "Moved up", Settings: "line:10%"},
+	}
+
+	warning := cv.validateGraphics(captions)
+	if warning == nil {
+		t.Fatal("expected a graphic_collision warning")
+	}
+	expected := []GraphicCollision{
+		{Cue: 1, Graphic: "score bug", Start: 0, End: 2},
+		{Cue: 3, Graphic: "lower third", Start: 18, End: 20},
+	}
+	if !reflect.DeepEqual(warning.Collisions, expected) {
+		t.Errorf("expected %+v, got %+v", expected, warning.Collisions)
+	}
+	if !reflect.DeepEqual(warning.SuggestedFix.Cues, []int{1, 3}) {
+		t.Errorf("expected cues 1 and 3 to move, got %v", warning.SuggestedFix.Cues)
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// messageCatalogs translate descriptions, keyed by base language and then by the
+// English format string the description was built from. Translations refer to the
+// format's arguments as {1}, {2}, ... so they can reorder them. Arguments are kept
+// as written, and descriptions without an entry stay in English.
+var messageCatalogs = map[string]map[string]string{
+	"es": {
+		"Caption coverage of %.2f%% in %s segments is below required %.2f%%":                                         "La cobertura de subtítulos de {1}% en los segmentos {2} es inferior al {3}% requerido",
+		"Caption coverage of %.2f%% is below required %.2f%%":                                                        "La cobertura de subtítulos de {1}% es inferior al {2}% requerido",
+		"Caption coverage of %.2f%% is below the %.2f%% warning threshold":                                           "La cobertura de subtítulos de {1}% es inferior al umbral de aviso del {2}%",
+		"Caption %d uncovered range(s) totaling %.2fs":                                                               "Subtitular {1} intervalo(s) sin cubrir que suman {2}s",
+		"Failed to detect language: %v":                                                                              "No se pudo detectar el idioma: {1}",
+		"Check the language detection endpoint and re-run validation":                                                "Revise el servicio de detección de idioma y vuelva a ejecutar la validación",
+		"Detected language '%s' does not match expected '%s'":                                                        "El idioma detectado '{1}' no coincide con el esperado '{2}'",
+		"Replace the %s track with an %s caption track":                                                              "Reemplace la pista {1} por una pista de subtítulos {2}",
+		"Cue on line %d skipped: %s":                                                                                 "Se omitió el cue de la línea {1}: {2}",
+		"Correct the cue timing on line %d":                                                                          "Corrija los tiempos del cue de la línea {1}",
+		"Cue at %s becomes negative (%s) after offset of %.3fs and was %s":                                           "El cue en {1} queda negativo ({2}) tras el desplazamiento de {3}s y fue {4}",
+		"Use an offset of at least %.3fs or remove the cue at %s":                                                    "Use un desplazamiento de al menos {1}s o elimine el cue en {2}",
+		"File mixes %d format sections; the first %s section starts at byte %d (line %d)":                            "El archivo mezcla {1} secciones de formato; la primera sección {2} empieza en el byte {3} (línea {4})",
+		"Split the file at line(s) %s and deliver each part on its own":                                              "Divida el archivo en la(s) línea(s) {1} y entregue cada parte por separado",
+		"File only partly parsed: %d cue(s) read, %d damaged block(s) starting at line %d":                           "Archivo leído solo en parte: {1} cue(s) leídos, {2} bloque(s) dañados a partir de la línea {3}",
+		"Repair or re-export the damaged block(s) at line(s) %s":                                                     "Repare o vuelva a exportar el/los bloque(s) dañados en la(s) línea(s) {1}",
+		"%s file has %s-style timestamps on %d cue(s), first at line %d":                                             "El archivo {1} tiene marcas de tiempo de estilo {2} en {3} cue(s), la primera en la línea {4}",
+		"Rewrite the timestamps at line(s) %s in %s syntax, or re-export the file":                                   "Reescriba las marcas de tiempo de la(s) línea(s) {1} con la sintaxis {2}, o vuelva a exportar el archivo",
+		"%d cue(s) repeat an earlier cue's times and text exactly and were left out of the other checks":             "{1} cue(s) repiten exactamente los tiempos y el texto de un cue anterior y se excluyeron de las demás comprobaciones",
+		"Delete %s, or run conform -dedupe":                                                                          "Elimine {1}, o ejecute conform -dedupe",
+		"%d language-tagged span(s) in %d cue(s) were detected as a different language than declared":                "{1} fragmento(s) etiquetados con idioma en {2} cue(s) se detectaron en un idioma distinto del declarado",
+		"Correct the language tags in %s or the text they enclose":                                                   "Corrija las etiquetas de idioma en {1} o el texto que contienen",
+		"Header declares language '%s' but the track should be '%s'":                                                 "La cabecera declara el idioma '{1}' pero la pista debería ser '{2}'",
+		"Set the header's Language to %s, or check that the right track was delivered":                               "Ponga {1} en el campo Language de la cabecera, o compruebe que se entregó la pista correcta",
+		"SDH annotations in %d cue(s) were detected as '%s', not the expected '%s'":                                  "Las anotaciones SDH de {1} cue(s) se detectaron como '{2}', no como el esperado '{3}'",
+		"Translate the sound effects and speaker labels in %s to %s":                                                 "Traduzca los efectos de sonido y las etiquetas de hablante de {1} a {2}",
+		"%d burst(s) of more than %g cues per second over %gs":                                                       "{1} ráfaga(s) de más de {2} cues por segundo en {3}s",
+		"Merge or retime %s so cues stay on screen long enough to read":                                              "Fusione o reajuste los tiempos de {1} para que los cues permanezcan en pantalla el tiempo suficiente para leerlos",
+		"No cues could be read as %s; read %d cue(s) as %s instead":                                                  "No se pudo leer ningún cue como {1}; se leyeron {2} cue(s) como {3}",
+		"Check the file's header and re-export it as %s":                                                             "Revise la cabecera del archivo y vuelva a exportarlo como {1}",
+		"Only %.2f words per minute over the window (%d words), below the plausible minimum of %g":                   "Solo {1} palabras por minuto en la ventana ({2} palabras), por debajo del mínimo plausible de {3}",
+		"Check the track captions all dialogue and is not a placeholder; replace it with a complete track if not":    "Compruebe que la pista subtitula todo el diálogo y no es provisional; si no, reemplácela por una pista completa",
+		"%d run(s) of consecutive cues repeat the same text for more than %gs":                                       "{1} serie(s) de cues consecutivos repiten el mismo texto durante más de {2}s",
+		"Replace the repeated text in %s with the dialogue it is stuck over":                                         "Sustituya el texto repetido en {1} por el diálogo sobre el que se quedó congelado",
+		"The language detector does not support the expected language '%s'":                                          "El detector de idioma no admite el idioma esperado '{1}'",
+		"Point -endpoint at a detector that supports %s, or correct -language":                                       "Apunte -endpoint a un detector que admita {1}, o corrija -language",
+		"The language detector answered '%s', which names no known language":                                         "El detector de idioma respondió '{1}', que no corresponde a ningún idioma conocido",
+		"The language detector's response does not name a language":                                                  "La respuesta del detector de idioma no indica ningún idioma",
+		"Check that -endpoint is a language detector that answers with a language tag":                               "Compruebe que -endpoint es un detector de idioma que responde con una etiqueta de idioma",
+		"%d positioned cue(s) extend outside the title-safe area (%g%% horizontal, %g%% vertical margins)":           "{1} cue(s) posicionados se salen del área segura de títulos (márgenes de {2}% horizontal y {3}% vertical)",
+		"Move %s inside the title-safe area, or drop the line and position settings":                                 "Mueva {1} dentro del área segura de títulos, o quite los ajustes de line y position",
+		"%d cue(s) are shown over on-screen graphics":                                                                "{1} cue(s) se muestran sobre gráficos en pantalla",
+		"Move %s clear of the graphics, e.g. with a line setting above them":                                         "Aparte {1} de los gráficos, por ejemplo con un ajuste line por encima de ellos",
+		"%d line(s) are wider than the %gpx safe area in %s at %gpx":                                                 "{1} línea(s) son más anchas que el área segura de {2}px en {3} a {4}px",
+		"Rewrap or shorten the overflowing lines in %s":                                                              "Vuelva a partir o acorte las líneas desbordadas en {1}",
+		"Unbalanced formatting tags in %d cue(s)":                                                                    "Etiquetas de formato desequilibradas en {1} cue(s)",
+		"Close or remove the unbalanced tags in %s":                                                                  "Cierre o elimine las etiquetas desequilibradas en {1}",
+		"%d invisible or non-normalized character(s) in %d cue(s)":                                                   "{1} carácter(es) invisibles o sin normalizar en {2} cue(s)",
+		"Remove invisible characters and normalize %s to NFC":                                                        "Elimine los caracteres invisibles y normalice {1} a NFC",
+		"Punctuation does not follow the house style: %s":                                                            "La puntuación no sigue el estilo de la casa: {1}",
+		"Rewrite quotes, dashes and ellipses in %s to the house style":                                               "Reescriba comillas, rayas y puntos suspensivos en {1} según el estilo de la casa",
+		"%d number(s) or date(s) in %d cue(s) are not formatted for %s":                                              "{1} número(s) o fecha(s) en {2} cue(s) no tienen el formato de {3}",
+		"Reformat numbers and dates in %s for %s":                                                                    "Cambie el formato de números y fechas en {1} al de {2}",
+		"Machine translation score %.2f is at or above %.2f (%.0f%% of cues untranslated, %.0f%% repeated trigrams)": "La puntuación de traducción automática {1} alcanza o supera {2} ({3}% de cues sin traducir, {4}% de trigramas repetidos)",
+		"Have a translator review the track before accepting it":                                                     "Haga que un traductor revise la pista antes de aceptarla",
+		"Translation quality model %s failed: %v":                                                                    "Falló el modelo de calidad de traducción {1}: {2}",
+		"Run %s by hand to see why it fails":                                                                         "Ejecute {1} manualmente para ver por qué falla",
+		"%d of %d identified speaker(s) have no captions in %s: %s":                                                  "{1} de {2} hablante(s) identificados no tienen subtítulos en {3}: {4}",
+		"Caption the dialogue of %s within the window":                                                               "Subtitule el diálogo de {1} dentro de la ventana",
+		"Segmentation quality issues: %s":                                                                            "Problemas de segmentación: {1}",
+		"Percentile targets missed: %s":                                                                              "Objetivos de percentil no cumplidos: {1}",
+		"Retime or re-segment %s so the file meets its percentile targets":                                           "Reajuste los tiempos o vuelva a segmentar {1} para que el archivo cumpla sus objetivos de percentil",
+		"%d cue(s) are shown for less than %g of the time their text takes to say":                                   "{1} cue(s) se muestran durante menos de {2} del tiempo que se tarda en decir su texto",
+		"Extend %s or condense the text so it can be said in the time it is shown":                                   "Alargue {1} o condense el texto para que pueda decirse en el tiempo en que se muestra",
+		"Re-segment %s at sentence or clause boundaries":                                                             "Vuelva a segmentar {1} en límites de oración o de cláusula",
+		"Average caption latency of %.2fs exceeds allowed %.2fs":                                                     "La latencia media de los subtítulos de {1}s supera los {2}s permitidos",
+		"Shift all cues by %.2fs to align with speech":                                                               "Desplace todos los cues {1}s para alinearlos con el habla",
+		"%d stretch(es) totaling %.2fs are dominated by cues recognized with confidence under %g":                    "{1} tramo(s) que suman {2}s están dominados por cues reconocidos con una confianza inferior a {3}",
+		"Have a person check %s against the audio before delivery":                                                   "Haga que una persona compruebe {1} con el audio antes de la entrega",
+		"Plugin %s failed: %v": "Falló el plugin {1}: {2}",
+		"The %s classifier flagged %d stretch(es) of cue text, scoring up to %.2f against a threshold of %.2f": "El clasificador {1} marcó {2} tramo(s) de texto de los cues, con una puntuación de hasta {3} frente a un umbral de {4}",
+		"Review %s and edit or sign off the flagged text":                                                      "Revise {1} y corrija o apruebe el texto marcado",
+		"The %s classifier failed: %v":                                                                         "Falló el clasificador {1}: {2}",
+		"Check the %s classifier endpoint and re-run validation":                                               "Revise el servicio del clasificador {1} y vuelva a ejecutar la validación",
+		"Run plugin %s by hand to see why it fails":                                                            "Ejecute el plugin {1} manualmente para ver por qué falla",
+	},
+	"pt": {
+		"Caption coverage of %.2f%% in %s segments is below required %.2f%%":                                         "A cobertura de legendas de {1}% nos segmentos {2} está abaixo dos {3}% exigidos",
+		"Caption coverage of %.2f%% is below required %.2f%%":                                                        "A cobertura de legendas de {1}% está abaixo dos {2}% exigidos",
+		"Caption coverage of %.2f%% is below the %.2f%% warning threshold":                                           "A cobertura de legendas de {1}% está abaixo do limite de aviso de {2}%",
+		"Caption %d uncovered range(s) totaling %.2fs":                                                               "Legendar {1} intervalo(s) sem cobertura que somam {2}s",
+		"Failed to detect language: %v":                                                                              "Falha ao detectar o idioma: {1}",
+		"Check the language detection endpoint and re-run validation":                                                "Verifique o serviço de detecção de idioma e execute a validação novamente",
+		"Detected language '%s' does not match expected '%s'":                                                        "O idioma detectado '{1}' não corresponde ao esperado '{2}'",
+		"Replace the %s track with an %s caption track":                                                              "Substitua a faixa {1} por uma faixa de legendas {2}",
+		"Cue on line %d skipped: %s":                                                                                 "Cue da linha {1} ignorado: {2}",
+		"Correct the cue timing on line %d":                                                                          "Corrija os tempos do cue da linha {1}",
+		"Cue at %s becomes negative (%s) after offset of %.3fs and was %s":                                           "O cue em {1} fica negativo ({2}) após o deslocamento de {3}s e foi {4}",
+		"Use an offset of at least %.3fs or remove the cue at %s":                                                    "Use um deslocamento de pelo menos {1}s ou remova o cue em {2}",
+		"File mixes %d format sections; the first %s section starts at byte %d (line %d)":                            "O arquivo mistura {1} seções de formato; a primeira seção {2} começa no byte {3} (linha {4})",
+		"Split the file at line(s) %s and deliver each part on its own":                                              "Divida o arquivo na(s) linha(s) {1} e entregue cada parte separadamente",
+		"File only partly parsed: %d cue(s) read, %d damaged block(s) starting at line %d":                           "Arquivo lido apenas em parte: {1} cue(s) lidos, {2} bloco(s) danificados a partir da linha {3}",
+		"Repair or re-export the damaged block(s) at line(s) %s":                                                     "Repare ou exporte novamente o(s) bloco(s) danificados na(s) linha(s) {1}",
+		"%s file has %s-style timestamps on %d cue(s), first at line %d":                                             "O arquivo {1} tem marcações de tempo no estilo {2} em {3} cue(s), a primeira na linha {4}",
+		"Rewrite the timestamps at line(s) %s in %s syntax, or re-export the file":                                   "Reescreva as marcações de tempo da(s) linha(s) {1} na sintaxe {2}, ou exporte o arquivo novamente",
+		"%d cue(s) repeat an earlier cue's times and text exactly and were left out of the other checks":             "{1} cue(s) repetem exatamente os tempos e o texto de um cue anterior e foram excluídos das demais verificações",
+		"Delete %s, or run conform -dedupe":                                                                          "Exclua {1}, ou execute conform -dedupe",
+		"%d language-tagged span(s) in %d cue(s) were detected as a different language than declared":                "{1} trecho(s) marcados com idioma em {2} cue(s) foram detectados em um idioma diferente do declarado",
+		"Correct the language tags in %s or the text they enclose":                                                   "Corrija as marcações de idioma em {1} ou o texto que elas envolvem",
+		"Header declares language '%s' but the track should be '%s'":                                                 "O cabeçalho declara o idioma '{1}', mas a faixa deveria ser '{2}'",
+		"Set the header's Language to %s, or check that the right track was delivered":                               "Defina {1} no campo Language do cabeçalho, ou verifique se a faixa correta foi entregue",
+		"SDH annotations in %d cue(s) were detected as '%s', not the expected '%s'":                                  "As anotações SDH de {1} cue(s) foram detectadas como '{2}', não como o esperado '{3}'",
+		"Translate the sound effects and speaker labels in %s to %s":                                                 "Traduza os efeitos sonoros e os rótulos de falante de {1} para {2}",
+		"%d burst(s) of more than %g cues per second over %gs":                                                       "{1} rajada(s) de mais de {2} cues por segundo em {3}s",
+		"Merge or retime %s so cues stay on screen long enough to read":                                              "Mescle ou reajuste os tempos de {1} para que os cues fiquem na tela tempo suficiente para serem lidos",
+		"No cues could be read as %s; read %d cue(s) as %s instead":                                                  "Nenhum cue pôde ser lido como {1}; {2} cue(s) foram lidos como {3}",
+		"Check the file's header and re-export it as %s":                                                             "Verifique o cabeçalho do arquivo e exporte-o novamente como {1}",
+		"Only %.2f words per minute over the window (%d words), below the plausible minimum of %g":                   "Apenas {1} palavras por minuto na janela ({2} palavras), abaixo do mínimo plausível de {3}",
+		"Check the track captions all dialogue and is not a placeholder; replace it with a complete track if not":    "Verifique se a faixa legenda todo o diálogo e não é provisória; caso contrário, substitua-a por uma faixa completa",
+		"%d run(s) of consecutive cues repeat the same text for more than %gs":                                       "{1} série(s) de cues consecutivos repetem o mesmo texto por mais de {2}s",
+		"Replace the repeated text in %s with the dialogue it is stuck over":                                         "Substitua o texto repetido em {1} pelo diálogo sobre o qual ficou congelado",
+		"The language detector does not support the expected language '%s'":                                          "O detector de idioma não suporta o idioma esperado '{1}'",
+		"Point -endpoint at a detector that supports %s, or correct -language":                                       "Aponte -endpoint para um detector que suporte {1}, ou corrija -language",
+		"The language detector answered '%s', which names no known language":                                         "O detector de idioma respondeu '{1}', que não corresponde a nenhum idioma conhecido",
+		"The language detector's response does not name a language":                                                  "A resposta do detector de idioma não indica nenhum idioma",
+		"Check that -endpoint is a language detector that answers with a language tag":                               "Verifique se -endpoint é um detector de idioma que responde com uma etiqueta de idioma",
+		"%d positioned cue(s) extend outside the title-safe area (%g%% horizontal, %g%% vertical margins)":           "{1} cue(s) posicionados ultrapassam a área de segurança de títulos (margens de {2}% horizontal e {3}% vertical)",
+		"Move %s inside the title-safe area, or drop the line and position settings":                                 "Mova {1} para dentro da área de segurança de títulos, ou remova os ajustes de line e position",
+		"%d cue(s) are shown over on-screen graphics":                                                                "{1} cue(s) aparecem sobre gráficos na tela",
+		"Move %s clear of the graphics, e.g. with a line setting above them":                                         "Afaste {1} dos gráficos, por exemplo com um ajuste line acima deles",
+		"%d line(s) are wider than the %gpx safe area in %s at %gpx":                                                 "{1} linha(s) são mais largas que a área de segurança de {2}px em {3} a {4}px",
+		"Rewrap or shorten the overflowing lines in %s":                                                              "Redistribua ou encurte as linhas que transbordam em {1}",
+		"Unbalanced formatting tags in %d cue(s)":                                                                    "Tags de formatação desbalanceadas em {1} cue(s)",
+		"Close or remove the unbalanced tags in %s":                                                                  "Feche ou remova as tags desbalanceadas em {1}",
+		"%d invisible or non-normalized character(s) in %d cue(s)":                                                   "{1} caractere(s) invisíveis ou não normalizados em {2} cue(s)",
+		"Remove invisible characters and normalize %s to NFC":                                                        "Remova os caracteres invisíveis e normalize {1} para NFC",
+		"Punctuation does not follow the house style: %s":                                                            "A pontuação não segue o estilo da casa: {1}",
+		"Rewrite quotes, dashes and ellipses in %s to the house style":                                               "Reescreva aspas, travessões e reticências em {1} conforme o estilo da casa",
+		"%d number(s) or date(s) in %d cue(s) are not formatted for %s":                                              "{1} número(s) ou data(s) em {2} cue(s) não estão no formato de {3}",
+		"Reformat numbers and dates in %s for %s":                                                                    "Ajuste o formato de números e datas em {1} para {2}",
+		"Machine translation score %.2f is at or above %.2f (%.0f%% of cues untranslated, %.0f%% repeated trigrams)": "A pontuação de tradução automática {1} atinge ou supera {2} ({3}% de cues sem tradução, {4}% de trigramas repetidos)",
+		"Have a translator review the track before accepting it":                                                     "Peça a um tradutor que revise a faixa antes de aceitá-la",
+		"Translation quality model %s failed: %v":                                                                    "Falha no modelo de qualidade de tradução {1}: {2}",
+		"Run %s by hand to see why it fails":                                                                         "Execute {1} manualmente para ver por que falha",
+		"%d of %d identified speaker(s) have no captions in %s: %s":                                                  "{1} de {2} falante(s) identificados não têm legendas em {3}: {4}",
+		"Caption the dialogue of %s within the window":                                                               "Legende o diálogo de {1} dentro da janela",
+		"Segmentation quality issues: %s":                                                                            "Problemas de segmentação: {1}",
+		"Percentile targets missed: %s":                                                                              "Metas de percentil não atingidas: {1}",
+		"Retime or re-segment %s so the file meets its percentile targets":                                           "Reajuste os tempos ou ressegmente {1} para que o arquivo atinja suas metas de percentil",
+		"%d cue(s) are shown for less than %g of the time their text takes to say":                                   "{1} cue(s) são exibidos por menos de {2} do tempo necessário para dizer seu texto",
+		"Extend %s or condense the text so it can be said in the time it is shown":                                   "Estenda {1} ou condense o texto para que possa ser dito no tempo em que é exibido",
+		"Re-segment %s at sentence or clause boundaries":                                                             "Segmente novamente {1} nos limites de frase ou oração",
+		"Average caption latency of %.2fs exceeds allowed %.2fs":                                                     "A latência média das legendas de {1}s excede os {2}s permitidos",
+		"Shift all cues by %.2fs to align with speech":                                                               "Desloque todos os cues em {1}s para alinhá-los à fala",
+		"%d stretch(es) totaling %.2fs are dominated by cues recognized with confidence under %g":                    "{1} trecho(s) que somam {2}s são dominados por cues reconhecidos com confiança inferior a {3}",
+		"Have a person check %s against the audio before delivery":                                                   "Peça a uma pessoa que confira {1} com o áudio antes da entrega",
+		"Plugin %s failed: %v": "Falha no plugin {1}: {2}",
+		"The %s classifier flagged %d stretch(es) of cue text, scoring up to %.2f against a threshold of %.2f": "O classificador {1} marcou {2} trecho(s) de texto dos cues, com pontuação de até {3} para um limite de {4}",
+		"Review %s and edit or sign off the flagged text":                                                      "Revise {1} e corrija ou aprove o texto marcado",
+		"The %s classifier failed: %v":                                                                         "Falha no classificador {1}: {2}",
+		"Check the %s classifier endpoint and re-run validation":                                               "Verifique o serviço do classificador {1} e execute a validação novamente",
+		"Run plugin %s by hand to see why it fails":                                                            "Execute o plugin {1} manualmente para ver por que falha",
+	},
+}
+
+// formatVerbPattern matches the fmt verbs used in descriptions
+var formatVerbPattern = regexp.MustCompile(`%(?:\.\d+)?[dfgsvq%]`)
+
+// localizedMessage matches descriptions built from one format string
+type localizedMessage struct {
+	pattern     *regexp.Regexp
+	translation string
+}
+
+// localizer rewrites the descriptions of validation results into one locale;
+// a nil *localizer leaves them in English
+type localizer struct {
+	messages []localizedMessage
+}
+
+// newLocalizer returns the localizer for a locale such as es-MX. English returns nil.
+func newLocalizer(locale string) (*localizer, error) {
+	base, _, _ := strings.Cut(strings.ToLower(locale), "-")
+	if base == "en" || base == "" {
+		return nil, nil
+	}
+	catalog, ok := messageCatalogs[base]
+	if !ok {
+		return nil, fmt.Errorf("no message catalog for locale %q (available: en, %s)", locale, strings.Join(catalogLocales(), ", "))
+	}
+
+	l := &localizer{}
+	for format, translation := range catalog {
+		l.messages = append(l.messages, localizedMessage{pattern: formatPattern(format), translation: translation})
+	}
+	// Longer formats first, so a specific message wins over a generic prefix; the
+	// catalog is a map, so ties are broken by pattern to match the same way every run
+	sort.Slice(l.messages, func(i, j int) bool {
+		a, b := l.messages[i].pattern.String(), l.messages[j].pattern.String()
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return a < b
+	})
+	return l, nil
+}
+
+// catalogLocales lists the locales with a message catalog
+func catalogLocales() []string {
+	var locales []string
+	for locale := range messageCatalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// formatPattern turns a format string into a regexp capturing each argument
+func formatPattern(format string) *regexp.Regexp {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range formatVerbPattern.FindAllStringIndex(format, -1) {
+		pattern.WriteString(regexp.QuoteMeta(format[last:loc[0]]))
+		if verb := format[loc[0]:loc[1]]; verb == "%%" {
+			pattern.WriteString("%")
+		} else {
+			pattern.WriteString("(.*?)")
+		}
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(format[last:]) + "$")
+	return regexp.MustCompile(pattern.String())
+}
+
+// translate returns the localized form of an English description
+func (l *localizer) translate(description string) string {
+	if l == nil {
+		return description
+	}
+	for _, message := range l.messages {
+		args := message.pattern.FindStringSubmatch(description)
+		if args == nil {
+			continue
+		}
+		translated := message.translation
+		for i, arg := range args[1:] {
+			translated = strings.ReplaceAll(translated, fmt.Sprintf("{%d}", i+1), arg)
+		}
+		return translated
+	}
+	return description
+}
+
+// localize translates every Description field reachable from v in place.
+// Only values behind pointers can be rewritten, which covers all validation results.
+func (l *localizer) localize(v interface{}) {
+	if l == nil {
+		return
+	}
+	l.localizeValue(reflect.ValueOf(v))
+}
+
+func (l *localizer) localizeValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			l.localizeValue(v.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			l.localizeValue(v.Index(i))
+		}
+	case reflect.Struct:
+		for i := range v.NumField() {
+			field := v.Field(i)
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			if v.Type().Field(i).Name == "Description" && field.Kind() == reflect.String && field.CanSet() {
+				field.SetString(l.translate(field.String()))
+				continue
+			}
+			l.localizeValue(field)
+		}
+	}
+}
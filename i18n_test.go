@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalizerTranslatesDescriptions(t *testing.T) {
+	es, err := newLocalizer("es-MX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"Caption coverage of 75.00% is below required 80.00%":         "La cobertura de subtítulos de 75.00% es inferior al 80.00% requerido",
+		"Detected language 'es-ES' does not match expected 'en-US'":   "El idioma detectado 'es-ES' no coincide con el esperado 'en-US'",
+		"Punctuation does not follow the house style: -- in 2 cue(s)": "La puntuación no sigue el estilo de la casa: -- in 2 cue(s)",
+		"Close or remove the unbalanced tags in cues 2-4":             "Cierre o elimine las etiquetas desequilibradas en cues 2-4",
+		"Something without a catalog entry":                           "Something without a catalog entry",
+	}
+	for english, expected := range cases {
+		if got := es.translate(english); got != expected {
+			t.Errorf("translate(%q) = %q, want %q", english, got, expected)
+		}
+	}
+
+	if l, err := newLocalizer("en-GB"); err != nil || l != nil {
+		t.Errorf("expected English to need no localizer, got %v, %v", l, err)
+	}
+	if _, err := newLocalizer("fr"); err == nil {
+		t.Error("expected an error for a locale without a catalog")
+	}
+}
+
+func TestCatalogsCoverTheSameMessages(t *testing.T) {
+	for locale, catalog := range messageCatalogs {
+		for format, translation := range catalog {
+			if _, ok := messageCatalogs["es"][format]; !ok {
+				t.Errorf("%s: %q has no Spanish translation", locale, format)
+			}
+			args := len(formatPattern(format).SubexpNames()) - 1
+			for i := 1; i <= args; i++ {
+				if !strings.Contains(translation, "{"+string(rune('0'+i))+"}") {
+					t.Errorf("%s: translation of %q drops argument %d", locale, format, i)
+				}
+			}
+		}
+		if len(catalog) != len(messageCatalogs["es"]) {
+			t.Errorf("%s catalog has %d messages, Spanish has %d", locale, len(catalog), len(messageCatalogs["es"]))
+		}
+	}
+}
+
+func TestValidateLocalizesOnlyDescriptions(t *testing.T) {
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "es-ES"})
+	}))
+	defer detector.Close()
+
+	path := filepath.Join(t.TempDir(), "captions.srt")
+	content := "1\n00:00:01,000 --> 00:00:03,000\nHola a todos\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cv := NewCaptionValidator(detector.URL)
+	cv.locale, _ = newLocalizer("pt")
+	report, err := cv.Validate(path, Window{Start: 0, End: 10}, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var issues []map[string]interface{}
+	for _, issue := range report.Errors {
+		data, _ := json.Marshal(issue)
+		var fields map[string]interface{}
+		json.Unmarshal(data, &fields)
+		issues = append(issues, fields)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("expected coverage and language errors, got %v", issues)
+	}
+
+	coverage := issues[0]
+	if coverage["type"] != "caption_coverage" || !strings.HasPrefix(coverage["description"].(string), "A cobertura de legendas de 20.00%") {
+		t.Errorf("expected a Portuguese coverage description, got %v", coverage)
+	}
+	language := issues[1]
+	fix := language["suggested_fix"].(map[string]interface{})
+	if language["type"] != "incorrect_language" || language["detected_language"] != "es-ES" || fix["action"] != FixReplaceTrack {
+		t.Errorf("expected machine fields to stay unchanged, got %v", language)
+	}
+	if fix["description"] != "Substitua a faixa es-ES por uma faixa de legendas en-US" {
+		t.Errorf("expected a Portuguese fix description, got %v", fix["description"])
+	}
+}
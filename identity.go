@@ -0,0 +1,28 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+)
+
+// fileDigest accumulates the SHA-256 and size of a file as the parser reads it,
+// so reports identify the exact file version without a second pass
+type fileDigest struct {
+	hash hash.Hash
+	size int64
+}
+
+func newFileDigest() *fileDigest {
+	return &fileDigest{hash: sha256.New()}
+}
+
+func (d *fileDigest) Write(p []byte) (int, error) {
+	d.size += int64(len(p))
+	return d.hash.Write(p)
+}
+
+// sum returns the hex-encoded SHA-256 of everything written
+func (d *fileDigest) sum() string {
+	return hex.EncodeToString(d.hash.Sum(nil))
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReportFileIdentity(t *testing.T) {
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	defer detector.Close()
+
+	content := []byte("WEBVTT\n\n00:00:00.000 --> 00:00:05.000\nHello\n\n00:00:05.000 --> 00:00:10.000\nWorld\n")
+	path := filepath.Join(t.TempDir(), "episode.vtt")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := NewCaptionValidator(detector.URL).Validate(path, Window{End: 10}, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+	if report.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected sha256 %x, got %s", sum, report.SHA256)
+	}
+	if report.Size != int64(len(content)) || report.Format != "webvtt" || report.Cues != 2 {
+		t.Errorf("unexpected identity fields: size=%d format=%q cues=%d", report.Size, report.Format, report.Cues)
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// FormatIMF is an IMF Composition Playlist validated through the timed text track it
+// assembles from its package
+const FormatIMF = "imf"
+
+// imfTextSequences are the CPL sequence kinds that carry IMSC timed text (SMPTE ST 2067-2)
+var imfTextSequences = []string{
+	"SubtitlesSequence",
+	"HearingImpairedCaptionsSequence",
+	"VisuallyImpairedTextSequence",
+	"CommentarySequence",
+	"KaraokeSequence",
+	"ForcedNarrativeSequence",
+}
+
+// assetMap is the part of an IMF package's ASSETMAP.xml that maps asset IDs to files
+type assetMap struct {
+	Assets []struct {
+		ID    string   `xml:"Id"`
+		Paths []string `xml:"ChunkList>Chunk>Path"`
+	} `xml:"AssetList>Asset"`
+}
+
+// readPackageFile reads a file of an IMF package, holding an open-file slot while it does
+func (cv *CaptionValidator) readPackageFile(path string) ([]byte, error) {
+	cv.openFiles.acquire()
+	defer cv.openFiles.release()
+	return os.ReadFile(longPath(path))
+}
+
+// loadAssetMap reads the ASSETMAP.xml of a package directory and returns the file of
+// each asset by lower-cased ID
+func (cv *CaptionValidator) loadAssetMap(dir string) (map[string]string, error) {
+	content, err := cv.readPackageFile(filepath.Join(dir, "ASSETMAP.xml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IMF asset map: %w", err)
+	}
+	var assets assetMap
+	if err := xml.Unmarshal(content, &assets); err != nil {
+		return nil, fmt.Errorf("failed to decode IMF asset map: %w", err)
+	}
+	files := map[string]string{}
+	for _, asset := range assets.Assets {
+		if len(asset.Paths) > 0 {
+			files[strings.ToLower(strings.TrimSpace(asset.ID))] = filepath.Join(dir, filepath.FromSlash(strings.TrimSpace(asset.Paths[0])))
+		}
+	}
+	return files, nil
+}
+
+// imfCompositions replaces each IMF package directory in paths with the CPLs its asset
+// map lists, in name order; other paths are kept as they are
+func (cv *CaptionValidator) imfCompositions(paths []string) ([]string, error) {
+	var compositions []string
+	for _, path := range paths {
+		if info, err := os.Stat(longPath(path)); err != nil || !info.IsDir() {
+			compositions = append(compositions, path)
+			continue
+		}
+		assets, err := cv.loadAssetMap(path)
+		if err != nil {
+			return nil, err
+		}
+		var cpls []string
+		for _, file := range assets {
+			if !strings.EqualFold(filepath.Ext(file), ".xml") {
+				continue
+			}
+			if content, err := cv.readPackageFile(file); err == nil && bytes.Contains(content, []byte("<CompositionPlaylist")) {
+				cpls = append(cpls, file)
+			}
+		}
+		if len(cpls) == 0 {
+			return nil, fmt.Errorf("IMF package %s has no CPL", path)
+		}
+		slices.Sort(cpls)
+		compositions = append(compositions, cpls...)
+	}
+	return compositions, nil
+}
+
+// imscDocumentPattern finds the IMSC document in a timed text track file: the MXF
+// essence holds it as plain XML, and a bare .ttml or .xml file is the document itself
+var imscDocumentPattern = regexp.MustCompile(`(?s)<\?xml.*?</(?:[\w-]+:)?tt\s*>`)
+
+// readTrackFile parses the IMSC document of a timed text track file
+func (cv *CaptionValidator) readTrackFile(path string) (*TTMLDocument, error) {
+	content, err := cv.readPackageFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read track file: %w", err)
+	}
+	document := imscDocumentPattern.Find(content)
+	if document == nil {
+		return nil, fmt.Errorf("track file %s holds no IMSC document", filepath.Base(path))
+	}
+	doc, err := cv.parseTTML(document)
+	if err != nil {
+		return nil, fmt.Errorf("track file %s: %w", filepath.Base(path), err)
+	}
+	return doc, nil
+}
+
+// imfTrack is one timed text track of a composition, assembled onto its timeline
+type imfTrack struct {
+	id       string
+	lang     string
+	cues     []Caption
+	failures []ParseFailure
+}
+
+// assembleIMF lays out every timed text track of a CPL on the composition timeline and
+// returns the cues of the one in the expected language, or of the first track. Each
+// resource plays its track file from the entry point for its source duration, as many
+// times as it repeats; cues are clipped to what is played. Segments follow each other
+// at the running time of their main image sequence. The CPL is copied to digest as
+// it is.
+func (cv *CaptionValidator) assembleIMF(cplPath string, digest io.Writer) ([]Caption, []ParseFailure, error) {
+	content, err := cv.readPackageFile(cplPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	digest.Write(content)
+	doc, rate, err := decodeCPL(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	assets, err := cv.loadAssetMap(filepath.Dir(cplPath))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tracks []*imfTrack
+	trackFiles := map[string]*TTMLDocument{}
+	position := 0.0
+	for i, segment := range doc.Segments {
+		duration, err := segment.length(rate)
+		if err != nil {
+			return nil, nil, fmt.Errorf("CPL %s: %w", segment.name(i), err)
+		}
+		for _, sequence := range segment.SequenceList.Sequences {
+			if !slices.Contains(imfTextSequences, sequence.XMLName.Local) {
+				continue
+			}
+			index := slices.IndexFunc(tracks, func(track *imfTrack) bool { return track.id == sequence.TrackID })
+			if index < 0 {
+				tracks = append(tracks, &imfTrack{id: sequence.TrackID})
+				index = len(tracks) - 1
+			}
+			track := tracks[index]
+
+			offset := position
+			for _, resource := range sequence.Resources {
+				resourceRate, err := parseEditRate(resource.EditRate, rate)
+				if err != nil {
+					return nil, nil, fmt.Errorf("CPL %s: %w", segment.name(i), err)
+				}
+				id := strings.ToLower(strings.TrimSpace(resource.TrackFileID))
+				file, ok := trackFiles[id]
+				if !ok {
+					path, listed := assets[id]
+					if !listed {
+						return nil, nil, fmt.Errorf("CPL %s: track file %s is not in the asset map", segment.name(i), resource.TrackFileID)
+					}
+					if file, err = cv.readTrackFile(path); err != nil {
+						return nil, nil, err
+					}
+					trackFiles[id] = file
+					if track.lang == "" {
+						track.lang = file.Lang
+					}
+					track.failures = append(track.failures, file.Failures...)
+				}
+
+				played := Window{Start: resourceRate.seconds(resource.EntryPoint), End: resourceRate.seconds(resource.EntryPoint + resource.sourceDuration())}
+				for range resource.repeats() {
+					for _, cue := range file.Cues {
+						shown, ok := played.Intersect(Window{Start: cue.StartTime, End: cue.EndTime})
+						if !ok {
+							continue
+						}
+						cue.StartTime = offset + shown.Start - played.Start
+						cue.EndTime = offset + shown.End - played.Start
+						track.cues = append(track.cues, cue)
+					}
+					offset += played.Duration()
+				}
+			}
+		}
+		position += duration
+	}
+	if len(tracks) == 0 {
+		return nil, nil, fmt.Errorf("CPL has no timed text track")
+	}
+
+	track := tracks[0]
+	expected, _, _ := strings.Cut(cv.expectedLanguage, "-")
+	for _, candidate := range tracks {
+		if lang, _, _ := strings.Cut(candidate.lang, "-"); expected != "" && strings.EqualFold(lang, expected) {
+			track = candidate
+			break
+		}
+	}
+	return track.cues, track.failures, nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeIMFPackage writes a two-segment package with Spanish subtitles and English
+// captions, and returns the CPL path
+func writeIMFPackage(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"ASSETMAP.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<AssetMap xmlns="http://www.smpte-ra.org/schemas/429-9/2007/AM">
+  <AssetList>
+    <Asset><Id>urn:uuid:cpl</Id><ChunkList><Chunk><Path>CPL_feature.xml</Path></Chunk></ChunkList></Asset>
+    <Asset><Id>urn:uuid:ES</Id><ChunkList><Chunk><Path>tt/feature_es.mxf</Path></Chunk></ChunkList></Asset>
+    <Asset><Id>urn:uuid:en</Id><ChunkList><Chunk><Path>tt/feature_en.mxf</Path></Chunk></ChunkList></Asset>
+  </AssetList>
+</AssetMap>`,
+		"CPL_feature.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<CompositionPlaylist xmlns="http://www.smpte-ra.org/schemas/2067-3/2016" xmlns:cc="http://www.smpte-ra.org/schemas/2067-2/2016">
+  <EditRate>24 1</EditRate>
+  <SegmentList>
+    <Segment>
+      <SequenceList>
+        <cc:MainImageSequence><ResourceList><Resource><IntrinsicDuration>240</IntrinsicDuration></Resource></ResourceList></cc:MainImageSequence>
+        <cc:SubtitlesSequence>
+          <TrackId>urn:uuid:track-es</TrackId>
+          <ResourceList>
+            <Resource><IntrinsicDuration>240</IntrinsicDuration><EntryPoint>48</EntryPoint><SourceDuration>120</SourceDuration><TrackFileId>urn:uuid:es</TrackFileId></Resource>
+            <Resource><IntrinsicDuration>240</IntrinsicDuration><SourceDuration>120</SourceDuration><TrackFileId>urn:uuid:es</TrackFileId></Resource>
+          </ResourceList>
+        </cc:SubtitlesSequence>
+        <cc:HearingImpairedCaptionsSequence>
+          <TrackId>urn:uuid:track-en</TrackId>
+          <ResourceList><Resource><IntrinsicDuration>240</IntrinsicDuration><TrackFileId>urn:uuid:en</TrackFileId></Resource></ResourceList>
+        </cc:HearingImpairedCaptionsSequence>
+      </SequenceList>
+    </Segment>
+    <Segment>
+      <SequenceList>
+        <cc:MainImageSequence><ResourceList><Resource><IntrinsicDuration>240</IntrinsicDuration></Resource></ResourceList></cc:MainImageSequence>
+        <cc:SubtitlesSequence>
+          <TrackId>urn:uuid:track-es</TrackId>
+          <ResourceList>
+            <Resource><IntrinsicDuration>240</IntrinsicDuration><SourceDuration>48</SourceDuration><RepeatCount>2</RepeatCount><TrackFileId>urn:uuid:es</TrackFileId></Resource>
+          </ResourceList>
+        </cc:SubtitlesSequence>
+      </SequenceList>
+    </Segment>
+  </SegmentList>
+</CompositionPlaylist>`,
+		// Track files wrap the document in MXF; only the XML matters here
+		"tt/feature_es.mxf": "\x06\x0e\x2b\x34\x02\x05\x01\x01" + `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" xml:lang="es"><body><div>
+  <p begin="00:00:01.000" end="00:00:03.000">Uno</p>
+  <p begin="00:00:04.000" end="00:00:06.000">Dos</p>
+  <p begin="00:00:08.000" end="00:00:09.000">Tres</p>
+</div></body></tt>` + "\x00\x00",
+		"tt/feature_en.mxf": `<?xml version="1.0"?><tt:tt xmlns:tt="http://www.w3.org/ns/ttml" xml:lang="en-US"><tt:body><tt:p begin="0s" end="10s">One</tt:p></tt:body></tt:tt>`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "CPL_feature.xml")
+}
+
+func TestAssembleIMF(t *testing.T) {
+	cpl := writeIMFPackage(t)
+
+	cv := NewCaptionValidator("")
+	cv.expectedLanguage = "es-ES"
+	cues, failures, err := cv.assembleIMF(cpl, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Entry points shift each resource, and the repeated resource plays twice
+	want := []Caption{
+		{StartTime: 0, EndTime: 1, Text: "Uno", Lines: 1},
+		{StartTime: 2, EndTime: 4, Text: "Dos", Lines: 1},
+		{StartTime: 6, EndTime: 8, Text: "Uno", Lines: 1},
+		{StartTime: 9, EndTime: 10, Text: "Dos", Lines: 1},
+		{StartTime: 11, EndTime: 12, Text: "Uno", Lines: 1},
+		{StartTime: 13, EndTime: 14, Text: "Uno", Lines: 1},
+	}
+	if len(failures) != 0 || len(cues) != len(want) {
+		t.Fatalf("expected %d cues and no failures, got %+v %+v", len(want), cues, failures)
+	}
+	for i := range want {
+		if cues[i] != want[i] {
+			t.Errorf("cue %d = %+v, want %+v", i, cues[i], want[i])
+		}
+	}
+
+	cv.expectedLanguage = "en-US"
+	cues, _, err = cv.assembleIMF(cpl, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cues) != 1 || cues[0].Text != "One" {
+		t.Errorf("expected the English caption track, got %+v", cues)
+	}
+
+	if err := os.Remove(filepath.Join(filepath.Dir(cpl), "tt", "feature_en.mxf")); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := cv.assembleIMF(cpl, io.Discard); err == nil {
+		t.Error("expected a missing track file to fail")
+	}
+}
+
+func TestValidateIMFPackage(t *testing.T) {
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "es-ES"})
+	}))
+	defer detector.Close()
+
+	cpl := writeIMFPackage(t)
+	cv := NewCaptionValidator(detector.URL)
+	inputs, err := cv.imfCompositions([]string{filepath.Dir(cpl)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != 1 || inputs[0] != cpl {
+		t.Fatalf("expected the package's CPL, got %v", inputs)
+	}
+
+	cv.expectedLanguage = "es-ES"
+	// Package files are read one at a time, never while the CPL is held open
+	cv.setLimits(ResourceLimits{MaxOpenFiles: 1})
+	report, err := cv.Validate(inputs[0], Window{Start: 0, End: 20}, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Format != FormatIMF || report.Cues != 6 || len(report.Errors) != 0 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if report.Coverage.CoveredSeconds != 8 {
+		t.Errorf("expected 8s covered on the assembled timeline, got %v", report.Coverage.CoveredSeconds)
+	}
+
+	if _, err := cv.imfCompositions([]string{t.TempDir()}); err == nil {
+		t.Error("expected a directory without ASSETMAP.xml to fail")
+	}
+}
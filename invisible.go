@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kinds of invisible characters
+const (
+	InvisibleZeroWidth  = "zero_width"
+	InvisibleBidi       = "unbalanced_bidi"
+	InvisibleNBSP       = "nbsp_misuse"
+	InvisibleControl    = "control"
+	InvisibleDecomposed = "decomposed"
+)
+
+// InvisibleCharacter is one problem character. Offset counts grapheme clusters into
+// the cue text as written, with its lines joined by a single space, so a combining
+// mark is reported at the letter it sits on.
+type InvisibleCharacter struct {
+	Cue       int    `json:"cue"`
+	Offset    int    `json:"offset"`
+	Codepoint string `json:"codepoint"`
+	Kind      string `json:"kind"`
+}
+
+// InvisibleCharacterWarning reports characters that render as nothing (or as a plain
+// space) but break downstream encoders
+type InvisibleCharacterWarning struct {
+	Type         string               `json:"type"`
+	Rule         string               `json:"rule"`
+	Severity     string               `json:"severity"`
+	Characters   []InvisibleCharacter `json:"characters"`
+	Description  string               `json:"description"`
+	SuggestedFix *SuggestedFix        `json:"suggested_fix,omitempty"`
+}
+
+// zeroWidthCharacters have no visible width and no place in caption text. ZWJ and
+// ZWNJ are left alone because Indic, Persian and emoji text need them.
+var zeroWidthCharacters = map[rune]bool{
+	'\u00AD': true, // soft hyphen
+	'\u180E': true, // Mongolian vowel separator
+	'\u200B': true, // zero width space
+	'\u2060': true, // word joiner
+	'\uFEFF': true, // byte order mark inside text
+}
+
+// nfcCompositions maps a combining mark to the Latin letters it composes with and
+// their precomposed forms, covering the diacritics common in caption text
+var nfcCompositions = map[rune][2]string{
+	'\u0300': {"aeinouAEINOU", "àèìǹòùÀÈÌǸÒÙ"},                                           // grave
+	'\u0301': {"acegilnorsuyzACEGILNORSUYZ", "áćéǵíĺńóŕśúýźÁĆÉǴÍĹŃÓŔŚÚÝŹ"},               // acute
+	'\u0302': {"aceghijosuwyACEGHIJOSUWY", "âĉêĝĥîĵôŝûŵŷÂĈÊĜĤÎĴÔŜÛŴŶ"},                   // circumflex
+	'\u0303': {"ainouAINOU", "ãĩñõũÃĨÑÕŨ"},                                               // tilde
+	'\u0308': {"aeiouyAEIOUY", "äëïöüÿÄËÏÖÜŸ"},                                           // diaeresis
+	'\u030A': {"auAU", "åůÅŮ"},                                                           // ring
+	'\u0327': {"cegklnrstCEGKLNRST", "çȩģķļņŗşţÇȨĢĶĻŅŖŞŢ"},                               // cedilla
+	'\u030C': {"acdeghijklnorstuzACDEGHIKLNORSTUZ", "ǎčďěǧȟǐǰǩľňǒřšťǔžǍČĎĚǦȞǏǨĽŇǑŘŠŤǓŽ"}, // caron
+}
+
+// composeRune returns the precomposed form of base followed by mark, if there is one
+func composeRune(base, mark rune) (rune, bool) {
+	pair, ok := nfcCompositions[mark]
+	if !ok {
+		return 0, false
+	}
+	if i := strings.IndexRune(pair[0], base); i >= 0 {
+		composed := []rune(pair[1])
+		return composed[utf8.RuneCountInString(pair[0][:i])], true
+	}
+	return 0, false
+}
+
+// normalizeNFC composes decomposed Latin letters into their precomposed forms, so
+// "e" + U+0301 and "é" compare, and are detected, the same
+func normalizeNFC(text string) string {
+	runes := []rune(text)
+	out := make([]rune, 0, len(runes))
+	for _, r := range runes {
+		if n := len(out); n > 0 {
+			if composed, ok := composeRune(out[n-1], r); ok {
+				out[n-1] = composed
+				continue
+			}
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
+
+// normalizeCaptions applies NFC normalization to every cue's text in place
+func normalizeCaptions(captions []Caption) {
+	for i := range captions {
+		captions[i].Text = normalizeNFC(captions[i].Text)
+	}
+}
+
+// invisibleCharacters finds zero-width, control and misused no-break space
+// characters, unbalanced bidi embeddings and isolates, and decomposed letters
+func invisibleCharacters(text string) []InvisibleCharacter {
+	var found []InvisibleCharacter
+	// clusters maps each rune to the grapheme cluster it belongs to
+	var clusters []int
+	for n, cluster := range graphemes(text) {
+		for range cluster {
+			clusters = append(clusters, n)
+		}
+	}
+	report := func(i int, r rune, kind string) {
+		found = append(found, InvisibleCharacter{Offset: clusters[i], Codepoint: fmt.Sprintf("U+%04X", r), Kind: kind})
+	}
+
+	runes := []rune(text)
+	// Open bidi embeddings/overrides (closed by PDF) and isolates (closed by PDI)
+	var embeddings, isolates []int
+	for i, r := range runes {
+		switch {
+		case zeroWidthCharacters[r]:
+			report(i, r, InvisibleZeroWidth)
+		case r == '\u202A' || r == '\u202B' || r == '\u202D' || r == '\u202E':
+			embeddings = append(embeddings, i)
+		case r == '\u202C':
+			if len(embeddings) == 0 {
+				report(i, r, InvisibleBidi)
+			} else {
+				embeddings = embeddings[:len(embeddings)-1]
+			}
+		case r == '\u2066' || r == '\u2067' || r == '\u2068':
+			isolates = append(isolates, i)
+		case r == '\u2069':
+			if len(isolates) == 0 {
+				report(i, r, InvisibleBidi)
+			} else {
+				isolates = isolates[:len(isolates)-1]
+			}
+		case r == '\u00A0' || r == '\u202F':
+			// No-break spaces belong between words (or before French punctuation),
+			// not at the edges of a line or next to another space
+			if i == 0 || i == len(runes)-1 || unicode.IsSpace(runes[i-1]) || unicode.IsSpace(runes[i+1]) {
+				report(i, r, InvisibleNBSP)
+			}
+		case unicode.IsControl(r):
+			report(i, r, InvisibleControl)
+		case i > 0:
+			if _, ok := composeRune(runes[i-1], r); ok {
+				report(i, r, InvisibleDecomposed)
+			}
+		}
+	}
+	for _, i := range append(embeddings, isolates...) {
+		report(i, runes[i], InvisibleBidi)
+	}
+	return found
+}
+
+// validateInvisibleCharacters checks every cue's text as written
+func (cv *CaptionValidator) validateInvisibleCharacters(captions []Caption) *InvisibleCharacterWarning {
+	var characters []InvisibleCharacter
+	var cues []int
+	for i, caption := range captions {
+		text := caption.Text
+		if caption.Markup != "" {
+			text = caption.Markup
+		}
+		found := invisibleCharacters(text)
+		if len(found) == 0 {
+			continue
+		}
+		for j := range found {
+			found[j].Cue = i + 1
+		}
+		characters = append(characters, found...)
+		cues = append(cues, i+1)
+	}
+	if len(characters) == 0 {
+		return nil
+	}
+
+	return &InvisibleCharacterWarning{
+		Type:        "invisible_character",
+		Severity:    SeverityWarning,
+		Characters:  characters,
+		Description: fmt.Sprintf("%d invisible or non-normalized character(s) in %d cue(s)", len(characters), len(cues)),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixCleanText,
+			Cues:        cues,
+			Description: fmt.Sprintf("Remove invisible characters and normalize %s to NFC", cueRange(cues)),
+		},
+	}
+}
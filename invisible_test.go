@@ -0,0 +1,62 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInvisibleCharacters(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []InvisibleCharacter
+	}{
+		{"clean text", "Café au lait, s'il vous plaît\u00A0!", nil},
+		{"zero width space", "Hello\u200Bworld", []InvisibleCharacter{{Offset: 5, Codepoint: "U+200B", Kind: InvisibleZeroWidth}}},
+		{"control character", "Bell\u0007", []InvisibleCharacter{{Offset: 4, Codepoint: "U+0007", Kind: InvisibleControl}}},
+		{"no-break space at line edge", "\u00A0Hello", []InvisibleCharacter{{Offset: 0, Codepoint: "U+00A0", Kind: InvisibleNBSP}}},
+		{"no-break space beside a space", "Hello \u00A0world", []InvisibleCharacter{{Offset: 6, Codepoint: "U+00A0", Kind: InvisibleNBSP}}},
+		{"balanced isolate", "Name: \u2067שלום\u2069!", nil},
+		{"unclosed isolate", "Name: \u2067שלום", []InvisibleCharacter{{Offset: 6, Codepoint: "U+2067", Kind: InvisibleBidi}}},
+		{"stray pop directional formatting", "Hi\u202C", []InvisibleCharacter{{Offset: 2, Codepoint: "U+202C", Kind: InvisibleBidi}}},
+		{"decomposed letter", "Cafe\u0301", []InvisibleCharacter{{Offset: 3, Codepoint: "U+0301", Kind: InvisibleDecomposed}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if found := invisibleCharacters(tt.text); !reflect.DeepEqual(found, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, found)
+			}
+		})
+	}
+}
+
+func TestNormalizeNFC(t *testing.T) {
+	if normalized := normalizeNFC("Cafe\u0301 n\u0303 A\u030A x\u0301"); normalized != "Café ñ Å x\u0301" {
+		t.Errorf("unexpected normalization %q", normalized)
+	}
+}
+
+func TestValidateInvisibleCharacters(t *testing.T) {
+	cv := NewCaptionValidator("http://test.com")
+	captions := []Caption{
+		{Text: "Fine"},
+		{Text: "Hello world", Markup: "<i>Hello\u200B</i> world"},
+	}
+
+	warn := cv.validateInvisibleCharacters(captions)
+	if warn == nil {
+		t.Fatal("expected invisible_character warning")
+	}
+	expected := []InvisibleCharacter{{Cue: 2, Offset: 8, Codepoint: "U+200B", Kind: InvisibleZeroWidth}}
+	if !reflect.DeepEqual(warn.Characters, expected) {
+		t.Errorf("expected offsets into the markup as written %+v, got %+v", expected, warn.Characters)
+	}
+	if warn.SuggestedFix.Action != FixCleanText || !reflect.DeepEqual(warn.SuggestedFix.Cues, []int{2}) {
+		t.Errorf("unexpected suggested fix %+v", warn.SuggestedFix)
+	}
+
+	if warn := cv.validateInvisibleCharacters(captions[:1]); warn != nil {
+		t.Errorf("expected no warning for clean text, got %+v", warn)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Program error types of files whose validation was given up on, so one
+// pathological file cannot stall or crash a whole batch
+const (
+	ProgramErrorTimeout  = "validation_timeout" // ran past -file_timeout
+	ProgramErrorInternal = "internal_error"     // panicked
+)
+
+// IsolationError reports a file whose validation timed out or panicked
+type IsolationError struct {
+	Type string // ProgramErrorTimeout or ProgramErrorInternal
+	Err  error
+}
+
+func (e *IsolationError) Error() string { return e.Err.Error() }
+func (e *IsolationError) Unwrap() error { return e.Err }
+
+// programErrorType returns the type of a program error, or "" for ordinary ones
+// such as an unsupported format
+func programErrorType(err error) string {
+	var isolated *IsolationError
+	if errors.As(err, &isolated) {
+		return isolated.Type
+	}
+	return ""
+}
+
+// isolationKey is the context key of the isolation a validation runs under
+type isolationKey struct{}
+
+// isolation decides, under its lock, whether an isolated validation's result is
+// still wanted once it starts writing state shared with other files
+type isolation struct {
+	mu        sync.Mutex
+	committed bool // the validation is writing shared state, so isolate waits for it
+	abandoned bool // the timeout came first, so the validation must not write it
+}
+
+// commitResult is called by a validation before it writes state shared with other
+// files, such as the baseline. It reports false once isolate has given up on the
+// validation; otherwise isolate waits for the validation's result, timeout or not.
+// Validations that are not isolated always commit.
+func commitResult(ctx context.Context) bool {
+	iso, ok := ctx.Value(isolationKey{}).(*isolation)
+	if !ok {
+		return true
+	}
+	iso.mu.Lock()
+	defer iso.mu.Unlock()
+	if iso.abandoned || ctx.Err() != nil {
+		return false
+	}
+	iso.committed = true
+	return true
+}
+
+// abandoned returns ctx's error once the isolated validation running under ctx is
+// past its timeout. Validations that are not isolated are never abandoned; their
+// ctx only bounds detection calls and external tools.
+func abandoned(ctx context.Context) error {
+	if _, ok := ctx.Value(isolationKey{}).(*isolation); ok {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// isolate runs one file's validation in its own goroutine. A panic is recovered
+// and returned as an internal_error; with a timeout, a validation still running
+// when it expires is abandoned as a validation_timeout. Go cannot stop a goroutine,
+// so an abandoned validation runs on until its next check of abandoned, with its
+// detection calls and external tools cancelled through ctx, and commitResult keeps
+// it from writing shared state. Its open-file slot and memory budget are released only when it
+// returns: the timeout frees the batch worker, not those resources.
+func isolate(file string, timeout time.Duration, validate func(ctx context.Context) (*FileReport, error)) (*FileReport, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	defer cancel()
+	iso := &isolation{}
+	ctx = context.WithValue(ctx, isolationKey{}, iso)
+
+	type result struct {
+		report *FileReport
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("%s: validation panicked: %v\n%s", file, r, debug.Stack())
+				done <- result{err: &IsolationError{Type: ProgramErrorInternal, Err: fmt.Errorf("internal error: %v", r)}}
+			}
+		}()
+		report, err := validate(ctx)
+		done <- result{report, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.report, r.err
+	case <-ctx.Done():
+	}
+	iso.mu.Lock()
+	if iso.committed {
+		// Already writing shared state: its result stands
+		iso.mu.Unlock()
+		r := <-done
+		return r.report, r.err
+	}
+	iso.abandoned = true
+	iso.mu.Unlock()
+	return nil, &IsolationError{Type: ProgramErrorTimeout, Err: fmt.Errorf("validation timed out after %s", timeout)}
+}
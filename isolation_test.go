@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsolate(t *testing.T) {
+	report, err := isolate("ok.srt", 0, func(ctx context.Context) (*FileReport, error) {
+		return &FileReport{File: "ok.srt"}, nil
+	})
+	if err != nil || report.File != "ok.srt" {
+		t.Errorf("expected the report to pass through, got %+v, %v", report, err)
+	}
+	failure := errors.New("unsupported caption format")
+	if _, err := isolate("bad.txt", 0, func(ctx context.Context) (*FileReport, error) { return nil, failure }); err != failure || programErrorType(err) != "" {
+		t.Errorf("expected an ordinary program error, got %v", err)
+	}
+
+	_, err = isolate("panic.srt", 0, func(ctx context.Context) (*FileReport, error) {
+		var cues []Caption
+		_ = cues[3]
+		return nil, nil
+	})
+	if programErrorType(err) != ProgramErrorInternal || !strings.Contains(err.Error(), "index out of range") {
+		t.Errorf("expected an internal_error, got %v", err)
+	}
+
+	stopped := make(chan struct{})
+	_, err = isolate("slow.srt", 20*time.Millisecond, func(ctx context.Context) (*FileReport, error) {
+		<-ctx.Done()
+		close(stopped)
+		time.Sleep(time.Second)
+		return &FileReport{}, nil
+	})
+	if programErrorType(err) != ProgramErrorTimeout || err.Error() != "validation timed out after 20ms" {
+		t.Errorf("expected a validation_timeout, got %v", err)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Error("expected the abandoned validation's context to be cancelled")
+	}
+}
+
+func TestIsolateCommit(t *testing.T) {
+	report, err := isolate("commit.srt", 20*time.Millisecond, func(ctx context.Context) (*FileReport, error) {
+		if !commitResult(ctx) {
+			return nil, errors.New("expected to commit before the timeout")
+		}
+		time.Sleep(100 * time.Millisecond)
+		return &FileReport{File: "commit.srt"}, nil
+	})
+	if err != nil || report.File != "commit.srt" {
+		t.Errorf("expected a committed validation to be waited for, got %+v, %v", report, err)
+	}
+
+	late := make(chan bool, 1)
+	_, err = isolate("late.srt", 20*time.Millisecond, func(ctx context.Context) (*FileReport, error) {
+		<-ctx.Done()
+		late <- abandoned(ctx) != nil && !commitResult(ctx)
+		return &FileReport{}, nil
+	})
+	if programErrorType(err) != ProgramErrorTimeout || !<-late {
+		t.Errorf("expected an abandoned validation not to commit, got %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if abandoned(cancelled) != nil || !commitResult(cancelled) {
+		t.Error("expected a validation that is not isolated never to be abandoned")
+	}
+}
+
+func TestBatchFileTimeoutLeavesBaseline(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "a.srt"), []byte("1\n00:00:01,000 --> 00:00:09,000\nHello there\n"), 0o644)
+	os.WriteFile(filepath.Join(root, "b.srt"), []byte("1\n00:00:01,000 --> 00:00:09,000\nSlow to detect\n"), 0o644)
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body bytes.Buffer
+		body.ReadFrom(r.Body)
+		if strings.Contains(body.String(), "Slow") {
+			<-r.Context().Done()
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	defer detector.Close()
+	resultOutput = io.Discard
+	defer func() { resultOutput = os.Stdout }()
+
+	cv := NewCaptionValidator(detector.URL)
+	cv.deadline = 200 * time.Millisecond
+	cv.baseline = &Baseline{Files: map[string][]BaselineEntry{}, update: true}
+	// Both files miss coverage over 20s, so each would be recorded
+	if _, err := cv.ValidateBatch(context.Background(), []string{root}, Window{End: 20}, 80, BatchOptions{Workers: 2}); err != nil {
+		t.Fatal(err)
+	}
+	// The abandoned validation of b.srt runs on briefly once its detection is cancelled
+	time.Sleep(100 * time.Millisecond)
+	cv.baseline.mu.Lock()
+	defer cv.baseline.mu.Unlock()
+	if _, ok := cv.baseline.Files[baselineKey(filepath.Join(root, "a.srt"))]; !ok {
+		t.Errorf("expected a.srt recorded, got %v", cv.baseline.Files)
+	}
+	if _, ok := cv.baseline.Files[baselineKey(filepath.Join(root, "b.srt"))]; ok {
+		t.Errorf("expected the timed out b.srt left out of the baseline, got %v", cv.baseline.Files)
+	}
+}
+
+func TestBatchFileTimeout(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "a.srt"), []byte("1\n00:00:01,000 --> 00:00:09,000\nHello there\n"), 0o644)
+	os.WriteFile(filepath.Join(root, "b.srt"), []byte("1\n00:00:01,000 --> 00:00:09,000\nSlow to detect\n"), 0o644)
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body bytes.Buffer
+		body.ReadFrom(r.Body)
+		if strings.Contains(body.String(), "Slow") {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	defer detector.Close()
+
+	var output bytes.Buffer
+	resultOutput = &output
+	defer func() { resultOutput = os.Stdout }()
+
+	cv := NewCaptionValidator(detector.URL)
+	cv.deadline = 200 * time.Millisecond
+	failed, err := cv.ValidateBatch(context.Background(), []string{root}, Window{End: 10}, 80, BatchOptions{Workers: 2})
+	if err != nil || !failed {
+		t.Fatalf("expected the timed out file to fail the batch, got %v, %v", failed, err)
+	}
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected two reports, got %q", output.String())
+	}
+	var a, b FileReport
+	json.Unmarshal([]byte(lines[0]), &a)
+	json.Unmarshal([]byte(lines[1]), &b)
+	if a.ProgramError != "" || a.Coverage == nil {
+		t.Errorf("expected a.srt to be validated, got %s", lines[0])
+	}
+	if b.ErrorType != ProgramErrorTimeout || b.ProgramError != "validation timed out after 200ms" {
+		t.Errorf("expected b.srt to time out, got %s", lines[1])
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// suspendedHyphenWords follow a deliberate trailing hyphen ("Haus- und Gartenarbeit",
+// "pre- and post-production"), so the hyphen before them is not a line break
+var suspendedHyphenWords = map[string]bool{
+	"and": true, "or": true, "und": true, "oder": true, "bis": true, "et": true, "ou": true,
+	"y": true, "o": true, "e": true, "en": true, "og": true, "och": true,
+}
+
+// smartJoin rebuilds running text from cue texts for language detection. Words
+// hyphenated across line or cue breaks are rejoined, dialogue dashes and
+// continuation ellipses are dropped, and cues are merged into whole sentences.
+func smartJoin(parts []string) []string {
+	var sentences []string
+	current := ""
+	for _, part := range parts {
+		text := dehyphenate(strings.Fields(part))
+		if text == "" {
+			continue
+		}
+		if current == "" {
+			current = trimContinuation(text)
+		} else {
+			current = joinFragments(current, text)
+		}
+		if endsSentence(current) {
+			sentences = append(sentences, current)
+			current = ""
+		}
+	}
+	if current != "" {
+		sentences = append(sentences, current)
+	}
+	return sentences
+}
+
+// dehyphenate joins words split by a line-break hyphen and drops dialogue dashes
+func dehyphenate(tokens []string) string {
+	var words []string
+	for _, token := range tokens {
+		if isDash(token) {
+			continue
+		}
+		if n := len(words); n > 0 && isBrokenWord(words[n-1], token) {
+			words[n-1] = strings.TrimSuffix(words[n-1], "-") + token
+			continue
+		}
+		words = append(words, token)
+	}
+	return strings.Join(words, " ")
+}
+
+// joinFragments appends the next cue to a sentence that runs across cues
+func joinFragments(left, right string) string {
+	// "We went..." / "...to the sea" is one sentence with continuation marks
+	if trimmed := trimContinuation(right); trimmed != right {
+		left = strings.TrimRight(strings.TrimSuffix(left, "..."), "…")
+		right = trimmed
+	}
+
+	lastSpace := strings.LastIndexByte(left, ' ')
+	firstWord, _, _ := strings.Cut(right, " ")
+	if isBrokenWord(left[lastSpace+1:], firstWord) {
+		return strings.TrimSuffix(left, "-") + right
+	}
+	return left + " " + right
+}
+
+// isBrokenWord reports whether word ends in a line-break hyphen that next continues
+func isBrokenWord(word, next string) bool {
+	stem, ok := strings.CutSuffix(word, "-")
+	if !ok || stem == "" {
+		return false
+	}
+	last, _ := utf8.DecodeLastRuneInString(stem)
+	first, _ := utf8.DecodeRuneInString(next)
+	return unicode.IsLetter(last) && unicode.IsLower(first) && !suspendedHyphenWords[strings.ToLower(normalizeWord(next))]
+}
+
+// trimContinuation strips a leading continuation ellipsis
+func trimContinuation(text string) string {
+	trimmed := strings.TrimLeft(strings.TrimPrefix(text, "..."), "…")
+	return strings.TrimSpace(trimmed)
+}
+
+// endsSentence reports whether text ends a sentence; a trailing ellipsis continues it
+func endsSentence(text string) bool {
+	if strings.HasSuffix(text, "...") || strings.HasSuffix(text, "…") {
+		return false
+	}
+	last, _ := utf8.DecodeLastRuneInString(strings.TrimRight(text, `"'”’)»`))
+	return strings.ContainsRune(sentenceEndPunctuation, last)
+}
+
+func isDash(token string) bool {
+	return token == "-" || token == "–" || token == "—"
+}
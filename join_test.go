@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSmartJoin(t *testing.T) {
+	tests := []struct {
+		name     string
+		parts    []string
+		expected []string
+	}{
+		{
+			name:     "hyphen across lines",
+			parts:    []string{"Die Verhand- lungen sind gescheitert."},
+			expected: []string{"Die Verhandlungen sind gescheitert."},
+		},
+		{
+			name:     "hyphen across cues",
+			parts:    []string{"Wir haben die inter-", "nationale Presse informiert."},
+			expected: []string{"Wir haben die internationale Presse informiert."},
+		},
+		{
+			name:     "suspended hyphen kept",
+			parts:    []string{"Haus- und Gartenarbeit."},
+			expected: []string{"Haus- und Gartenarbeit."},
+		},
+		{
+			name:     "sentence across cues with continuation ellipses",
+			parts:    []string{"Nous sommes allés...", "...à la plage.", "- Oui ? - Non."},
+			expected: []string{"Nous sommes allés à la plage.", "Oui ? Non."},
+		},
+		{
+			name:     "unfinished sentence at end",
+			parts:    []string{"It was", "late"},
+			expected: []string{"It was late"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := smartJoin(tt.parts); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// languageAliases maps the other ways detectors name a language to its ISO 639-1
+// code: ISO 639-2 bibliographic and terminology codes, ISO 639-3 codes where they
+// differ, English names, and the deprecated codes BCP 47 replaced
+var languageAliases = map[string]string{
+	"ara": "ar", "arabic": "ar",
+	"ben": "bn", "bengali": "bn", "bangla": "bn",
+	"bul": "bg", "bulgarian": "bg",
+	"cat": "ca", "catalan": "ca",
+	"ces": "cs", "cze": "cs", "czech": "cs",
+	"cym": "cy", "wel": "cy", "welsh": "cy",
+	"dan": "da", "danish": "da",
+	"deu": "de", "ger": "de", "german": "de",
+	"ell": "el", "gre": "el", "greek": "el",
+	"eng": "en", "english": "en",
+	"est": "et", "estonian": "et",
+	"eus": "eu", "baq": "eu", "basque": "eu",
+	"fas": "fa", "per": "fa", "pes": "fa", "persian": "fa", "farsi": "fa",
+	"fin": "fi", "finnish": "fi",
+	"fil": "tl", "tgl": "tl", "tagalog": "tl", "filipino": "tl",
+	"fra": "fr", "fre": "fr", "french": "fr",
+	"gle": "ga", "irish": "ga",
+	"glg": "gl", "galician": "gl",
+	"guj": "gu", "gujarati": "gu",
+	"heb": "he", "hebrew": "he", "iw": "he",
+	"hin": "hi", "hindi": "hi",
+	"hrv": "hr", "croatian": "hr",
+	"hun": "hu", "hungarian": "hu",
+	"hye": "hy", "arm": "hy", "armenian": "hy",
+	"ind": "id", "indonesian": "id", "in": "id",
+	"isl": "is", "ice": "is", "icelandic": "is",
+	"ita": "it", "italian": "it",
+	"jpn": "ja", "japanese": "ja",
+	"kan": "kn", "kannada": "kn",
+	"kat": "ka", "geo": "ka", "georgian": "ka",
+	"kaz": "kk", "kazakh": "kk",
+	"khm": "km", "khmer": "km",
+	"kor": "ko", "korean": "ko",
+	"lav": "lv", "lvs": "lv", "latvian": "lv",
+	"lit": "lt", "lithuanian": "lt",
+	"mal": "ml", "malayalam": "ml",
+	"mar": "mr", "marathi": "mr",
+	"msa": "ms", "may": "ms", "zsm": "ms", "malay": "ms",
+	"nld": "nl", "dut": "nl", "dutch": "nl", "flemish": "nl",
+	"nor": "no", "norwegian": "no",
+	"nob": "nb", "bokmal": "nb", "bokmål": "nb",
+	"nno": "nn", "nynorsk": "nn",
+	"pan": "pa", "punjabi": "pa",
+	"pol": "pl", "polish": "pl",
+	"por": "pt", "portuguese": "pt",
+	"ron": "ro", "rum": "ro", "romanian": "ro", "mo": "ro",
+	"rus": "ru", "russian": "ru",
+	"slk": "sk", "slo": "sk", "slovak": "sk",
+	"slv": "sl", "slovenian": "sl", "slovene": "sl",
+	"spa": "es", "spanish": "es", "castilian": "es",
+	"sqi": "sq", "alb": "sq", "albanian": "sq",
+	"srp": "sr", "serbian": "sr",
+	"swa": "sw", "swh": "sw", "swahili": "sw",
+	"swe": "sv", "swedish": "sv",
+	"tam": "ta", "tamil": "ta",
+	"tel": "te", "telugu": "te",
+	"tha": "th", "thai": "th",
+	"tur": "tr", "turkish": "tr",
+	"ukr": "uk", "ukrainian": "uk",
+	"urd": "ur", "urdu": "ur",
+	"vie": "vi", "vietnamese": "vi",
+	"yid": "yi", "yiddish": "yi", "ji": "yi",
+	"zho": "zh", "chi": "zh", "cmn": "zh", "chinese": "zh", "mandarin": "zh",
+}
+
+// undeterminedLanguages are the codes for "no language" that detectors answer when
+// they cannot tell: BCP 47's und, mul, mis and zxx, and words to the same effect
+var undeterminedLanguages = map[string]bool{
+	"und": true, "mul": true, "mis": true, "zxx": true,
+	"unknown": true, "none": true, "null": true,
+}
+
+// canonicalLanguage turns a detector's answer into a canonical BCP 47 tag, so "en",
+// "eng", "English" and "en_us" all compare as the language they name: the primary
+// subtag becomes its ISO 639-1 code, "_" becomes "-", regions are upper-cased and
+// scripts title-cased ("zh_hans_cn" is "zh-Hans-CN"). ok is false for answers that
+// name no language: unknown names, undetermined codes and malformed tags.
+func canonicalLanguage(tag string) (canonical string, ok bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if code, ok := languageAliases[tag]; ok {
+		return code, true
+	}
+	if !languageTagPattern.MatchString(tag) {
+		return "", false
+	}
+	subtags := strings.Split(strings.ReplaceAll(tag, "_", "-"), "-")
+	if code, ok := languageAliases[subtags[0]]; ok {
+		subtags[0] = code
+	}
+	if undeterminedLanguages[subtags[0]] {
+		return "", false
+	}
+	for i, subtag := range subtags[1:] {
+		switch {
+		case subtag == "x" || subtag == "u" || subtag == "t":
+			return strings.Join(subtags, "-"), true // private use and extensions stay lower case
+		case len(subtag) == 2 || len(subtag) == 3 && subtag[0] >= '0' && subtag[0] <= '9':
+			subtags[i+1] = strings.ToUpper(subtag)
+		case len(subtag) == 4 && i == 0:
+			subtags[i+1] = strings.ToUpper(subtag[:1]) + subtag[1:]
+		}
+	}
+	return strings.Join(subtags, "-"), true
+}
+
+// sameLanguage compares a detected language with the expected one as canonical tags,
+// falling back to comparing them as written when the expected tag cannot be read
+func sameLanguage(detected, expected string) bool {
+	if canonical, ok := canonicalLanguage(expected); ok {
+		expected = canonical
+	}
+	return detected == expected
+}
+
+// UnknownLanguageError reports a detector answer that names no known language, such
+// as "und" or a language name missing from the alias table. It is kept apart from
+// incorrect_language, since the track's language is still unknown. It is also the
+// error detectLanguage returns for such answers.
+type UnknownLanguageError struct {
+	Type         string        `json:"type"`
+	Rule         string        `json:"rule"`
+	Endpoint     string        `json:"endpoint"`
+	DetectedLang string        `json:"detected_language"` // the answer as the detector gave it
+	ExpectedLang string        `json:"expected_language"`
+	Description  string        `json:"description"`
+	SuggestedFix *SuggestedFix `json:"suggested_fix,omitempty"`
+}
+
+func (e *UnknownLanguageError) Error() string {
+	return fmt.Sprintf("language detector answered unknown language %q", e.DetectedLang)
+}
+
+// canonicalDetection canonicalizes a detector's answer, or describes it as an
+// *UnknownLanguageError
+func (cv *CaptionValidator) canonicalDetection(lang string) (string, error) {
+	if canonical, ok := canonicalLanguage(lang); ok {
+		return canonical, nil
+	}
+	return "", &UnknownLanguageError{
+		Type:         "unknown_detected_language",
+		Endpoint:     cv.endpoint,
+		DetectedLang: lang,
+		ExpectedLang: cv.expectedLanguage,
+		Description:  fmt.Sprintf("The language detector answered '%s', which names no known language", lang),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixCheckDetector,
+			Description: "Check that -endpoint is a language detector that answers with a language tag",
+		},
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCanonicalLanguage(t *testing.T) {
+	tests := []struct {
+		tag       string
+		canonical string
+		ok        bool
+	}{
+		{"en", "en", true},
+		{"eng", "en", true},
+		{"English", "en", true},
+		{"en_US", "en-US", true},
+		{"en-us", "en-US", true},
+		{"spa_MX", "es-MX", true},
+		{"zh_hans_cn", "zh-Hans-CN", true},
+		{"es-419", "es-419", true},
+		{"iw", "he", true},
+		{"yue", "yue", true},
+		{" pt-BR\n", "pt-BR", true},
+		{"de-CH-x-zh", "de-CH-x-zh", true},
+		{"und", "", false},
+		{"zxx", "", false},
+		{"Unknown", "", false},
+		{"klingon", "", false},
+		{"en US", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		canonical, ok := canonicalLanguage(tt.tag)
+		if canonical != tt.canonical || ok != tt.ok {
+			t.Errorf("%q: expected %q, %v; got %q, %v", tt.tag, tt.canonical, tt.ok, canonical, ok)
+		}
+	}
+}
+
+func TestDetectorLanguageConventions(t *testing.T) {
+	answer := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(answer))
+	}))
+	defer server.Close()
+
+	cv := NewCaptionValidator(server.URL)
+	cv.expectedLanguage = "en-US"
+	captions := []Caption{{StartTime: 1, EndTime: 3, Text: "Hello world"}}
+	for _, answer = range []string{`{"lang": "en_US"}`, `{"language": "eng-us"}`, "en-us"} {
+		if issue := cv.validateLanguage(context.Background(), captions); issue != nil {
+			t.Errorf("%s: expected a match, got %+v", answer, issue)
+		}
+	}
+
+	answer = `{"lang": "spa"}`
+	if issue, ok := cv.validateLanguage(context.Background(), captions).(*IncorrectLanguageError); !ok || issue.DetectedLang != "es" {
+		t.Errorf("expected incorrect_language naming es, got %+v", issue)
+	}
+
+	for _, answer = range []string{`{"lang": "und"}`, `{"language": "Elvish"}`, "unknown"} {
+		issue, ok := cv.validateLanguage(context.Background(), captions).(*UnknownLanguageError)
+		if !ok {
+			t.Fatalf("%s: expected unknown_detected_language, got %+v", answer, issue)
+		}
+		if issue.Endpoint != server.URL || issue.ExpectedLang != "en-US" || issue.SuggestedFix.Action != FixCheckDetector {
+			t.Errorf("unexpected error %+v", issue)
+		}
+	}
+	if _, err := cv.detectLanguage(context.Background(), "Hello world"); !errors.As(err, new(*UnknownLanguageError)) {
+		t.Errorf("expected detectLanguage to fail on an unknown answer, got %v", err)
+	}
+}
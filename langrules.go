@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"strings"
+)
+
+// LanguageRules are the reading-speed and length limits suited to a language. CJK
+// scripts pack a word or more into each character, so they are read at far fewer
+// characters per second and wrapped at shorter lines. Limits are in columns, where
+// fullwidth characters count as two: Japanese's 13 characters per line are 26.
+type LanguageRules struct {
+	MaxCPS        float64 // columns per second a cue may be read at
+	MaxChars      int     // columns a cue may hold before it is split
+	MaxLineLength int     // columns per line; 0 keeps the delivery profile's
+}
+
+// defaultLanguageRules apply to languages without rules of their own
+var defaultLanguageRules = LanguageRules{MaxCPS: 20, MaxChars: 84}
+
+// languageRuleSets are keyed by primary language subtag
+var languageRuleSets = map[string]LanguageRules{
+	"ja": {MaxCPS: 8, MaxChars: 52, MaxLineLength: 26},
+	"zh": {MaxCPS: 18, MaxChars: 64, MaxLineLength: 32},
+	"ko": {MaxCPS: 24, MaxChars: 64, MaxLineLength: 32},
+	// Long compounds make German slower to read but no shorter to wrap
+	"de": {MaxCPS: 17, MaxChars: 84},
+}
+
+// languageRulesFor returns the rules for a language tag such as ja-JP
+func languageRulesFor(language string) LanguageRules {
+	base, _, _ := strings.Cut(strings.ToLower(language), "-")
+	if rules, ok := languageRuleSets[base]; ok {
+		return rules
+	}
+	return defaultLanguageRules
+}
+
+// setFlags returns the names of the flags given on the command line, so language
+// defaults only fill in what was not set explicitly
+func setFlags(fs *flag.FlagSet) map[string]bool {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	return set
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestLanguageRulesFor(t *testing.T) {
+	if rules := languageRulesFor("ja-JP"); rules.MaxCPS != 8 || rules.MaxLineLength != 26 {
+		t.Errorf("expected Japanese limits, got %+v", rules)
+	}
+	if rules := languageRulesFor("ZH-Hant-TW"); rules != languageRuleSets["zh"] {
+		t.Errorf("expected Chinese limits for a traditional Chinese tag, got %+v", rules)
+	}
+	if rules := languageRulesFor("en-US"); rules != defaultLanguageRules {
+		t.Errorf("expected the defaults for English, got %+v", rules)
+	}
+	if rules := languageRulesFor(""); rules != defaultLanguageRules {
+		t.Errorf("expected the defaults without a language, got %+v", rules)
+	}
+}
+
+func TestSetFlagsOnlyListsGivenFlags(t *testing.T) {
+	fs := flag.NewFlagSet("suggest", flag.ContinueOnError)
+	fs.Float64("max_cps", 20, "")
+	fs.Int("max_chars", 84, "")
+	fs.String("language", "", "")
+	fs.Parse([]string{"-language", "ja-JP", "-max_chars", "40"})
+	set := setFlags(fs)
+	if !set["language"] || !set["max_chars"] || set["max_cps"] {
+		t.Errorf("unexpected set flags %v", set)
+	}
+}
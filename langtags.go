@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// langSpanPattern matches WebVTT language spans such as <lang es-MX>Hola</lang>;
+// group 1 is the declared tag and group 2 the span text
+var langSpanPattern = regexp.MustCompile(`<lang\s+([A-Za-z]{2,3}(?:-[A-Za-z0-9]+)*)\s*>(.*?)</lang>`)
+
+// minLanguageSpanWords is the fewest words a span needs to be worth detecting;
+// detectors are unreliable on a word or two
+const minLanguageSpanWords = 3
+
+// LanguageTagMismatch is a span whose declared language differs from the detected one
+type LanguageTagMismatch struct {
+	Cue      int    `json:"cue"`
+	Declared string `json:"declared_language"`
+	Detected string `json:"detected_language"`
+	Text     string `json:"text"`
+}
+
+// LanguageTagMismatchWarning reports cue spans tagged with the wrong language
+type LanguageTagMismatchWarning struct {
+	Type         string                `json:"type"`
+	Rule         string                `json:"rule"`
+	Severity     string                `json:"severity"`
+	Mismatches   []LanguageTagMismatch `json:"mismatches"`
+	Description  string                `json:"description"`
+	SuggestedFix *SuggestedFix         `json:"suggested_fix,omitempty"`
+}
+
+// sameBaseLanguage compares the primary subtags of two language tags, so es matches es-MX
+func sameBaseLanguage(a, b string) bool {
+	baseA, _, _ := strings.Cut(a, "-")
+	baseB, _, _ := strings.Cut(b, "-")
+	return strings.EqualFold(baseA, baseB)
+}
+
+// withoutForeignSpans removes spans tagged with a language other than expected, so
+// quoted foreign dialogue does not count against the track's language
+func withoutForeignSpans(text, expected string) string {
+	if !strings.Contains(text, "<lang") {
+		return text
+	}
+	return strings.Join(strings.Fields(langSpanPattern.ReplaceAllStringFunc(text, func(span string) string {
+		if sameBaseLanguage(langSpanPattern.FindStringSubmatch(span)[1], expected) {
+			return span
+		}
+		return " "
+	})), " ")
+}
+
+// validateLanguageTags sends each declared-language span to the detector and reports
+// spans whose detected language contradicts the tag. Spans too short to detect
+// reliably, and spans the detector fails on, are skipped.
+func (cv *CaptionValidator) validateLanguageTags(ctx context.Context, captions []Caption) *LanguageTagMismatchWarning {
+	var mismatches []LanguageTagMismatch
+	var cues []int
+	for i, caption := range captions {
+		flagged := false
+		for _, match := range langSpanPattern.FindAllStringSubmatch(caption.Text, -1) {
+			declared, text := match[1], stripMarkup(match[2])
+			if len(textWords(text)) < minLanguageSpanWords {
+				continue
+			}
+			detected, err := cv.detectLanguage(ctx, redactText(text, cv.redactMode))
+			if err != nil || sameBaseLanguage(declared, detected) {
+				continue
+			}
+			mismatches = append(mismatches, LanguageTagMismatch{Cue: i + 1, Declared: declared, Detected: detected, Text: text})
+			flagged = true
+		}
+		if flagged {
+			cues = append(cues, i+1)
+		}
+	}
+	if len(mismatches) == 0 {
+		return nil
+	}
+
+	return &LanguageTagMismatchWarning{
+		Type:        "language_tag_mismatch",
+		Severity:    SeverityWarning,
+		Mismatches:  mismatches,
+		Description: fmt.Sprintf("%d language-tagged span(s) in %d cue(s) were detected as a different language than declared", len(mismatches), len(cues)),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixRetagLanguage,
+			Cues:        cues,
+			Description: fmt.Sprintf("Correct the language tags in %s or the text they enclose", cueRange(cues)),
+		},
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithoutForeignSpans(t *testing.T) {
+	text := "He said <lang fr>je ne sais quoi</lang> and left <lang en-GB>for good</lang>"
+	if got := withoutForeignSpans(text, "en-US"); got != "He said and left <lang en-GB>for good</lang>" {
+		t.Errorf("unexpected text: %q", got)
+	}
+	if got := withoutForeignSpans("No tags here", "en-US"); got != "No tags here" {
+		t.Errorf("expected untagged text unchanged, got %q", got)
+	}
+}
+
+func TestValidateLanguageTags(t *testing.T) {
+	// The detector answers French only for the French sentence
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		lang := "es-ES"
+		if strings.Contains(string(body), "bonjour") {
+			lang = "fr-FR"
+		}
+		json.NewEncoder(w).Encode(map[string]string{"lang": lang})
+	}))
+	defer detector.Close()
+
+	cv := NewCaptionValidator(detector.URL)
+	captions := []Caption{
+		{StartTime: 0, EndTime: 2, Text: "<lang es>¿Dónde está la biblioteca?</lang>"},
+		{StartTime: 2, EndTime: 4, Text: "She answered <lang es>bonjour mes amis, bienvenue</lang>"},
+		{StartTime: 4, EndTime: 6, Text: "<lang de>Ja</lang>"},
+	}
+
+	warning := cv.validateLanguageTags(context.Background(), captions)
+	if warning == nil {
+		t.Fatal("expected a language_tag_mismatch warning")
+	}
+	expected := LanguageTagMismatch{Cue: 2, Declared: "es", Detected: "fr-FR", Text: "bonjour mes amis, bienvenue"}
+	if len(warning.Mismatches) != 1 || warning.Mismatches[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, warning.Mismatches)
+	}
+	if warning.SuggestedFix.Action != FixRetagLanguage {
+		t.Errorf("unexpected fix: %+v", warning.SuggestedFix)
+	}
+
+	if cv.validateLanguageTags(context.Background(), captions[:1]) != nil {
+		t.Error("expected no warning when tags match detection")
+	}
+}
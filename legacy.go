@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// Modes of -legacy_output. Batch reports used to reach parsers as a file's issues,
+// one JSON line each, as single-file mode still prints them; the mode keeps those
+// lines coming for one major version while parsers move to reports.
+const (
+	LegacyAlongside = "alongside" // each issue's line, then the report
+	LegacyInstead   = "instead"   // only the issue lines
+)
+
+// parseLegacyOutput checks a -legacy_output mode; empty prints reports only
+func parseLegacyOutput(mode string) (string, error) {
+	switch mode {
+	case "", LegacyAlongside, LegacyInstead:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid -legacy_output %q: use %s or %s", mode, LegacyAlongside, LegacyInstead)
+}
+
+// printReport prints a batch report as -legacy_output asks. Without a report, a
+// file that could not be validated has no line of its own, so its error is logged.
+func (cv *CaptionValidator) printReport(report *FileReport) {
+	if cv.legacy == "" {
+		printJSON(report)
+		return
+	}
+	for _, issue := range report.Errors {
+		printJSON(issue)
+	}
+	if cv.legacy == LegacyAlongside {
+		printJSON(report)
+	} else if report.ProgramError != "" {
+		log.Printf("%s: %s", report.File, report.ProgramError)
+	}
+}
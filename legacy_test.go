@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseLegacyOutput(t *testing.T) {
+	for _, mode := range []string{"", LegacyAlongside, LegacyInstead} {
+		if got, err := parseLegacyOutput(mode); err != nil || got != mode {
+			t.Errorf("%q: got %q, %v", mode, got, err)
+		}
+	}
+	if _, err := parseLegacyOutput("both"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestPrintReportLegacyOutput(t *testing.T) {
+	var output bytes.Buffer
+	resultOutput = &output
+	defer func() { resultOutput = os.Stdout }()
+
+	report := &FileReport{File: "a.srt", Window: "00:00:00.000-00:00:10.000", Errors: []interface{}{
+		&CaptionCoverageError{Type: "caption_coverage"},
+		&IncorrectLanguageError{Type: "incorrect_language"},
+	}}
+	broken := &FileReport{File: "b.txt", Errors: []interface{}{}, ProgramError: "unsupported caption format"}
+	tests := []struct {
+		mode     string
+		prefixes []string
+	}{
+		{"", []string{`{"file":"a.srt"`, `{"file":"b.txt"`}},
+		{LegacyAlongside, []string{`{"type":"caption_coverage"`, `{"type":"incorrect_language"`, `{"file":"a.srt"`, `{"file":"b.txt"`}},
+		{LegacyInstead, []string{`{"type":"caption_coverage"`, `{"type":"incorrect_language"`}},
+	}
+	for _, tt := range tests {
+		output.Reset()
+		cv := &CaptionValidator{legacy: tt.mode}
+		cv.printReport(report)
+		cv.printReport(broken)
+		lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+		if len(lines) != len(tt.prefixes) {
+			t.Errorf("%q: expected %d lines, got %q", tt.mode, len(tt.prefixes), output.String())
+			continue
+		}
+		for i, prefix := range tt.prefixes {
+			if !strings.HasPrefix(lines[i], prefix) {
+				t.Errorf("%q: line %d: expected %s..., got %s", tt.mode, i+1, prefix, lines[i])
+			}
+		}
+	}
+}
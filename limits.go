@@ -0,0 +1,72 @@
+package main
+
+import "sync"
+
+// ResourceLimits bounds the resources batch validation may hold at once. Zero values mean unlimited.
+type ResourceLimits struct {
+	MaxOpenFiles int   // concurrently open file and directory handles
+	MemoryBudget int64 // bytes of caption content held in memory across in-flight validations
+}
+
+// semaphore is a counting semaphore; a nil semaphore never blocks
+type semaphore chan struct{}
+
+func newSemaphore(n int) semaphore {
+	if n <= 0 {
+		return nil
+	}
+	return make(semaphore, n)
+}
+
+func (s semaphore) acquire() {
+	if s != nil {
+		s <- struct{}{}
+	}
+}
+
+func (s semaphore) release() {
+	if s != nil {
+		<-s
+	}
+}
+
+// memoryBudget is a weighted semaphore over bytes; a nil budget never blocks
+type memoryBudget struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	limit int64
+	used  int64
+}
+
+func newMemoryBudget(limit int64) *memoryBudget {
+	if limit <= 0 {
+		return nil
+	}
+	mb := &memoryBudget{limit: limit}
+	mb.cond = sync.NewCond(&mb.mu)
+	return mb
+}
+
+// acquire blocks until n bytes fit in the budget. A request larger than the whole
+// budget waits until nothing else is held, so oversized files still run, one at a time.
+func (mb *memoryBudget) acquire(n int64) {
+	if mb == nil {
+		return
+	}
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	for mb.used > 0 && mb.used+n > mb.limit {
+		mb.cond.Wait()
+	}
+	mb.used += n
+}
+
+func (mb *memoryBudget) release(n int64) {
+	if mb == nil {
+		return
+	}
+	mb.mu.Lock()
+	mb.used -= n
+	mb.mu.Unlock()
+	mb.cond.Broadcast()
+}
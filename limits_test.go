@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryBudget(t *testing.T) {
+	mb := newMemoryBudget(100)
+
+	mb.acquire(60)
+	acquired := make(chan struct{})
+	go func() {
+		mb.acquire(60)
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquire should block while the budget is exhausted")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	mb.release(60)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("acquire should proceed once the budget is released")
+	}
+	mb.release(60)
+
+	// Requests larger than the whole budget still run when nothing else is held
+	mb.acquire(500)
+	mb.release(500)
+}
+
+func TestUnlimitedResources(t *testing.T) {
+	// Zero limits disable the controls entirely and must never block
+	var files semaphore = newSemaphore(0)
+	for range 1000 {
+		files.acquire()
+	}
+	mb := newMemoryBudget(0)
+	mb.acquire(1 << 40)
+	mb.release(1 << 40)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// numberConvention is how a locale writes decimals and numeric dates
+type numberConvention struct {
+	decimal   byte   // decimal separator, '.' or ','
+	dateOrder string // order of numeric dates: "dmy", "mdy" or "ymd"
+}
+
+// localeConventions by language tag; full tags override their base language
+var localeConventions = map[string]numberConvention{
+	"de": {',', "dmy"}, "fr": {',', "dmy"}, "es": {',', "dmy"}, "it": {',', "dmy"},
+	"pt": {',', "dmy"}, "nl": {',', "dmy"}, "ru": {',', "dmy"}, "pl": {',', "dmy"},
+	"sv": {',', "ymd"}, "da": {',', "dmy"}, "nb": {',', "dmy"}, "no": {',', "dmy"},
+	"fi": {',', "dmy"}, "cs": {',', "dmy"}, "tr": {',', "dmy"}, "uk": {',', "dmy"},
+	"el": {',', "dmy"}, "ro": {',', "dmy"}, "hu": {',', "ymd"},
+	"ja": {'.', "ymd"}, "zh": {'.', "ymd"}, "ko": {'.', "ymd"},
+	"he": {'.', "dmy"}, "hi": {'.', "dmy"}, "th": {'.', "dmy"}, "ar": {'.', "dmy"},
+	"es-MX": {'.', "dmy"}, "de-CH": {'.', "dmy"},
+}
+
+// conventionFor returns the number convention of a language tag such as pt-BR
+func conventionFor(language string) (numberConvention, bool) {
+	if convention, ok := localeConventions[language]; ok {
+		return convention, true
+	}
+	base, _, _ := strings.Cut(language, "-")
+	convention, ok := localeConventions[strings.ToLower(base)]
+	return convention, ok
+}
+
+var (
+	// numericDatePattern matches dates such as 31/12/2024, 12-31-24 or 2024.12.31
+	numericDatePattern = regexp.MustCompile(`\b(\d{1,4})([/.-])(\d{1,2})([/.-])(\d{1,4})\b`)
+	// separatedNumberPattern matches numbers with at least one . or , separator
+	separatedNumberPattern = regexp.MustCompile(`\d+(?:[.,]\d+)+`)
+)
+
+// LocaleFinding is one number or date written in another locale's convention
+type LocaleFinding struct {
+	Cue     int    `json:"cue"`
+	Text    string `json:"text"`
+	Problem string `json:"problem"`
+}
+
+// LocaleFormatWarning reports numbers and dates that do not follow the expected
+// language's conventions
+type LocaleFormatWarning struct {
+	Type         string          `json:"type"`
+	Rule         string          `json:"rule"`
+	Severity     string          `json:"severity"`
+	Language     string          `json:"language"`
+	Findings     []LocaleFinding `json:"findings"`
+	Description  string          `json:"description"`
+	SuggestedFix *SuggestedFix   `json:"suggested_fix,omitempty"`
+}
+
+// numberSeparators infers the decimal and grouping separators of a number such as
+// "1.000,50". Either is 0 when the number is ambiguous, e.g. "1,000".
+func numberSeparators(number string) (decimal, grouping byte) {
+	lastDot, lastComma := strings.LastIndexByte(number, '.'), strings.LastIndexByte(number, ',')
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		if lastDot > lastComma {
+			return '.', ','
+		}
+		return ',', '.'
+	case strings.Count(number, ".") > 1:
+		return 0, '.'
+	case strings.Count(number, ",") > 1:
+		return 0, ','
+	}
+	separator := max(lastDot, lastComma)
+	if len(number)-separator-1 != 3 {
+		return number[separator], 0
+	}
+	return 0, 0
+}
+
+// dateProblem reports a numeric date whose order is unambiguously wrong for order
+func dateProblem(parts [3]string, order string) string {
+	var values [3]int
+	for i, part := range parts {
+		values[i], _ = strconv.Atoi(part)
+	}
+	var written string
+	switch {
+	case len(parts[0]) == 4:
+		written = "ymd"
+	case values[0] > 12 && values[1] <= 12:
+		written = "dmy"
+	case values[1] > 12 && values[0] <= 12:
+		written = "mdy"
+	default:
+		return ""
+	}
+	if written == order {
+		return ""
+	}
+	return fmt.Sprintf("date written %s, expected %s", strings.ToUpper(written), strings.ToUpper(order))
+}
+
+// localeProblems lists numbers and dates in text that break convention
+func localeProblems(text string, convention numberConvention) []LocaleFinding {
+	var findings []LocaleFinding
+	for _, match := range numericDatePattern.FindAllStringSubmatch(text, -1) {
+		if match[2] != match[4] {
+			continue
+		}
+		if problem := dateProblem([3]string{match[1], match[3], match[5]}, convention.dateOrder); problem != "" {
+			findings = append(findings, LocaleFinding{Text: match[0], Problem: problem})
+		}
+	}
+
+	// Dates are matched first so their parts are not read as numbers
+	text = numericDatePattern.ReplaceAllString(text, " ")
+	for _, number := range separatedNumberPattern.FindAllString(text, -1) {
+		decimal, grouping := numberSeparators(number)
+		switch {
+		case decimal != 0 && decimal != convention.decimal:
+			findings = append(findings, LocaleFinding{Text: number, Problem: fmt.Sprintf("decimal separator %q, expected %q", decimal, convention.decimal)})
+		case grouping != 0 && grouping == convention.decimal:
+			findings = append(findings, LocaleFinding{Text: number, Problem: fmt.Sprintf("thousands separator %q is this locale's decimal separator", grouping)})
+		}
+	}
+	return findings
+}
+
+// validateLocaleFormat checks numbers and numeric dates against the expected
+// language's conventions. English and languages without a known convention are
+// not checked.
+func (cv *CaptionValidator) validateLocaleFormat(captions []Caption) *LocaleFormatWarning {
+	if base, _, _ := strings.Cut(cv.expectedLanguage, "-"); strings.EqualFold(base, "en") {
+		return nil
+	}
+	convention, ok := conventionFor(cv.expectedLanguage)
+	if !ok {
+		return nil
+	}
+
+	var findings []LocaleFinding
+	var cues []int
+	for i, caption := range captions {
+		found := localeProblems(caption.Text, convention)
+		if len(found) == 0 {
+			continue
+		}
+		for j := range found {
+			found[j].Cue = i + 1
+		}
+		findings = append(findings, found...)
+		cues = append(cues, i+1)
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	return &LocaleFormatWarning{
+		Type:        "locale_format",
+		Severity:    SeverityWarning,
+		Language:    cv.expectedLanguage,
+		Findings:    findings,
+		Description: fmt.Sprintf("%d number(s) or date(s) in %d cue(s) are not formatted for %s", len(findings), len(cues), cv.expectedLanguage),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixLocalizeNumbers,
+			Cues:        cues,
+			Language:    cv.expectedLanguage,
+			Description: fmt.Sprintf("Reformat numbers and dates in %s for %s", cueRange(cues), cv.expectedLanguage),
+		},
+	}
+}
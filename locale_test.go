@@ -0,0 +1,63 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNumberSeparators(t *testing.T) {
+	tests := []struct {
+		number            string
+		decimal, grouping byte
+	}{
+		{"1,000.50", '.', ','},
+		{"1.000,50", ',', '.'},
+		{"1,000,000", 0, ','},
+		{"3.5", '.', 0},
+		{"3,25", ',', 0},
+		{"1,000", 0, 0},
+	}
+	for _, tt := range tests {
+		decimal, grouping := numberSeparators(tt.number)
+		if decimal != tt.decimal || grouping != tt.grouping {
+			t.Errorf("%s: expected decimal %q grouping %q, got %q %q", tt.number, tt.decimal, tt.grouping, decimal, grouping)
+		}
+	}
+}
+
+func TestValidateLocaleFormat(t *testing.T) {
+	captions := []Caption{
+		{Text: "Das kostet 1.000,50 Euro."},
+		{Text: "Das kostet 1,000.50 Euro, am 12/31/2024."},
+		{Text: "Am 31.12.2024 um 10.000 Uhr"},
+		{Text: "Nur 1,000"},
+	}
+
+	cv := NewCaptionValidator("http://test.com")
+	cv.expectedLanguage = "de-DE"
+	warn := cv.validateLocaleFormat(captions)
+	if warn == nil {
+		t.Fatal("expected locale_format warning")
+	}
+	expected := []LocaleFinding{
+		{Cue: 2, Text: "12/31/2024", Problem: "date written MDY, expected DMY"},
+		{Cue: 2, Text: "1,000.50", Problem: `decimal separator '.', expected ','`},
+	}
+	if !reflect.DeepEqual(warn.Findings, expected) {
+		t.Errorf("expected %+v, got %+v", expected, warn.Findings)
+	}
+	if warn.SuggestedFix.Action != FixLocalizeNumbers || warn.SuggestedFix.Language != "de-DE" {
+		t.Errorf("unexpected suggested fix %+v", warn.SuggestedFix)
+	}
+
+	// Mexican Spanish uses a decimal point
+	cv.expectedLanguage = "es-MX"
+	if warn := cv.validateLocaleFormat(captions[1:2]); warn == nil || len(warn.Findings) != 1 {
+		t.Errorf("expected only the date to be flagged for es-MX, got %+v", warn)
+	}
+
+	cv.expectedLanguage = "en-US"
+	if warn := cv.validateLocaleFormat(captions); warn != nil {
+		t.Errorf("expected English to be skipped, got %+v", warn)
+	}
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// The lsp command speaks the Language Server Protocol's base protocol: JSON-RPC 2.0
+// messages, each preceded by a Content-Length header, on stdin and stdout. An editor
+// sends a caption file's text as it is opened and on every change (full document
+// sync) and gets the validation issues back as diagnostics.
+
+// JSON-RPC error codes
+const (
+	lspParseError     = -32700
+	lspMethodNotFound = -32601
+)
+
+// Diagnostic severities
+const (
+	lspSeverityError   = 1
+	lspSeverityWarning = 2
+)
+
+// lspUnboundedWindow is validated when no -window is given; coverage is not checked over it
+var lspUnboundedWindow = Window{Start: 0, End: 24 * 60 * 60}
+
+// lspMessage is a request, response or notification
+type lspMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"` // set on requests and responses
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *lspError        `json:"error,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`      // 0-based
+	Character int `json:"character"` // 0-based, in UTF-16 code units
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+// lspDiagnostic is one validation issue at the lines it concerns. Data holds the
+// issue as validate would print it, suggested fix included.
+type lspDiagnostic struct {
+	Range    lspRange    `json:"range"`
+	Severity int         `json:"severity"`
+	Code     string      `json:"code,omitempty"`
+	Source   string      `json:"source"`
+	Message  string      `json:"message"`
+	Data     interface{} `json:"data,omitempty"`
+}
+
+// lspDocument is the part of a textDocument/did* notification's params the server reads
+type lspDocument struct {
+	TextDocument struct {
+		URI     string `json:"uri"`
+		Text    string `json:"text"`
+		Version int    `json:"version"`
+	} `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+// lspServer validates open documents; messages are handled one at a time
+type lspServer struct {
+	cv       *CaptionValidator
+	window   Window
+	coverage float64
+	docs     map[string]string // text of each open document by URI
+	out      io.Writer
+	shutdown bool // a shutdown request was received
+}
+
+// runLSP implements the lsp subcommand
+func runLSP(args []string) {
+	fs := flag.NewFlagSet("lsp", flag.ExitOnError)
+	endpoint := fs.String("endpoint", "", "Language detection endpoint URL; without one the language is not checked")
+	language := fs.String("language", "en-US", "Expected caption language")
+	locale := fs.String("locale", "en", "Language of the diagnostic messages: en, es or pt")
+	disable := fs.String("disable", "", "Comma-separated rule IDs or issue types never to report")
+	profile := fs.String("profile", "netflix", "Delivery profile whose punctuation style, percentile targets and font are checked: bbc, cea608 or netflix")
+	var percentileFlags stringList
+	fs.Var(&percentileFlags, "percentile", "Percentile target for a cue statistic, e.g. \"p100 cps <= 20\"; overrides the -profile target for the same percentile (repeatable)")
+	windowFlag := fs.String("window", "", "Time window as START-END coverage is checked over (default: coverage is not checked)")
+	coverage := fs.Float64("coverage", 80, "Required coverage percentage over -window")
+	resultsFD := resultsFDFlag(fs)
+	fs.Usage = commandUsage(fs, "lsp", "lsp [flags]")
+	fs.Parse(args)
+	if err := setResultsFD(*resultsFD); err != nil {
+		log.Fatal(err)
+	}
+
+	deliveryProfile, ok := deliveryProfiles[*profile]
+	if !ok {
+		log.Fatalf("Unknown -profile %q", *profile)
+	}
+	var percentileOverrides []PercentileTarget
+	for _, s := range percentileFlags {
+		target, err := ParsePercentileTarget(s)
+		if err != nil {
+			log.Fatal(err)
+		}
+		percentileOverrides = append(percentileOverrides, target)
+	}
+	window, requiredCoverage := lspUnboundedWindow, 0.0
+	if *windowFlag != "" {
+		var err error
+		if window, err = ParseWindow(*windowFlag); err != nil {
+			log.Fatal(err)
+		}
+		requiredCoverage = *coverage
+	}
+	localizer, err := newLocalizer(*locale)
+	if err != nil {
+		log.Fatal(err)
+	}
+	disabled, err := parseRuleList(*disable)
+	if err != nil {
+		log.Fatal(err)
+	}
+	metrics, err := lookupFont(deliveryProfile.Font)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	validator := NewCaptionValidator(*endpoint)
+	validator.expectedLanguage = *language
+	validator.locale = localizer
+	validator.disabled = disabled
+	validator.punctuation = deliveryProfile.Punctuation
+	validator.percentiles = mergePercentileTargets(deliveryProfile.Percentiles, percentileOverrides)
+	validator.rendering = &LineRendering{Font: metrics, Size: deliveryProfile.FontSize}
+	validator.markupErrors = true
+
+	server := &lspServer{cv: validator, window: window, coverage: requiredCoverage, docs: map[string]string{}, out: resultOutput}
+	os.Exit(server.serve(os.Stdin))
+}
+
+// serve handles messages from in until the client sends exit or closes the stream,
+// and returns the exit code: 0 if the client asked for a shutdown first
+func (s *lspServer) serve(in io.Reader) int {
+	reader := bufio.NewReader(in)
+	for {
+		body, err := readLSPMessage(reader)
+		if err == io.EOF {
+			return 1
+		}
+		if err != nil {
+			log.Printf("lsp: %v", err)
+			return 1
+		}
+		var msg lspMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			null := json.RawMessage("null")
+			s.write(lspMessage{ID: &null, Error: &lspError{Code: lspParseError, Message: err.Error()}})
+			continue
+		}
+		if msg.Method == "exit" {
+			if s.shutdown {
+				return 0
+			}
+			return 1
+		}
+		s.handle(msg)
+	}
+}
+
+// readLSPMessage reads one message's headers and returns its body
+func readLSPMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line == "" && length < 0 {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("failed to read message header: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		name, value, _ := strings.Cut(line, ":")
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil || length < 0 {
+				return nil, fmt.Errorf("invalid Content-Length %q", value)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, errors.New("message has no Content-Length header")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("failed to read message body: %w", err)
+	}
+	return body, nil
+}
+
+// handle answers a request or acts on a notification. Unknown notifications are
+// ignored, as the protocol asks.
+func (s *lspServer) handle(msg lspMessage) {
+	switch msg.Method {
+	case "initialize":
+		s.reply(msg, map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": map[string]interface{}{"openClose": true, "change": 1}, // full document sync
+			},
+			"serverInfo": map[string]string{"name": "caption-validator", "version": buildVersion()},
+		})
+	case "shutdown":
+		s.shutdown = true
+		s.reply(msg, nil)
+	case "textDocument/didOpen", "textDocument/didChange", "textDocument/didClose":
+		var params lspDocument
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			log.Printf("lsp: invalid %s params: %v", msg.Method, err)
+			return
+		}
+		uri := params.TextDocument.URI
+		switch msg.Method {
+		case "textDocument/didOpen":
+			s.docs[uri] = params.TextDocument.Text
+		case "textDocument/didChange":
+			if len(params.ContentChanges) == 0 {
+				return
+			}
+			s.docs[uri] = params.ContentChanges[len(params.ContentChanges)-1].Text
+		case "textDocument/didClose":
+			delete(s.docs, uri)
+			s.publish(uri, []lspDiagnostic{})
+			return
+		}
+		s.publish(uri, s.diagnose(uri, s.docs[uri]))
+	default:
+		if msg.ID != nil {
+			s.write(lspMessage{ID: msg.ID, Error: &lspError{Code: lspMethodNotFound, Message: "method not found: " + msg.Method}})
+		}
+	}
+}
+
+// diagnose validates a document's text in memory, named like the document
+func (s *lspServer) diagnose(uri, text string) []lspDiagnostic {
+	name := "document"
+	if parsed, err := url.Parse(uri); err == nil && path.Base(parsed.Path) != "/" && path.Base(parsed.Path) != "." {
+		name = path.Base(parsed.Path)
+	}
+	report, err := s.cv.ValidateBytes(context.Background(), []byte(text), ValidateOptions{Name: name, Window: s.window, Coverage: s.coverage})
+	if err != nil {
+		return []lspDiagnostic{lspFileDiagnostic(err.Error())}
+	}
+	return lspDiagnostics(report.Errors)
+}
+
+// lspDiagnostics turns issues into diagnostics: one per cue the suggested fix
+// targets, or per source line it names, or one on the first line for issues about
+// the whole file
+func lspDiagnostics(issues []interface{}) []lspDiagnostic {
+	diagnostics := []lspDiagnostic{}
+	for _, issue := range issues {
+		description, _ := issueField(issue, "Description", "description").(string)
+		diagnostic := lspDiagnostic{
+			Severity: lspSeverityError,
+			Code:     issueRule(issue),
+			Source:   "caption-validator",
+			Message:  description,
+			Data:     issue,
+		}
+		if issueSeverity(issue) == SeverityWarning {
+			diagnostic.Severity = lspSeverityWarning
+		}
+		var ranges []lspRange
+		if fix := issueFix(issue); fix != nil {
+			for _, span := range fix.Spans {
+				ranges = append(ranges, lspLines(span.StartLine, span.EndLine))
+			}
+			if len(ranges) == 0 {
+				for _, line := range fix.Lines {
+					ranges = append(ranges, lspLines(line, line))
+				}
+			}
+		}
+		if len(ranges) == 0 {
+			ranges = append(ranges, lspLines(1, 1))
+		}
+		for _, r := range ranges {
+			diagnostic.Range = r
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+	return diagnostics
+}
+
+// lspLines is the range covering 1-based lines first through last
+func lspLines(first, last int) lspRange {
+	return lspRange{Start: lspPosition{Line: first - 1}, End: lspPosition{Line: last}}
+}
+
+// lspFileDiagnostic reports a document that could not be validated at all
+func lspFileDiagnostic(message string) lspDiagnostic {
+	return lspDiagnostic{Range: lspLines(1, 1), Severity: lspSeverityError, Source: "caption-validator", Message: message}
+}
+
+// publish sends a document's diagnostics; an empty list clears them
+func (s *lspServer) publish(uri string, diagnostics []lspDiagnostic) {
+	params, _ := json.Marshal(map[string]interface{}{"uri": uri, "diagnostics": diagnostics})
+	s.write(lspMessage{Method: "textDocument/publishDiagnostics", Params: params})
+}
+
+// reply answers a request
+func (s *lspServer) reply(request lspMessage, result interface{}) {
+	if request.ID == nil {
+		return
+	}
+	if result == nil {
+		// A null result still has to be sent, which omitempty would drop
+		s.writeRaw(fmt.Appendf(nil, `{"jsonrpc":"2.0","id":%s,"result":null}`, *request.ID))
+		return
+	}
+	s.write(lspMessage{ID: request.ID, Result: result})
+}
+
+// write sends a message with its Content-Length header
+func (s *lspServer) write(msg lspMessage) {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("lsp: %v", err)
+		return
+	}
+	s.writeRaw(body)
+}
+
+func (s *lspServer) writeRaw(body []byte) {
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// lspInput frames messages the way an editor sends them
+func lspInput(messages ...string) io.Reader {
+	var in strings.Builder
+	for _, msg := range messages {
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+	return strings.NewReader(in.String())
+}
+
+func TestLSPServer(t *testing.T) {
+	metrics, err := lookupFont("arial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cv := NewCaptionValidator("")
+	cv.rendering = &LineRendering{Font: metrics, Size: 66}
+
+	long := "WEBVTT\n\n00:00:01.000 --> 00:00:04.000\nThis line is far too long to fit inside the safe area of the frame\n"
+	short := "WEBVTT\n\n00:00:01.000 --> 00:00:04.000\nShort enough\n"
+	document := func(method, text string) string {
+		params, _ := json.Marshal(map[string]interface{}{
+			"textDocument":   map[string]interface{}{"uri": "file:///work/ep1.vtt", "version": 1, "text": text},
+			"contentChanges": []map[string]string{{"text": text}},
+		})
+		return fmt.Sprintf(`{"jsonrpc":"2.0","method":%q,"params":%s}`, method, params)
+	}
+
+	var out bytes.Buffer
+	server := &lspServer{cv: cv, window: lspUnboundedWindow, docs: map[string]string{}, out: &out}
+	code := server.serve(lspInput(
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		document("textDocument/didOpen", long),
+		document("textDocument/didChange", short),
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	))
+	if code != 0 {
+		t.Errorf("expected exit code 0 after shutdown, got %d", code)
+	}
+
+	var replies []map[string]json.RawMessage
+	reader := bufio.NewReader(&out)
+	for {
+		body, err := readLSPMessage(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var reply map[string]json.RawMessage
+		json.Unmarshal(body, &reply)
+		replies = append(replies, reply)
+	}
+	if len(replies) != 5 {
+		t.Fatalf("expected 5 messages, got %d:\n%s", len(replies), out.String())
+	}
+	if !strings.Contains(string(replies[0]["result"]), `"change":1`) {
+		t.Errorf("expected full document sync, got %s", replies[0]["result"])
+	}
+
+	var opened struct {
+		URI         string          `json:"uri"`
+		Diagnostics []lspDiagnostic `json:"diagnostics"`
+	}
+	json.Unmarshal(replies[1]["params"], &opened)
+	if opened.URI != "file:///work/ep1.vtt" || len(opened.Diagnostics) != 1 {
+		t.Fatalf("expected one diagnostic, got %s", replies[1]["params"])
+	}
+	if d := opened.Diagnostics[0]; d.Code != "CV0503" || d.Severity != lspSeverityError || d.Range != lspLines(3, 4) {
+		t.Errorf("unexpected diagnostic %+v", d)
+	}
+	if !strings.Contains(string(replies[2]["params"]), `"diagnostics":[]`) {
+		t.Errorf("expected the change to clear the diagnostics, got %s", replies[2]["params"])
+	}
+	if !strings.Contains(string(replies[3]["error"]), "-32601") || string(replies[4]["result"]) != "null" {
+		t.Errorf("unexpected replies %s, %s", replies[3]["error"], replies[4]["result"])
+	}
+}
+
+func TestLSPDiagnosticsWithoutCues(t *testing.T) {
+	diagnostics := lspDiagnostics([]interface{}{
+		&PartialParseError{Type: "partial_parse", SuggestedFix: &SuggestedFix{Lines: []int{4, 9}}},
+		&CaptionCoverageError{Type: "caption_coverage", Severity: SeverityWarning},
+	})
+	if len(diagnostics) != 3 || diagnostics[0].Range != lspLines(4, 4) || diagnostics[1].Range != lspLines(9, 9) {
+		t.Fatalf("expected a diagnostic per line, got %+v", diagnostics)
+	}
+	if diagnostics[2].Range != lspLines(1, 1) || diagnostics[2].Severity != lspSeverityWarning || diagnostics[2].Code != "CV0201" {
+		t.Errorf("expected a file-level warning on the first line, got %+v", diagnostics[2])
+	}
+}
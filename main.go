@@ -1,32 +1,731 @@
-// Caption validator validates WebVTT and SRT caption files for coverage and language
+// Caption-validator checks caption files for coverage of a time window, language
+// and delivery quality, and prints its results as JSON lines.
+//
+// Usage:
+//
+//	caption-validator [validate] [flags] path [more paths, directories or URLs...]
+//	caption-validator command [flags] [arguments]
+//
+// It reads WebVTT, SRT, TTML/IMSC and Scenarist SCC files, PGS and VobSub bitmap
+// subtitles through an -ocr_cmd tool, OCR JSON, and with -imf the timed text
+// tracks of IMF packages. One file is validated on its own; several paths or a
+// directory are validated in batch mode by -workers at a time, filtered by
+// -include and -exclude, optionally split across machines with -shard
+// INDEX/COUNT, recorded with -manifest and re-run with -resume. Inputs may also be
+// sftp:// and ftps:// URLs, downloaded under -fetch_dir before validation.
+//
+// -endpoint names the language detection service and -language the expected
+// language; -window (or -t_start and -t_end) and -coverage set the coverage check.
+// Further flags enable optional checks, switch rules off with -disable, filter
+// known issues through a -baseline, and send results to syslog, email, webhooks
+// or a signed report. The commands are:
+//
+//	validate        validate files (the default command)
+//	probe           print the format, encoding, cue count and timing range of files
+//	conform         rewrite a file's cues to a delivery profile or another format
+//	suggest         print edit lists that split long cues and merge short ones
+//	check-endpoint  check that a language detector answers known samples correctly
+//	follow          poll a live WebVTT stream and alert when its coverage drops
+//	merge-reports   combine the reports of sharded batch runs into one
+//	serve           serve validation over HTTP, described by /openapi.json
+//	lsp             send live diagnostics to caption editors as a language server
+//	help            show the commands, or a command's flags and examples
+//	completion      print a bash, zsh or fish completion script
+//
+// "caption-validator help COMMAND" lists a command's flags and examples.
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
 )
 
+// stringList is a repeatable string flag, e.g. -include '*.srt' -include '*.vtt'
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// metaKeyPattern keeps -meta keys usable as logfmt keys
+var metaKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// metaFlag collects repeatable key=value pairs, e.g. -meta asset_id=ABC123 -meta vendor=Acme
+type metaFlag map[string]string
+
+func (m metaFlag) String() string {
+	pairs := make([]string, 0, len(m))
+	for key, value := range m {
+		pairs = append(pairs, key+"="+value)
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (m metaFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || !metaKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid metadata %q: expected key=value with a key of letters, digits, '_', '-' or '.'", value)
+	}
+	if _, exists := m[key]; exists {
+		return fmt.Errorf("metadata key %q given twice", key)
+	}
+	m[key] = val
+	return nil
+}
+
+// timestampFlag is a time flag accepting seconds, HH:MM:SS.mmm or a Go duration like 1h30m
+type timestampFlag float64
+
+func (t *timestampFlag) String() string { return strconv.FormatFloat(float64(*t), 'f', -1, 64) }
+
+func (t *timestampFlag) Set(value string) error {
+	seconds, err := parseTimestamp(value)
+	if err != nil {
+		return err
+	}
+	*t = timestampFlag(seconds)
+	return nil
+}
+
+// exitInterrupted is the exit code after SIGINT/SIGTERM stopped work early. Results
+// for work that was already in flight are still written before exiting.
+const exitInterrupted = 3
+
+// Exit codes -fail_on asks for when files did not pass
+const (
+	exitFailed = 4 // some file failed
+	exitWarned = 5 // with -fail_on warn, some file had warnings and none failed
+)
+
+// failOnExitCode is the exit code for a run's results under a -fail_on policy
+func failOnExitCode(policy string, counts DigestCounts) int {
+	switch {
+	case counts.Failed > 0:
+		return exitFailed
+	case policy == SummaryWarn && counts.Warned > 0:
+		return exitWarned
+	}
+	return 0
+}
+
+// shutdownContext is cancelled on the first SIGINT or SIGTERM so work can drain;
+// a second signal kills the process as usual
+func shutdownContext() context.Context {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+		log.Print("Shutting down: finishing in-flight work (signal again to force)")
+	}()
+	return ctx
+}
+
 func main() {
-	var tStart = flag.Float64("t_start", 0, "Start time in seconds")
-	var tEnd = flag.Float64("t_end", 0, "End time in seconds")
+	validateUsage := commandUsage(flag.CommandLine, "validate", "[validate] [flags] captions-filepath [more paths or directories...]")
+	flag.Usage = func() {
+		printCommands(flag.CommandLine.Output())
+		fmt.Fprintln(flag.CommandLine.Output())
+		validateUsage()
+	}
+
+	// Subcommands take their own flags; anything else is the validation command
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch cmd := findCommand(args[0]); {
+		case cmd == nil:
+		case cmd.name == "help":
+			runHelp(args[1:])
+			return
+		case cmd.name == "completion":
+			runCompletion(args[1:])
+			return
+		case cmd.name == "validate":
+			flag.Usage = validateUsage
+			fallthrough
+		default:
+			cmd.run(args[1:])
+			return
+		}
+	}
+	runValidate(args)
+}
+
+// runValidate implements the validate command, which is also run when the first
+// argument is not a command name
+func runValidate(args []string) {
+	var tStart, tEnd timestampFlag
+	flag.Var(&tStart, "t_start", "Start time in seconds, HH:MM:SS.mmm or a duration like 1h30m")
+	flag.Var(&tEnd, "t_end", "End time in seconds, HH:MM:SS.mmm or a duration like 1h30m")
+	var windowFlag = flag.String("window", "", "Time window as START-END, e.g. 00:05:00-01:30:00 or 5m-90m (overrides -t_start/-t_end)")
+	var imf = flag.Bool("imf", false, "Treat directory arguments as IMF packages and validate the timed text track of each CPL")
+	var coverageImage = flag.String("coverage_image", "", "Draw each file's captioned and uncovered time to this SVG (or .png) file; {name} is replaced with the caption file's name")
+	var programFile = flag.String("program", "", "EDL or IMF CPL whose program segments coverage is measured over (overrides -window and -t_start/-t_end)")
+	var frameRate = flag.String("frame_rate", "24", "Timecode frame rate of the -program EDL, -segment_map times and -asset_list durations, e.g. 23.976, 25 or 29.97")
+	var segmentMapFile = flag.String("segment_map", "", "JSON map of content segments (recap, program, credits, ...) and the coverage each type must reach, checked instead of -coverage over the window")
+	var assetList = flag.String("asset_list", "", "CSV or XML asset list from a MAM giving the files its name patterns match their duration (the window end), language and coverage")
+	var offset timestampFlag
+	flag.Var(&offset, "offset", "Seconds (or duration like -5s) added to every cue time before validation")
+	var timeBase = flag.String("time_base", TimeBaseSeconds, "Unit of the cue times in JSON cue documents, such as captions extracted from a transport stream: seconds, 90khz (90 kHz ticks from 0) or pts (MPEG-TS PTS, unwrapped at 2^33)")
+	var ptsOrigin = flag.Int64("pts_origin", 0, "With -time_base pts, the PTS of program time 0, e.g. the stream's first video PTS")
+	var allowPartial = flag.Bool("allow_partial", false, "Let partly parsed (damaged or truncated) files pass; parse failures are still reported")
+	var repairHybrids = flag.Bool("repair_hybrids", false, "Accept SRT/WebVTT hybrid timestamps without reporting format_mismatch")
+	var safeArea SafeArea
+	flag.Var(&safeArea, "safe_area", "Title-safe margins in percent of the frame, as 10 or HORIZONTAL,VERTICAL; WebVTT cues positioned outside are reported as unsafe_position (0 disables)")
+	var graphicsPath = flag.String("graphics", "", "JSON file of on-screen graphic regions and times; cues shown over them are reported as graphic_collision")
+	var lineOverflow = flag.Bool("line_overflow", false, "Measure cue lines with font metrics and report lines wider than the safe area as line_overflow")
+	var font = flag.String("font", "", "Font lines are measured in for -line_overflow: arial, helvetica or courier (defaults to the -profile font, else arial)")
+	var fontSize = flag.Float64("font_size", 0, "Font size in pixels of a 1920x1080 frame for -line_overflow (defaults to the -profile size, else 66)")
+	var markupErrors = flag.Bool("markup_errors", false, "Report unbalanced SRT formatting tags as markup_error")
+	var invisibleChars = flag.Bool("invisible_chars", false, "Warn about zero-width, control, misplaced no-break space and unbalanced bidi characters, and non-NFC text")
+	var profile = flag.String("profile", "", "Delivery profile whose punctuation style and percentile targets are enforced: bbc, cea608 or netflix")
+	var quotes = flag.String("quotes", "", "Required quote style: straight or curly (overrides -profile)")
+	var dashes = flag.String("dashes", "", "Required dash style: em_dash or double_hyphen (overrides -profile)")
+	var ellipsis = flag.String("ellipsis", "", "Required ellipsis style: character or dots (overrides -profile)")
+	var mtThreshold = flag.Float64("mt_threshold", 0, "Warn as quality_suspect when the machine translation score (0-1) reaches this value (0 disables)")
+	var mtModel = flag.String("mt_model", "", "Command that scores machine translation (cues JSON on stdin, {\"score\": 0-1} on stdout) instead of the built-in heuristic")
+	var ocrCmd = flag.String("ocr_cmd", "", "Command that OCRs PGS and VobSub subtitles: run with the file path, prints OCR JSON on stdout")
+	var metadataLanguage = flag.Bool("metadata_language", false, "Warn when the language declared in the file header differs from -language")
+	var annotationLanguage = flag.Bool("annotation_language", false, "Check the language of SDH sound effects and speaker labels apart from the dialogue")
+	var speakerCoverage = flag.Bool("speaker_coverage", false, "Warn when a labeled speaker has no captions within the window")
 	var coverage = flag.Float64("coverage", 80, "Required coverage percentage")
+	var coverageWarn = flag.Float64("coverage_warn", 0, "Coverage percentage under which a file that meets -coverage is still reported with a warning (0 disables)")
+	var failOn = flag.String("fail_on", "", "Exit non-zero when a file fails (fail: exit 4) or also when it only has warnings (warn: exit 5)")
+	var coverageTolerance = flag.Float64("coverage_tolerance", 0, "Percentage points below -coverage that still pass, e.g. 0.05 passes 79.95% at 80%")
+	var coverageMetric = flag.String("coverage_metric", CoverageWallClock, "Coverage metric that gates delivery: wall_clock or dialogue_weighted")
+	var minReadable = flag.Float64("min_readable", 1.0, "Cues shorter than this many seconds are discounted in dialogue-weighted coverage")
+	var endPadding = flag.Float64("end_padding", 0, "Seconds added to each cue's end when measuring coverage, as players keep text on screen briefly, e.g. 0.25")
+	var clampWindowTo = flag.String("clamp_window", "", "Cut a window that runs past the media back to its end and note it as window_clamped: media (the duration the file declares) or last_cue (also the end of the last cue)")
 	var endpoint = flag.String("endpoint", "", "Language detection endpoint URL")
-	flag.Parse()
+	var connectTimeout = flag.Duration("connect_timeout", defaultDetectorTimeouts.Connect, "Timeout for connecting to the language detection endpoint")
+	var requestTimeout = flag.Duration("request_timeout", defaultDetectorTimeouts.Request, "Timeout for one language detection call")
+	detectorHeaders := headerFlag{}
+	flag.Var(detectorHeaders, "detector_header", "Header sent with every language detection call as \"Name: value\", e.g. \"X-Team: captions\" (repeatable)")
+	var runID = flag.String("run_id", "", "ID of this run, sent to the detector in the User-Agent and written to every report (default: random)")
+	var showVersion = flag.Bool("version", false, "Print the validator's version and exit")
+	var detectorRetries = flag.Int("detector_retries", 0, "Times a language detection call that times out, cannot connect or gets a 429 or 5xx is retried, with a backoff starting at 500ms")
+	var validationDeadline = flag.Duration("validation_deadline", 0, "Limit on all language detection calls for one file together (0 for none)")
+	var fileTimeout = flag.Duration("file_timeout", 0, "Limit on each file's whole validation; a file still running is reported as validation_timeout and the batch moves on (0 for none)")
+	var stats = flag.Bool("stats", false, "Print validation and detector latency percentiles to stderr as JSON after the run")
+	var language = flag.String("language", "en-US", "Expected caption language; numbers and dates are checked against its conventions")
+	var locale = flag.String("locale", "en", "Language of the human-readable descriptions in the output: en, es or pt (types, actions and other fields stay in English)")
+	var disable = flag.String("disable", "", "Comma-separated rule IDs or issue types never to report, e.g. CV0403,markup_error")
+	var baselinePath = flag.String("baseline", "", "Baseline file of known violations; only issues not recorded in it are reported")
+	var updateBaseline = flag.Bool("update_baseline", false, "Record this run's violations in the -baseline file instead of filtering them (other files' entries are kept)")
+	var inlineDisable = flag.Bool("inline_disable", false, "Let WebVTT files switch rules off with cv-disable NOTE comments; coverage and language rules still need -disable")
+	var directives = flag.Bool("directives", false, "Let WebVTT files override -language, exclude ranges from coverage and raise -coverage with cv- NOTE directives")
+	var redact = flag.String("redact", "", "Redact proper nouns and numbers before language detection: mask or hash")
+	var smartJoin = flag.Bool("smart_join", false, "Rejoin hyphenated words and sentences broken across lines and cues before language detection")
+	var sampleChars = flag.Int("sample_chars", 0, "Send at most this many characters, sampled across the file, for language detection (0 sends all)")
+	var classifierFlags stringList
+	flag.Var(&classifierFlags, "classifier", "Text classification endpoint cue text is also sent to, such as a toxicity or PII detector, as NAME=URL with optional ,type=ISSUE_TYPE ,threshold=SCORE ,severity=error|warning and ,label.LABEL=ISSUE_TYPE (repeatable)")
+	var detectChunk = flag.Float64("detect_chunk", 0, "On incorrect_language, detect the track this many seconds at a time and report the share of captioned time in each language (0 disables)")
+	var asr = flag.String("asr", "", "Word-level ASR JSON used as timing reference for sync checks")
+	var maxLatency = flag.Float64("max_latency", 2, "Allowed average caption delay in seconds versus ASR reference")
+	var minASRConfidence = flag.Float64("min_asr_confidence", 0, "Warn as low_asr_confidence where cues whose -asr words average a confidence under this, e.g. 0.6, make up most of a -asr_confidence_window stretch (0 disables)")
+	var asrConfidenceWindow = flag.Float64("asr_confidence_window", 60, "Seconds of the window -min_asr_confidence is measured over at a time")
+	var maxMidSentence = flag.Float64("max_mid_sentence", 0, "Max percentage of cues ending mid-sentence (0 disables)")
+	var maxOneWord = flag.Float64("max_one_word", 0, "Max percentage of one-word cues (0 disables)")
+	var maxClauseBreaks = flag.Float64("max_clause_breaks", 0, "Max percentage of cues broken across clause boundaries (0 disables)")
+	var flashRate = flag.Float64("flash_rate", 0, "Warn as cue_flash when more than this many cues per second appear over -flash_window (0 disables)")
+	var flashWindow = flag.Float64("flash_window", 3, "Span in seconds over which -flash_rate is measured")
+	var maxStuck = flag.Float64("max_stuck", 0, "Warn as stuck_caption when consecutive cues repeat the same text for more than this many seconds (0 disables)")
+	var minSpeechRatio = flag.Float64("min_speech_ratio", 0, "Warn as implausible_duration when a cue is shown for less than this share of the time its text takes to say, e.g. 0.5 (0 disables)")
+	var minWPM = flag.Float64("min_wpm", 0, "Warn as low_dialogue_density when the window averages fewer words per minute, e.g. 20 for scripted drama (0 disables)")
+	var fetchDir = flag.String("fetch_dir", "", "Directory sftp:// and ftps:// inputs are downloaded to, required with -shard, -resume or -baseline (default: a temporary directory removed after the run)")
+	var remoteKey = flag.String("remote_key", "", "Private key for sftp:// inputs, or TLS client key for ftps:// inputs with -remote_cert")
+	var remoteCert = flag.String("remote_cert", "", "TLS client certificate for ftps:// inputs")
+	var remotePasswordEnv = flag.String("remote_password_env", "", "Environment variable holding the password for ftps:// inputs")
+	var percentileFlags stringList
+	flag.Var(&percentileFlags, "percentile", "Percentile target for a cue statistic (cps, duration, chars or gap), e.g. \"p95 cps <= 20\" or \"median duration >= 1.5\"; overrides the -profile target for the same percentile (repeatable)")
+	var include, exclude stringList
+	flag.Var(&include, "include", "Glob pattern of files to validate in batch mode (repeatable)")
+	flag.Var(&exclude, "exclude", "Glob pattern of files or directories to skip in batch mode (repeatable)")
+	var followSymlinks = flag.Bool("follow_symlinks", false, "Follow symlinked files and directories in batch mode")
+	var shardFlag = flag.String("shard", "", "Validate only shard INDEX/COUNT of the batch, e.g. 3/10; files are assigned by a hash of their path, so pods given the same inputs split them without overlap")
+	var manifestPath = flag.String("manifest", "", "Write a batch run manifest recording each file's status to this file")
+	var resume = flag.String("resume", "", "Re-run only the files a previous batch manifest did not finish (not run or transient detector failures); the manifest is updated in place unless -manifest is set")
+	var workers = flag.Int("workers", runtime.NumCPU(), "Maximum in-flight validations in batch mode")
+	var maxOpenFiles = flag.Int("max_open_files", 256, "Maximum concurrently open file handles (0 for unlimited)")
+	var memoryBudget = flag.Int64("memory_budget_mb", 0, "Maximum MB of caption content held in memory at once (0 for unlimited)")
+	var pluginsDir = flag.String("plugins", "", "Directory of external validator executables (cues JSON on stdin, errors on stdout)")
+	var syslogTarget = flag.String("syslog", "", "Also send a one-line summary of each validation to syslog: local (syslog/journald) or udp://host:port or tcp://host:port")
+	var emailPath = flag.String("email", "", "JSON file of SMTP settings; a digest of the run with counts by error type and report links is emailed when it finishes")
+	var notifyPath = flag.String("notify", "", "JSON file of Slack or Teams webhooks posted a summary of the run when it finishes")
+	meta := metaFlag{}
+	flag.Var(meta, "meta", "Pass-through metadata as key=value, echoed as \"meta\" in every output record and notification (repeatable)")
+	var signKey = flag.String("sign_key", "", "Ed25519 PKCS#8 PEM key used to sign the report")
+	var signCmd = flag.String("sign_cmd", "", "External signing command (e.g. KMS wrapper): signing input on stdin, base64 signature on stdout")
+	var signKeyID = flag.String("sign_key_id", "", "Key ID recorded in the signature header")
+	var signatureOut = flag.String("signature_out", "", "Write a detached JWS signature to this file instead of appending an attestation line")
+	resultsFD := resultsFDFlag(flag.CommandLine)
+	var legacyOutput = flag.String("legacy_output", "", "Also print each issue as its own JSON line, as before batch reports: alongside the reports or instead of them (deprecated, removed in the next major version)")
+	flag.CommandLine.Parse(args)
+
+	if *showVersion {
+		fmt.Fprintln(resultOutput, "caption-validator", buildVersion())
+		return
+	}
+	var shard Shard
+	if *shardFlag != "" {
+		var err error
+		if shard, err = ParseShard(*shardFlag); err != nil {
+			log.Fatal(err)
+		}
+		if _, ok := meta["shard"]; ok {
+			log.Fatal("-meta shard is set by -shard")
+		}
+		meta["shard"] = shard.String()
+	}
+	resultMeta = meta
+	if err := setResultsFD(*resultsFD); err != nil {
+		log.Fatal(err)
+	}
 
 	// Validate arguments
 	if flag.NArg() < 1 {
-		log.Fatal("Usage: caption-validator [flags] captions-filepath")
+		log.Fatal("Usage: caption-validator [flags] captions-filepath [more paths or directories...]")
 	}
 	if *endpoint == "" {
 		log.Fatal("Language detection endpoint is required (use -endpoint flag)")
 	}
-	if *tEnd <= *tStart {
-		log.Fatal("End time must be greater than start time")
+	if !validRedactMode(*redact) {
+		log.Fatalf("Invalid -redact mode %q (use mask or hash)", *redact)
+	}
+	if *coverageTolerance < 0 {
+		log.Fatal("-coverage_tolerance cannot be negative")
+	}
+	if !validCoverageMetric(*coverageMetric) {
+		log.Fatalf("Invalid -coverage_metric %q (use wall_clock or dialogue_weighted)", *coverageMetric)
+	}
+	var punctuation PunctuationStyle
+	var percentiles []PercentileTarget
+	fontProfile := deliveryProfiles["netflix"]
+	if *profile != "" {
+		deliveryProfile, ok := deliveryProfiles[*profile]
+		if !ok {
+			log.Fatalf("Unknown -profile %q", *profile)
+		}
+		punctuation = deliveryProfile.Punctuation
+		percentiles = deliveryProfile.Percentiles
+		fontProfile = deliveryProfile
+	}
+	var percentileOverrides []PercentileTarget
+	for _, s := range percentileFlags {
+		target, err := ParsePercentileTarget(s)
+		if err != nil {
+			log.Fatal(err)
+		}
+		percentileOverrides = append(percentileOverrides, target)
+	}
+	percentiles = mergePercentileTargets(percentiles, percentileOverrides)
+	if *quotes != "" {
+		punctuation.Quotes = *quotes
+	}
+	if *dashes != "" {
+		punctuation.Dash = *dashes
+	}
+	if *ellipsis != "" {
+		punctuation.Ellipsis = *ellipsis
+	}
+	if err := punctuation.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	window := Window{Start: float64(tStart), End: float64(tEnd)}
+	if *windowFlag != "" {
+		var err error
+		if window, err = ParseWindow(*windowFlag); err != nil {
+			log.Fatal(err)
+		}
+	}
+	var program []ProgramSegment
+	if *programFile != "" {
+		rate, err := parseFrameRate(*frameRate)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if program, err = loadProgramSegments(*programFile, rate); err != nil {
+			log.Fatal(err)
+		}
+		window = programSpan(program)
+	}
+	var segmentMap *SegmentMap
+	if *segmentMapFile != "" {
+		if *programFile != "" {
+			log.Fatal("-segment_map and -program cannot be combined")
+		}
+		rate, err := parseFrameRate(*frameRate)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if segmentMap, err = loadSegmentMap(*segmentMapFile, rate); err != nil {
+			log.Fatal(err)
+		}
+		// Without a window, the other checks run over the mapped segments
+		if window.End == 0 {
+			window = segmentMap.span()
+		}
+	}
+	var assets AssetList
+	if *assetList != "" {
+		rate, err := parseFrameRate(*frameRate)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if assets, err = loadAssetList(*assetList, rate); err != nil {
+			log.Fatal(err)
+		}
+	}
+	// With an asset list the window may end at each file's duration instead
+	if err := window.Validate(); err != nil && (assets == nil || window.End != 0) {
+		log.Fatal(err)
+	}
+	localizer, err := newLocalizer(*locale)
+	if err != nil {
+		log.Fatal(err)
+	}
+	disabled, err := parseRuleList(*disable)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *updateBaseline && *baselinePath == "" {
+		log.Fatal("-update_baseline needs a -baseline file to write")
 	}
 
 	// Validate caption file
 	validator := NewCaptionValidator(*endpoint)
-	if err := validator.ValidateFile(flag.Arg(0), *tStart, *tEnd, *coverage); err != nil {
+	validator.expectedLanguage = *language
+	validator.redactMode = *redact
+	validator.sampleChars = *sampleChars
+	validator.smartJoin = *smartJoin
+	validator.detectChunk = *detectChunk
+	for _, spec := range classifierFlags {
+		classifier, err := parseClassifier(spec)
+		if err != nil {
+			log.Fatal(err)
+		}
+		validator.classifiers = append(validator.classifiers, classifier)
+	}
+	if len(validator.classifiers) > 0 {
+		validator.classifications = newClassificationCache()
+	}
+	validator.asrPath = *asr
+	validator.offset = float64(offset)
+	if validator.timeBase, err = parseTimeBase(*timeBase); err != nil {
 		log.Fatal(err)
 	}
-}
\ No newline at end of file
+	ptsOriginSet := setFlags(flag.CommandLine)["pts_origin"]
+	if ptsOriginSet && validator.timeBase != TimeBasePTS {
+		log.Fatal("-pts_origin needs -time_base pts")
+	}
+	if *ptsOrigin < 0 || *ptsOrigin >= ptsWrap {
+		log.Fatal("-pts_origin must be a 33-bit PTS")
+	}
+	validator.ptsOrigin, validator.ptsOriginSet = *ptsOrigin, ptsOriginSet
+	validator.allowPartial = *allowPartial
+	validator.markupErrors = *markupErrors
+	validator.repairHybrids = *repairHybrids
+	validator.speakerCheck = *speakerCoverage
+	validator.invisibleCheck = *invisibleChars
+	validator.metadataCheck = *metadataLanguage
+	validator.sdhCheck = *annotationLanguage
+	validator.directives = *directives
+	validator.inlineRules = *inlineDisable
+	validator.locale = localizer
+	validator.disabled = disabled
+	if *baselinePath != "" {
+		if validator.baseline, err = loadBaseline(*baselinePath, *updateBaseline); err != nil {
+			log.Fatal(err)
+		}
+	}
+	validator.safeArea = safeArea
+	if *lineOverflow {
+		if *font != "" {
+			fontProfile.Font = *font
+		}
+		if *fontSize != 0 {
+			fontProfile.FontSize = *fontSize
+		}
+		metrics, err := lookupFont(fontProfile.Font)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if fontProfile.FontSize <= 0 {
+			log.Fatal("-font_size must be positive")
+		}
+		validator.rendering = &LineRendering{Font: metrics, Size: fontProfile.FontSize}
+	}
+	if *graphicsPath != "" {
+		if validator.graphics, err = loadGraphics(*graphicsPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	validator.punctuation = punctuation
+	validator.percentiles = percentiles
+	validator.mtThreshold = *mtThreshold
+	validator.mtModel = strings.Fields(*mtModel)
+	validator.ocrCommand = strings.Fields(*ocrCmd)
+	validator.coverageMetric = *coverageMetric
+	validator.minReadable = *minReadable
+	if *endPadding < 0 {
+		log.Fatal("-end_padding cannot be negative")
+	}
+	validator.endPadding = *endPadding
+	if validator.clampWindow, err = parseClampWindow(*clampWindowTo); err != nil {
+		log.Fatal(err)
+	}
+	validator.tolerance = *coverageTolerance
+	if *coverageWarn != 0 && *coverageWarn <= *coverage {
+		log.Fatal("-coverage_warn must be above -coverage")
+	}
+	validator.coverageWarn = *coverageWarn
+	validator.program = program
+	validator.segmentMap = segmentMap
+	validator.assets = assets
+	validator.maxLatency = *maxLatency
+	if *minASRConfidence != 0 && *asr == "" {
+		log.Fatal("-min_asr_confidence needs an -asr transcript with word confidences")
+	}
+	if *asrConfidenceWindow <= 0 {
+		log.Fatal("-asr_confidence_window must be positive")
+	}
+	validator.minASRConfidence = *minASRConfidence
+	validator.asrConfidenceWindow = *asrConfidenceWindow
+	validator.setTimeouts(DetectorTimeouts{Connect: *connectTimeout, Request: *requestTimeout, Validation: *validationDeadline})
+	if *detectorRetries < 0 {
+		log.Fatal("-detector_retries cannot be negative")
+	}
+	validator.retries = *detectorRetries
+	if *fileTimeout < 0 {
+		log.Fatal("-file_timeout cannot be negative")
+	}
+	validator.deadline = *fileTimeout
+	validator.headers = http.Header(detectorHeaders)
+	if *runID != "" {
+		validator.runID = *runID
+	}
+	if validator.legacy, err = parseLegacyOutput(*legacyOutput); err != nil {
+		log.Fatal(err)
+	}
+	if validator.legacy != "" {
+		log.Print("-legacy_output is deprecated and will be removed in the next major version; parse the per-file reports instead")
+	}
+	if *stats {
+		validator.stats = &runStats{}
+	}
+	validator.segmentation = SegmentationThresholds{
+		MidSentence: *maxMidSentence,
+		OneWord:     *maxOneWord,
+		ClauseBreak: *maxClauseBreaks,
+	}
+	if *flashRate > 0 && *flashWindow <= 0 {
+		log.Fatal("-flash_window must be positive")
+	}
+	validator.flash = FlashLimits{Rate: *flashRate, Window: *flashWindow}
+	validator.minWPM = *minWPM
+	validator.maxStuck = *maxStuck
+	validator.minSpeechRatio = *minSpeechRatio
+	if *pluginsDir != "" {
+		plugins, err := discoverPlugins(*pluginsDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		validator.plugins = plugins
+	}
+	if *syslogTarget != "" {
+		summaries, err := newSyslogSummaries(*syslogTarget)
+		if err != nil {
+			log.Fatal(err)
+		}
+		validator.summaries = summaries
+	}
+	var email *EmailConfig
+	if *emailPath != "" {
+		if email, err = loadEmailConfig(*emailPath); err != nil {
+			log.Fatal(err)
+		}
+		validator.digest = &runDigest{}
+	}
+	var webhooks []Webhook
+	if *notifyPath != "" {
+		if webhooks, err = loadWebhooks(*notifyPath); err != nil {
+			log.Fatal(err)
+		}
+		validator.digest = &runDigest{}
+	}
+	switch *failOn {
+	case "":
+	case SummaryFail, SummaryWarn:
+		if validator.digest == nil {
+			validator.digest = &runDigest{}
+		}
+	default:
+		log.Fatalf("unknown -fail_on %q (use fail or warn)", *failOn)
+	}
+	validator.setLimits(ResourceLimits{
+		MaxOpenFiles: *maxOpenFiles,
+		MemoryBudget: *memoryBudget << 20,
+	})
+
+	// Fail fast when the detector says it cannot identify the expected language
+	if !validator.disabled[ruleIDs["detector_capability"]] {
+		capabilityErr, err := validator.checkDetectorCapability(context.Background())
+		if err != nil {
+			log.Printf("Skipping detector language check: %v", err)
+		}
+		if capabilityErr != nil {
+			printJSON(capabilityErr)
+			os.Exit(1)
+		}
+	}
+
+	// Tee results into a buffer so the exact bytes emitted can be signed afterwards
+	var signer Signer
+	var report bytes.Buffer
+	if *signKey != "" || *signCmd != "" {
+		var err error
+		if signer, err = newSigner(*signKey, *signCmd); err != nil {
+			log.Fatal(err)
+		}
+		resultOutput = io.MultiWriter(resultOutput, &report)
+	}
+
+	validator.coverageImage = *coverageImage
+	opts := BatchOptions{
+		Workers: *workers,
+		Walk: WalkOptions{
+			Include:        include,
+			Exclude:        exclude,
+			FollowSymlinks: *followSymlinks,
+		},
+		Manifest: *manifestPath,
+		Shard:    shard,
+	}
+	if *resume != "" {
+		if opts.Resume, err = loadManifest(*resume); err != nil {
+			log.Fatal(err)
+		}
+		if opts.Manifest == "" {
+			opts.Manifest = *resume
+		}
+	}
+	// Shards, manifests and baselines know files by path, and a temporary download
+	// directory has a new path every run
+	if *fetchDir == "" && slices.ContainsFunc(flag.Args(), isRemoteSource) && (shard.Count > 0 || *resume != "" || *baselinePath != "") {
+		log.Fatal("sftp:// and ftps:// inputs need -fetch_dir with -shard, -resume or -baseline, so downloads keep their paths from run to run")
+	}
+	remote := RemoteOptions{Key: *remoteKey, Cert: *remoteCert, PasswordEnv: *remotePasswordEnv, ConnectTimeout: *connectTimeout}
+
+	ctx := shutdownContext()
+	failed, err := validator.validateInputs(ctx, flag.Args(), window, *coverage, *fetchDir, remote, *imf, opts)
+	if errors.Is(err, ErrInterrupted) {
+		log.Print(err)
+	} else if err != nil {
+		log.Fatal(err)
+	}
+
+	if *updateBaseline {
+		if err := validator.baseline.save(*baselinePath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if email != nil {
+		// A failed digest is logged but never changes the run's exit code
+		if err := validator.digest.send(email); err != nil {
+			log.Print(err)
+		}
+	}
+	for _, err := range validator.digest.notify(webhooks) {
+		log.Print(err)
+	}
+	if validator.stats != nil {
+		fmt.Fprintln(os.Stderr, validator.stats.snapshot())
+	}
+	if signer != nil {
+		if err := attestReport(signer, report.Bytes(), *signKeyID, *signatureOut); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if ctx.Err() != nil {
+		os.Exit(exitInterrupted)
+	}
+	if failed {
+		os.Exit(1)
+	}
+	if *failOn != "" {
+		if code := failOnExitCode(*failOn, validator.digest.counts()); code != 0 {
+			os.Exit(code)
+		}
+	}
+}
+
+// validateInputs validates the command's inputs: one file on its own, anything else
+// in batch mode. sftp:// and ftps:// inputs are downloaded first, and temporary copies
+// are removed when it returns, whether validation finished or failed. It reports
+// whether a batch had failing files.
+func (cv *CaptionValidator) validateInputs(ctx context.Context, inputs []string, window Window, coverage float64, fetchDir string, remote RemoteOptions, imf bool, opts BatchOptions) (bool, error) {
+	if slices.ContainsFunc(inputs, isRemoteSource) {
+		local, cleanupRemote, err := fetchRemoteInputs(inputs, fetchDir, remote)
+		if err != nil {
+			return false, err
+		}
+		defer cleanupRemote()
+		inputs = local
+	}
+	if imf {
+		var err error
+		if inputs, err = cv.imfCompositions(inputs); err != nil {
+			return false, err
+		}
+	}
+
+	if cv.coverageImage != "" && isBatch(inputs) && !strings.Contains(cv.coverageImage, "{name}") {
+		return false, errors.New("-coverage_image needs {name} in its path to draw more than one file")
+	}
+	if isBatch(inputs) || opts.Shard.Count > 0 {
+		// Batch mode for multiple inputs or directories, one JSON report per file
+		return cv.ValidateBatch(ctx, inputs, window, coverage, opts)
+	}
+	return false, cv.ValidateFile(inputs[0], window, coverage)
+}
+
+// attestReport signs the emitted report, writing a detached JWS to signatureOut or
+// appending an attestation line with an embedded JWS to the results
+func attestReport(signer Signer, report []byte, keyID, signatureOut string) error {
+	detached := signatureOut != ""
+	jws, err := signReport(signer, report, keyID, detached)
+	if err != nil {
+		return err
+	}
+	if detached {
+		return os.WriteFile(signatureOut, []byte(jws+"\n"), 0644)
+	}
+	printJSON(Attestation{Type: "attestation", Alg: "EdDSA", JWS: jws})
+	return nil
+}
+
+// isBatch reports whether the inputs call for batch mode rather than a single file
+func isBatch(args []string) bool {
+	if len(args) > 1 {
+		return true
+	}
+	info, err := os.Stat(args[0])
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Manifest statuses
+const (
+	ManifestPassed    = "passed"
+	ManifestWarned    = "warned" // passed with warnings only
+	ManifestFailed    = "failed"
+	ManifestError     = "error"     // could not be validated, e.g. an unsupported format
+	ManifestTransient = "transient" // the detector failed; re-run on resume
+)
+
+// ManifestEntry records how one file of a batch run ended. A manifest is a JSON
+// line per file, written as each report is printed, so it survives a crash.
+type ManifestEntry struct {
+	File   string `json:"file"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"` // program error, or why the result is transient
+}
+
+// final reports whether the entry's file needs no re-run on resume
+func (e ManifestEntry) final() bool {
+	return e.Status != ManifestTransient
+}
+
+// manifestEntry classifies a report. Language detection failures are transient,
+// since the file itself may well pass once the detector answers.
+func manifestEntry(report FileReport) ManifestEntry {
+	entry := ManifestEntry{File: report.File, Status: ManifestPassed}
+	if report.ProgramError != "" {
+		entry.Status, entry.Reason = ManifestError, report.ProgramError
+		return entry
+	}
+	for _, issue := range report.Errors {
+		if failure, ok := issue.(*LanguageDetectionFailedError); ok {
+			entry.Status, entry.Reason = ManifestTransient, failure.Description
+			return entry
+		}
+	}
+	switch issuesResult(report.Errors) {
+	case SummaryWarn:
+		entry.Status = ManifestWarned
+	case SummaryFail:
+		entry.Status = ManifestFailed
+	}
+	return entry
+}
+
+// loadManifest reads a manifest into entries by normalized file path. A later line
+// for the same file replaces an earlier one.
+func loadManifest(path string) (map[string]ManifestEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	defer file.Close()
+
+	entries := map[string]ManifestEntry{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry ManifestEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.File == "" {
+			// A run killed mid-write can leave a partial last line; its file is re-run
+			continue
+		}
+		entries[normalizePath(entry.File)] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	// assOverridePattern matches ASS/SSA override blocks such as {\an8} or {\i1}
+	assOverridePattern = regexp.MustCompile(`\{\\[^}]*\}`)
+	// markupTagPattern matches HTML-style tags; group 1 is "/" for closing tags, group 2 the tag name
+	markupTagPattern = regexp.MustCompile(`<(/?)([a-zA-Z]+)[^<>]*>`)
+)
+
+// MarkupError reports cues whose formatting tags are unbalanced
+type MarkupError struct {
+	Type         string        `json:"type"`
+	Rule         string        `json:"rule"`
+	Cues         []int         `json:"cues"`
+	Problems     []string      `json:"problems"`
+	Description  string        `json:"description"`
+	SuggestedFix *SuggestedFix `json:"suggested_fix,omitempty"`
+}
+
+// stripMarkup reduces SubRip cue text to plain text: ASS override blocks and
+// tags are removed (nested or unclosed alike), then HTML entities are decoded
+func stripMarkup(text string) string {
+	text = assOverridePattern.ReplaceAllString(text, "")
+	text = markupTagPattern.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	text = strings.ReplaceAll(text, " ", " ")
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// markupProblems lists unbalanced tags in text, e.g. "unclosed <b>"
+func markupProblems(text string) []string {
+	var problems []string
+	var open []string
+	for _, match := range markupTagPattern.FindAllStringSubmatch(text, -1) {
+		name := strings.ToLower(match[2])
+		if match[1] == "" {
+			open = append(open, name)
+			continue
+		}
+
+		// Find the innermost matching open tag; anything opened after it is mis-nested
+		i := len(open) - 1
+		for i >= 0 && open[i] != name {
+			i--
+		}
+		if i < 0 {
+			problems = append(problems, fmt.Sprintf("</%s> without opening tag", name))
+			continue
+		}
+		for _, inner := range open[i+1:] {
+			problems = append(problems, fmt.Sprintf("<%s> closed by </%s>", inner, name))
+		}
+		open = open[:i]
+	}
+	for _, name := range open {
+		problems = append(problems, fmt.Sprintf("unclosed <%s>", name))
+	}
+	return problems
+}
+
+// validateMarkup checks the tags of every cue that was written with markup
+func (cv *CaptionValidator) validateMarkup(captions []Caption) *MarkupError {
+	var cues []int
+	var problems []string
+	for i, caption := range captions {
+		for _, problem := range markupProblems(caption.Markup) {
+			if len(cues) == 0 || cues[len(cues)-1] != i+1 {
+				cues = append(cues, i+1)
+			}
+			problems = append(problems, fmt.Sprintf("cue %d: %s", i+1, problem))
+		}
+	}
+	if len(cues) == 0 {
+		return nil
+	}
+
+	return &MarkupError{
+		Type:        "markup_error",
+		Cues:        cues,
+		Problems:    problems,
+		Description: fmt.Sprintf("Unbalanced formatting tags in %d cue(s)", len(cues)),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixBalanceTags,
+			Cues:        cues,
+			Description: fmt.Sprintf("Close or remove the unbalanced tags in %s", cueRange(cues)),
+		},
+	}
+}
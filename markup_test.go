@@ -0,0 +1,65 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStripMarkup(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"<i><b>Nested</b></i> tags", "Nested tags"},
+		{"<i>Unclosed italics", "Unclosed italics"},
+		{`<font color="#ffff00">Yellow</font>`, "Yellow"},
+		{`{\an8}Top of screen`, "Top of screen"},
+		{"Fish &amp; chips&nbsp;today", "Fish & chips today"},
+		{"&lt;i&gt; is literal", "<i> is literal"},
+		{"No markup", "No markup"},
+	}
+
+	for _, tt := range tests {
+		if result := stripMarkup(tt.input); result != tt.expected {
+			t.Errorf("stripMarkup(%q) = %q, expected %q", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestMarkupProblems(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"<i><b>Fine</b></i>", nil},
+		{"<i><b>Crossed</i></b>", []string{"<b> closed by </i>", "</b> without opening tag"}},
+		{"<I>Open", []string{"unclosed <i>"}},
+	}
+
+	for _, tt := range tests {
+		if result := markupProblems(tt.input); !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("markupProblems(%q) = %q, expected %q", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestParseSRTStripsMultiLineMarkup(t *testing.T) {
+	cv := NewCaptionValidator("http://test.com")
+
+	srt := "1\n00:00:01,000 --> 00:00:04,000\n<i>Spoken off screen,\nacross two lines</i>\n\n2\n00:00:05,000 --> 00:00:06,000\n<b>Never closed\n"
+	captions, _, err := cv.parseSRT(srt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(captions) != 2 || captions[0].Text != "Spoken off screen, across two lines" {
+		t.Fatalf("expected stripped multi-line cue, got %+v", captions)
+	}
+
+	markupErr := cv.validateMarkup(captions)
+	if markupErr == nil {
+		t.Fatal("expected markup_error, got none")
+	}
+	if !reflect.DeepEqual(markupErr.Cues, []int{2}) || markupErr.SuggestedFix.Action != FixBalanceTags {
+		t.Errorf("unexpected markup_error: %+v", markupErr)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// MergeSummary totals the reports merge-reports combined. It follows the reports
+// as the last line of the merged output.
+type MergeSummary struct {
+	Type          string         `json:"type"`
+	Inputs        int            `json:"inputs"`
+	Files         int            `json:"files"`
+	Passed        int            `json:"passed"`
+	Warned        int            `json:"warned"`
+	Failed        int            `json:"failed"`
+	Errors        int            `json:"errors"`      // files that could not be validated
+	Duplicates    int            `json:"duplicates"`  // reports dropped for a file already reported
+	ErrorTypes    map[string]int `json:"error_types"` // failed and warned files per error type
+	CoveredMs     int64          `json:"covered_ms"`  // over every validated file's window
+	WindowMs      int64          `json:"window_ms"`
+	WallClock     float64        `json:"wall_clock_coverage"`      // percentage of all windows captioned
+	Shards        []string       `json:"shards,omitempty"`         // shards the reports came from, from their "meta"
+	MissingShards []string       `json:"missing_shards,omitempty"` // shards of the same count no report came from
+}
+
+// mergedReport is one report line of a batch output file
+type mergedReport struct {
+	raw    []byte // the line as written, "meta" included
+	input  string // the file it was read from
+	report FileReport
+	shard  string
+}
+
+// runMergeReports implements the merge-reports subcommand
+func runMergeReports(args []string) {
+	fs := flag.NewFlagSet("merge-reports", flag.ExitOnError)
+	resultsFD := resultsFDFlag(fs)
+	fs.Usage = commandUsage(fs, "merge-reports", "merge-reports [flags] reports.jsonl [more reports...]")
+	fs.Parse(args)
+	if err := setResultsFD(*resultsFD); err != nil {
+		log.Fatal(err)
+	}
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	reports, summary, err := mergeReports(fs.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, report := range reports {
+		fmt.Fprintln(resultOutput, string(report.raw))
+	}
+	printJSON(summary)
+}
+
+// mergeReports reads batch output files and returns one report per file, in path
+// order, with their summary. A file reported more than once keeps the report from
+// the last input, unless only an earlier one was validated: a pod that could not
+// read a file does not undo one that could. Lines other than reports, such as
+// attestations, are skipped.
+func mergeReports(inputs []string) ([]mergedReport, *MergeSummary, error) {
+	summary := &MergeSummary{Type: "merge_summary", Inputs: len(inputs), ErrorTypes: map[string]int{}}
+	byFile := map[string]mergedReport{}
+	for _, input := range inputs {
+		reports, err := readReports(input)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, report := range reports {
+			key := normalizePath(report.report.File)
+			previous, seen := byFile[key]
+			if !seen {
+				byFile[key] = report
+				continue
+			}
+			summary.Duplicates++
+			if report.report.ProgramError != "" && previous.report.ProgramError == "" {
+				continue
+			}
+			if previous.report.SHA256 != report.report.SHA256 {
+				log.Printf("%s: the reports in %s and %s are of different versions of the file; keeping %s's", report.report.File, previous.input, report.input, report.input)
+			}
+			byFile[key] = report
+		}
+	}
+
+	reports := slices.SortedFunc(maps.Values(byFile), func(a, b mergedReport) int {
+		return strings.Compare(a.report.File, b.report.File)
+	})
+	digest := &runDigest{}
+	shards := map[string]bool{}
+	for _, merged := range reports {
+		report := merged.report
+		var err error
+		if report.ProgramError != "" {
+			err = errors.New(report.ProgramError)
+		}
+		digest.record(summarize(report.File, &report, err, 0))
+		if report.Coverage != nil {
+			summary.CoveredMs += report.Coverage.CoveredMs
+			summary.WindowMs += report.Coverage.WindowMs
+		}
+		if merged.shard != "" {
+			shards[merged.shard] = true
+		}
+	}
+	counts := digest.counts()
+	summary.Files, summary.Passed, summary.Warned, summary.Failed, summary.Errors = counts.Files, counts.Passed, counts.Warned, counts.Failed, counts.Errors
+	summary.ErrorTypes = counts.Types
+	if summary.WindowMs > 0 {
+		summary.WallClock = roundCoverage(100 * float64(summary.CoveredMs) / float64(summary.WindowMs))
+	}
+	summary.Shards, summary.MissingShards = shardCoverage(shards)
+	return reports, summary, nil
+}
+
+// readReports reads the report lines of one batch output file
+func readReports(input string) ([]mergedReport, error) {
+	file, err := os.Open(longPath(input))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reports: %w", err)
+	}
+	defer file.Close()
+
+	var reports []mergedReport
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var report struct {
+			FileReport
+			Meta map[string]string `json:"meta"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &report); err != nil {
+			return nil, fmt.Errorf("%s:%d: not a JSON report: %w", input, line, err)
+		}
+		if report.File == "" {
+			continue
+		}
+		reports = append(reports, mergedReport{
+			raw:    slices.Clone(scanner.Bytes()),
+			input:  input,
+			report: report.FileReport,
+			shard:  report.Meta["shard"],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", input, err)
+	}
+	return reports, nil
+}
+
+// shardCoverage returns the shards seen in order and, when they all split the same
+// count, the shards of that count that are missing
+func shardCoverage(seen map[string]bool) (shards, missing []string) {
+	counts := map[int]bool{}
+	var parsed []Shard
+	for name := range seen {
+		shard, err := ParseShard(name)
+		if err != nil {
+			continue
+		}
+		parsed = append(parsed, shard)
+		counts[shard.Count] = true
+	}
+	slices.SortFunc(parsed, func(a, b Shard) int { return a.Index - b.Index })
+	for _, shard := range parsed {
+		shards = append(shards, shard.String())
+	}
+	if len(counts) != 1 {
+		return shards, nil
+	}
+	count := parsed[0].Count
+	for index := 1; index <= count; index++ {
+		if shard := (Shard{Index: index, Count: count}); !seen[shard.String()] {
+			missing = append(missing, shard.String())
+		}
+	}
+	return shards, missing
+}
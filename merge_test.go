@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMergeReports(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, lines ...string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	coverage := `"coverage":{"wall_clock":70,"gating_metric":"wall_clock","covered_ms":21000,"window_ms":30000}`
+	shard1 := write("shard-1.jsonl",
+		`{"file":"a.srt","sha256":"aa",`+coverage+`,"errors":[{"type":"caption_coverage","rule":"CV0201","severity":"error"}],"meta":{"shard":"1/3"}}`,
+		`{"file":"c.srt","errors":[],"program_error":"failed to open file","meta":{"shard":"1/3"}}`,
+	)
+	shard2 := write("shard-2.jsonl",
+		`{"file":"b.srt","sha256":"bb",`+coverage+`,"errors":[{"type":"stuck_caption","rule":"CV0206","severity":"warning"}],"meta":{"shard":"2/3"}}`,
+		`{"type":"attestation","jws":"e30..sig"}`,
+	)
+	// A re-run that reads c.srt but could not read a.srt again
+	rerun := write("rerun.jsonl",
+		`{"file":"./a.srt","errors":[],"program_error":"failed to open file"}`,
+		`{"file":"c.srt","sha256":"cc",`+coverage+`,"errors":[]}`,
+	)
+
+	reports, summary, err := mergeReports([]string{shard1, shard2, rerun})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, report := range reports {
+		files = append(files, report.report.File)
+	}
+	if !slices.Equal(files, []string{"a.srt", "b.srt", "c.srt"}) || reports[0].report.SHA256 != "aa" || reports[2].report.SHA256 != "cc" {
+		t.Errorf("unexpected reports %v", files)
+	}
+	if !strings.Contains(string(reports[0].raw), `"meta":{"shard":"1/3"}`) {
+		t.Errorf("expected the report line to be kept as written, got %s", reports[0].raw)
+	}
+	if summary.Files != 3 || summary.Passed != 1 || summary.Warned != 1 || summary.Failed != 1 || summary.Errors != 0 || summary.Duplicates != 2 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	if summary.ErrorTypes["caption_coverage"] != 1 || summary.ErrorTypes["stuck_caption"] != 1 || summary.WindowMs != 90000 || summary.WallClock != 70 {
+		t.Errorf("unexpected totals %+v", summary)
+	}
+	if !slices.Equal(summary.Shards, []string{"1/3", "2/3"}) || !slices.Equal(summary.MissingShards, []string{"3/3"}) {
+		t.Errorf("unexpected shards %v, missing %v", summary.Shards, summary.MissingShards)
+	}
+
+	if _, _, err := mergeReports([]string{write("broken.jsonl", "not json")}); err == nil || !strings.Contains(err.Error(), "broken.jsonl:1") {
+		t.Errorf("expected the broken line to be named, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMetaFlag(t *testing.T) {
+	meta := metaFlag{}
+	for _, value := range []string{"asset_id=ABC123", "vendor=Acme Subtitles", "note=a=b"} {
+		if err := meta.Set(value); err != nil {
+			t.Fatalf("Set(%q): %v", value, err)
+		}
+	}
+	if got := meta.String(); got != "asset_id=ABC123,note=a=b,vendor=Acme Subtitles" {
+		t.Errorf("unexpected String() %q", got)
+	}
+	for _, value := range []string{"asset_id=XYZ", "no_separator", "=value", "asset id=1"} {
+		if err := meta.Set(value); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestPrintJSONAddsMeta(t *testing.T) {
+	var output bytes.Buffer
+	resultOutput, resultMeta = &output, map[string]string{"asset_id": "ABC123", "vendor": "Acme"}
+	defer func() { resultOutput, resultMeta = os.Stdout, nil }()
+
+	printJSON(&IncorrectLanguageError{Type: "incorrect_language", DetectedLang: "es-ES"})
+	printJSON(struct{}{})
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if !strings.HasPrefix(lines[0], `{"type":"incorrect_language",`) || !strings.HasSuffix(lines[0], `,"meta":{"asset_id":"ABC123","vendor":"Acme"}}`) {
+		t.Errorf("expected meta at the end of the record, got %s", lines[0])
+	}
+	if lines[1] != `{"meta":{"asset_id":"ABC123","vendor":"Acme"}}` {
+		t.Errorf("expected meta in an empty record, got %s", lines[1])
+	}
+
+	summary := ValidationSummary{File: "ep1.srt", Result: SummaryPass}.String()
+	if !strings.HasSuffix(summary, ` meta.asset_id="ABC123" meta.vendor="Acme"`) {
+		t.Errorf("expected meta in the syslog summary, got %s", summary)
+	}
+	n := testDigest().notification()
+	if last := n.facts[len(n.facts)-1]; last != [2]string{"vendor", "Acme"} {
+		t.Errorf("expected meta in webhook facts, got %v", n.facts)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FileMetadata is what a caption file declares about itself in its header, or in the
+// root element and head of a TTML document. SRT has no header.
+type FileMetadata struct {
+	Title     string            `json:"title,omitempty"`
+	Language  string            `json:"language,omitempty"`
+	Kind      string            `json:"kind,omitempty"`
+	FrameRate float64           `json:"frame_rate,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"` // every "Key: value" header line as written
+}
+
+// MetadataLanguageWarning reports a declared language that differs from the expected one
+type MetadataLanguageWarning struct {
+	Type         string        `json:"type"`
+	Rule         string        `json:"rule"`
+	Severity     string        `json:"severity"`
+	Declared     string        `json:"declared_language"`
+	Expected     string        `json:"expected_language"`
+	Description  string        `json:"description"`
+	SuggestedFix *SuggestedFix `json:"suggested_fix,omitempty"`
+}
+
+// parseWebVTTHeader reads metadata from the WEBVTT header block: text after the
+// WEBVTT signature is the title, and the lines below it are "Key: value" fields
+func parseWebVTTHeader(lines []string) *FileMetadata {
+	if len(lines) == 0 || !strings.HasPrefix(strings.TrimPrefix(lines[0], "\ufeff"), "WEBVTT") {
+		return nil
+	}
+	metadata := &FileMetadata{
+		Title: strings.TrimLeft(strings.TrimPrefix(strings.TrimPrefix(lines[0], "\ufeff"), "WEBVTT"), " \t-"),
+	}
+	for _, line := range lines[1:] {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if metadata.Fields == nil {
+			metadata.Fields = map[string]string{}
+		}
+		metadata.Fields[key] = value
+
+		switch strings.ToLower(key) {
+		case "title":
+			metadata.Title = value
+		case "language", "lang", "srclang":
+			metadata.Language = value
+		case "kind":
+			metadata.Kind = value
+		case "framerate", "frame-rate", "x-frame-rate":
+			metadata.FrameRate, _ = strconv.ParseFloat(value, 64)
+		}
+	}
+	if metadata.Title == "" && metadata.Fields == nil {
+		return nil
+	}
+	return metadata
+}
+
+// readMetadata returns the header metadata of a caption file, or nil when it has none
+func (cv *CaptionValidator) readMetadata(filepath, format string) *FileMetadata {
+	if format != "webvtt" && format != FormatTTML {
+		return nil
+	}
+	cv.openFiles.acquire()
+	defer cv.openFiles.release()
+	file, err := cv.openCaption(filepath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	if format == FormatTTML {
+		return parseTTMLHead(file)
+	}
+
+	for block, err := range scanBlocks(file) {
+		if err != nil {
+			return nil
+		}
+		return parseWebVTTHeader(block.Lines)
+	}
+	return nil
+}
+
+// validateMetadataLanguage checks that a declared header language matches the expected language
+func (cv *CaptionValidator) validateMetadataLanguage(metadata *FileMetadata) *MetadataLanguageWarning {
+	if metadata == nil || metadata.Language == "" || sameBaseLanguage(metadata.Language, cv.expectedLanguage) {
+		return nil
+	}
+	return &MetadataLanguageWarning{
+		Type:        "metadata_language_mismatch",
+		Severity:    SeverityWarning,
+		Declared:    metadata.Language,
+		Expected:    cv.expectedLanguage,
+		Description: fmt.Sprintf("Header declares language '%s' but the track should be '%s'", metadata.Language, cv.expectedLanguage),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixSetHeaderLanguage,
+			Language:    cv.expectedLanguage,
+			Description: fmt.Sprintf("Set the header's Language to %s, or check that the right track was delivered", cv.expectedLanguage),
+		},
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseWebVTTHeader(t *testing.T) {
+	metadata := parseWebVTTHeader([]string{"\ufeffWEBVTT - Episode 4", "Kind: captions", "Language: es-MX", "X-Frame-Rate: 23.976"})
+	if metadata == nil {
+		t.Fatal("expected metadata")
+	}
+	if metadata.Title != "Episode 4" || metadata.Language != "es-MX" || metadata.Kind != "captions" || metadata.FrameRate != 23.976 {
+		t.Errorf("unexpected metadata: %+v", metadata)
+	}
+	if metadata.Fields["Kind"] != "captions" || len(metadata.Fields) != 3 {
+		t.Errorf("expected header fields as written, got %+v", metadata.Fields)
+	}
+
+	if parseWebVTTHeader([]string{"WEBVTT"}) != nil {
+		t.Error("expected no metadata for a bare signature")
+	}
+	if parseWebVTTHeader([]string{"1", "00:00:01,000 --> 00:00:02,000"}) != nil {
+		t.Error("expected no metadata for SRT")
+	}
+}
+
+func TestValidateMetadataLanguage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "episode.vtt")
+	content := "WEBVTT\nLanguage: de\n\n00:00:01.000 --> 00:00:02.000\nHallo\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cv := NewCaptionValidator("http://test.com")
+	metadata := cv.readMetadata(path, "webvtt")
+	if metadata == nil || metadata.Language != "de" {
+		t.Fatalf("expected declared language de, got %+v", metadata)
+	}
+
+	warning := cv.validateMetadataLanguage(metadata)
+	if warning == nil || warning.Declared != "de" || warning.Expected != "en-US" || warning.SuggestedFix.Action != FixSetHeaderLanguage {
+		t.Errorf("unexpected warning: %+v", warning)
+	}
+
+	cv.expectedLanguage = "de-DE"
+	if warning := cv.validateMetadataLanguage(metadata); warning != nil {
+		t.Errorf("expected de to match de-DE, got %+v", warning)
+	}
+}
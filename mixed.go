@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormatSection is a run of a caption file written in one format
+type FormatSection struct {
+	Format string `json:"format"`
+	Offset int64  `json:"byte_offset"`
+	Line   int    `json:"line"`
+	Cues   int    `json:"cues"` // blocks with a timing line
+}
+
+// MixedFormatError reports a file that holds bodies of more than one format, such
+// as an SRT file appended to a WebVTT file
+type MixedFormatError struct {
+	Type           string          `json:"type"`
+	Rule           string          `json:"rule"`
+	DeclaredFormat string          `json:"declared_format"`
+	Sections       []FormatSection `json:"sections"`
+	Description    string          `json:"description"`
+	SuggestedFix   *SuggestedFix   `json:"suggested_fix,omitempty"`
+}
+
+// blockStartsFormat returns the format a block begins a body of when it differs
+// from current: a WEBVTT header, or SRT cue 1 with comma timestamps. Numbered
+// WebVTT cues and stray hybrid timestamps further into a body do not start one.
+func blockStartsFormat(block cueBlock, current string) string {
+	if current != "webvtt" && strings.HasPrefix(strings.TrimPrefix(block.Lines[0], "\ufeff"), "WEBVTT") {
+		return "webvtt"
+	}
+	if current != "srt" && len(block.Lines) > 1 && block.Lines[0] == "1" {
+		if times, ok := splitTiming(block.Lines[1]); ok && strings.Contains(times[0], ",") {
+			return "srt"
+		}
+	}
+	return ""
+}
+
+// formatSections splits a caption file into runs of one format, starting with the
+// declared format
+func (cv *CaptionValidator) formatSections(filepath, format string) []FormatSection {
+	cv.openFiles.acquire()
+	defer cv.openFiles.release()
+	file, err := cv.openCaption(filepath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	sections := []FormatSection{{Format: format, Line: 1}}
+	for block, err := range scanBlocks(file) {
+		if err != nil {
+			return nil
+		}
+		current := &sections[len(sections)-1]
+		if !block.First {
+			if next := blockStartsFormat(block, current.Format); next != "" {
+				sections = append(sections, FormatSection{Format: next, Offset: block.Offset, Line: block.Line})
+				current = &sections[len(sections)-1]
+			}
+		}
+		if strings.Contains(block.Lines[0], "-->") || len(block.Lines) > 1 && strings.Contains(block.Lines[1], "-->") {
+			cues := 1
+			if current.Format == "srt" {
+				cues = max(len(cv.srtCueStarts(block.Lines)), 1)
+			}
+			current.Cues += cues
+		}
+	}
+	return sections
+}
+
+// validateMixedFormat reports files whose content switches format part way through
+func (cv *CaptionValidator) validateMixedFormat(filepath, format string) *MixedFormatError {
+	if !isTextFormat(format) {
+		return nil
+	}
+	sections := cv.formatSections(filepath, format)
+	if len(sections) < 2 {
+		return nil
+	}
+
+	var lines []int
+	for _, section := range sections[1:] {
+		lines = append(lines, section.Line)
+	}
+	first := sections[1]
+	return &MixedFormatError{
+		Type:           "mixed_format_content",
+		DeclaredFormat: format,
+		Sections:       sections,
+		Description:    fmt.Sprintf("File mixes %d format sections; the first %s section starts at byte %d (line %d)", len(sections), first.Format, first.Offset, first.Line),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixSplitFile,
+			Lines:       lines,
+			Description: fmt.Sprintf("Split the file at line(s) %s and deliver each part on its own", joinInts(lines)),
+		},
+	}
+}
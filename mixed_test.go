@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateMixedFormat(t *testing.T) {
+	vtt := "WEBVTT\n\n1\n00:00:01.000 --> 00:00:02.000\nNumbered WebVTT cue\n\n00:00:03.000 --> 00:00:04.000\nSecond\n\n"
+	srt := "1\n00:00:05,000 --> 00:00:06,000\nAppended SRT\n\n2\n00:00:07,000 --> 00:00:08,000\nMore SRT\n"
+	dir := t.TempDir()
+	cv := NewCaptionValidator("")
+
+	path := filepath.Join(dir, "concatenated.vtt")
+	if err := os.WriteFile(path, []byte(vtt+srt+"\n"+vtt), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mixedErr := cv.validateMixedFormat(path, "webvtt")
+	if mixedErr == nil {
+		t.Fatal("expected mixed_format_content")
+	}
+	srtOffset := int64(len(vtt))
+	vttOffset := int64(len(vtt + srt + "\n"))
+	expected := []FormatSection{
+		{Format: "webvtt", Offset: 0, Line: 1, Cues: 2},
+		{Format: "srt", Offset: srtOffset, Line: 10, Cues: 2},
+		{Format: "webvtt", Offset: vttOffset, Line: 18, Cues: 2},
+	}
+	if !reflect.DeepEqual(mixedErr.Sections, expected) {
+		t.Errorf("expected sections %+v, got %+v", expected, mixedErr.Sections)
+	}
+	if lines := strings.Split(vtt+srt+"\n"+vtt, "\n"); lines[9] != "1" || lines[17] != "WEBVTT" {
+		t.Fatalf("test offsets are off: %q, %q", lines[9], lines[18])
+	}
+	if !reflect.DeepEqual(mixedErr.SuggestedFix.Lines, []int{10, 18}) {
+		t.Errorf("expected split points at lines 10 and 18, got %v", mixedErr.SuggestedFix.Lines)
+	}
+
+	// A single SRT-style timestamp is a hybrid, not a second body
+	path = filepath.Join(dir, "hybrid.vtt")
+	if err := os.WriteFile(path, []byte("WEBVTT\n\n3\n00:00:01,000 --> 00:00:02,000\nHybrid\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if mixedErr := cv.validateMixedFormat(path, "webvtt"); mixedErr != nil {
+		t.Errorf("expected no mixed content for a hybrid cue, got %+v", mixedErr)
+	}
+}
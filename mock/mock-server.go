@@ -2,7 +2,6 @@ package main
 
 import (
 	"encoding/json"
-	"fmt"
 	"io"
 	"log"
 	"net/http"
@@ -14,22 +13,22 @@ type LanguageResponse struct {
 
 const mockLanguage = "es-ES"
 
+// mockLanguages is the inventory served at /languages
+var mockLanguages = []string{"de", "en", "es", "fr", "it", "pt"}
+
 func detectHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Read the text from request body
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
+	// Read the text from request body; the mock answers the same whatever it says
+	if _, err := io.Copy(io.Discard, r.Body); err != nil {
 		http.Error(w, "Failed to read body", http.StatusBadRequest)
 		return
 	}
 	defer r.Body.Close()
 
-	fmt.Printf("Received text: %s\n", string(body))
-
 	// Always return mock language for testing
 	response := LanguageResponse{Lang: mockLanguage}
 
@@ -37,11 +36,23 @@ func detectHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+func languagesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]string{"languages": mockLanguages})
+}
+
 func main() {
 	http.HandleFunc("/detect", detectHandler)
+	http.HandleFunc("/languages", languagesHandler)
 
-	fmt.Println("Mock language detection server starting on :8081")
-	fmt.Printf("POST /detect - accepts plaintext, returns {\"lang\": \"%s\"}\n", mockLanguage)
+	// Diagnostics go to stderr; the mock never writes to stdout
+	log.Println("Mock language detection server starting on :8081")
+	log.Printf("POST /detect - accepts plaintext, returns {\"lang\": \"%s\"}", mockLanguage)
+	log.Println("GET /languages - returns {\"languages\": [...]}, the languages the mock claims to support")
 
 	log.Fatal(http.ListenAndServe(":8081", nil))
 }
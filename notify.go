@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Webhook kinds
+const (
+	WebhookSlack = "slack"
+	WebhookTeams = "teams"
+)
+
+// notifyTop is how many error types and files a notification lists
+const notifyTop = 5
+
+// notifyTimeout bounds each webhook post
+const notifyTimeout = 10 * time.Second
+
+// Webhook is one entry of the -notify file. On lists the run results that are
+// posted: pass, warn (some file had only warnings), fail (some file failed) and
+// error (some file could not be validated); it defaults to fail and error.
+type Webhook struct {
+	Kind string   `json:"kind"` // slack or teams
+	URL  string   `json:"url"`
+	On   []string `json:"on,omitempty"`
+}
+
+// loadWebhooks reads and checks a -notify file: a JSON array of Webhook
+func loadWebhooks(path string) ([]Webhook, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notify config: %w", err)
+	}
+	var webhooks []Webhook
+	if err := json.Unmarshal(content, &webhooks); err != nil {
+		return nil, fmt.Errorf("failed to decode notify config: %w", err)
+	}
+	for i := range webhooks {
+		webhook := &webhooks[i]
+		if webhook.Kind != WebhookSlack && webhook.Kind != WebhookTeams {
+			return nil, fmt.Errorf("webhook %d: unknown kind %q (use slack or teams)", i+1, webhook.Kind)
+		}
+		if !strings.HasPrefix(webhook.URL, "https://") && !strings.HasPrefix(webhook.URL, "http://") {
+			return nil, fmt.Errorf("webhook %d: url must be http or https", i+1)
+		}
+		if len(webhook.On) == 0 {
+			webhook.On = []string{SummaryFail, SummaryError}
+		}
+		for _, result := range webhook.On {
+			if result != SummaryPass && result != SummaryWarn && result != SummaryFail && result != SummaryError {
+				return nil, fmt.Errorf("webhook %d: unknown result %q (use pass, warn, fail or error)", i+1, result)
+			}
+		}
+	}
+	return webhooks, nil
+}
+
+// result is the overall result of a run: error if any file could not be validated,
+// otherwise fail if any file failed, otherwise warn if any file had warnings
+func (counts DigestCounts) result() string {
+	switch {
+	case counts.Errors > 0:
+		return SummaryError
+	case counts.Failed > 0:
+		return SummaryFail
+	case counts.Warned > 0:
+		return SummaryWarn
+	}
+	return SummaryPass
+}
+
+// topTypes returns the n most common error types, most common first, then by name
+func (counts DigestCounts) topTypes(n int) []string {
+	types := make([]string, 0, len(counts.Types))
+	for errType := range counts.Types {
+		types = append(types, errType)
+	}
+	slices.SortFunc(types, func(a, b string) int {
+		if counts.Types[a] != counts.Types[b] {
+			return counts.Types[b] - counts.Types[a]
+		}
+		return strings.Compare(a, b)
+	})
+	return types[:min(n, len(types))]
+}
+
+// worstFiles returns the failed files with the most errors, then files that could
+// not be validated
+func (rd *runDigest) worstFiles(n int) []ValidationSummary {
+	rd.mu.Lock()
+	var worst []ValidationSummary
+	for _, summary := range rd.summaries {
+		if summary.Result != SummaryPass {
+			worst = append(worst, summary)
+		}
+	}
+	rd.mu.Unlock()
+	slices.SortFunc(worst, func(a, b ValidationSummary) int {
+		if a.Errors != b.Errors {
+			return b.Errors - a.Errors
+		}
+		return strings.Compare(a.File, b.File)
+	})
+	return worst[:min(n, len(worst))]
+}
+
+// notification is the content of a webhook post before it is shaped for Slack or Teams
+type notification struct {
+	title string
+	facts [][2]string // label and value, then the -meta pairs
+	types []string    // "caption_coverage: 12 file(s)"
+	files []string    // "ep1.srt: 3 error(s)"
+}
+
+// notification condenses the run for a chat message
+func (rd *runDigest) notification() notification {
+	counts := rd.counts()
+	n := notification{
+		title: fmt.Sprintf("Caption validation %s: %d of %d files failed", counts.result(), counts.Failed, counts.Files),
+		facts: [][2]string{
+			{"Files", fmt.Sprint(counts.Files)},
+			{"Passed", fmt.Sprint(counts.Passed)},
+			{"Warnings only", fmt.Sprint(counts.Warned)},
+			{"Failed", fmt.Sprint(counts.Failed)},
+			{"Not validated", fmt.Sprint(counts.Errors)},
+		},
+	}
+	for _, key := range slices.Sorted(maps.Keys(resultMeta)) {
+		n.facts = append(n.facts, [2]string{key, resultMeta[key]})
+	}
+	for _, errType := range counts.topTypes(notifyTop) {
+		n.types = append(n.types, fmt.Sprintf("%s: %d file(s)", errType, counts.Types[errType]))
+	}
+	for _, summary := range rd.worstFiles(notifyTop) {
+		switch summary.Result {
+		case SummaryError:
+			n.files = append(n.files, fmt.Sprintf("%s: could not be validated", summary.File))
+		case SummaryWarn:
+			n.files = append(n.files, fmt.Sprintf("%s: %d warning(s)", summary.File, summary.Errors))
+		default:
+			n.files = append(n.files, fmt.Sprintf("%s: %d error(s)", summary.File, summary.Errors))
+		}
+	}
+	return n
+}
+
+// slackPayload formats a notification as Slack Block Kit
+func (n notification) slackPayload() interface{} {
+	var facts []string
+	for _, fact := range n.facts {
+		facts = append(facts, fmt.Sprintf("*%s:* %s", fact[0], fact[1]))
+	}
+	section := func(text string) map[string]interface{} {
+		return map[string]interface{}{"type": "section", "text": map[string]string{"type": "mrkdwn", "text": text}}
+	}
+	blocks := []interface{}{
+		map[string]interface{}{"type": "header", "text": map[string]string{"type": "plain_text", "text": n.title}},
+		section(strings.Join(facts, "   ")),
+	}
+	if len(n.types) > 0 {
+		blocks = append(blocks, section("*Top error types*\n• "+strings.Join(n.types, "\n• ")))
+	}
+	if len(n.files) > 0 {
+		blocks = append(blocks, section("*Worst files*\n• "+strings.Join(n.files, "\n• ")))
+	}
+	return map[string]interface{}{"text": n.title, "blocks": blocks}
+}
+
+// teamsPayload formats a notification as an Adaptive Card, as Teams workflow
+// webhooks expect
+func (n notification) teamsPayload() interface{} {
+	var facts []map[string]string
+	for _, fact := range n.facts {
+		facts = append(facts, map[string]string{"title": fact[0], "value": fact[1]})
+	}
+	textBlock := func(text string, bold bool) map[string]interface{} {
+		block := map[string]interface{}{"type": "TextBlock", "text": text, "wrap": true}
+		if bold {
+			block["weight"] = "Bolder"
+		}
+		return block
+	}
+	body := []interface{}{
+		map[string]interface{}{"type": "TextBlock", "text": n.title, "size": "Medium", "weight": "Bolder", "wrap": true},
+		map[string]interface{}{"type": "FactSet", "facts": facts},
+	}
+	if len(n.types) > 0 {
+		body = append(body, textBlock("Top error types", true), textBlock("- "+strings.Join(n.types, "\n- "), false))
+	}
+	if len(n.files) > 0 {
+		body = append(body, textBlock("Worst files", true), textBlock("- "+strings.Join(n.files, "\n- "), false))
+	}
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []interface{}{map[string]interface{}{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]interface{}{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"body":    body,
+			},
+		}},
+	}
+}
+
+// notify posts the run's summary to every webhook configured for its result and
+// returns the errors of those that failed
+func (rd *runDigest) notify(webhooks []Webhook) []error {
+	if len(webhooks) == 0 {
+		return nil
+	}
+	result := rd.counts().result()
+	var matching []Webhook
+	for _, webhook := range webhooks {
+		if slices.Contains(webhook.On, result) {
+			matching = append(matching, webhook)
+		}
+	}
+	return rd.notification().post(matching)
+}
+
+// post sends the notification to each webhook, shaped for its kind, and returns the
+// errors of those that failed
+func (n notification) post(webhooks []Webhook) []error {
+	client := &http.Client{Timeout: notifyTimeout}
+	var errs []error
+	for _, webhook := range webhooks {
+		payload := n.slackPayload()
+		if webhook.Kind == WebhookTeams {
+			payload = n.teamsPayload()
+		}
+		body, _ := json.Marshal(payload)
+		resp, err := client.Post(webhook.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s notification failed: %w", webhook.Kind, err))
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			errs = append(errs, fmt.Errorf("%s notification failed: %s", webhook.Kind, resp.Status))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testDigest() *runDigest {
+	digest := &runDigest{}
+	digest.record(ValidationSummary{File: "ep1.srt", Result: SummaryFail, Errors: 1, Types: []string{"caption_coverage"}})
+	digest.record(ValidationSummary{File: "ep2.srt", Result: SummaryFail, Errors: 3, Types: []string{"caption_coverage", "markup_error", "incorrect_language"}})
+	digest.record(ValidationSummary{File: "ep3.srt", Result: SummaryPass})
+	return digest
+}
+
+func TestNotification(t *testing.T) {
+	n := testDigest().notification()
+	if n.title != "Caption validation fail: 2 of 3 files failed" {
+		t.Errorf("unexpected title %q", n.title)
+	}
+	if !reflect.DeepEqual(n.types, []string{"caption_coverage: 2 file(s)", "incorrect_language: 1 file(s)", "markup_error: 1 file(s)"}) {
+		t.Errorf("unexpected top types %v", n.types)
+	}
+	if !reflect.DeepEqual(n.files, []string{"ep2.srt: 3 error(s)", "ep1.srt: 1 error(s)"}) {
+		t.Errorf("unexpected worst files %v", n.files)
+	}
+}
+
+func TestNotifyPostsBySeverity(t *testing.T) {
+	posts := map[string]map[string]interface{}{}
+	hooks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]interface{}
+		json.Unmarshal(body, &payload)
+		posts[r.URL.Path] = payload
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer hooks.Close()
+
+	webhooks := []Webhook{
+		{Kind: WebhookSlack, URL: hooks.URL + "/slack", On: []string{SummaryFail, SummaryError}},
+		{Kind: WebhookTeams, URL: hooks.URL + "/teams", On: []string{SummaryFail}},
+		{Kind: WebhookSlack, URL: hooks.URL + "/errors-only", On: []string{SummaryError}},
+		{Kind: WebhookSlack, URL: hooks.URL + "/broken", On: []string{SummaryFail}},
+	}
+	errs := testDigest().notify(webhooks)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "400") {
+		t.Errorf("expected the broken webhook to fail, got %v", errs)
+	}
+	if _, ok := posts["/errors-only"]; ok {
+		t.Error("expected a failed run not to post to an errors-only webhook")
+	}
+
+	slack := posts["/slack"]
+	if slack["text"] != "Caption validation fail: 2 of 3 files failed" || len(slack["blocks"].([]interface{})) != 4 {
+		t.Errorf("unexpected Slack payload %v", slack)
+	}
+	teams := posts["/teams"]
+	attachment := teams["attachments"].([]interface{})[0].(map[string]interface{})
+	card := attachment["content"].(map[string]interface{})
+	if teams["type"] != "message" || attachment["contentType"] != "application/vnd.microsoft.card.adaptive" || card["type"] != "AdaptiveCard" {
+		t.Errorf("unexpected Teams payload %v", teams)
+	}
+
+	if errs := (*runDigest)(nil).notify(nil); errs != nil {
+		t.Errorf("expected nothing to post without webhooks, got %v", errs)
+	}
+}
+
+func TestLoadWebhooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify.json")
+	os.WriteFile(path, []byte(`[{"kind": "teams", "url": "https://example.webhook.office.com/abc"}]`), 0644)
+	webhooks, err := loadWebhooks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(webhooks[0].On, []string{SummaryFail, SummaryError}) {
+		t.Errorf("expected fail and error by default, got %v", webhooks[0].On)
+	}
+
+	for _, content := range []string{
+		`[{"kind": "discord", "url": "https://example.com"}]`,
+		`[{"kind": "slack", "url": "hooks.slack.com"}]`,
+		`[{"kind": "slack", "url": "https://hooks.slack.com/x", "on": ["warning"]}]`,
+	} {
+		os.WriteFile(path, []byte(content), 0644)
+		if _, err := loadWebhooks(path); err == nil {
+			t.Errorf("expected an error for %s", content)
+		}
+	}
+}
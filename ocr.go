@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Formats of bitmap subtitles and of the timed text OCR makes of them
+const (
+	FormatPGS     = "pgs"      // Blu-ray presentation graphics, .sup
+	FormatVobSub  = "vobsub"   // DVD subpictures, .idx with its .sub
+	FormatOCRJSON = "ocr_json" // text already recognized from a bitmap format
+)
+
+// ocrTimeout bounds one run of the -ocr_cmd tool; a feature's subtitles can take minutes
+const ocrTimeout = 10 * time.Minute
+
+// OCRDocument is the timed text an OCR tool produces from bitmap subtitles, or a
+// caption extractor from a stream. Times are in seconds, or as -time_base says, and
+// text lines are separated by "\n".
+type OCRDocument struct {
+	Source string   `json:"source,omitempty"` // bitmap file the text was recognized from
+	Cues   []OCRCue `json:"cues"`
+}
+
+// OCRCue is one recognized subtitle image
+type OCRCue struct {
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+	Text      string  `json:"text"`
+}
+
+// sniffBitmapFormat recognizes bitmap subtitles and OCR output by their first bytes,
+// or returns ""
+func sniffBitmapFormat(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte("PG")):
+		return FormatPGS
+	case bytes.HasPrefix(header, []byte("# VobSub index file")):
+		return FormatVobSub
+	case bytes.HasPrefix(header, []byte{0x00, 0x00, 0x01, 0xBA}): // MPEG program stream pack
+		return FormatVobSub
+	case bytes.HasPrefix(bytes.TrimLeft(bytes.TrimPrefix(header, []byte("\ufeff")), " \t\r\n"), []byte("{")):
+		return FormatOCRJSON
+	}
+	return ""
+}
+
+// isBitmapFormat reports whether a format has to go through -ocr_cmd before it can be read
+func isBitmapFormat(format string) bool {
+	return format == FormatPGS || format == FormatVobSub
+}
+
+// textFormats are the formats of text files that can be scanned block by block
+var textFormats = []string{"webvtt", "srt"}
+
+// isTextFormat reports whether a format is a text file that can be scanned block by block
+func isTextFormat(format string) bool {
+	return slices.Contains(textFormats, format)
+}
+
+// ocrCues decodes OCR JSON into cues. Cues that cannot be used are returned as parse
+// failures whose line is the cue's position in the document.
+func (cv *CaptionValidator) ocrCues(source io.Reader) iter.Seq2[Caption, error] {
+	return func(yield func(Caption, error) bool) {
+		var doc OCRDocument
+		if err := json.NewDecoder(source).Decode(&doc); err != nil {
+			yield(Caption{}, fmt.Errorf("failed to decode OCR output: %w", err))
+			return
+		}
+		clock := cv.newCueClock()
+		for i, cue := range doc.Cues {
+			cue.StartTime, cue.EndTime = clock.seconds(cue.StartTime), clock.seconds(cue.EndTime)
+			text := strings.TrimSpace(strings.ReplaceAll(cue.Text, "\r\n", "\n"))
+			var failure *ParseFailure
+			switch {
+			case cue.StartTime < 0 || cue.EndTime <= cue.StartTime:
+				failure = &ParseFailure{Line: i + 1, Kind: FailureTimestamp, Text: text,
+					Reason: fmt.Sprintf("cue %d ends at %gs, not after its start at %gs", i+1, cue.EndTime, cue.StartTime)}
+			case text == "":
+				failure = &ParseFailure{Line: i + 1, Kind: FailureEmptyCue, Text: text, Reason: fmt.Sprintf("cue %d has no recognized text", i+1)}
+			}
+			if failure != nil {
+				if !yield(Caption{}, failure) {
+					return
+				}
+				continue
+			}
+
+			lines := strings.Split(text, "\n")
+			caption := Caption{
+				StartTime: cue.StartTime,
+				EndTime:   cue.EndTime,
+				Text:      strings.Join(lines, " "),
+				Lines:     len(lines),
+				Layout:    cv.cueLayout(lines),
+			}
+			if !yield(caption, nil) {
+				return
+			}
+		}
+	}
+}
+
+// runOCR runs the -ocr_cmd tool on a bitmap subtitle file and returns the OCR JSON it
+// writes to stdout. The file's path is appended to the command's arguments, and the
+// tool is killed when ctx is done.
+func (cv *CaptionValidator) runOCR(ctx context.Context, path, format string) ([]byte, error) {
+	if len(cv.ocrCommand) == 0 {
+		return nil, fmt.Errorf("%s subtitles are bitmaps: set -ocr_cmd or validate OCR JSON instead", format)
+	}
+	ctx, cancel := context.WithTimeout(ctx, ocrTimeout)
+	defer cancel()
+
+	args := append(cv.ocrCommand[1:len(cv.ocrCommand):len(cv.ocrCommand)], path)
+	cmd := exec.CommandContext(ctx, cv.ocrCommand[0], args...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("OCR of %s failed: %w", path, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestSniffBitmapFormat(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"PG\x00\x01\x00\x00\x00\x00\x00\x00\x16", FormatPGS},
+		{"# VobSub index file, v7 (do not modify this line!)\n", FormatVobSub},
+		{"\x00\x00\x01\xba\x44\x00\x04\x00", FormatVobSub},
+		{"\ufeff\n  {\"cues\": []}", FormatOCRJSON},
+		{"WEBVTT\n\n", "webvtt"},
+		{"1\n00:00:01,000 --> 00:00:02,000\n", "srt"},
+		{"plain text", "unknown"},
+	}
+	for _, tt := range tests {
+		if got := sniffFormat([]byte(tt.header)); got != tt.want {
+			t.Errorf("sniffFormat(%q) = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestOCRCues(t *testing.T) {
+	doc := `{"source": "feature.sup", "cues": [
+		{"start_time": 1, "end_time": 3, "text": "Where are you going?\r\nHome."},
+		{"start_time": 5, "end_time": 4, "text": "Backwards"},
+		{"start_time": 6, "end_time": 7, "text": "  "}
+	]}`
+	cv := NewCaptionValidator("")
+	captions, failures, err := collectCues(cv.Cues(strings.NewReader(doc), FormatOCRJSON), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(captions) != 1 || captions[0].Text != "Where are you going? Home." || captions[0].Lines != 2 {
+		t.Fatalf("unexpected captions: %+v", captions)
+	}
+	if len(failures) != 2 || failures[0].Kind != FailureTimestamp || failures[0].Line != 2 || failures[1].Kind != FailureEmptyCue {
+		t.Fatalf("unexpected failures: %+v", failures)
+	}
+
+	if _, _, err := collectCues(cv.Cues(strings.NewReader("{"), FormatOCRJSON), 0); err == nil {
+		t.Fatal("expected an error for truncated OCR JSON")
+	}
+}
+
+func TestValidateBitmapSubtitles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the OCR stand-in uses sh")
+	}
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	defer detector.Close()
+
+	dir := t.TempDir()
+	sup := filepath.Join(dir, "feature.sup")
+	if err := os.WriteFile(sup, []byte("PG\x00\x00\x00\x00\x00\x00\x00\x00\x16"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ocrJSON := `{"cues": [{"start_time": 0, "end_time": 1, "text": "Hello there."}]}`
+	tool := filepath.Join(dir, "ocr")
+	if err := os.WriteFile(tool, []byte("#!/bin/sh\ntest -f \"$2\" || exit 1\necho '"+ocrJSON+"'\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cv := NewCaptionValidator(detector.URL)
+	if _, err := cv.Validate(sup, Window{Start: 0, End: 4}, 80); err == nil || !strings.Contains(err.Error(), "-ocr_cmd") {
+		t.Fatalf("expected an error asking for -ocr_cmd, got %v", err)
+	}
+
+	cv.ocrCommand = []string{tool, "--lang=eng"}
+	report, err := cv.Validate(sup, Window{Start: 0, End: 4}, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Format != FormatPGS || report.SHA256 == "" {
+		t.Errorf("expected a hashed pgs report, got format %q sha256 %q", report.Format, report.SHA256)
+	}
+	if len(report.Errors) != 1 {
+		t.Fatalf("expected only caption_coverage, got %+v", report.Errors)
+	}
+	if coverageErr, ok := report.Errors[0].(*CaptionCoverageError); !ok || coverageErr.ActualCoverage != 25 {
+		t.Errorf("expected 25%% coverage from the OCR cues, got %+v", report.Errors[0])
+	}
+
+	// Text OCR'd ahead of time is validated without the tool
+	path := filepath.Join(dir, "feature.json")
+	if err := os.WriteFile(path, []byte(ocrJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cv.ocrCommand = nil
+	report, err = cv.Validate(path, Window{Start: 0, End: 4}, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Format != FormatOCRJSON || len(report.Errors) != 1 {
+		t.Errorf("unexpected report for OCR JSON: %+v", report)
+	}
+}
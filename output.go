@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// The output contract: results, one JSON object per line (or the cues conform
+// writes), go to stdout or the -results_fd descriptor and nowhere else; logs,
+// progress and -stats go to stderr through the log package. Nothing else is
+// printed, so a consumer can parse every line of the results stream.
+
+// resultsFDFlag registers -results_fd on a command's flag set
+func resultsFDFlag(fs *flag.FlagSet) *int {
+	return fs.Int("results_fd", 1, "File descriptor results are written to, e.g. 3 to keep stdout free; logs always go to stderr")
+}
+
+// setResultsFD sends results to an inherited file descriptor instead of stdout
+func setResultsFD(fd int) error {
+	switch {
+	case fd == 1:
+		return nil
+	case fd == 2:
+		return errors.New("-results_fd 2 would mix results into the logs on stderr")
+	case fd < 0:
+		return fmt.Errorf("invalid -results_fd %d", fd)
+	}
+	file := os.NewFile(uintptr(fd), "results")
+	if _, err := file.Stat(); err != nil {
+		return fmt.Errorf("-results_fd %d is not open: %w", fd, err)
+	}
+	resultOutput = file
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestHelperMain runs the CLI when the tests start the test binary as a subprocess
+func TestHelperMain(t *testing.T) {
+	if os.Getenv("CV_HELPER_MAIN") != "1" {
+		t.Skip("only run as a subprocess")
+	}
+	args := os.Args
+	for i, arg := range args {
+		if arg == "--" {
+			args = args[i+1:]
+			break
+		}
+	}
+	os.Args = append([]string{"caption-validator"}, args...)
+	main()
+	os.Exit(0)
+}
+
+// runCLI runs the CLI with args and returns what it wrote to stdout, to stderr and
+// to an extra file inherited as descriptor 3
+func runCLI(t *testing.T, args ...string) (stdout, stderr, fd3 string) {
+	t.Helper()
+	results, err := os.Create(filepath.Join(t.TempDir(), "fd3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer results.Close()
+
+	cmd := exec.Command(os.Args[0], append([]string{"-test.run=^TestHelperMain$", "--"}, args...)...)
+	cmd.Env = append(os.Environ(), "CV_HELPER_MAIN=1")
+	cmd.ExtraFiles = []*os.File{results}
+	var out, errs bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errs
+	cmd.Run() // failing validations exit non-zero
+	written, err := os.ReadFile(results.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out.String(), errs.String(), string(written)
+}
+
+// assertJSONLines fails unless every line of stream is a JSON object
+func assertJSONLines(t *testing.T, name, stream string) {
+	t.Helper()
+	for _, line := range strings.Split(strings.TrimSuffix(stream, "\n"), "\n") {
+		var object map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &object); err != nil {
+			t.Errorf("%s: line %q is not a JSON object", name, line)
+		}
+	}
+}
+
+// timingFields matches a report's timestamps and durations, which differ between runs
+var timingFields = regexp.MustCompile(`,"started_at":"[^"]*","finished_at":"[^"]*","durations":\{[^}]*\}`)
+
+// withoutTiming removes the timing of every report in a stream
+func withoutTiming(stream string) string {
+	return timingFields.ReplaceAllString(stream, "")
+}
+
+func TestOutputStreamPurity(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts the CLI as a subprocess")
+	}
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "es-ES"})
+	}))
+	defer detector.Close()
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.srt"), []byte("1\n00:00:01,000 --> 00:00:03,000\nHola\n\n2\n00:00:04,000 --> 00:00:06,000\nbroken --> line\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "b.srt"), []byte("1\n00:00:01,000 --> 00:00:09,000\nHola mundo\n"), 0o644)
+
+	// Results, failing and passing, are the only output on stdout; logs and stats
+	// go to stderr and are never JSON results
+	args := []string{"-endpoint", detector.URL, "-t_end", "10", "-stats", "-run_id", "purity", dir}
+	stdout, stderr, _ := runCLI(t, args...)
+	if stdout == "" || stderr == "" {
+		t.Fatalf("expected results on stdout and logs on stderr, got %q and %q", stdout, stderr)
+	}
+	assertJSONLines(t, "stdout", stdout)
+	if strings.Contains(stderr, `"file":`) {
+		t.Errorf("results leaked into stderr: %q", stderr)
+	}
+
+	// -results_fd moves every result off stdout
+	stdoutFD, stderrFD, fd3 := runCLI(t, append([]string{"-results_fd", "3"}, args...)...)
+	if stdoutFD != "" {
+		t.Errorf("expected nothing on stdout with -results_fd 3, got %q", stdoutFD)
+	}
+	if withoutTiming(fd3) != withoutTiming(stdout) {
+		t.Errorf("expected the results on descriptor 3, got %q", fd3)
+	}
+	if strings.Contains(stderrFD, `"file":`) {
+		t.Errorf("results leaked into stderr: %q", stderrFD)
+	}
+
+	// Subcommands keep the same contract
+	probeOut, _, _ := runCLI(t, "probe", filepath.Join(dir, "a.srt"))
+	assertJSONLines(t, "probe", probeOut)
+}
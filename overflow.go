@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// frameWidth is the width font sizes are given against: -font_size is in pixels of
+// a 1920x1080 frame
+const frameWidth = 1920.0
+
+// defaultLineMargin is the horizontal safe margin, in percent of the frame, used
+// when -safe_area is not set
+const defaultLineMargin = 10.0
+
+// LineRendering is the font and size lines are measured with for line_overflow
+type LineRendering struct {
+	Font *FontMetrics
+	Size float64 // pixels on a 1920x1080 frame
+}
+
+// LineOverflow is one cue line rendered wider than the safe area
+type LineOverflow struct {
+	Cue   int     `json:"cue"`
+	Line  int     `json:"line"` // 1-based line within the cue
+	Text  string  `json:"text"`
+	Width float64 `json:"width_px"`
+}
+
+// LineOverflowError reports cue lines too wide for the safe area in the chosen font
+type LineOverflowError struct {
+	Type         string         `json:"type"`
+	Rule         string         `json:"rule"`
+	Font         string         `json:"font"`
+	FontSize     float64        `json:"font_size_px"`
+	SafeWidth    float64        `json:"safe_width_px"`
+	Lines        []LineOverflow `json:"lines"`
+	Description  string         `json:"description"`
+	SuggestedFix *SuggestedFix  `json:"suggested_fix,omitempty"`
+}
+
+// cueLayout joins a cue's text lines with "\n", tags stripped, for line_overflow.
+// Nothing is kept unless the check is enabled, so parsing does not pay for it.
+func (cv *CaptionValidator) cueLayout(lines []string) string {
+	if cv.rendering == nil {
+		return ""
+	}
+	stripped := make([]string, len(lines))
+	for i, line := range lines {
+		stripped[i] = stripMarkup(line)
+	}
+	return strings.Join(stripped, "\n")
+}
+
+// safeWidth is the width in pixels between the horizontal safe margins
+func (cv *CaptionValidator) safeWidth() float64 {
+	margin := defaultLineMargin
+	if cv.safeArea.enabled() {
+		margin = cv.safeArea.Horizontal
+	}
+	return frameWidth * (100 - 2*margin) / 100
+}
+
+// validateLineWidths measures every cue line in the configured font and reports
+// those wider than the safe area
+func (cv *CaptionValidator) validateLineWidths(captions []Caption) *LineOverflowError {
+	safeWidth := cv.safeWidth()
+	var overflows []LineOverflow
+	var cues []int
+	for i, caption := range captions {
+		overflowed := false
+		for j, line := range strings.Split(caption.Layout, "\n") {
+			if width := cv.rendering.Font.width(line, cv.rendering.Size); width > safeWidth {
+				overflows = append(overflows, LineOverflow{Cue: i + 1, Line: j + 1, Text: line, Width: math.Round(width*10) / 10})
+				overflowed = true
+			}
+		}
+		if overflowed {
+			cues = append(cues, i+1)
+		}
+	}
+	if len(overflows) == 0 {
+		return nil
+	}
+
+	return &LineOverflowError{
+		Type:        "line_overflow",
+		Font:        cv.rendering.Font.Name,
+		FontSize:    cv.rendering.Size,
+		SafeWidth:   safeWidth,
+		Lines:       overflows,
+		Description: fmt.Sprintf("%d line(s) are wider than the %gpx safe area in %s at %gpx", len(overflows), safeWidth, cv.rendering.Font.Name, cv.rendering.Size),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixRewrapLines,
+			Cues:        cues,
+			Description: fmt.Sprintf("Rewrap or shorten the overflowing lines in %s", cueRange(cues)),
+		},
+	}
+}
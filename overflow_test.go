@@ -0,0 +1,84 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestFontWidth(t *testing.T) {
+	arial, err := lookupFont("Arial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// H 722 + i 222 + space 278 + W 944 + é (accented lowercase) 556 = 2722
+	if got := arial.width("Hi Wé", 100); math.Abs(got-272.2) > 1e-9 {
+		t.Errorf("expected 272.2px, got %g", got)
+	}
+	// Combining marks take no space, CJK is full width
+	if got := arial.width("é漢", 100); math.Abs(got-155.6) > 1e-9 {
+		t.Errorf("expected 155.6px, got %g", got)
+	}
+	courier, _ := lookupFont("courier")
+	if courier.width("WWii", 10) != courier.width("iiWW", 10) || courier.width("W—", 10) != 12 {
+		t.Error("expected monospaced widths")
+	}
+	if _, err := lookupFont("comic sans"); err == nil {
+		t.Error("expected an error for an unknown font")
+	}
+
+	// The cea608 profile's 32 columns fill its safe width exactly
+	cea608 := deliveryProfiles["cea608"]
+	if got := courier.width("12345678901234567890123456789012", cea608.FontSize); got != frameWidth*0.8 {
+		t.Errorf("expected 32 Courier columns to span %gpx, got %g", frameWidth*0.8, got)
+	}
+}
+
+func TestValidateLineWidths(t *testing.T) {
+	arial, _ := lookupFont("arial")
+	cv := NewCaptionValidator("http://test.com")
+	cv.rendering = &LineRendering{Font: arial, Size: 66}
+
+	captions := []Caption{
+		{Text: "This fits comfortably on a single line", Layout: cv.cueLayout([]string{"This fits comfortably on a single line"})},
+		{Text: "short WIDE LETTERS MAKE THIS LINE MUCH TOO WIDE", Layout: cv.cueLayout([]string{"short", "<i>WIDE LETTERS MAKE THIS LINE MUCH TOO WIDE</i>"})},
+	}
+	if captions[1].Layout != "short\nWIDE LETTERS MAKE THIS LINE MUCH TOO WIDE" {
+		t.Fatalf("unexpected layout %q", captions[1].Layout)
+	}
+
+	overflow := cv.validateLineWidths(captions)
+	if overflow == nil {
+		t.Fatal("expected line_overflow")
+	}
+	if overflow.SafeWidth != 1536 || len(overflow.Lines) != 1 {
+		t.Fatalf("unexpected overflow %+v", overflow)
+	}
+	line := overflow.Lines[0]
+	if line.Cue != 2 || line.Line != 2 || line.Text != "WIDE LETTERS MAKE THIS LINE MUCH TOO WIDE" || line.Width <= 1536 {
+		t.Errorf("unexpected overflowing line %+v", line)
+	}
+	if overflow.SuggestedFix.Action != FixRewrapLines || !reflect.DeepEqual(overflow.SuggestedFix.Cues, []int{2}) {
+		t.Errorf("unexpected suggested fix %+v", overflow.SuggestedFix)
+	}
+
+	// A narrower safe area from -safe_area makes the first line overflow too
+	cv.safeArea = SafeArea{Horizontal: 30, Vertical: 10}
+	if overflow := cv.validateLineWidths(captions); overflow == nil || len(overflow.Lines) != 2 {
+		t.Errorf("expected both long lines to overflow a 768px safe width, got %+v", overflow)
+	}
+}
+
+func TestCueLayoutOnlyWhenEnabled(t *testing.T) {
+	cv := NewCaptionValidator("http://test.com")
+	captions, _, err := cv.parseWebVTT("WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nfirst line\nsecond line\n")
+	if err != nil || captions[0].Layout != "" {
+		t.Errorf("expected no layout without line_overflow, got %q, %v", captions[0].Layout, err)
+	}
+	arial, _ := lookupFont("arial")
+	cv.rendering = &LineRendering{Font: arial, Size: 66}
+	captions, _, _ = cv.parseSRT("1\n00:00:01,000 --> 00:00:02,000\n<b>first</b> line\nsecond line\n")
+	if captions[0].Layout != "first line\nsecond line" {
+		t.Errorf("unexpected SRT layout %q", captions[0].Layout)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Parse failure kinds
+const (
+	FailureTimestamp      = "timestamp_range" // timing line present but unusable
+	FailureMissingTiming  = "missing_timing"  // block with no timing line
+	FailureEmptyCue       = "empty_cue"       // SRT cue with timing but no text
+	FailureTruncatedCue   = "truncated_cue"   // file ends part-way through a cue
+	FailureFormatMismatch = "format_mismatch" // cue parsed with the other format's timestamps
+	FailureInvalidData    = "invalid_data"    // SCC byte pair that is not four hex digits
+)
+
+// ParseFailure describes part of a caption file the parser could not turn into a cue
+type ParseFailure struct {
+	Line   int    `json:"line"`
+	Kind   string `json:"kind"`
+	Text   string `json:"text"`
+	Reason string `json:"reason"`
+}
+
+func (f *ParseFailure) Error() string {
+	return fmt.Sprintf("line %d: %s", f.Line, f.Reason)
+}
+
+// structural reports whether the failure means the file itself is damaged, as opposed
+// to a cue with bad timing values (reported separately as timestamp_range) or a
+// hybrid timestamp that was repaired (reported as format_mismatch)
+func (f ParseFailure) structural() bool {
+	return f.Kind != FailureTimestamp && f.Kind != FailureFormatMismatch
+}
+
+// PartialParseError reports a file that was only partly parsed
+type PartialParseError struct {
+	Type         string         `json:"type"`
+	Rule         string         `json:"rule"`
+	ParsedCues   int            `json:"parsed_cues"`
+	Failures     []ParseFailure `json:"failures"`
+	Description  string         `json:"description"`
+	SuggestedFix *SuggestedFix  `json:"suggested_fix,omitempty"`
+}
+
+// newPartialParseError summarizes structural parse failures, or returns nil if there are none
+func newPartialParseError(parsedCues int, failures []ParseFailure) *PartialParseError {
+	var structural []ParseFailure
+	var lines []int
+	for _, failure := range failures {
+		if failure.structural() {
+			structural = append(structural, failure)
+			lines = append(lines, failure.Line)
+		}
+	}
+	if len(structural) == 0 {
+		return nil
+	}
+
+	return &PartialParseError{
+		Type:        "partial_parse",
+		ParsedCues:  parsedCues,
+		Failures:    structural,
+		Description: fmt.Sprintf("File only partly parsed: %d cue(s) read, %d damaged block(s) starting at line %d", parsedCues, len(structural), structural[0].Line),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixRepairBlocks,
+			Lines:       lines,
+			Description: fmt.Sprintf("Repair or re-export the damaged block(s) at line(s) %s", joinInts(lines)),
+		},
+	}
+}
+
+// timestampRangeErrors converts timing failures into timestamp_range errors
+func timestampRangeErrors(failures []ParseFailure) []*TimestampRangeError {
+	var rangeErrs []*TimestampRangeError
+	for _, failure := range failures {
+		if failure.Kind == FailureTimestamp {
+			rangeErrs = append(rangeErrs, newTimestampRangeError(failure.Line, failure.Text, failure.Reason))
+		}
+	}
+	return rangeErrs
+}
+
+// timingFailure classifies an unusable timing line; in an unterminated final block it
+// means the file was cut off mid-cue
+func timingFailure(line int, timing string, err error, truncated bool) *ParseFailure {
+	if truncated {
+		return &ParseFailure{Line: line, Kind: FailureTruncatedCue, Text: timing, Reason: fmt.Sprintf("file ends mid-cue: %v", err)}
+	}
+	return &ParseFailure{Line: line, Kind: FailureTimestamp, Text: timing, Reason: err.Error()}
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FormatMismatchError reports cues whose timestamps use the other format's syntax,
+// e.g. SRT comma timestamps under a WEBVTT header
+type FormatMismatchError struct {
+	Type            string        `json:"type"`
+	Rule            string        `json:"rule"`
+	DeclaredFormat  string        `json:"declared_format"`
+	TimestampFormat string        `json:"timestamp_format"`
+	MismatchedCues  int           `json:"mismatched_cues"`
+	Lines           []int         `json:"lines"`
+	Description     string        `json:"description"`
+	SuggestedFix    *SuggestedFix `json:"suggested_fix,omitempty"`
+}
+
+// newFormatMismatchError summarizes repaired hybrid timestamps, or returns nil if there are none
+func newFormatMismatchError(format string, failures []ParseFailure) *FormatMismatchError {
+	var lines []int
+	for _, failure := range failures {
+		if failure.Kind == FailureFormatMismatch {
+			lines = append(lines, failure.Line)
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	declared, timestamps, other := "WebVTT", "SRT", "srt"
+	if format == "srt" {
+		declared, timestamps, other = "SRT", "WebVTT", "webvtt"
+	}
+	return &FormatMismatchError{
+		Type:            "format_mismatch",
+		DeclaredFormat:  format,
+		TimestampFormat: other,
+		MismatchedCues:  len(lines),
+		Lines:           lines,
+		Description:     fmt.Sprintf("%s file has %s-style timestamps on %d cue(s), first at line %d", declared, timestamps, len(lines), lines[0]),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixConvertTimestamps,
+			Lines:       lines,
+			Description: fmt.Sprintf("Rewrite the timestamps at line(s) %s in %s syntax, or re-export the file", joinInts(lines), declared),
+		},
+	}
+}
+
+// FormatRedetectedInfo records that a file parsed to no cues as the format its header
+// suggests and was read with another parser instead, as happens with mislabeled files
+type FormatRedetectedInfo struct {
+	Type           string        `json:"type"`
+	Rule           string        `json:"rule"`
+	Severity       string        `json:"severity"`
+	DetectedFormat string        `json:"detected_format"`
+	ParsedFormat   string        `json:"parsed_format"`
+	Cues           int           `json:"cues"`
+	Description    string        `json:"description"`
+	SuggestedFix   *SuggestedFix `json:"suggested_fix,omitempty"`
+}
+
+// reparseAlternate tries the other text parsers on a file that gave no cues as format
+// and returns the first that reads cues from it, or nil when none does
+func (cv *CaptionValidator) reparseAlternate(filepath, format string) (*FormatRedetectedInfo, []Caption, []ParseFailure) {
+	for _, alternate := range textFormats {
+		if alternate == format {
+			continue
+		}
+		captions, failures, err := cv.parseFile(filepath, alternate)
+		if err != nil || len(captions) == 0 {
+			continue
+		}
+		return &FormatRedetectedInfo{
+			Type:           "format_redetected",
+			Severity:       SeverityInfo,
+			DetectedFormat: format,
+			ParsedFormat:   alternate,
+			Cues:           len(captions),
+			Description:    fmt.Sprintf("No cues could be read as %s; read %d cue(s) as %s instead", format, len(captions), alternate),
+			SuggestedFix: &SuggestedFix{
+				Action:      FixRelabelFormat,
+				Description: fmt.Sprintf("Check the file's header and re-export it as %s", alternate),
+			},
+		}, captions, failures
+	}
+	return nil, nil, nil
+}
+
+// splitTiming splits a timing line at its one "-->"
+func splitTiming(line string) ([2]string, bool) {
+	start, end, found := strings.Cut(line, "-->")
+	return [2]string{start, end}, found && !strings.Contains(end, "-->")
+}
+
+// parseCueTimes parses the start and end of a split timing line. A time that parse
+// rejects but alternate accepts is kept, and mismatch reports that it happened.
+func parseCueTimes(times [2]string, parse, alternate func(string) (float64, error)) (start, end float64, mismatch bool, err error) {
+	var values [2]float64
+	for i, raw := range times {
+		raw = strings.TrimSpace(raw)
+		value, parseErr := parse(raw)
+		if parseErr != nil {
+			if value, err = alternate(raw); err != nil {
+				return 0, 0, false, parseErr
+			}
+			mismatch = true
+		}
+		values[i] = value
+	}
+	return values[0], values[1], mismatch, nil
+}
+
+// mismatchNote records a cue recovered from a hybrid timestamp
+func mismatchNote(mismatch bool, line int, timing, reason string) *ParseFailure {
+	if !mismatch {
+		return nil
+	}
+	return &ParseFailure{Line: line, Kind: FailureFormatMismatch, Text: timing, Reason: reason}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRecoversFromDamagedBlocks(t *testing.T) {
+	cv := NewCaptionValidator("http://test.com")
+
+	// Missing timing in the middle, cut off part-way through the timing line at the end
+	vtt := "WEBVTT\n\nNOTE skipped\n\n00:00:01.000 --> 00:00:02.000\nFirst\n\nNo timing here\n\n00:00:03.000 --> 00:00:04.000\nSecond\n\n00:00:05.000 --> 00:00:0"
+	captions, failures, err := cv.parseWebVTT(vtt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(captions) != 2 || captions[1].Text != "Second" {
+		t.Errorf("expected 2 recovered captions, got %+v", captions)
+	}
+	if len(failures) != 2 {
+		t.Fatalf("expected 2 failures, got %+v", failures)
+	}
+	if failures[0].Kind != FailureMissingTiming || failures[0].Line != 8 {
+		t.Errorf("expected missing_timing on line 8, got %+v", failures[0])
+	}
+	if failures[1].Kind != FailureTruncatedCue || failures[1].Line != 13 {
+		t.Errorf("expected truncated_cue on line 13, got %+v", failures[1])
+	}
+
+	// Missing index is tolerated; a cue without text is not
+	srt := "00:00:01,000 --> 00:00:02,000\nNo index\n\n2\n00:00:03,000 --> 00:00:04,000\n\n3\n00:00:05,000 --> 00:00:06,000\nLast\n"
+	captions, failures, err = cv.parseSRT(srt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(captions) != 2 || captions[0].Text != "No index" {
+		t.Errorf("expected 2 recovered captions, got %+v", captions)
+	}
+	if len(failures) != 1 || failures[0].Kind != FailureEmptyCue || failures[0].Line != 5 {
+		t.Errorf("expected empty_cue on line 5, got %+v", failures)
+	}
+}
+
+func TestNewPartialParseError(t *testing.T) {
+	if err := newPartialParseError(3, []ParseFailure{{Line: 4, Kind: FailureTimestamp}}); err != nil {
+		t.Errorf("timing failures alone should not make a partial parse, got %+v", err)
+	}
+
+	err := newPartialParseError(3, []ParseFailure{
+		{Line: 4, Kind: FailureTimestamp},
+		{Line: 9, Kind: FailureMissingTiming},
+		{Line: 20, Kind: FailureTruncatedCue},
+	})
+	if err == nil {
+		t.Fatal("expected partial_parse error, got none")
+	}
+	if err.ParsedCues != 3 || len(err.Failures) != 2 {
+		t.Errorf("unexpected partial_parse error: %+v", err)
+	}
+	if err.SuggestedFix.Action != FixRepairBlocks || len(err.SuggestedFix.Lines) != 2 || err.SuggestedFix.Lines[1] != 20 {
+		t.Errorf("unexpected suggested fix: %+v", err.SuggestedFix)
+	}
+}
+
+func TestValidateAllowPartial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "truncated.srt")
+	content := "1\n00:00:00,000 --> 00:00:10,000\nComplete cue\n\n2\n00:00:10,000 --> 00:00:1"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cv := NewCaptionValidator(server.URL)
+	window := Window{Start: 0, End: 10}
+	report, err := cv.Validate(path, window, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Errors) != 1 {
+		t.Fatalf("expected only a partial_parse error, got %+v", report.Errors)
+	}
+	if partial, ok := report.Errors[0].(*PartialParseError); !ok || partial.ParsedCues != 1 {
+		t.Errorf("expected partial_parse error with 1 parsed cue, got %+v", report.Errors[0])
+	}
+
+	cv.allowPartial = true
+	report, err = cv.Validate(path, window, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Errors) != 0 {
+		t.Errorf("expected partial file to pass with allow_partial, got %+v", report.Errors)
+	}
+	if len(report.ParseFailures) != 1 || report.ParseFailures[0].Kind != FailureTruncatedCue {
+		t.Errorf("expected truncated_cue failure in report, got %+v", report.ParseFailures)
+	}
+}
+
+func TestParseRepairsHybridTimestamps(t *testing.T) {
+	cv := NewCaptionValidator("http://test.com")
+
+	vtt := "WEBVTT\n\n00:00:01,000 --> 00:00:02,000\nComma\n\n00:00:03.000 --> 00:00:04,500\nMixed\n\n00:00:05.000 --> 00:00:06.000\nFine\n"
+	captions, failures, err := cv.parseWebVTT(vtt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(captions) != 3 || captions[1].EndTime != 4.5 {
+		t.Errorf("expected all 3 cues recovered, got %+v", captions)
+	}
+	mismatch := newFormatMismatchError("webvtt", failures)
+	if mismatch == nil {
+		t.Fatal("expected format_mismatch error, got none")
+	}
+	if mismatch.TimestampFormat != "srt" || mismatch.MismatchedCues != 2 || mismatch.Lines[0] != 3 || mismatch.Lines[1] != 6 {
+		t.Errorf("unexpected format_mismatch error: %+v", mismatch)
+	}
+	if newPartialParseError(len(captions), failures) != nil {
+		t.Error("repaired hybrids should not count as a partial parse")
+	}
+
+	srt := "1\n00:00:01.000 --> 00:00:02.000\nDots\n"
+	captions, failures, err = cv.parseSRT(srt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(captions) != 1 || len(failures) != 1 || failures[0].Kind != FailureFormatMismatch {
+		t.Errorf("expected repaired SRT cue with a format_mismatch note, got %+v / %+v", captions, failures)
+	}
+}
+
+func TestValidateRedetectsMislabeledFormat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	defer server.Close()
+
+	// The header runs into the only cue, so WebVTT reads it as a header block
+	path := filepath.Join(t.TempDir(), "glued.vtt")
+	if err := os.WriteFile(path, []byte("WEBVTT\n00:00:00,000 --> 00:00:10,000\nThe only cue\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cv := NewCaptionValidator(server.URL)
+	report, err := cv.Validate(path, Window{Start: 0, End: 10}, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Format != "srt" || len(report.Errors) != 1 {
+		t.Fatalf("expected the file read as srt with one info, got %s %+v", report.Format, report.Errors)
+	}
+	info, ok := report.Errors[0].(*FormatRedetectedInfo)
+	if !ok || info.DetectedFormat != "webvtt" || info.ParsedFormat != "srt" || info.Cues != 1 || info.Severity != SeverityInfo {
+		t.Errorf("unexpected format_redetected info: %+v", report.Errors[0])
+	}
+	if summary := summarize(path, report, nil, 0); summary.Result != SummaryPass {
+		t.Errorf("expected an info to leave the file passing, got %s", summary.Result)
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// normalizePath cleans a path and upper-cases its drive letter, so "c:/Vendor/ep1.srt"
+// and `C:\Vendor\ep1.srt` are the same key in manifests, baselines and batch inputs.
+// Off Windows it only cleans the path.
+func normalizePath(path string) string {
+	path = filepath.Clean(path)
+	if volume := filepath.VolumeName(path); len(volume) == 2 && volume[1] == ':' {
+		path = strings.ToUpper(volume) + path[2:]
+	}
+	return path
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+
+package main
+
+// longPath returns path unchanged; only Windows limits path length this way
+func longPath(path string) string {
+	return path
+}
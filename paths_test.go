@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNormalizePathCleans(t *testing.T) {
+	if got := normalizePath(filepath.Join("vendor", ".", "season1", "..", "ep1.srt")); got != filepath.Join("vendor", "ep1.srt") {
+		t.Errorf("unexpected normalized path %q", got)
+	}
+}
+
+func TestResumeMatchesEquivalentPaths(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "manifest.jsonl")
+	unclean := dir + string(filepath.Separator) + "." + string(filepath.Separator) + "ep1.srt"
+	os.WriteFile(manifest, []byte(`{"file": `+strconv.Quote(unclean)+`, "status": "passed"}`+"\n"), 0644)
+
+	entries, err := loadManifest(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := entries[normalizePath(filepath.Join(dir, "ep1.srt"))]; !ok {
+		t.Errorf("expected the entry under its normalized path, got %v", entries)
+	}
+}
+
+func TestLongPathValidates(t *testing.T) {
+	// Deep vendor folder trees exceed the 260 character Windows limit
+	dir := t.TempDir()
+	for len(dir) < 300 {
+		dir = filepath.Join(dir, strings.Repeat("vendor", 5))
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Skip("cannot create long paths here:", err)
+	}
+	path := filepath.Join(dir, "ep1.srt")
+	if err := os.WriteFile(longPath(path), []byte("1\n00:00:01,000 --> 00:00:02,000\nHello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cv := NewCaptionValidator("http://localhost:0")
+	format, err := cv.detectFormat(path)
+	if err != nil || format != "srt" {
+		t.Fatalf("expected an SRT file, got %q, %v", format, err)
+	}
+	captions, _, err := cv.parseFile(path, format)
+	if err != nil || len(captions) != 1 {
+		t.Errorf("expected one cue, got %v, %v", captions, err)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// maxShortPath is the longest path, directories included, Windows APIs accept
+// without the extended-length prefix
+const maxShortPath = 247
+
+// longPath returns the extended-length form of a path that is too long for the
+// Windows MAX_PATH limit: `\\?\C:\...` for drive paths and `\\?\UNC\server\share\...`
+// for UNC paths. Relative paths are made absolute first, since the prefix turns off
+// the usual path parsing. Short paths are returned unchanged.
+func longPath(path string) string {
+	if strings.HasPrefix(path, `\\?\`) || strings.HasPrefix(path, `\\.\`) {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || len(abs) <= maxShortPath {
+		return path
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
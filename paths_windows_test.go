@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizePathDriveLetters(t *testing.T) {
+	cases := map[string]string{
+		`c:/Vendor/ep1.srt`:         `C:\Vendor\ep1.srt`,
+		`C:\Vendor\.\ep1.srt`:       `C:\Vendor\ep1.srt`,
+		`\\qc-nas\captions\ep1.srt`: `\\qc-nas\captions\ep1.srt`,
+		`//qc-nas/captions/ep1.srt`: `\\qc-nas\captions\ep1.srt`,
+	}
+	for path, expected := range cases {
+		if got := normalizePath(path); got != expected {
+			t.Errorf("normalizePath(%q) = %q, want %q", path, got, expected)
+		}
+	}
+}
+
+func TestLongPathPrefixes(t *testing.T) {
+	deep := strings.Repeat(`\vendor-folder`, 20) + `\ep1.srt`
+	if got := longPath(`C:` + deep); got != `\\?\C:`+deep {
+		t.Errorf("unexpected drive long path %q", got)
+	}
+	if got := longPath(`\\qc-nas\captions` + deep); got != `\\?\UNC\qc-nas\captions`+deep {
+		t.Errorf("unexpected UNC long path %q", got)
+	}
+	if got := longPath(`C:\short\ep1.srt`); got != `C:\short\ep1.srt` {
+		t.Errorf("expected a short path unchanged, got %q", got)
+	}
+	if got := longPath(`\\?\C:` + deep); got != `\\?\C:`+deep {
+		t.Errorf("expected a prefixed path unchanged, got %q", got)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PercentileTarget bounds a percentile of a cue statistic, e.g. p95 cps <= 20. Noisy
+// auto-captions break absolute per-cue limits on a few cues; a percentile says how
+// much of the file has to stay within them.
+type PercentileTarget struct {
+	Statistic  string  `json:"statistic"`  // one of cueStatistics
+	Percentile float64 `json:"percentile"` // 50 for the median
+	Operator   string  `json:"operator"`   // <= or >=
+	Target     float64 `json:"target"`
+}
+
+// PercentileResult is a missed target and the value the file reached
+type PercentileResult struct {
+	PercentileTarget
+	Actual float64 `json:"actual"`
+}
+
+// PercentileTargetError reports cue statistics whose percentiles miss their targets
+type PercentileTargetError struct {
+	Type         string             `json:"type"`
+	Rule         string             `json:"rule"`
+	TotalCues    int                `json:"total_cues"`
+	Missed       []PercentileResult `json:"missed_targets"`
+	Description  string             `json:"description"`
+	SuggestedFix *SuggestedFix      `json:"suggested_fix,omitempty"`
+}
+
+// cueValue is one cue's value of a statistic, with its 1-based cue number
+type cueValue struct {
+	cue   int
+	value float64
+}
+
+// cueStatistics compute a statistic for every cue it applies to
+var cueStatistics = map[string]func(captions []Caption) []cueValue{
+	// Reading speed in characters per second; cues without a duration are left out
+	"cps": func(captions []Caption) []cueValue {
+		var values []cueValue
+		for i, caption := range captions {
+			if caption.EndTime > caption.StartTime {
+				values = append(values, cueValue{i + 1, cueCPS(caption)})
+			}
+		}
+		return values
+	},
+	// Seconds on screen
+	"duration": func(captions []Caption) []cueValue {
+		values := make([]cueValue, len(captions))
+		for i, caption := range captions {
+			values[i] = cueValue{i + 1, caption.EndTime - caption.StartTime}
+		}
+		return values
+	},
+	// Characters, fullwidth ones counting as two
+	"chars": func(captions []Caption) []cueValue {
+		values := make([]cueValue, len(captions))
+		for i, caption := range captions {
+			values[i] = cueValue{i + 1, float64(textWidth(caption.Text))}
+		}
+		return values
+	},
+	// Seconds since the previous cue ended, 0 for overlapping cues
+	"gap": func(captions []Caption) []cueValue {
+		var values []cueValue
+		for i := 1; i < len(captions); i++ {
+			values = append(values, cueValue{i + 1, max(captions[i].StartTime-captions[i-1].EndTime, 0)})
+		}
+		return values
+	},
+}
+
+var percentileTargetPattern = regexp.MustCompile(`^(?:p(\d+(?:\.\d+)?)|(median))\s+([a-z]+)\s*(<=|>=)\s*(\d+(?:\.\d+)?)$`)
+
+// ParsePercentileTarget parses a target such as "p95 cps <= 20" or "median duration >= 1.5"
+func ParsePercentileTarget(s string) (PercentileTarget, error) {
+	m := percentileTargetPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if m == nil {
+		return PercentileTarget{}, fmt.Errorf("invalid percentile target %q: expected e.g. \"p95 cps <= 20\" or \"median duration >= 1.5\"", s)
+	}
+	percentile := 50.0
+	if m[2] == "" {
+		percentile, _ = strconv.ParseFloat(m[1], 64)
+	}
+	if percentile <= 0 || percentile > 100 {
+		return PercentileTarget{}, fmt.Errorf("invalid percentile target %q: the percentile must be between 0 and 100", s)
+	}
+	if _, ok := cueStatistics[m[3]]; !ok {
+		return PercentileTarget{}, fmt.Errorf("invalid percentile target %q: unknown statistic %s (use %s)", s, m[3], strings.Join(slices.Sorted(maps.Keys(cueStatistics)), ", "))
+	}
+	target, _ := strconv.ParseFloat(m[5], 64)
+	return PercentileTarget{Statistic: m[3], Percentile: percentile, Operator: m[4], Target: target}, nil
+}
+
+// String formats the target the way ParsePercentileTarget reads it
+func (t PercentileTarget) String() string {
+	return fmt.Sprintf("p%g %s %s %g", t.Percentile, t.Statistic, t.Operator, t.Target)
+}
+
+// met reports whether value is on the target's side of it
+func (t PercentileTarget) met(value float64) bool {
+	if t.Operator == ">=" {
+		return value >= t.Target
+	}
+	return value <= t.Target
+}
+
+// mergePercentileTargets returns base with overrides added; an override replaces the
+// base target for the same statistic and percentile
+func mergePercentileTargets(base, overrides []PercentileTarget) []PercentileTarget {
+	merged := slices.DeleteFunc(slices.Clone(base), func(target PercentileTarget) bool {
+		return slices.ContainsFunc(overrides, func(override PercentileTarget) bool {
+			return override.Statistic == target.Statistic && override.Percentile == target.Percentile
+		})
+	})
+	return append(merged, overrides...)
+}
+
+// nearestRank returns the p-th percentile of sorted values by the nearest-rank method
+func nearestRank(sorted []float64, p float64) float64 {
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[max(i, 0)]
+}
+
+// validatePercentiles evaluates the percentile targets over the file's cues. The
+// fix lists the cues on the wrong side of each missed target.
+func (cv *CaptionValidator) validatePercentiles(captions []Caption, targets []PercentileTarget) *PercentileTargetError {
+	var missed []PercentileResult
+	var details []string
+	flagged := map[int]bool{}
+	for _, target := range targets {
+		values := cueStatistics[target.Statistic](captions)
+		if len(values) == 0 {
+			continue
+		}
+		sorted := make([]float64, len(values))
+		for i, v := range values {
+			sorted[i] = v.value
+		}
+		slices.Sort(sorted)
+		actual := math.Round(nearestRank(sorted, target.Percentile)*100) / 100
+		if target.met(actual) {
+			continue
+		}
+		missed = append(missed, PercentileResult{PercentileTarget: target, Actual: actual})
+		details = append(details, fmt.Sprintf("p%g %s is %g (target %s %g)", target.Percentile, target.Statistic, actual, target.Operator, target.Target))
+		for _, v := range values {
+			if !target.met(v.value) {
+				flagged[v.cue] = true
+			}
+		}
+	}
+	if len(missed) == 0 {
+		return nil
+	}
+	cues := slices.Sorted(maps.Keys(flagged))
+
+	return &PercentileTargetError{
+		Type:        "percentile_target",
+		TotalCues:   len(captions),
+		Missed:      missed,
+		Description: "Percentile targets missed: " + strings.Join(details, ", "),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixRetimeCues,
+			Cues:        cues,
+			Description: fmt.Sprintf("Retime or re-segment %s so the file meets its percentile targets", cueRange(cues)),
+		},
+	}
+}
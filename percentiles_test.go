@@ -0,0 +1,65 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParsePercentileTarget(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected PercentileTarget
+	}{
+		{"p95 cps <= 20", PercentileTarget{"cps", 95, "<=", 20}},
+		{"median duration >= 1.5", PercentileTarget{"duration", 50, ">=", 1.5}},
+		{"P99.5 chars<=84", PercentileTarget{"chars", 99.5, "<=", 84}},
+	}
+	for _, tt := range tests {
+		target, err := ParsePercentileTarget(tt.input)
+		if err != nil || target != tt.expected {
+			t.Errorf("%q: expected %+v, got %+v (%v)", tt.input, tt.expected, target, err)
+		}
+	}
+	for _, input := range []string{"p95 cps < 20", "p0 cps <= 20", "p101 cps <= 20", "p95 wpm <= 200", "cps <= 20"} {
+		if _, err := ParsePercentileTarget(input); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
+
+func TestMergePercentileTargets(t *testing.T) {
+	profile := deliveryProfiles["netflix"].Percentiles
+	merged := mergePercentileTargets(profile, []PercentileTarget{{"cps", 95, "<=", 17}, {"gap", 10, ">=", 0.08}})
+	expected := []PercentileTarget{{"duration", 50, ">=", 1.5}, {"cps", 95, "<=", 17}, {"gap", 10, ">=", 0.08}}
+	if !slices.Equal(merged, expected) {
+		t.Errorf("expected %+v, got %+v", expected, merged)
+	}
+	if profile[0].Target != 20 {
+		t.Error("expected the profile's targets to be left alone")
+	}
+}
+
+func TestValidatePercentiles(t *testing.T) {
+	// Twenty 2s cues of 20 characters (10 cps) with one 0.5s cue reading at 40 cps
+	var captions []Caption
+	for i := range 20 {
+		captions = append(captions, Caption{StartTime: float64(i * 3), EndTime: float64(i*3 + 2), Text: "Twenty characters ok"})
+	}
+	captions[7].EndTime = captions[7].StartTime + 0.5
+
+	cv := NewCaptionValidator("")
+	if issue := cv.validatePercentiles(captions, deliveryProfiles["netflix"].Percentiles); issue != nil {
+		t.Errorf("expected one fast cue to stay within p95 cps <= 20, got %+v", issue)
+	}
+
+	issue := cv.validatePercentiles(captions, []PercentileTarget{{"cps", 95, "<=", 20}, {"cps", 100, "<=", 20}, {"duration", 50, ">=", 2.5}})
+	if issue == nil {
+		t.Fatal("expected missed targets")
+	}
+	if len(issue.Missed) != 2 || issue.Missed[0].Percentile != 100 || issue.Missed[0].Actual != 40 || issue.Missed[1].Actual != 2 {
+		t.Errorf("unexpected missed targets %+v", issue.Missed)
+	}
+	if len(issue.SuggestedFix.Cues) != 20 || issue.SuggestedFix.Action != FixRetimeCues {
+		t.Errorf("expected every cue short of 2.5s flagged, got %v", issue.SuggestedFix.Cues)
+	}
+}
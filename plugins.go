@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// pluginTimeout bounds how long a single plugin may run per file
+const pluginTimeout = 30 * time.Second
+
+// PluginError reports a plugin that failed to run or returned unusable output
+type PluginError struct {
+	Type         string        `json:"type"`
+	Rule         string        `json:"rule"`
+	Plugin       string        `json:"plugin"`
+	Description  string        `json:"description"`
+	SuggestedFix *SuggestedFix `json:"suggested_fix,omitempty"`
+}
+
+// PluginInput is the JSON document written to each plugin's stdin
+type PluginInput struct {
+	File   string `json:"file"`
+	Format string `json:"format"`
+	Window struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+	} `json:"window"`
+	Cues []Caption `json:"cues"`
+}
+
+// discoverPlugins lists executables in dir in name order
+func discoverPlugins(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugins directory: %w", err)
+	}
+
+	var plugins []string
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if isExecutable(entry.Name(), info.Mode()) {
+			plugins = append(plugins, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(plugins)
+	return plugins, nil
+}
+
+// isExecutable checks the exec bit, or the extension on Windows
+func isExecutable(name string, mode os.FileMode) bool {
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(name))
+		return ext == ".exe" || ext == ".bat" || ext == ".cmd"
+	}
+	return mode&0111 != 0
+}
+
+// runPlugins feeds the cue list to every plugin and merges the errors they report.
+// Plugin failures are reported as plugin_error entries rather than aborting validation.
+// Plugins still running when ctx is done are killed.
+func (cv *CaptionValidator) runPlugins(ctx context.Context, file, format string, window Window, captions []Caption) []interface{} {
+	input := PluginInput{File: file, Format: format, Cues: captions}
+	input.Window.Start, input.Window.End = window.Start, window.End
+	if input.Cues == nil {
+		input.Cues = []Caption{}
+	}
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return nil
+	}
+
+	var issues []interface{}
+	for _, plugin := range cv.plugins {
+		name := filepath.Base(plugin)
+		results, err := runPlugin(ctx, plugin, payload)
+		if err != nil {
+			issues = append(issues, &PluginError{
+				Type:        "plugin_error",
+				Plugin:      name,
+				Description: fmt.Sprintf("Plugin %s failed: %v", name, err),
+				SuggestedFix: &SuggestedFix{
+					Action:      FixCheckPlugin,
+					Description: fmt.Sprintf("Run plugin %s by hand to see why it fails", name),
+				},
+			})
+			continue
+		}
+		for _, result := range results {
+			result["plugin"] = name
+			issues = append(issues, result)
+		}
+	}
+	return issues
+}
+
+// runPlugin executes one plugin and parses its stdout as JSON objects, one per line
+func runPlugin(ctx context.Context, plugin string, payload []byte) ([]map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, pluginTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, plugin)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			return nil, fmt.Errorf("invalid JSON output: %w", err)
+		}
+		if _, ok := result["type"].(string); !ok {
+			return nil, fmt.Errorf("output is missing a string \"type\" field: %s", line)
+		}
+		results = append(results, result)
+	}
+	return results, scanner.Err()
+}
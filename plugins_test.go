@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestRunPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts use sh")
+	}
+
+	dir := t.TempDir()
+	scripts := map[string]string{
+		// Echoes back the cue count it received so the stdin contract is exercised
+		"10-cue-count": "#!/bin/sh\ncount=$(grep -o '\"text\"' | wc -l | tr -d ' ')\necho \"{\\\"type\\\": \\\"custom_check\\\", \\\"cues\\\": $count, \\\"description\\\": \\\"checked\\\"}\"\n",
+		"20-broken":    "#!/bin/sh\necho 'not json'\n",
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not a plugin"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plugins, err := discoverPlugins(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plugins) != 2 {
+		t.Fatalf("expected 2 plugins, got %v", plugins)
+	}
+
+	cv := NewCaptionValidator("http://test.com")
+	cv.plugins = plugins
+	captions := []Caption{
+		{StartTime: 1.0, EndTime: 3.0, Text: "Hello"},
+		{StartTime: 5.0, EndTime: 7.0, Text: "World"},
+	}
+
+	issues := cv.runPlugins(context.Background(), "test.vtt", "webvtt", Window{Start: 0, End: 10}, captions)
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %d: %v", len(issues), issues)
+	}
+
+	custom, ok := issues[0].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected plugin result map, got %T", issues[0])
+	}
+	if custom["type"] != "custom_check" || custom["plugin"] != "10-cue-count" || custom["cues"] != 2.0 {
+		t.Errorf("unexpected plugin result: %v", custom)
+	}
+
+	broken, ok := issues[1].(*PluginError)
+	if !ok || broken.Type != "plugin_error" || broken.Plugin != "20-broken" {
+		t.Errorf("expected plugin_error for broken plugin, got %v", issues[1])
+	}
+}
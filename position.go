@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// cueLineHeight is the height of one caption row as a percentage of the frame:
+// the 15 CEA-608 rows spread over the 80% title-safe height
+const cueLineHeight = 80.0 / 15
+
+// SafeArea is the title-safe margin, in percent of the frame, kept clear on the
+// left and right (Horizontal) and top and bottom (Vertical) edges. It is also a
+// flag value written as "10" for both or "10,5" for horizontal,vertical.
+type SafeArea struct {
+	Horizontal float64 `json:"horizontal_margin"`
+	Vertical   float64 `json:"vertical_margin"`
+}
+
+// enabled reports whether positions are checked
+func (sa SafeArea) enabled() bool {
+	return sa.Horizontal > 0 || sa.Vertical > 0
+}
+
+func (sa *SafeArea) String() string {
+	if sa.Horizontal == sa.Vertical {
+		return strconv.FormatFloat(sa.Horizontal, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(sa.Horizontal, 'f', -1, 64) + "," + strconv.FormatFloat(sa.Vertical, 'f', -1, 64)
+}
+
+func (sa *SafeArea) Set(value string) error {
+	horizontal, vertical, split := strings.Cut(value, ",")
+	if !split {
+		vertical = horizontal
+	}
+	var margins [2]float64
+	for i, raw := range []string{horizontal, vertical} {
+		margin, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(raw), "%"), 64)
+		if err != nil || margin < 0 || margin >= 50 {
+			return fmt.Errorf("invalid safe area %q: expected margins from 0 to 50 percent, e.g. 10 or 10,5", value)
+		}
+		margins[i] = margin
+	}
+	sa.Horizontal, sa.Vertical = margins[0], margins[1]
+	return nil
+}
+
+// UnsafePosition is one cue whose explicit position puts it outside the safe area
+type UnsafePosition struct {
+	Cue       int      `json:"cue"`
+	Settings  string   `json:"settings"`
+	Edges     []string `json:"edges"`     // "top", "bottom", "left" or "right"
+	Overshoot float64  `json:"overshoot"` // percentage points past the margin, on the worst edge
+}
+
+// UnsafePositionError reports positioned cues that leave the title-safe area
+type UnsafePositionError struct {
+	Type         string           `json:"type"`
+	Rule         string           `json:"rule"`
+	SafeArea     SafeArea         `json:"safe_area"`
+	Positions    []UnsafePosition `json:"positions"`
+	Description  string           `json:"description"`
+	SuggestedFix *SuggestedFix    `json:"suggested_fix,omitempty"`
+}
+
+// cueSettings are the WebVTT cue settings that place a cue
+type cueSettings struct {
+	line, position, size          string
+	lineAlign, positionAlign      string
+	align, vertical               string
+	hasLine, hasPosition, hasSize bool
+}
+
+// parseCueSettings reads a WebVTT settings list such as "line:90% position:10%,line-left".
+// Unknown settings are ignored, as renderers do.
+func parseCueSettings(settings string) cueSettings {
+	var cs cueSettings
+	for _, setting := range strings.Fields(settings) {
+		name, value, ok := strings.Cut(setting, ":")
+		if !ok {
+			continue
+		}
+		switch name {
+		case "line":
+			cs.line, cs.lineAlign, _ = strings.Cut(value, ",")
+			cs.hasLine = true
+		case "position":
+			cs.position, cs.positionAlign, _ = strings.Cut(value, ",")
+			cs.hasPosition = true
+		case "size":
+			cs.size, cs.hasSize = value, true
+		case "align":
+			cs.align = value
+		case "vertical":
+			cs.vertical = value
+		}
+	}
+	return cs
+}
+
+// cueSettingsText returns the settings after the end time of a WebVTT timing line
+func cueSettingsText(end string) string {
+	end = strings.TrimSpace(end)
+	space := strings.IndexAny(end, " \t")
+	if space < 0 {
+		return ""
+	}
+	return strings.Join(strings.Fields(end[space:]), " ")
+}
+
+// percentSetting parses a value such as "90%"
+func percentSetting(value string) (float64, bool) {
+	raw, ok := strings.CutSuffix(value, "%")
+	if !ok {
+		return 0, false
+	}
+	percent, err := strconv.ParseFloat(raw, 64)
+	return percent, err == nil && percent >= 0 && percent <= 100
+}
+
+// lineExtent returns the top and bottom of a cue box placed by its line setting.
+// Line numbers count rows from the top, or from the bottom when negative.
+func (cs cueSettings) lineExtent(lines int) (top, bottom float64, ok bool) {
+	height := float64(max(lines, 1)) * cueLineHeight
+	if percent, isPercent := percentSetting(cs.line); isPercent {
+		switch cs.lineAlign {
+		case "center":
+			return percent - height/2, percent + height/2, true
+		case "end":
+			return percent - height, percent, true
+		}
+		return percent, percent + height, true
+	}
+	row, err := strconv.Atoi(cs.line)
+	if err != nil {
+		return 0, 0, false
+	}
+	if row >= 0 {
+		top = float64(row) * cueLineHeight
+		return top, top + height, true
+	}
+	bottom = 100 + float64(row+1)*cueLineHeight
+	return bottom - height, bottom, true
+}
+
+// positionExtent returns the left and right of a cue box placed by its position and
+// size settings. Without an explicit size only the anchor point is known, so both
+// edges are the anchor.
+func (cs cueSettings) positionExtent() (left, right float64, ok bool) {
+	anchor, alignment := 50.0, cs.positionAlign
+	if alignment == "" || alignment == "auto" {
+		switch cs.align {
+		case "left", "start":
+			anchor, alignment = 0, "line-left"
+		case "right", "end":
+			anchor, alignment = 100, "line-right"
+		default:
+			alignment = "center"
+		}
+	}
+	if cs.hasPosition && cs.position != "auto" {
+		if anchor, ok = percentSetting(cs.position); !ok {
+			return 0, 0, false
+		}
+	}
+	width := 0.0
+	if cs.hasSize {
+		if width, ok = percentSetting(cs.size); !ok {
+			return 0, 0, false
+		}
+	}
+	switch alignment {
+	case "line-left":
+		return anchor, anchor + width, true
+	case "line-right":
+		return anchor - width, anchor, true
+	}
+	return anchor - width/2, anchor + width/2, true
+}
+
+// cueCharWidth is the approximate width of one character as a percentage of a 16:9
+// frame, half a row high
+const cueCharWidth = cueLineHeight / 2 * 9 / 16
+
+// cueBox estimates where a cue is rendered, in percent of the frame. Cues without a
+// line setting sit on the bottom row, and without a size the width is estimated
+// from the text.
+func cueBox(caption Caption) (top, bottom, left, right float64) {
+	cs := parseCueSettings(caption.Settings)
+	var ok bool
+	if !cs.hasLine {
+		cs.line = "-1"
+	}
+	if top, bottom, ok = cs.lineExtent(caption.Lines); !ok {
+		top, bottom, _ = cueSettings{line: "-1"}.lineExtent(caption.Lines)
+	}
+
+	if !cs.hasSize {
+		lineChars := textWidth(caption.Text) / max(caption.Lines, 1)
+		cs.size, cs.hasSize = strconv.FormatFloat(min(float64(lineChars)*cueCharWidth, 100), 'f', 2, 64)+"%", true
+	}
+	if left, right, ok = cs.positionExtent(); !ok {
+		left, right, _ = cueSettings{size: cs.size, hasSize: true}.positionExtent()
+	}
+	return top, bottom, left, right
+}
+
+// unsafeEdges lists the edges of a positioned cue that cross the safe area and the
+// largest overshoot. Cues without position settings are placed by the player and
+// are not checked; neither are vertical cues.
+func (sa SafeArea) unsafeEdges(caption Caption) ([]string, float64) {
+	cs := parseCueSettings(caption.Settings)
+	if cs.vertical != "" {
+		return nil, 0
+	}
+
+	var edges []string
+	var overshoot float64
+	check := func(edge string, past float64) {
+		if past > 1e-9 {
+			edges = append(edges, edge)
+			overshoot = max(overshoot, past)
+		}
+	}
+	if cs.hasLine {
+		if top, bottom, ok := cs.lineExtent(caption.Lines); ok {
+			check("top", sa.Vertical-top)
+			check("bottom", bottom-(100-sa.Vertical))
+		}
+	}
+	if cs.hasPosition || cs.hasSize {
+		if left, right, ok := cs.positionExtent(); ok {
+			check("left", sa.Horizontal-left)
+			check("right", right-(100-sa.Horizontal))
+		}
+	}
+	return edges, math.Round(overshoot*100) / 100
+}
+
+// validatePositions checks every cue with WebVTT position settings against the
+// title-safe area
+func (cv *CaptionValidator) validatePositions(captions []Caption) *UnsafePositionError {
+	var positions []UnsafePosition
+	var cues []int
+	for i, caption := range captions {
+		if caption.Settings == "" {
+			continue
+		}
+		if edges, overshoot := cv.safeArea.unsafeEdges(caption); len(edges) > 0 {
+			positions = append(positions, UnsafePosition{Cue: i + 1, Settings: caption.Settings, Edges: edges, Overshoot: overshoot})
+			cues = append(cues, i+1)
+		}
+	}
+	if len(positions) == 0 {
+		return nil
+	}
+
+	return &UnsafePositionError{
+		Type:        "unsafe_position",
+		SafeArea:    cv.safeArea,
+		Positions:   positions,
+		Description: fmt.Sprintf("%d positioned cue(s) extend outside the title-safe area (%g%% horizontal, %g%% vertical margins)", len(cues), cv.safeArea.Horizontal, cv.safeArea.Vertical),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixRepositionCues,
+			Cues:        cues,
+			Description: fmt.Sprintf("Move %s inside the title-safe area, or drop the line and position settings", cueRange(cues)),
+		},
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSafeAreaUnsafeEdges(t *testing.T) {
+	area := SafeArea{Horizontal: 10, Vertical: 10}
+	cases := []struct {
+		settings string
+		lines    int
+		edges    []string
+	}{
+		{"", 1, nil},
+		{"line:-1", 1, []string{"bottom"}},
+		{"line:-3", 2, nil},
+		{"line:0", 1, []string{"top"}},
+		{"line:85%", 1, []string{"bottom"}},
+		{"line:90%,end", 1, nil},
+		{"line:50%,center position:50% size:80%", 2, nil},
+		{"position:5%,line-left", 1, []string{"left"}},
+		{"position:80%,line-left size:20%", 1, []string{"right"}},
+		{"align:start size:50%", 1, []string{"left"}},
+		{"line:95% position:98%", 1, []string{"bottom", "right"}},
+		{"vertical:rl line:0", 1, nil},
+		{"line:auto", 1, nil},
+	}
+	for _, c := range cases {
+		edges, _ := area.unsafeEdges(Caption{Settings: c.settings, Lines: c.lines})
+		if !reflect.DeepEqual(edges, c.edges) {
+			t.Errorf("%q (%d line(s)): expected edges %v, got %v", c.settings, c.lines, c.edges, edges)
+		}
+	}
+}
+
+func TestSafeAreaFlag(t *testing.T) {
+	var area SafeArea
+	if err := area.Set("10,5%"); err != nil || area != (SafeArea{Horizontal: 10, Vertical: 5}) {
+		t.Errorf("expected 10,5 margins, got %+v, %v", area, err)
+	}
+	if err := area.Set("7.5"); err != nil || area != (SafeArea{Horizontal: 7.5, Vertical: 7.5}) || area.String() != "7.5" {
+		t.Errorf("expected 7.5 on both axes, got %+v, %v", area, err)
+	}
+	for _, invalid := range []string{"", "50", "-1", "10,x"} {
+		if err := area.Set(invalid); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestValidatePositions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "positioned.vtt")
+	content := "WEBVTT\n\n00:00:01.000 --> 00:00:02.000 line:-1 align:center\nAt the very bottom\n\n" +
+		"00:00:02.000 --> 00:00:03.000\nPlaced by the player\n\n" +
+		"00:00:03.000 --> 00:00:04.000 line:10% position:50%\nTwo lines\nnear the top\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cv := NewCaptionValidator("")
+	captions, _, err := cv.parseFile(path, "webvtt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if captions[0].Settings != "line:-1 align:center" || captions[2].Lines != 2 {
+		t.Fatalf("expected cue settings and line counts to be kept, got %+v", captions)
+	}
+
+	cv.safeArea = SafeArea{Horizontal: 10, Vertical: 10}
+	positionErr := cv.validatePositions(captions)
+	if positionErr == nil {
+		t.Fatal("expected an unsafe_position error")
+	}
+	expected := []UnsafePosition{{Cue: 1, Settings: "line:-1 align:center", Edges: []string{"bottom"}, Overshoot: 10}}
+	if !reflect.DeepEqual(positionErr.Positions, expected) {
+		t.Errorf("expected %+v, got %+v", expected, positionErr.Positions)
+	}
+	if positionErr.SuggestedFix.Action != FixRepositionCues || !reflect.DeepEqual(positionErr.SuggestedFix.Cues, []int{1}) {
+		t.Errorf("expected reposition_cues for cue 1, got %+v", positionErr.SuggestedFix)
+	}
+}
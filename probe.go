@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"unicode/utf8"
+)
+
+// ProbeResult describes what the tool detects about a caption file, without validating it
+type ProbeResult struct {
+	File       string     `json:"file"`
+	Format     string     `json:"format"`
+	Encoding   string     `json:"encoding"`
+	CueCount   int        `json:"cue_count"`
+	FirstStart float64    `json:"first_cue_start"`
+	LastEnd    float64    `json:"last_cue_end"`
+	HasStyling bool       `json:"has_styling"`
+	HasRegions bool       `json:"has_regions"`
+	Cues       []ProbeCue `json:"cues,omitempty"` // with -cues
+}
+
+// ProbeCue is one cue's timing and where it sits in the file, as listed by probe -cues
+type ProbeCue struct {
+	CueSpan
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+}
+
+var (
+	// Inline markup tags (<b>, <i>, <u>, <c.class>, <font ...>) and ASS override blocks ({\i1})
+	stylingTagPattern = regexp.MustCompile(`</?(b|i|u|c|font)[\s.>]|\{\\[^}]*\}`)
+	// WebVTT STYLE blocks
+	styleBlockPattern = regexp.MustCompile(`(?m)^STYLE\s*$`)
+	// WebVTT REGION definition blocks or region cue settings
+	regionPattern = regexp.MustCompile(`(?m)^REGION\s*$|-->.*\bregion:\S+`)
+)
+
+// runProbe implements the probe subcommand, printing one ProbeResult per file
+func runProbe(args []string) {
+	fs := flag.NewFlagSet("probe", flag.ExitOnError)
+	cues := fs.Bool("cues", false, "List every cue's times, byte range and line span in the file")
+	resultsFD := resultsFDFlag(fs)
+	fs.Usage = commandUsage(fs, "probe", "probe [-cues] captions-filepath [more paths...]")
+	fs.Parse(args)
+	if err := setResultsFD(*resultsFD); err != nil {
+		log.Fatal(err)
+	}
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	cv := NewCaptionValidator("")
+	for _, path := range fs.Args() {
+		result, err := cv.Probe(path, *cues)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printJSON(result)
+	}
+}
+
+// Probe detects format, encoding, cue count, timing range and styling/region usage of a file,
+// and with cues lists where each cue sits in it.
+// Unsupported formats are reported as "unknown" rather than returned as errors.
+func (cv *CaptionValidator) Probe(filepath string, cues bool) (*ProbeResult, error) {
+	content, err := os.ReadFile(longPath(filepath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	result := &ProbeResult{
+		File:     filepath,
+		Format:   "unknown",
+		Encoding: detectEncoding(content),
+	}
+
+	format, err := cv.detectFormat(filepath)
+	if err != nil {
+		return result, nil
+	}
+	result.Format = format
+
+	captions, _, err := cv.parseFile(filepath, format)
+	if err != nil {
+		return nil, err
+	}
+	result.CueCount = len(captions)
+	for i, caption := range captions {
+		if i == 0 || caption.StartTime < result.FirstStart {
+			result.FirstStart = caption.StartTime
+		}
+		if caption.EndTime > result.LastEnd {
+			result.LastEnd = caption.EndTime
+		}
+		if cues {
+			result.Cues = append(result.Cues, ProbeCue{CueSpan: CueSpan{Cue: i + 1, SourceSpan: caption.Source}, StartTime: caption.StartTime, EndTime: caption.EndTime})
+		}
+	}
+
+	result.HasStyling = stylingTagPattern.Match(content) || (format == "webvtt" && styleBlockPattern.Match(content))
+	result.HasRegions = format == "webvtt" && regionPattern.Match(content)
+	return result, nil
+}
+
+// detectEncoding identifies the text encoding from byte order marks and UTF-8 validity
+func detectEncoding(content []byte) string {
+	switch {
+	case bytes.HasPrefix(content, []byte{0xEF, 0xBB, 0xBF}):
+		return "utf-8-bom"
+	case bytes.HasPrefix(content, []byte{0xFF, 0xFE}):
+		return "utf-16le"
+	case bytes.HasPrefix(content, []byte{0xFE, 0xFF}):
+		return "utf-16be"
+	case utf8.Valid(content):
+		return "utf-8"
+	default:
+		return "unknown"
+	}
+}
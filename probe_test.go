@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProbe(t *testing.T) {
+	cv := NewCaptionValidator("")
+
+	tests := []struct {
+		name     string
+		content  string
+		expected ProbeResult
+	}{
+		{
+			name:     "WebVTT with styling and regions",
+			content:  "WEBVTT\n\nSTYLE\n::cue { color: yellow }\n\nREGION\nid:top\n\n00:00:01.000 --> 00:00:05.000 region:top\nHello <b>world</b>\n\n00:00:06.000 --> 00:00:10.000\nBye",
+			expected: ProbeResult{Format: "webvtt", Encoding: "utf-8", CueCount: 2, FirstStart: 1, LastEnd: 10, HasStyling: true, HasRegions: true},
+		},
+		{
+			name:     "SRT with byte order mark",
+			content:  "\ufeff1\n00:00:02,000 --> 00:00:04,500\nHello world",
+			expected: ProbeResult{Format: "srt", Encoding: "utf-8-bom", CueCount: 1, FirstStart: 2, LastEnd: 4.5},
+		},
+		{
+			name:     "unsupported format",
+			content:  "This is just plain text",
+			expected: ProbeResult{Format: "unknown", Encoding: "utf-8"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile, err := os.CreateTemp("", "test_probe_*")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpFile.Name())
+			tmpFile.WriteString(tt.content)
+			tmpFile.Close()
+
+			result, err := cv.Probe(tmpFile.Name(), false)
+			if err != nil {
+				t.Fatalf("Probe failed: %v", err)
+			}
+			tt.expected.File = tmpFile.Name()
+			if !reflect.DeepEqual(*result, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, *result)
+			}
+		})
+	}
+}
+
+func TestProbeCuesLocateBlocks(t *testing.T) {
+	content := "1\r\n00:00:01,000 --> 00:00:02,000\r\nHi\r\n\r\n\r\n2\r\n00:00:03,000 --> 00:00:04,000\r\nTwo\r\nlines\r\n"
+	path := filepath.Join(t.TempDir(), "cues.srt")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := NewCaptionValidator("").Probe(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Cues) != 2 {
+		t.Fatalf("expected 2 cues, got %+v", result.Cues)
+	}
+	second := result.Cues[1]
+	if second.StartLine != 6 || second.EndLine != 9 || second.StartTime != 3 {
+		t.Errorf("unexpected second cue %+v", second)
+	}
+	block := content[second.Offset : second.Offset+second.Length]
+	if block != "2\r\n00:00:03,000 --> 00:00:04,000\r\nTwo\r\nlines\r\n" {
+		t.Errorf("expected the span to cover the whole block, got %q", block)
+	}
+}
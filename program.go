@@ -0,0 +1,481 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ProgramSegment is a stretch of program content on the master's timeline, in seconds
+// from the first frame of the EDL or CPL
+type ProgramSegment struct {
+	Name string `json:"name"`
+	Window
+}
+
+// SegmentCoverage is the coverage of one program segment
+type SegmentCoverage struct {
+	Name           string  `json:"name"`
+	StartTime      float64 `json:"start_time"`
+	EndTime        float64 `json:"end_time"`
+	Window         string  `json:"window"`
+	Coverage       float64 `json:"coverage"` // gating metric
+	CoveredSeconds float64 `json:"covered_seconds"`
+	Passed         bool    `json:"passed"`
+}
+
+// FrameRate is a timecode rate as a fraction, e.g. 24000/1001
+type FrameRate struct {
+	Num, Den int64
+}
+
+// nominal is the frame count per timecode second, e.g. 30 for 29.97
+func (r FrameRate) nominal() int64 {
+	return (r.Num + r.Den - 1) / r.Den
+}
+
+// seconds converts a frame count to seconds
+func (r FrameRate) seconds(frames int64) float64 {
+	return float64(frames*r.Den) / float64(r.Num)
+}
+
+// parseFrameRate accepts integer rates and their NTSC variants: 23.976, 29.97, 59.94
+func parseFrameRate(s string) (FrameRate, error) {
+	fps, err := strconv.ParseFloat(s, 64)
+	if err != nil || fps <= 0 {
+		return FrameRate{}, fmt.Errorf("invalid frame rate %q", s)
+	}
+	if nominal := math.Round(fps); math.Abs(fps-nominal) < 0.001 {
+		return FrameRate{Num: int64(nominal), Den: 1}, nil
+	}
+	if nominal := math.Round(fps * 1.001); math.Abs(fps-nominal/1.001) < 0.01 {
+		return FrameRate{Num: int64(nominal) * 1000, Den: 1001}, nil
+	}
+	return FrameRate{}, fmt.Errorf("unsupported frame rate %q (use an integer rate, 23.976, 29.97 or 59.94)", s)
+}
+
+// timecodePattern matches HH:MM:SS:FF, with ';' before the frames for drop frame
+var timecodePattern = regexp.MustCompile(`^(\d{2}):(\d{2}):(\d{2})([:;.,])(\d{2,3})$`)
+
+// timecodeFrames converts SMPTE timecode to a frame count. Drop-frame timecode skips
+// two frame numbers (four at 59.94) every minute except every tenth.
+func timecodeFrames(tc string, rate FrameRate, dropFrame bool) (int64, error) {
+	m := timecodePattern.FindStringSubmatch(tc)
+	if m == nil {
+		return 0, fmt.Errorf("invalid timecode %q", tc)
+	}
+	hours, _ := strconv.ParseInt(m[1], 10, 64)
+	minutes, _ := strconv.ParseInt(m[2], 10, 64)
+	seconds, _ := strconv.ParseInt(m[3], 10, 64)
+	frames, _ := strconv.ParseInt(m[5], 10, 64)
+	nominal := rate.nominal()
+	if minutes > 59 || seconds > 59 || frames >= nominal {
+		return 0, fmt.Errorf("invalid timecode %q at %g fps", tc, float64(rate.Num)/float64(rate.Den))
+	}
+
+	total := ((hours*60+minutes)*60+seconds)*nominal + frames
+	if (dropFrame || m[4] == ";" || m[4] == ",") && rate.Den == 1001 && nominal%30 == 0 {
+		dropped := nominal / 15 // 2 at 29.97, 4 at 59.94
+		totalMinutes := hours*60 + minutes
+		total -= dropped * (totalMinutes - totalMinutes/10)
+	}
+	return total, nil
+}
+
+// programExclusions are EDL reel and clip names that are not program content
+var programExclusions = regexp.MustCompile(`(?i)^(bl|blk|black|blacks|bars|bars ?(and|&) ?tone|tone|slate|countdown|leader)$`)
+
+// edlEventPattern matches a CMX 3600 event: number, reel, track, transition, an
+// optional transition duration, then source in/out and record in/out
+var edlEventPattern = regexp.MustCompile(`^(\d{3,})\s+(\S+)\s+(\S+)\s+(C|D|W\d+|K\s*B?|K\s*O)\s+(?:\d+\s+)?(\S+)\s+(\S+)\s+(\S+)\s+(\S+)\s*$`)
+
+// edlEvent is one video event of an EDL, in frames on the record timeline
+type edlEvent struct {
+	number   string
+	in, out  int64
+	excluded bool
+}
+
+// parseEDL reads the video events of a CMX 3600 EDL. Events on black, bars, tone or
+// slate reels, or whose clip name says so, are left out; the remaining events are
+// merged into segments where they butt together.
+func parseEDL(content []byte, rate FrameRate) ([]ProgramSegment, error) {
+	var events []edlEvent
+	dropFrame := false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "FCM:"):
+			dropFrame = strings.Contains(strings.ToUpper(line), "DROP") && !strings.Contains(strings.ToUpper(line), "NON")
+		case strings.HasPrefix(line, "*") && len(events) > 0:
+			if name, ok := strings.CutPrefix(strings.TrimSpace(strings.TrimPrefix(line, "*")), "FROM CLIP NAME:"); ok && programExclusions.MatchString(strings.TrimSpace(name)) {
+				events[len(events)-1].excluded = true
+			}
+		default:
+			m := edlEventPattern.FindStringSubmatch(line)
+			if m == nil || !strings.ContainsAny(m[3], "VB") {
+				continue
+			}
+			in, err := timecodeFrames(m[7], rate, dropFrame)
+			if err != nil {
+				return nil, fmt.Errorf("EDL line %d: %w", lineNo, err)
+			}
+			out, err := timecodeFrames(m[8], rate, dropFrame)
+			if err != nil {
+				return nil, fmt.Errorf("EDL line %d: %w", lineNo, err)
+			}
+			if out <= in {
+				return nil, fmt.Errorf("EDL line %d: record out %s is not after record in %s", lineNo, m[8], m[7])
+			}
+			events = append(events, edlEvent{number: m[1], in: in, out: out, excluded: programExclusions.MatchString(m[2])})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("EDL has no video events")
+	}
+
+	// Record times count from the first frame of the master, e.g. 01:00:00:00
+	slices.SortStableFunc(events, func(a, b edlEvent) int { return cmp.Compare(a.in, b.in) })
+	origin := events[0].in
+	var segments []ProgramSegment
+	var first, last edlEvent
+	flush := func() {
+		if first.number == "" {
+			return
+		}
+		name := "event " + first.number
+		if last.number != first.number {
+			name = "events " + first.number + "-" + last.number
+		}
+		segments = append(segments, ProgramSegment{Name: name, Window: Window{Start: rate.seconds(first.in - origin), End: rate.seconds(last.out - origin)}})
+		first = edlEvent{}
+	}
+	for _, event := range events {
+		switch {
+		case event.excluded:
+			flush()
+		case first.number != "" && event.in <= last.out:
+			last.out = max(last.out, event.out)
+			last.number = event.number
+		default:
+			flush()
+			first, last = event, event
+		}
+	}
+	flush()
+	return segments, nil
+}
+
+// cplDocument is the part of an IMF Composition Playlist (SMPTE ST 2067-3) that places
+// segments and markers on the timeline
+type cplDocument struct {
+	XMLName  xml.Name     `xml:"CompositionPlaylist"`
+	EditRate string       `xml:"EditRate"`
+	Segments []cplSegment `xml:"SegmentList>Segment"`
+}
+
+type cplSegment struct {
+	ID           string          `xml:"Id"`
+	Annotation   string          `xml:"Annotation"`
+	SequenceList cplSequenceList `xml:"SequenceList"`
+}
+
+// cplSequenceList holds one sequence per track, named by kind, e.g. MainImageSequence
+type cplSequenceList struct {
+	Sequences []cplSequence `xml:",any"`
+}
+
+type cplSequence struct {
+	XMLName   xml.Name
+	TrackID   string        `xml:"TrackId"`
+	Resources []cplResource `xml:"ResourceList>Resource"`
+}
+
+type cplResource struct {
+	EditRate          string      `xml:"EditRate"`
+	IntrinsicDuration int64       `xml:"IntrinsicDuration"`
+	EntryPoint        int64       `xml:"EntryPoint"`
+	SourceDuration    *int64      `xml:"SourceDuration"`
+	RepeatCount       *int64      `xml:"RepeatCount"`
+	TrackFileID       string      `xml:"TrackFileId"`
+	Markers           []cplMarker `xml:"Marker"`
+}
+
+type cplMarker struct {
+	Label  string `xml:"Label"`
+	Offset int64  `xml:"Offset"`
+}
+
+// duration is how many edit units the resource plays, repeats included
+func (r cplResource) duration() int64 {
+	return r.sourceDuration() * r.repeats()
+}
+
+// sourceDuration is how many edit units one play of the resource lasts
+func (r cplResource) sourceDuration() int64 {
+	if r.SourceDuration != nil {
+		return *r.SourceDuration
+	}
+	return r.IntrinsicDuration - r.EntryPoint
+}
+
+// repeats is how many times the resource plays
+func (r cplResource) repeats() int64 {
+	if r.RepeatCount != nil {
+		return *r.RepeatCount
+	}
+	return 1
+}
+
+// parseEditRate reads a CPL edit rate such as "24000 1001", falling back to def
+func parseEditRate(s string, def FrameRate) (FrameRate, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return def, nil
+	}
+	if len(fields) != 2 {
+		return FrameRate{}, fmt.Errorf("invalid edit rate %q", s)
+	}
+	num, err1 := strconv.ParseInt(fields[0], 10, 64)
+	den, err2 := strconv.ParseInt(fields[1], 10, 64)
+	if err1 != nil || err2 != nil || num <= 0 || den <= 0 {
+		return FrameRate{}, fmt.Errorf("invalid edit rate %q", s)
+	}
+	return FrameRate{Num: num, Den: den}, nil
+}
+
+// decodeCPL parses a CPL and its composition edit rate
+func decodeCPL(content []byte) (*cplDocument, FrameRate, error) {
+	var doc cplDocument
+	if err := xml.Unmarshal(content, &doc); err != nil {
+		return nil, FrameRate{}, fmt.Errorf("failed to decode CPL: %w", err)
+	}
+	rate, err := parseEditRate(doc.EditRate, FrameRate{})
+	if err != nil || rate.Num == 0 {
+		return nil, FrameRate{}, fmt.Errorf("CPL has no valid EditRate")
+	}
+	return &doc, rate, nil
+}
+
+// name is the segment's annotation, or its position for segments without one
+func (segment cplSegment) name(index int) string {
+	if segment.Annotation != "" {
+		return segment.Annotation
+	}
+	return fmt.Sprintf("segment %d", index+1)
+}
+
+// length is the running time of the segment's main image sequence in seconds
+func (segment cplSegment) length(rate FrameRate) (float64, error) {
+	for _, sequence := range segment.SequenceList.Sequences {
+		if sequence.XMLName.Local != "MainImageSequence" {
+			continue
+		}
+		length := 0.0
+		for _, resource := range sequence.Resources {
+			resourceRate, err := parseEditRate(resource.EditRate, rate)
+			if err != nil {
+				return 0, err
+			}
+			length += resourceRate.seconds(resource.duration())
+		}
+		return length, nil
+	}
+	return 0, fmt.Errorf("no MainImageSequence")
+}
+
+// cplExcludedMarkers pair the first- and last-frame markers of content that is not
+// program: bars and tone, commercial blacks, and head and tail slates
+var cplExcludedMarkers = [][2]string{{"FFBT", "LFBT"}, {"FFCB", "LFCB"}, {"FFHS", "LFHS"}, {"FFTS", "LFTS"}}
+
+// parseCPL lays out the segments of an IMF CPL by the duration of their main image
+// sequence. Program content runs from the FFOC to the LFOC marker when the CPL has
+// them, less the bars, blacks and slates its markers bound.
+func parseCPL(content []byte) ([]ProgramSegment, error) {
+	doc, rate, err := decodeCPL(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []ProgramSegment
+	markers := map[string]float64{} // label to seconds; last frames are stored as their end
+	position := 0.0
+	for i, segment := range doc.Segments {
+		name := segment.name(i)
+		duration, err := segment.length(rate)
+		if err != nil {
+			return nil, fmt.Errorf("CPL %s: %w", name, err)
+		}
+		for _, sequence := range segment.SequenceList.Sequences {
+			offset := position
+			for _, resource := range sequence.Resources {
+				resourceRate, err := parseEditRate(resource.EditRate, rate)
+				if err != nil {
+					return nil, fmt.Errorf("CPL %s: %w", name, err)
+				}
+				for _, marker := range resource.Markers {
+					at := offset + resourceRate.seconds(marker.Offset)
+					if strings.HasPrefix(marker.Label, "LF") {
+						at += resourceRate.seconds(1)
+					}
+					markers[marker.Label] = at
+				}
+				offset += resourceRate.seconds(resource.duration())
+			}
+		}
+		if duration > 0 {
+			segments = append(segments, ProgramSegment{Name: name, Window: Window{Start: position, End: position + duration}})
+		}
+		position += duration
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("CPL has no segments")
+	}
+
+	program := Window{Start: 0, End: position}
+	if start, ok := markers["FFOC"]; ok {
+		program.Start = start
+	}
+	if end, ok := markers["LFOC"]; ok {
+		program.End = end
+	}
+	var excluded []Window
+	for _, pair := range cplExcludedMarkers {
+		start, okStart := markers[pair[0]]
+		end, okEnd := markers[pair[1]]
+		if okStart && okEnd {
+			excluded = append(excluded, Window{Start: start, End: end})
+		}
+	}
+
+	var kept []ProgramSegment
+	for _, segment := range segments {
+		window, ok := segment.Intersect(program)
+		if !ok {
+			continue
+		}
+		for _, piece := range subtractWindows(window, excluded) {
+			kept = append(kept, ProgramSegment{Name: segment.Name, Window: piece})
+		}
+	}
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("CPL markers leave no program content")
+	}
+	return kept, nil
+}
+
+// subtractWindows returns the parts of w outside every excluded window, in order
+func subtractWindows(w Window, excluded []Window) []Window {
+	pieces := []Window{w}
+	for _, cut := range excluded {
+		var next []Window
+		for _, piece := range pieces {
+			if before := (Window{Start: piece.Start, End: min(piece.End, cut.Start)}); before.End > before.Start {
+				next = append(next, before)
+			}
+			if after := (Window{Start: max(piece.Start, cut.End), End: piece.End}); after.End > after.Start {
+				next = append(next, after)
+			}
+		}
+		pieces = next
+	}
+	return pieces
+}
+
+// loadProgramSegments reads the -program file: an IMF CPL, recognized by its root
+// element, or otherwise a CMX 3600 EDL timed at rate
+func loadProgramSegments(path string, rate FrameRate) ([]ProgramSegment, error) {
+	content, err := os.ReadFile(longPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read program file: %w", err)
+	}
+	if bytes.Contains(content, []byte("<CompositionPlaylist")) {
+		return parseCPL(content)
+	}
+	return parseEDL(content, rate)
+}
+
+// programSpan is the window from the start of the first segment to the end of the last
+func programSpan(segments []ProgramSegment) Window {
+	return Window{Start: segments[0].Start, End: segments[len(segments)-1].End}
+}
+
+// measureProgramCoverage measures each program segment and totals them, so coverage
+// ignores whatever lies between segments
+func (cv *CaptionValidator) measureProgramCoverage(captions []Caption, requiredCoverage float64) (CoverageMetrics, []SegmentCoverage) {
+	var results []SegmentCoverage
+	var parts []CoverageMetrics
+	for _, segment := range cv.program {
+		metrics := measureCoverage(captions, segment.Window, cv.minReadable, cv.coverageMetric)
+		parts = append(parts, metrics)
+		results = append(results, SegmentCoverage{
+			Name:           segment.Name,
+			StartTime:      segment.Start,
+			EndTime:        segment.End,
+			Window:         segment.Window.String(),
+			Coverage:       metrics.gatingValue(),
+			CoveredSeconds: metrics.CoveredSeconds,
+			Passed:         coveragePasses(metrics.gatingValue(), requiredCoverage, cv.tolerance),
+		})
+	}
+	return cv.sumCoverage(parts, programSpan(cv.program)), results
+}
+
+// sumCoverage totals coverage measured over separate windows, weighting each by its
+// length; span is the window the total is reported over
+func (cv *CaptionValidator) sumCoverage(parts []CoverageMetrics, span Window) CoverageMetrics {
+	var coveredMs, windowMs int64
+	weighted := 0.0
+	for _, metrics := range parts {
+		coveredMs += metrics.CoveredMs
+		windowMs += metrics.WindowMs
+		weighted += metrics.DialogueWeighted * float64(metrics.WindowMs)
+	}
+
+	total := measureCoverage(nil, span, cv.minReadable, cv.coverageMetric)
+	total.CoveredMs, total.WindowMs = coveredMs, windowMs
+	total.CoveredSeconds, total.WindowSeconds = float64(coveredMs)/1000, float64(windowMs)/1000
+	if windowMs > 0 {
+		total.WallClock = roundCoverage(float64(coveredMs*100) / float64(windowMs))
+		total.DialogueWeighted = roundCoverage(weighted / float64(windowMs))
+	}
+	return total
+}
+
+// programCoverageFix lists the uncovered ranges inside program segments
+func (cv *CaptionValidator) programCoverageFix(captions []Caption) *SuggestedFix {
+	windows := make([]Window, len(cv.program))
+	for i, segment := range cv.program {
+		windows[i] = segment.Window
+	}
+	return windowsCoverageFix(captions, windows)
+}
+
+// windowsCoverageFix lists the uncovered ranges inside each of the windows
+func windowsCoverageFix(captions []Caption, windows []Window) *SuggestedFix {
+	var gaps []Window
+	total := 0.0
+	for _, window := range windows {
+		for _, gap := range coverageGaps(captions, window) {
+			gaps = append(gaps, gap)
+			total += gap.Duration()
+		}
+	}
+	return &SuggestedFix{
+		Action:      FixCaptionGaps,
+		Gaps:        gaps,
+		Description: fmt.Sprintf("Caption %d uncovered range(s) totaling %.2fs", len(gaps), total),
+	}
+}
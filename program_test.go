@@ -0,0 +1,165 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTimecodeFrames(t *testing.T) {
+	ntsc, _ := parseFrameRate("29.97")
+	film, _ := parseFrameRate("23.976")
+	pal, _ := parseFrameRate("25")
+	tests := []struct {
+		tc   string
+		rate FrameRate
+		drop bool
+		want int64
+	}{
+		{"00:00:01:00", pal, false, 25},
+		{"01:00:00:00", film, false, 86400},
+		{"00:01:00;02", ntsc, false, 1800},
+		{"00:10:00;00", ntsc, false, 17982},
+		{"00:10:00:00", ntsc, true, 17982},
+		{"00:10:00:00", ntsc, false, 18000},
+	}
+	for _, tt := range tests {
+		got, err := timecodeFrames(tt.tc, tt.rate, tt.drop)
+		if err != nil || got != tt.want {
+			t.Errorf("timecodeFrames(%s) = %d, %v; want %d", tt.tc, got, err, tt.want)
+		}
+	}
+	if _, err := timecodeFrames("00:00:00:25", pal, false); err == nil {
+		t.Error("expected frame 25 to be rejected at 25 fps")
+	}
+	if _, err := parseFrameRate("12.5x"); err == nil {
+		t.Error("expected an invalid frame rate to be rejected")
+	}
+}
+
+func TestParseEDL(t *testing.T) {
+	edl := `TITLE: FEATURE_MASTER
+FCM: NON-DROP FRAME
+
+001  BARS     V     C        00:00:00:00 00:01:00:00 00:59:00:00 01:00:00:00
+002  A001     V     C        10:00:00:00 10:05:00:00 01:00:00:00 01:05:00:00
+* FROM CLIP NAME: SC01
+003  A002     B     C        11:00:00:00 11:05:00:00 01:05:00:00 01:10:00:00
+004  AX       V     C        00:00:00:00 00:00:05:00 01:10:00:00 01:10:05:00
+* FROM CLIP NAME: SLATE
+005  A003     V     C        12:00:00:00 12:10:00:00 01:10:05:00 01:20:05:00
+006  A003     A     C        12:00:00:00 12:10:00:00 01:10:05:00 01:20:05:00
+`
+	segments, err := parseEDL([]byte(edl), FrameRate{Num: 25, Den: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ProgramSegment{
+		{Name: "events 002-003", Window: Window{Start: 60, End: 660}},
+		{Name: "event 005", Window: Window{Start: 665, End: 1265}},
+	}
+	if len(segments) != len(want) {
+		t.Fatalf("expected %d segments, got %+v", len(want), segments)
+	}
+	for i := range want {
+		if segments[i] != want[i] {
+			t.Errorf("segment %d = %+v, want %+v", i, segments[i], want[i])
+		}
+	}
+
+	if _, err := parseEDL([]byte("TITLE: EMPTY\n"), FrameRate{Num: 25, Den: 1}); err == nil {
+		t.Error("expected an EDL without events to be rejected")
+	}
+}
+
+func TestParseCPL(t *testing.T) {
+	cpl := `<?xml version="1.0" encoding="UTF-8"?>
+<CompositionPlaylist xmlns="http://www.smpte-ra.org/schemas/2067-3/2016" xmlns:cc="http://www.smpte-ra.org/schemas/2067-2/2016">
+  <EditRate>24 1</EditRate>
+  <SegmentList>
+    <Segment>
+      <Id>urn:uuid:1</Id>
+      <Annotation>Head</Annotation>
+      <SequenceList>
+        <MarkerSequence>
+          <ResourceList>
+            <Resource>
+              <IntrinsicDuration>480</IntrinsicDuration>
+              <Marker><Label>FFBT</Label><Offset>0</Offset></Marker>
+              <Marker><Label>LFBT</Label><Offset>239</Offset></Marker>
+              <Marker><Label>FFOC</Label><Offset>240</Offset></Marker>
+            </Resource>
+          </ResourceList>
+        </MarkerSequence>
+        <cc:MainImageSequence>
+          <ResourceList>
+            <Resource><IntrinsicDuration>600</IntrinsicDuration><EntryPoint>120</EntryPoint></Resource>
+          </ResourceList>
+        </cc:MainImageSequence>
+      </SequenceList>
+    </Segment>
+    <Segment>
+      <Id>urn:uuid:2</Id>
+      <SequenceList>
+        <cc:MainImageSequence>
+          <ResourceList>
+            <Resource><EditRate>24 1</EditRate><IntrinsicDuration>2400</IntrinsicDuration><SourceDuration>1200</SourceDuration><RepeatCount>2</RepeatCount></Resource>
+          </ResourceList>
+        </cc:MainImageSequence>
+        <MarkerSequence>
+          <ResourceList>
+            <Resource>
+              <IntrinsicDuration>2400</IntrinsicDuration>
+              <Marker><Label>LFOC</Label><Offset>2159</Offset></Marker>
+            </Resource>
+          </ResourceList>
+        </MarkerSequence>
+      </SequenceList>
+    </Segment>
+  </SegmentList>
+</CompositionPlaylist>`
+	segments, err := parseCPL([]byte(cpl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ProgramSegment{
+		{Name: "Head", Window: Window{Start: 10, End: 20}},
+		{Name: "segment 2", Window: Window{Start: 20, End: 110}},
+	}
+	if len(segments) != len(want) {
+		t.Fatalf("expected %d segments, got %+v", len(want), segments)
+	}
+	for i := range want {
+		if segments[i].Name != want[i].Name || math.Abs(segments[i].Start-want[i].Start) > 1e-9 || math.Abs(segments[i].End-want[i].End) > 1e-9 {
+			t.Errorf("segment %d = %+v, want %+v", i, segments[i], want[i])
+		}
+	}
+}
+
+func TestMeasureProgramCoverage(t *testing.T) {
+	cv := NewCaptionValidator("")
+	cv.program = []ProgramSegment{
+		{Name: "act 1", Window: Window{Start: 0, End: 10}},
+		{Name: "act 2", Window: Window{Start: 20, End: 30}},
+	}
+	// The cue over the break between acts does not count
+	captions := []Caption{
+		{StartTime: 0, EndTime: 10, Text: "One"},
+		{StartTime: 10, EndTime: 20, Text: "Bumper"},
+		{StartTime: 20, EndTime: 24, Text: "Two"},
+	}
+	total, segments := cv.measureProgramCoverage(captions, 80)
+	if total.WallClock != 70 || total.WindowSeconds != 20 {
+		t.Errorf("expected 70%% of 20s, got %.2f%% of %.2fs", total.WallClock, total.WindowSeconds)
+	}
+	if len(segments) != 2 || !segments[0].Passed || segments[1].Passed || segments[1].Coverage != 40 {
+		t.Errorf("unexpected segment results: %+v", segments)
+	}
+
+	coverageErr := cv.validateCoverage(captions, Window{Start: 0, End: 30}, 80)
+	if coverageErr == nil {
+		t.Fatal("expected caption_coverage over the program segments")
+	}
+	if gaps := coverageErr.SuggestedFix.Gaps; len(gaps) != 1 || gaps[0] != (Window{Start: 24, End: 30}) {
+		t.Errorf("expected only the gap inside act 2, got %v", gaps)
+	}
+}
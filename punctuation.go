@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Punctuation style choices; an empty choice is not checked
+const (
+	QuotesStraight = "straight"
+	QuotesCurly    = "curly"
+
+	DashEm           = "em_dash"
+	DashDoubleHyphen = "double_hyphen"
+
+	EllipsisCharacter = "character"
+	EllipsisDots      = "dots"
+)
+
+// PunctuationStyle is the house style for quotes, dashes and ellipses
+type PunctuationStyle struct {
+	Quotes   string `json:"quotes,omitempty"`
+	Dash     string `json:"dash,omitempty"`
+	Ellipsis string `json:"ellipsis,omitempty"`
+}
+
+// enabled reports whether any part of the style is checked
+func (ps PunctuationStyle) enabled() bool {
+	return ps.Quotes != "" || ps.Dash != "" || ps.Ellipsis != ""
+}
+
+// Validate rejects unknown style choices
+func (ps PunctuationStyle) Validate() error {
+	for _, choice := range []struct{ name, value, a, b string }{
+		{"quotes", ps.Quotes, QuotesStraight, QuotesCurly},
+		{"dash", ps.Dash, DashEm, DashDoubleHyphen},
+		{"ellipsis", ps.Ellipsis, EllipsisCharacter, EllipsisDots},
+	} {
+		if choice.value != "" && choice.value != choice.a && choice.value != choice.b {
+			return fmt.Errorf("invalid %s style %q: expected %s or %s", choice.name, choice.value, choice.a, choice.b)
+		}
+	}
+	return nil
+}
+
+// StyleViolation is one punctuation form found where the style expects another
+type StyleViolation struct {
+	Rule     string `json:"rule"`
+	Found    string `json:"found"`
+	Expected string `json:"expected"`
+	Cues     []int  `json:"cues"`
+}
+
+// PunctuationStyleWarning reports punctuation that does not follow the selected style
+type PunctuationStyleWarning struct {
+	Type         string           `json:"type"`
+	Rule         string           `json:"rule"`
+	Severity     string           `json:"severity"`
+	Style        PunctuationStyle `json:"style"`
+	Violations   []StyleViolation `json:"violations"`
+	Description  string           `json:"description"`
+	SuggestedFix *SuggestedFix    `json:"suggested_fix,omitempty"`
+}
+
+// punctuationRule lists the forms that violate one style choice and the form to use instead
+type punctuationRule struct {
+	rule     string
+	wrong    []string
+	expected string
+}
+
+// punctuationRules returns the rules the style enforces, in report order
+func (ps PunctuationStyle) punctuationRules() []punctuationRule {
+	var rules []punctuationRule
+	switch ps.Quotes {
+	case QuotesCurly:
+		rules = append(rules, punctuationRule{"quotes", []string{`"`, "'"}, "“ ” ‘ ’"})
+	case QuotesStraight:
+		rules = append(rules, punctuationRule{"quotes", []string{"“", "”", "‘", "’"}, `" '`})
+	}
+	switch ps.Dash {
+	case DashEm:
+		rules = append(rules, punctuationRule{"dash", []string{"--"}, "—"})
+	case DashDoubleHyphen:
+		rules = append(rules, punctuationRule{"dash", []string{"—"}, "--"})
+	}
+	switch ps.Ellipsis {
+	case EllipsisCharacter:
+		rules = append(rules, punctuationRule{"ellipsis", []string{"..."}, "…"})
+	case EllipsisDots:
+		rules = append(rules, punctuationRule{"ellipsis", []string{"…"}, "..."})
+	}
+	return rules
+}
+
+// validatePunctuationStyle finds quotes, dashes and ellipses that do not match style
+func (cv *CaptionValidator) validatePunctuationStyle(captions []Caption, style PunctuationStyle) *PunctuationStyleWarning {
+	var violations []StyleViolation
+	flagged := map[int]bool{}
+	for _, rule := range style.punctuationRules() {
+		for _, wrong := range rule.wrong {
+			violation := StyleViolation{Rule: rule.rule, Found: wrong, Expected: rule.expected}
+			for i, caption := range captions {
+				if strings.Contains(caption.Text, wrong) {
+					violation.Cues = append(violation.Cues, i+1)
+					flagged[i+1] = true
+				}
+			}
+			if len(violation.Cues) > 0 {
+				violations = append(violations, violation)
+			}
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	cues := make([]int, 0, len(flagged))
+	for i := range captions {
+		if flagged[i+1] {
+			cues = append(cues, i+1)
+		}
+	}
+	details := make([]string, len(violations))
+	for i, violation := range violations {
+		details[i] = fmt.Sprintf("%s in %d cue(s)", violation.Found, len(violation.Cues))
+	}
+
+	return &PunctuationStyleWarning{
+		Type:        "punctuation_style",
+		Severity:    SeverityWarning,
+		Style:       style,
+		Violations:  violations,
+		Description: "Punctuation does not follow the house style: " + strings.Join(details, ", "),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixRestylePunctuation,
+			Cues:        cues,
+			Description: fmt.Sprintf("Rewrite quotes, dashes and ellipses in %s to the house style", cueRange(cues)),
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidatePunctuationStyle(t *testing.T) {
+	cv := NewCaptionValidator("http://test.com")
+	captions := []Caption{
+		{Text: "“Hello,” she said."},
+		{Text: "Wait -- what..."},
+		{Text: "I don't know…"},
+	}
+
+	warn := cv.validatePunctuationStyle(captions, deliveryProfiles["netflix"].Punctuation)
+	if warn == nil {
+		t.Fatal("expected punctuation_style warning")
+	}
+	expected := []StyleViolation{
+		{Rule: "quotes", Found: "“", Expected: `" '`, Cues: []int{1}},
+		{Rule: "quotes", Found: "”", Expected: `" '`, Cues: []int{1}},
+		{Rule: "dash", Found: "--", Expected: "—", Cues: []int{2}},
+		{Rule: "ellipsis", Found: "...", Expected: "…", Cues: []int{2}},
+	}
+	if !reflect.DeepEqual(warn.Violations, expected) {
+		t.Errorf("expected %+v, got %+v", expected, warn.Violations)
+	}
+	if warn.SuggestedFix.Action != FixRestylePunctuation || !reflect.DeepEqual(warn.SuggestedFix.Cues, []int{1, 2}) {
+		t.Errorf("unexpected suggested fix %+v", warn.SuggestedFix)
+	}
+
+	// Only the configured parts of a style are checked
+	if warn := cv.validatePunctuationStyle(captions[2:], PunctuationStyle{Ellipsis: EllipsisCharacter}); warn != nil {
+		t.Errorf("expected no warning, got %+v", warn)
+	}
+	warn = cv.validatePunctuationStyle(captions[2:], PunctuationStyle{Quotes: QuotesCurly, Ellipsis: EllipsisDots})
+	if warn == nil || len(warn.Violations) != 2 {
+		t.Errorf("expected straight apostrophe and ellipsis character violations, got %+v", warn)
+	}
+}
+
+func TestPunctuationStyleValidate(t *testing.T) {
+	if err := (PunctuationStyle{Quotes: QuotesCurly, Dash: DashDoubleHyphen}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (PunctuationStyle{Dash: "en_dash"}).Validate(); err == nil {
+		t.Error("expected error for unknown dash style")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ValidateOptions are the parameters of one in-memory validation
+type ValidateOptions struct {
+	Name     string  // file name in the report, matched against -asset_list and baselines (default: "captions")
+	Window   Window  // the range coverage is measured over
+	Coverage float64 // required coverage percentage
+}
+
+// ValidateReader validates a caption file read from r, as Validate does one on disk,
+// for services that hold the captions in memory: just downloaded, decrypted or
+// generated. The whole file is read before validation starts. Bitmap subtitles and
+// IMF compositions cannot be validated this way, as their cues come from other files.
+// Cancelling ctx cancels the file's language detection.
+func (cv *CaptionValidator) ValidateReader(ctx context.Context, r io.Reader, opts ValidateOptions) (*FileReport, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read captions: %w", err)
+	}
+	return cv.ValidateBytes(ctx, content, opts)
+}
+
+// ValidateBytes validates a caption file held in content, like ValidateReader.
+// content is not modified and may be reused once it returns.
+func (cv *CaptionValidator) ValidateBytes(ctx context.Context, content []byte, opts ValidateOptions) (*FileReport, error) {
+	name := opts.Name
+	if name == "" {
+		name = "captions"
+	}
+	memCV := *cv
+	memCV.content = content
+	if memCV.content == nil {
+		memCV.content = []byte{}
+	}
+	return memCV.validate(ctx, name, opts.Window, opts.Coverage)
+}
+
+// openCaption opens the caption file being validated: the content ValidateBytes
+// holds, or the file at filepath
+func (cv *CaptionValidator) openCaption(filepath string) (io.ReadCloser, error) {
+	if cv.content != nil {
+		return io.NopCloser(bytes.NewReader(cv.content)), nil
+	}
+	return os.Open(longPath(filepath))
+}
+
+// contextReader fails reads once the isolated validation reading under ctx is
+// abandoned, so its parse stops
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr contextReader) Read(p []byte) (int, error) {
+	if err := abandoned(cr.ctx); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+// captionSize returns the size in bytes of the caption file being validated
+func (cv *CaptionValidator) captionSize(filepath string) (int64, error) {
+	if cv.content != nil {
+		return int64(len(cv.content)), nil
+	}
+	info, err := os.Stat(longPath(filepath))
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
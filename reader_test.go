@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "es-ES"})
+	}))
+	defer server.Close()
+	content := []byte("WEBVTT\n\nNOTE cv-expect-lang: es-ES\n\n00:00:01.000 --> 00:00:05.000\nHola, ¿qué tal?\n")
+	path := filepath.Join(t.TempDir(), "ep1.vtt")
+	os.WriteFile(path, content, 0o644)
+
+	cv := NewCaptionValidator(server.URL)
+	cv.directives = true
+	window := Window{Start: 0, End: 10}
+	onDisk, err := cv.Validate(path, window, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inMemory, err := cv.ValidateBytes(context.Background(), content, ValidateOptions{Name: "ep1.vtt", Window: window, Coverage: 80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same file validates the same way from memory as from disk, if not as fast
+	onDisk.File = inMemory.File
+	onDisk.StartedAt, onDisk.FinishedAt, onDisk.Durations = inMemory.StartedAt, inMemory.FinishedAt, inMemory.Durations
+	diskJSON, _ := json.Marshal(onDisk)
+	memoryJSON, _ := json.Marshal(inMemory)
+	if !bytes.Equal(diskJSON, memoryJSON) {
+		t.Errorf("expected the same report from memory as from disk:\n%s\n%s", memoryJSON, diskJSON)
+	}
+	if inMemory.File != "ep1.vtt" || inMemory.Format != "webvtt" || len(inMemory.Directives) != 1 || len(inMemory.Errors) != 1 {
+		t.Errorf("unexpected report %s", memoryJSON)
+	}
+	if cv.content != nil {
+		t.Error("expected the validator to be left reading from disk")
+	}
+
+	report, err := cv.ValidateReader(context.Background(), strings.NewReader("1\n00:00:01,000 --> 00:00:09,000\nHola\n"), ValidateOptions{Window: window, Coverage: 80})
+	if err != nil || report.File != "captions" || report.Format != "srt" || report.Cues != 1 {
+		t.Errorf("unexpected report %+v, %v", report, err)
+	}
+}
+
+func TestValidateBytesRejects(t *testing.T) {
+	cv := NewCaptionValidator("")
+	window := Window{Start: 0, End: 10}
+	if _, err := cv.ValidateBytes(context.Background(), []byte("PG\x00\x00"), ValidateOptions{Window: window}); err == nil || !strings.Contains(err.Error(), "in memory") {
+		t.Errorf("expected bitmap subtitles to be rejected, got %v", err)
+	}
+	if _, err := cv.ValidateBytes(context.Background(), nil, ValidateOptions{Window: window}); err == nil {
+		t.Error("expected empty content to be an unsupported format")
+	}
+	if _, err := cv.ValidateReader(context.Background(), iotestErrReader{}, ValidateOptions{Window: window}); err == nil {
+		t.Error("expected a read error")
+	}
+}
+
+func TestValidateBytesCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cv := NewCaptionValidator(server.URL)
+	report, err := cv.ValidateBytes(ctx, []byte("1\n00:00:01,000 --> 00:00:09,000\nHello there\n"), ValidateOptions{Window: Window{Start: 0, End: 10}, Coverage: 80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Errors) != 1 {
+		t.Fatalf("expected one issue, got %+v", report.Errors)
+	}
+	if _, ok := report.Errors[0].(*LanguageDetectionFailedError); !ok {
+		t.Errorf("expected detection to fail with the context, got %+v", report.Errors[0])
+	}
+}
+
+// iotestErrReader fails every read
+type iotestErrReader struct{}
+
+func (iotestErrReader) Read([]byte) (int, error) { return 0, errors.New("connection reset") }
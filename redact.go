@@ -0,0 +1,107 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Redaction modes for text sent to the language detection endpoint
+const (
+	RedactNone = ""
+	RedactMask = "mask" // replace with [NAME] / [NUMBER] placeholders
+	RedactHash = "hash" // replace with stable short hashes so repeated names stay consistent
+)
+
+// validRedactMode reports whether mode is a supported redaction mode
+func validRedactMode(mode string) bool {
+	return mode == RedactNone || mode == RedactMask || mode == RedactHash
+}
+
+// redactText masks or hashes likely proper nouns and any token containing digits.
+// Proper nouns are capitalized words that do not start a sentence (simple heuristic).
+func redactText(text, mode string) string {
+	if mode == RedactNone {
+		return text
+	}
+
+	tokens := strings.Fields(text)
+	sentenceStart := true
+	for i, token := range tokens {
+		core := strings.TrimFunc(token, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		switch {
+		case core == "":
+		case strings.ContainsFunc(core, unicode.IsDigit):
+			tokens[i] = strings.Replace(token, core, redactToken(core, "NUMBER", mode), 1)
+		case !sentenceStart && isCapitalized(core) && !isPronounI(core):
+			tokens[i] = strings.Replace(token, core, redactToken(core, "NAME", mode), 1)
+		}
+
+		last, _ := utf8.DecodeLastRuneInString(token)
+		sentenceStart = strings.ContainsRune(sentenceEndPunctuation, last)
+	}
+	return strings.Join(tokens, " ")
+}
+
+// redactToken produces the replacement for a redacted token
+func redactToken(token, kind, mode string) string {
+	if mode == RedactHash {
+		sum := sha256.Sum256([]byte(token))
+		return strings.ToLower(kind[:1]) + "_" + hex.EncodeToString(sum[:4])
+	}
+	return "[" + kind + "]"
+}
+
+func isCapitalized(word string) bool {
+	first, _ := utf8.DecodeRuneInString(word)
+	return unicode.IsUpper(first)
+}
+
+// isPronounI matches "I" and its contractions (I'm, I'll, I've)
+func isPronounI(word string) bool {
+	return word == "I" || strings.HasPrefix(word, "I'") || strings.HasPrefix(word, "I’")
+}
+
+// sampleText joins caption texts, bounded to roughly limit characters by taking cues
+// spread evenly across the file. A limit of 0 sends everything.
+func sampleText(parts []string, limit int) string {
+	text := strings.Join(parts, " ")
+	if limit <= 0 || len(text) <= limit {
+		return text
+	}
+
+	// Pick every step-th cue so the sample spans the whole program, not just the opening
+	step := max(len(text)/limit, 1)
+
+	var sample strings.Builder
+	for i := 0; i < len(parts); i += step {
+		if sample.Len() > 0 {
+			if sample.Len()+1+len(parts[i]) > limit {
+				break
+			}
+			sample.WriteByte(' ')
+		} else if len(parts[i]) > limit {
+			return truncateAtWord(parts[i], limit)
+		}
+		sample.WriteString(parts[i])
+	}
+	return sample.String()
+}
+
+// truncateAtWord cuts text to at most limit bytes without splitting a word, or
+// failing that a grapheme cluster
+func truncateAtWord(text string, limit int) string {
+	if cut := strings.LastIndexByte(text[:limit+1], ' '); cut > 0 {
+		return text[:cut]
+	}
+	cut := 0
+	for _, cluster := range graphemes(text) {
+		if cut+len(cluster) > limit {
+			break
+		}
+		cut += len(cluster)
+	}
+	return text[:cut]
+}
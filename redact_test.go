@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactText(t *testing.T) {
+	text := "Welcome back. I met Maria in Paris on 12 May, call 555-0100!"
+
+	masked := redactText(text, RedactMask)
+	expected := "Welcome back. I met [NAME] in [NAME] on [NUMBER] [NAME], call [NUMBER]!"
+	if masked != expected {
+		t.Errorf("expected %q, got %q", expected, masked)
+	}
+
+	hashed := redactText("Maria met Maria and Maria", RedactHash)
+	fields := strings.Fields(hashed)
+	if fields[0] != "Maria" {
+		t.Errorf("sentence-initial word should not be redacted, got %q", fields[0])
+	}
+	if !strings.HasPrefix(fields[2], "n_") || fields[2] != fields[4] {
+		t.Errorf("expected stable name hashes, got %q", hashed)
+	}
+
+	if redactText(text, RedactNone) != text {
+		t.Error("expected text unchanged with redaction disabled")
+	}
+}
+
+func TestSampleText(t *testing.T) {
+	parts := []string{"first cue here", "second cue here", "third cue here", "fourth cue here", "fifth cue here", "sixth cue here"}
+
+	if sampleText(parts, 0) != strings.Join(parts, " ") {
+		t.Error("expected full text with no limit")
+	}
+
+	sample := sampleText(parts, 40)
+	if len(sample) > 40 {
+		t.Errorf("sample exceeds limit: %q", sample)
+	}
+	if !strings.HasPrefix(sample, "first cue here") || strings.Contains(sample, "second") {
+		t.Errorf("expected evenly spaced cues, got %q", sample)
+	}
+
+	if sample := sampleText([]string{"one very long cue that exceeds the limit"}, 12); sample != "one very" {
+		t.Errorf("expected truncation at a word boundary, got %q", sample)
+	}
+}
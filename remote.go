@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// remoteTimeout bounds downloading one remote input; a season of vendor drops can be large
+const remoteTimeout = 30 * time.Minute
+
+// RemoteOptions are the credentials used to download sftp:// and ftps:// inputs
+type RemoteOptions struct {
+	Key            string        // SSH identity for sftp://, TLS client key for ftps://
+	Cert           string        // TLS client certificate for ftps://
+	PasswordEnv    string        // environment variable holding the ftps:// password
+	ConnectTimeout time.Duration // establishing each connection
+}
+
+// isRemoteSource reports whether an input is a URL to download rather than a local path
+func isRemoteSource(input string) bool {
+	return strings.HasPrefix(input, "sftp://") || strings.HasPrefix(input, "ftps://")
+}
+
+// remoteTarget is where a remote input is downloaded under dir: host, then the remote path
+func remoteTarget(u *url.URL, dir string) string {
+	return filepath.Join(dir, u.Hostname(), filepath.FromSlash(path.Clean("/"+u.Path)))
+}
+
+// fetchRemoteInputs downloads every sftp:// and ftps:// input, file or directory, under
+// dir and returns the inputs with each URL replaced by its local copy. Without dir the
+// copies go to a temporary directory that cleanup removes.
+func fetchRemoteInputs(inputs []string, dir string, opts RemoteOptions) (local []string, cleanup func(), err error) {
+	cleanup = func() {}
+	if dir == "" {
+		if dir, err = os.MkdirTemp("", "caption-validator-remote-"); err != nil {
+			return nil, cleanup, fmt.Errorf("failed to create download directory: %w", err)
+		}
+		temp := dir
+		cleanup = func() { os.RemoveAll(temp) }
+	}
+	for _, input := range inputs {
+		if !isRemoteSource(input) {
+			local = append(local, input)
+			continue
+		}
+		u, err := url.Parse(input)
+		if err != nil || u.Host == "" {
+			cleanup()
+			return nil, func() {}, fmt.Errorf("invalid remote input %q", input)
+		}
+		target := remoteTarget(u, dir)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			cleanup()
+			return nil, func() {}, fmt.Errorf("failed to create download directory: %w", err)
+		}
+		if u.Scheme == "sftp" {
+			err = fetchSFTP(u, target, opts)
+		} else {
+			err = fetchFTPS(u, target, opts)
+		}
+		if err != nil {
+			cleanup()
+			return nil, func() {}, fmt.Errorf("failed to download %s: %w", u.Redacted(), err)
+		}
+		local = append(local, target)
+	}
+	return local, cleanup, nil
+}
+
+// sftpProgram is the OpenSSH client sftp:// inputs are downloaded with
+var sftpProgram = "sftp"
+
+// sftpArgs builds the sftp command line for a URL. BatchMode keeps it from prompting,
+// so only keys (-remote_key, the agent or the user's default identities) are used.
+// A host or user starting with "-" is refused so it cannot be read as an option.
+func sftpArgs(u *url.URL, opts RemoteOptions) ([]string, error) {
+	args := []string{"-q", "-b", "-", "-o", "BatchMode=yes"}
+	if opts.ConnectTimeout > 0 {
+		args = append(args, "-o", fmt.Sprintf("ConnectTimeout=%d", int(opts.ConnectTimeout.Seconds())))
+	}
+	if port := u.Port(); port != "" {
+		args = append(args, "-P", port)
+	}
+	if opts.Key != "" {
+		args = append(args, "-i", opts.Key)
+	}
+	destination := u.Hostname()
+	if u.User != nil {
+		destination = u.User.Username() + "@" + destination
+	}
+	if strings.HasPrefix(destination, "-") {
+		return nil, fmt.Errorf("invalid sftp destination %q", destination)
+	}
+	return append(args, "--", destination), nil
+}
+
+// sftpRemotePath is the path sftp fetches: absolute, or relative to the login
+// directory when the URL path starts with /~/
+func sftpRemotePath(u *url.URL) string {
+	if rest, ok := strings.CutPrefix(u.Path, "/~/"); ok {
+		return rest
+	}
+	return u.Path
+}
+
+// sftpGetCommand is the batch command that fetches remote into target. sftp reads
+// double-quoted arguments without Go's escapes, so paths with quotes, backslashes or
+// line breaks are refused rather than escaped.
+func sftpGetCommand(remote, target string) (string, error) {
+	for _, p := range []string{remote, target} {
+		if strings.ContainsAny(p, "\"\\\r\n") {
+			return "", fmt.Errorf("unsupported sftp path %q: quotes, backslashes and line breaks are not allowed", p)
+		}
+	}
+	return fmt.Sprintf("get -R \"%s\" \"%s\"\n", remote, target), nil
+}
+
+// fetchSFTP downloads a remote file or directory tree to target with the sftp program
+func fetchSFTP(u *url.URL, target string, opts RemoteOptions) error {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+
+	args, err := sftpArgs(u, opts)
+	if err != nil {
+		return err
+	}
+	get, err := sftpGetCommand(sftpRemotePath(u), target)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, sftpProgram, args...)
+	cmd.Stdin = strings.NewReader(get)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("sftp: %w", err)
+	}
+	return nil
+}
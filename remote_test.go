@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSFTPArgs(t *testing.T) {
+	u, _ := url.Parse("sftp://captions@exchange.example.com:2222/~/drops/season1")
+	args, err := sftpArgs(u, RemoteOptions{Key: "/keys/vendor_ed25519", ConnectTimeout: 10 * time.Second})
+	expected := []string{"-q", "-b", "-", "-o", "BatchMode=yes", "-o", "ConnectTimeout=10", "-P", "2222", "-i", "/keys/vendor_ed25519", "--", "captions@exchange.example.com"}
+	if err != nil || !slices.Equal(args, expected) {
+		t.Errorf("expected %q, got %q: %v", expected, args, err)
+	}
+	if remote := sftpRemotePath(u); remote != "drops/season1" {
+		t.Errorf("expected a home-relative path, got %q", remote)
+	}
+
+	u, _ = url.Parse("sftp://exchange.example.com/srv/drops/ep1.srt")
+	if args, err := sftpArgs(u, RemoteOptions{}); err != nil || !slices.Equal(args, []string{"-q", "-b", "-", "-o", "BatchMode=yes", "--", "exchange.example.com"}) {
+		t.Errorf("unexpected args without options: %q: %v", args, err)
+	}
+	if remote := sftpRemotePath(u); remote != "/srv/drops/ep1.srt" {
+		t.Errorf("expected an absolute path, got %q", remote)
+	}
+
+	for _, input := range []string{"sftp://-oProxyCommand=x/drops", "sftp://-oProxyCommand=x@exchange.example.com/drops"} {
+		if u, err = url.Parse(input); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sftpArgs(u, RemoteOptions{}); err == nil {
+			t.Errorf("%s: expected a destination starting with - to be refused", input)
+		}
+	}
+}
+
+func TestSFTPGetCommand(t *testing.T) {
+	get, err := sftpGetCommand("drops/épisode 1", "/tmp/dl/épisode 1")
+	if expected := "get -R \"drops/épisode 1\" \"/tmp/dl/épisode 1\"\n"; err != nil || get != expected {
+		t.Errorf("expected %q, got %q: %v", expected, get, err)
+	}
+	for _, remote := range []string{`drops/"ep1".srt`, `drops\ep1.srt`, "drops/ep1\nget /etc/passwd"} {
+		if _, err := sftpGetCommand(remote, "/tmp/dl"); err == nil {
+			t.Errorf("expected %q to be refused", remote)
+		}
+	}
+}
+
+func TestRemoteTarget(t *testing.T) {
+	u, _ := url.Parse("ftps://vendor@ftp.example.com:21/drops/../drops/ep1.srt")
+	if target := remoteTarget(u, "staging"); target != filepath.Join("staging", "ftp.example.com", "drops", "ep1.srt") {
+		t.Errorf("unexpected target %s", target)
+	}
+}
+
+func TestFetchRemoteInputsKeepsLocalPaths(t *testing.T) {
+	local, cleanup, err := fetchRemoteInputs([]string{"a.srt", "dir"}, t.TempDir(), RemoteOptions{})
+	defer cleanup()
+	if err != nil || !slices.Equal(local, []string{"a.srt", "dir"}) {
+		t.Errorf("expected local paths unchanged, got %q: %v", local, err)
+	}
+	if _, _, err := fetchRemoteInputs([]string{"sftp:///no-host"}, t.TempDir(), RemoteOptions{}); err == nil {
+		t.Error("expected an error for a URL without a host")
+	}
+}
+
+func TestValidateInputsRemovesDownloadsOnFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the sftp stand-in uses sh")
+	}
+	tool := filepath.Join(t.TempDir(), "sftp")
+	// Reads "get -R REMOTE TARGET" from stdin and writes a file no parser accepts
+	if err := os.WriteFile(tool, []byte("#!/bin/sh\nread line\neval \"set -- $line\"\necho garbage > \"$4\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer func(program string) { sftpProgram = program }(sftpProgram)
+	sftpProgram = tool
+	temp := t.TempDir()
+	t.Setenv("TMPDIR", temp)
+
+	cv := NewCaptionValidator("http://test.com")
+	_, err := cv.validateInputs(context.Background(), []string{"sftp://exchange.example.com/drops/ep1.srt"}, Window{End: 60}, 80, "", RemoteOptions{}, false, BatchOptions{})
+	if err == nil {
+		t.Fatal("expected the downloaded file to fail validation")
+	}
+	if entries, _ := os.ReadDir(temp); len(entries) != 0 {
+		t.Errorf("expected the download directory removed, found %v", entries)
+	}
+}
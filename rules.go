@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ruleIDs gives every check a stable ID, grouped by area: 01 file structure,
+// 02 timing and coverage, 03 language, 04 cue text, 05 placement, 09 plugins and
+// classifiers.
+// IDs are never reused or renumbered, so suppressions keep working across releases.
+var ruleIDs = map[string]string{
+	"timestamp_range":              "CV0101",
+	"partial_parse":                "CV0102",
+	"format_mismatch":              "CV0103",
+	"format_redetected":            "CV0106",
+	"mixed_format_content":         "CV0104",
+	"duplicate_cue":                "CV0105",
+	"caption_coverage":             "CV0201",
+	"caption_sync":                 "CV0202",
+	"speaker_coverage":             "CV0203",
+	"cue_flash":                    "CV0204",
+	"low_dialogue_density":         "CV0205",
+	"stuck_caption":                "CV0206",
+	"percentile_target":            "CV0207",
+	"implausible_duration":         "CV0208",
+	"incorrect_language":           "CV0301",
+	"language_tag_mismatch":        "CV0302",
+	"metadata_language_mismatch":   "CV0303",
+	"quality_suspect":              "CV0304",
+	"annotation_language_mismatch": "CV0305",
+	"detector_capability":          "CV0306",
+	"detector_protocol_error":      "CV0307",
+	"language_detection_failed":    "CV0308",
+	"unknown_detected_language":    "CV0309",
+	"markup_error":                 "CV0401",
+	"invisible_character":          "CV0402",
+	"punctuation_style":            "CV0403",
+	"locale_format":                "CV0404",
+	"segmentation_quality":         "CV0405",
+	"low_asr_confidence":           "CV0406",
+	"unsafe_position":              "CV0501",
+	"graphic_collision":            "CV0502",
+	"line_overflow":                "CV0503",
+	"plugin_error":                 "CV0901",
+	"classifier_flagged":           "CV0902",
+	"classifier_failed":            "CV0903",
+}
+
+// gatingRules decide whether a file is delivered at all, so a file's own cv-disable
+// comments cannot switch them off; only the operator's -disable can
+var gatingRules = map[string]bool{
+	ruleIDs["caption_coverage"]:          true,
+	ruleIDs["incorrect_language"]:        true,
+	ruleIDs["language_detection_failed"]: true,
+	ruleIDs["unknown_detected_language"]: true,
+}
+
+// Suppression scopes of a cv-disable comment
+const (
+	ScopeNextCue = "next-cue"
+	ScopeFile    = "file"
+)
+
+// suppressionPrefix starts a suppression comment inside a WebVTT NOTE block
+const suppressionPrefix = "cv-disable"
+
+// resolveRule returns the rule ID for an ID or an issue type such as "markup_error"
+func resolveRule(name string) (string, bool) {
+	if id, ok := ruleIDs[name]; ok {
+		return id, true
+	}
+	for _, id := range ruleIDs {
+		if strings.EqualFold(id, name) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// parseRuleList parses the -disable flag: rule IDs or issue types separated by commas
+func parseRuleList(list string) (map[string]bool, error) {
+	rules := map[string]bool{}
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		id, ok := resolveRule(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown rule %q (use an ID such as CV0401 or a type such as markup_error)", name)
+		}
+		rules[id] = true
+	}
+	return rules, nil
+}
+
+// parseSuppression reads a WebVTT NOTE block such as "NOTE cv-disable CV0403 next-cue".
+// Rules are separated by spaces or commas and may be IDs or issue types; the scope
+// is next-cue unless "file" is given. Unknown rules are ignored.
+func parseSuppression(lines []string) (rules []string, scope string, ok bool) {
+	if len(lines) == 0 || !isWebVTTMetadataBlock(lines[0]) || !strings.HasPrefix(lines[0], "NOTE") {
+		return nil, "", false
+	}
+	text := strings.TrimSpace(strings.TrimPrefix(strings.Join(lines, " "), "NOTE"))
+	directive, found := strings.CutPrefix(text, suppressionPrefix)
+	if !found {
+		return nil, "", false
+	}
+
+	scope = ScopeNextCue
+	for _, token := range strings.FieldsFunc(directive, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+		switch token {
+		case ScopeNextCue, ScopeFile:
+			scope = token
+		default:
+			if id, known := resolveRule(token); known {
+				rules = append(rules, id)
+			}
+		}
+	}
+	return rules, scope, true
+}
+
+// readFileSuppressions returns the rules a WebVTT file disables for the whole file,
+// or nil unless -inline_disable lets files suppress rules
+func (cv *CaptionValidator) readFileSuppressions(filepath, format string) map[string]bool {
+	if !cv.inlineRules || format != "webvtt" {
+		return nil
+	}
+	cv.openFiles.acquire()
+	defer cv.openFiles.release()
+	file, err := cv.openCaption(filepath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var rules map[string]bool
+	for block, err := range scanBlocks(file) {
+		if err != nil {
+			break
+		}
+		if ids, scope, ok := parseSuppression(block.Lines); ok && scope == ScopeFile {
+			if rules == nil {
+				rules = map[string]bool{}
+			}
+			for _, id := range ids {
+				rules[id] = true
+			}
+		}
+	}
+	return rules
+}
+
+// issueRule returns the rule of a validation result, setting it from the type on
+// built-in issues. Plugin results keep whatever "rule" they report.
+func issueRule(issue interface{}) string {
+	if result, ok := issue.(map[string]interface{}); ok {
+		rule, _ := result["rule"].(string)
+		return rule
+	}
+	v := reflect.ValueOf(issue)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return ""
+	}
+	typ, rule := v.Elem().FieldByName("Type"), v.Elem().FieldByName("Rule")
+	if !typ.IsValid() || !rule.IsValid() || !rule.CanSet() {
+		return ""
+	}
+	if rule.String() == "" {
+		rule.SetString(ruleIDs[typ.String()])
+	}
+	return rule.String()
+}
+
+// issueField returns a named field of a validation result: a struct field of a
+// built-in issue, or a JSON key of a plugin result
+func issueField(issue interface{}, field, key string) interface{} {
+	if result, ok := issue.(map[string]interface{}); ok {
+		return result[key]
+	}
+	v := reflect.ValueOf(issue)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	if f := v.Elem().FieldByName(field); f.IsValid() {
+		return f.Interface()
+	}
+	return nil
+}
+
+// sortIssues orders a file's issues by type, then by start time, so reports of
+// the same file are identical from run to run and diff cleanly. Issues without a
+// start time sort first within their type; ties keep the order checks ran in.
+func sortIssues(issues []interface{}) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		typeI, _ := issueField(issues[i], "Type", "type").(string)
+		typeJ, _ := issueField(issues[j], "Type", "type").(string)
+		if typeI != typeJ {
+			return typeI < typeJ
+		}
+		startI, _ := issueField(issues[i], "StartTime", "start_time").(float64)
+		startJ, _ := issueField(issues[j], "StartTime", "start_time").(float64)
+		return startI < startJ
+	})
+}
+
+// issueFix returns a built-in validation result's suggested fix, or nil
+func issueFix(issue interface{}) *SuggestedFix {
+	fix, _ := issueField(issue, "SuggestedFix", "").(*SuggestedFix)
+	return fix
+}
+
+// issueCues returns the cues a validation result's suggested fix targets
+func issueCues(issue interface{}) []int {
+	if fix := issueFix(issue); fix != nil {
+		return fix.Cues
+	}
+	return nil
+}
+
+// suppressIssues sets the rule of every issue and drops those disabled by -disable,
+// and with -inline_disable by a file-scope comment or by next-cue comments on every
+// cue they target; comments never drop gating rules. Cue numbers index cues;
+// duplicate_cue numbers index parsed, before duplicates were removed. It returns
+// the kept issues and the IDs of those dropped.
+func (cv *CaptionValidator) suppressIssues(issues []interface{}, cues, parsed []Caption, fileRules map[string]bool) ([]interface{}, []string) {
+	kept := issues[:0]
+	var suppressed []string
+	for _, issue := range issues {
+		rule := issueRule(issue)
+		inline := cv.inlineRules && !gatingRules[rule] && (fileRules[rule] || cuesSuppress(issue, rule, cues, parsed))
+		if rule != "" && (cv.disabled[rule] || inline) {
+			suppressed = append(suppressed, rule)
+			continue
+		}
+		kept = append(kept, issue)
+	}
+	sort.Strings(suppressed)
+	return kept, suppressed
+}
+
+// cuesSuppress reports whether every cue an issue targets disables its rule
+func cuesSuppress(issue interface{}, rule string, cues, parsed []Caption) bool {
+	targets := issueCues(issue)
+	if _, ok := issue.(*DuplicateCueWarning); ok {
+		cues = parsed
+	}
+	if len(targets) == 0 {
+		return false
+	}
+	for _, cue := range targets {
+		if cue < 1 || cue > len(cues) || !slices.Contains(strings.Fields(cues[cue-1].Suppressed), rule) {
+			return false
+		}
+	}
+	return true
+}
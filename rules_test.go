@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseSuppression(t *testing.T) {
+	cases := []struct {
+		lines []string
+		rules []string
+		scope string
+		ok    bool
+	}{
+		{[]string{"NOTE cv-disable CV0301 next-cue"}, []string{"CV0301"}, ScopeNextCue, true},
+		{[]string{"NOTE cv-disable cv0403,markup_error"}, []string{"CV0403", "CV0401"}, ScopeNextCue, true},
+		{[]string{"NOTE", "cv-disable CV0402 file"}, []string{"CV0402"}, ScopeFile, true},
+		{[]string{"NOTE cv-disable CV9999"}, nil, ScopeNextCue, true},
+		{[]string{"NOTE reviewed by QC"}, nil, "", false},
+		{[]string{"00:00:01.000 --> 00:00:02.000", "cv-disable CV0301"}, nil, "", false},
+	}
+	for _, c := range cases {
+		rules, scope, ok := parseSuppression(c.lines)
+		if !reflect.DeepEqual(rules, c.rules) || scope != c.scope || ok != c.ok {
+			t.Errorf("parseSuppression(%q) = %v, %q, %v, want %v, %q, %v", c.lines, rules, scope, ok, c.rules, c.scope, c.ok)
+		}
+	}
+}
+
+func TestParseRuleList(t *testing.T) {
+	rules, err := parseRuleList("CV0403, markup_error,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rules, map[string]bool{"CV0403": true, "CV0401": true}) {
+		t.Errorf("unexpected rules %v", rules)
+	}
+	if _, err := parseRuleList("CV0403,no_such_check"); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+}
+
+func TestRuleIDsAreUnique(t *testing.T) {
+	seen := map[string]string{}
+	for issueType, id := range ruleIDs {
+		if other, ok := seen[id]; ok {
+			t.Errorf("%s and %s share rule %s", issueType, other, id)
+		}
+		seen[id] = issueType
+	}
+}
+
+func TestValidateSuppressesRules(t *testing.T) {
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	defer detector.Close()
+
+	inline := true
+	validate := func(content string, disabled map[string]bool) *FileReport {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "captions.vtt")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cv := NewCaptionValidator(detector.URL)
+		cv.punctuation = PunctuationStyle{Dash: DashEm}
+		cv.disabled = disabled
+		cv.inlineRules = inline
+		report, err := cv.Validate(path, Window{Start: 0, End: 4}, 80)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+	types := func(report *FileReport) []string {
+		var found []string
+		for _, issue := range report.Errors {
+			data, _ := json.Marshal(issue)
+			var fields map[string]interface{}
+			json.Unmarshal(data, &fields)
+			found = append(found, fields["type"].(string)+" "+fields["rule"].(string))
+		}
+		return found
+	}
+
+	cues := "00:00:00.000 --> 00:00:02.000\nWait -- what\n\n00:00:02.000 --> 00:00:04.000\nFine -- go on\n"
+
+	// A next-cue comment covers one cue, so an issue on two cues is still reported
+	report := validate("WEBVTT\n\nNOTE cv-disable CV0403\n\n"+cues, nil)
+	if found := types(report); !reflect.DeepEqual(found, []string{"punctuation_style CV0403"}) || report.Suppressed != nil {
+		t.Errorf("expected the punctuation warning with its rule, got %v, suppressed %v", found, report.Suppressed)
+	}
+
+	report = validate("WEBVTT\n\nNOTE cv-disable punctuation_style\n\n00:00:00.000 --> 00:00:02.000\nWait -- what\n\nNOTE cv-disable CV0403 next-cue\n\n00:00:02.000 --> 00:00:04.000\nFine -- go on\n", nil)
+	if found := types(report); found != nil || !reflect.DeepEqual(report.Suppressed, []string{"CV0403"}) {
+		t.Errorf("expected the warning to be suppressed, got %v, suppressed %v", found, report.Suppressed)
+	}
+
+	report = validate("WEBVTT\n\nNOTE cv-disable CV0403 file\n\n"+cues, nil)
+	if found := types(report); found != nil || !reflect.DeepEqual(report.Suppressed, []string{"CV0403"}) {
+		t.Errorf("expected a file-scope comment to suppress the warning, got %v", found)
+	}
+
+	// A file cannot switch off the rules that decide whether it is delivered
+	report = validate("WEBVTT\n\nNOTE cv-disable caption_coverage incorrect_language file\n\n00:00:00.000 --> 00:00:01.000\nHi\n", nil)
+	if found := types(report); !reflect.DeepEqual(found, []string{"caption_coverage CV0201"}) || report.Suppressed != nil {
+		t.Errorf("expected caption_coverage to be reported, got %v, suppressed %v", found, report.Suppressed)
+	}
+
+	// Without -inline_disable the comments are ignored
+	inline = false
+	report = validate("WEBVTT\n\nNOTE cv-disable CV0403 file\n\n"+cues, nil)
+	if found := types(report); !reflect.DeepEqual(found, []string{"punctuation_style CV0403"}) || report.Suppressed != nil {
+		t.Errorf("expected the comment to be ignored, got %v, suppressed %v", found, report.Suppressed)
+	}
+
+	report = validate("WEBVTT\n\n"+cues, map[string]bool{"CV0403": true})
+	if found := types(report); found != nil || !reflect.DeepEqual(report.Suppressed, []string{"CV0403"}) {
+		t.Errorf("expected -disable to suppress the warning, got %v", found)
+	}
+}
+
+func TestSortIssues(t *testing.T) {
+	late := map[string]interface{}{"type": "brand_name", "start_time": 40.0}
+	early := map[string]interface{}{"type": "brand_name", "start_time": 10.0}
+	coverage := &CaptionCoverageError{Type: "caption_coverage"}
+	language := &IncorrectLanguageError{Type: "incorrect_language"}
+	first := &TimestampRangeError{Type: "timestamp_range", Line: 3}
+	second := &TimestampRangeError{Type: "timestamp_range", Line: 9}
+	issues := []interface{}{language, first, late, second, coverage, early}
+	sortIssues(issues)
+	expected := []interface{}{early, late, coverage, language, first, second}
+	for i := range expected {
+		if fmt.Sprint(issues[i]) != fmt.Sprint(expected[i]) {
+			t.Fatalf("expected issue %d to be %+v, got %+v", i, expected[i], issues[i])
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FormatSCC is a Scenarist SCC file of CEA-608 caption data
+const FormatSCC = "scc"
+
+// sccHeader starts every SCC file
+const sccHeader = "Scenarist_SCC V1.0"
+
+// sccRate is the NTSC rate SCC timecode counts and 608 byte pairs are sent at, one
+// pair per frame
+var sccRate = FrameRate{Num: 30000, Den: 1001}
+
+// 608 caption modes
+const (
+	sccPopOn   = "pop-on"   // text is built off screen and shown at once by EOC
+	sccRollUp  = "roll-up"  // text appears as it arrives on a base row, scrolling up at CR
+	sccPaintOn = "paint-on" // text appears as it arrives, anywhere on screen
+)
+
+// sccBasicChars are the standard characters 608 puts where ASCII has others
+var sccBasicChars = map[byte]rune{
+	0x2A: 'á', 0x5C: 'é', 0x5E: 'í', 0x5F: 'ó', 0x60: 'ú',
+	0x7B: 'ç', 0x7C: '÷', 0x7D: 'Ñ', 0x7E: 'ñ', 0x7F: '█',
+}
+
+// sccSpecialChars are the special characters, 0x30 to 0x3F after 0x11; 0x39 is the
+// transparent space
+var sccSpecialChars = []rune("®°½¿™¢£♪à èâêîôû")
+
+// sccExtendedChars are the extended characters, 0x20 to 0x3F after 0x12 (Spanish and
+// French) or 0x13 (Portuguese and German). Each replaces the standard character sent
+// before it as a fallback for older decoders.
+var sccExtendedChars = map[byte][]rune{
+	0x12: []rune("ÁÉÓÚÜü‘¡*'—©℠•“”ÀÂÇÈÊËëÎÏïÔÙùÛ«»"),
+	0x13: []rune("ÃãÍÌìÒòÕõ{}\\^_|~ÄäÖöß¥¤│ÅåØø┌┐└┘"),
+}
+
+// sccPACRows is the first row of the two each preamble address code's first byte
+// (channel 1) addresses; second bytes with 0x20 set address the second
+var sccPACRows = map[byte]int{0x11: 1, 0x12: 3, 0x15: 5, 0x16: 7, 0x17: 9, 0x10: 11, 0x13: 12, 0x14: 14}
+
+// sccDecoder turns channel 1 of a 608 byte pair stream into cues as a decoder would
+// show them: a cue starts whenever the text on screen is replaced and ends when it
+// is erased or replaced again. Text arriving in roll-up or paint-on mode extends the
+// cue on screen rather than starting a new one; a roll-up CR starts a new one.
+type sccDecoder struct {
+	cv        *CaptionValidator
+	mode      string
+	rollRows  int // rows kept on screen in roll-up mode
+	row       int // row the cursor is on
+	displayed map[int][]rune
+	hidden    map[int][]rune // pop-on text waiting for EOC
+	channel   int            // data channel the last control code addressed
+	last      [2]byte        // previous control code, as 608 sends each one twice
+	shown     *Caption       // cue on screen, without its end time
+	cues      []Caption
+}
+
+func newSCCDecoder(cv *CaptionValidator) *sccDecoder {
+	return &sccDecoder{cv: cv, mode: sccPopOn, row: 15, channel: 1, displayed: map[int][]rune{}, hidden: map[int][]rune{}}
+}
+
+// sccRowsText returns the text of a memory's rows, top to bottom
+func sccRowsText(rows map[int][]rune) []string {
+	var lines []string
+	for _, row := range slices.Sorted(maps.Keys(rows)) {
+		if line := strings.TrimSpace(string(rows[row])); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// end takes the cue on screen down at t
+func (d *sccDecoder) end(t float64) {
+	if d.shown != nil && t > d.shown.StartTime {
+		d.shown.EndTime = t
+		d.cues = append(d.cues, *d.shown)
+	}
+	d.shown = nil
+}
+
+// show puts the displayed memory on screen at t: as a new cue when replace is set or
+// nothing is shown, otherwise as the text of the cue already on screen
+func (d *sccDecoder) show(t float64, replace bool) {
+	lines := sccRowsText(d.displayed)
+	if replace || len(lines) == 0 {
+		d.end(t)
+	}
+	if len(lines) == 0 {
+		return
+	}
+	if d.shown == nil {
+		d.shown = &Caption{StartTime: t}
+	}
+	d.shown.Text = strings.Join(lines, " ")
+	d.shown.Lines = len(lines)
+	d.shown.Layout = d.cv.cueLayout(lines)
+}
+
+// memory is the memory text is written to in the current mode
+func (d *sccDecoder) memory() map[int][]rune {
+	if d.mode == sccPopOn {
+		return d.hidden
+	}
+	return d.displayed
+}
+
+// write adds characters at the cursor; backspace first removes the one before it
+func (d *sccDecoder) write(t float64, backspace bool, chars ...rune) {
+	memory := d.memory()
+	row := memory[d.row]
+	if backspace && len(row) > 0 {
+		row = row[:len(row)-1]
+	}
+	memory[d.row] = append(row, chars...)
+	if d.mode != sccPopOn {
+		d.show(t, false)
+	}
+}
+
+// pair decodes one byte pair sent at t. Parity bits are dropped; pairs of a control
+// code repeated straight after itself are skipped.
+func (d *sccDecoder) pair(t float64, b1, b2 byte) {
+	b1, b2 = b1&0x7F, b2&0x7F
+	if b1 == 0 && b2 == 0 {
+		return // padding
+	}
+	if b1 < 0x10 || b1 > 0x1F {
+		d.last = [2]byte{}
+		if d.channel == 1 {
+			var chars []rune
+			for _, b := range []byte{b1, b2} {
+				if b < 0x20 {
+					continue
+				}
+				if r, ok := sccBasicChars[b]; ok {
+					chars = append(chars, r)
+				} else {
+					chars = append(chars, rune(b))
+				}
+			}
+			if len(chars) > 0 {
+				d.write(t, false, chars...)
+			}
+		}
+		return
+	}
+	if d.last == [2]byte{b1, b2} {
+		d.last = [2]byte{}
+		return
+	}
+	d.last = [2]byte{b1, b2}
+	d.channel = 1
+	if b1 >= 0x18 {
+		d.channel = 2
+		return
+	}
+
+	switch {
+	case b1 == 0x11 && b2 >= 0x20 && b2 <= 0x2F:
+		d.write(t, false, ' ') // mid-row style change, shown as a space
+	case b1 == 0x11 && b2 >= 0x30 && b2 <= 0x3F:
+		d.write(t, false, sccSpecialChars[b2-0x30])
+	case (b1 == 0x12 || b1 == 0x13) && b2 >= 0x20 && b2 <= 0x3F:
+		d.write(t, true, sccExtendedChars[b1][b2-0x20])
+	case (b1 == 0x14 || b1 == 0x15) && b2 >= 0x20 && b2 <= 0x2F:
+		d.command(t, b2)
+	case b2 >= 0x40:
+		if row, ok := sccPACRows[b1]; ok {
+			if b2&0x20 != 0 {
+				row++
+			}
+			if d.mode != sccRollUp {
+				d.row = row // roll-up text stays on its base row
+			}
+		}
+	}
+}
+
+// command runs a miscellaneous control code
+func (d *sccDecoder) command(t float64, code byte) {
+	switch code {
+	case 0x20: // RCL, resume caption loading
+		d.mode = sccPopOn
+	case 0x25, 0x26, 0x27: // RU2, RU3, RU4
+		if d.mode != sccRollUp {
+			d.displayed, d.hidden = map[int][]rune{}, map[int][]rune{}
+			d.end(t)
+			d.row = 15
+		}
+		d.mode, d.rollRows = sccRollUp, int(code-0x25)+2
+	case 0x29: // RDC, resume direct captioning
+		d.mode = sccPaintOn
+	case 0x21: // BS
+		d.write(t, true)
+	case 0x24: // DER, delete to end of row: the cursor is always at the end here
+	case 0x2C: // EDM, erase displayed memory
+		d.displayed = map[int][]rune{}
+		d.end(t)
+	case 0x2E: // ENM, erase non-displayed memory
+		d.hidden = map[int][]rune{}
+	case 0x2F: // EOC, end of caption: swap memories
+		d.displayed, d.hidden = d.hidden, d.displayed
+		d.show(t, true)
+	case 0x2D: // CR
+		if d.mode != sccRollUp {
+			return
+		}
+		rolled := map[int][]rune{}
+		for row, text := range d.displayed {
+			if row-1 > d.row-d.rollRows {
+				rolled[row-1] = text
+			}
+		}
+		d.displayed = rolled
+		d.show(t, true)
+	}
+}
+
+// sccCues decodes an SCC file into cues. Lines with a bad timecode or byte pairs
+// are returned as parse failures, and a cue still on screen at the end of the file
+// ends with its last byte pair.
+func (cv *CaptionValidator) sccCues(source io.Reader) iter.Seq2[Caption, error] {
+	return func(yield func(Caption, error) bool) {
+		decoder := newSCCDecoder(cv)
+		var failures []ParseFailure
+		lastTime := 0.0
+		sawHeader := false
+		scanner := bufio.NewScanner(source)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for lineNo := 1; scanner.Scan(); lineNo++ {
+			line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+			if line == "" {
+				continue
+			}
+			if !sawHeader {
+				if line != sccHeader {
+					yield(Caption{}, fmt.Errorf("not an SCC file: expected %q on the first line", sccHeader))
+					return
+				}
+				sawHeader = true
+				continue
+			}
+			fields := strings.Fields(line)
+			frames, err := timecodeFrames(fields[0], sccRate, false)
+			if err != nil {
+				failures = append(failures, ParseFailure{Line: lineNo, Kind: FailureTimestamp, Text: line, Reason: err.Error()})
+				continue
+			}
+			for i, word := range fields[1:] {
+				value, err := strconv.ParseUint(word, 16, 16)
+				if err != nil || len(word) != 4 {
+					failures = append(failures, ParseFailure{Line: lineNo, Kind: FailureInvalidData, Text: line, Reason: fmt.Sprintf("invalid byte pair %q", word)})
+					break
+				}
+				lastTime = sccRate.seconds(frames + int64(i))
+				decoder.pair(lastTime, byte(value>>8), byte(value))
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			yield(Caption{}, fmt.Errorf("failed to read file: %w", err))
+			return
+		}
+		decoder.end(lastTime + sccRate.seconds(1))
+
+		for i := range failures {
+			if !yield(Caption{}, &failures[i]) {
+				return
+			}
+		}
+		for _, caption := range decoder.cues {
+			if !yield(caption, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sccLine writes byte pairs as an SCC line, with the odd parity bits 608 sends
+func sccLine(timecode string, pairs ...uint16) string {
+	parity := func(b byte) byte {
+		if bits.OnesCount8(b)%2 == 0 {
+			return b | 0x80
+		}
+		return b
+	}
+	words := []string{timecode}
+	for _, pair := range pairs {
+		words = append(words, fmt.Sprintf("%02x%02x", parity(byte(pair>>8)), parity(byte(pair))))
+	}
+	return strings.Join(words, " ")
+}
+
+func TestSCCCues(t *testing.T) {
+	content := strings.Join([]string{
+		sccHeader,
+		"",
+		// Pop-on: RCL, PAC row 15, "Hello", a music note, EOC; each control code twice
+		sccLine("00:00:01:00", 0x1420, 0x1420, 0x1470, 0x1470, 0x4865, 0x6c6c, 0x6f20, 0x1137, 0x1137, 0x142f, 0x142f),
+		sccLine("00:00:04:00", 0x142c, 0x142c),
+		// Roll-up: RU2, CR, then "Hi", a CR and "Yo" joining it on screen
+		sccLine("00:00:05:00", 0x1425, 0x1425, 0x142d, 0x142d, 0x4869),
+		sccLine("00:00:06:00", 0x142d, 0x142d, 0x596f),
+		sccLine("00:00:08:00", 0x142c, 0x142c),
+		// Paint-on: RDC, "Caf", "e" replaced by the extended É
+		sccLine("00:00:09:00", 0x1429, 0x1429, 0x1470, 0x1470, 0x4361, 0x6665, 0x1221, 0x1221),
+		sccLine("00:00:10:00", 0x142c, 0x142c),
+		"00:00:11:00 94zz",
+		"00:00:99:00 942c",
+	}, "\n")
+	captions, failures, err := collectCues(NewCaptionValidator("").Cues(strings.NewReader(content), FormatSCC), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(frames int64) float64 { return sccRate.seconds(frames) }
+	want := []Caption{
+		{StartTime: at(30 + 9), EndTime: at(120), Text: "Hello ♪", Lines: 1},
+		{StartTime: at(150 + 4), EndTime: at(180), Text: "Hi", Lines: 1},
+		{StartTime: at(180), EndTime: at(240), Text: "Hi Yo", Lines: 2},
+		{StartTime: at(270 + 4), EndTime: at(300), Text: "CafÉ", Lines: 1},
+	}
+	if len(captions) != len(want) {
+		t.Fatalf("expected %d cues, got %+v", len(want), captions)
+	}
+	for i, caption := range captions {
+		if math.Abs(caption.StartTime-want[i].StartTime) > 1e-9 || math.Abs(caption.EndTime-want[i].EndTime) > 1e-9 || caption.Text != want[i].Text || caption.Lines != want[i].Lines {
+			t.Errorf("cue %d = %+v, want %+v", i, caption, want[i])
+		}
+	}
+	if len(failures) != 2 || failures[0].Kind != FailureInvalidData || failures[0].Line != 10 || failures[1].Kind != FailureTimestamp {
+		t.Errorf("expected an invalid_data and a timestamp_range failure, got %+v", failures)
+	}
+
+	if _, _, err := collectCues(NewCaptionValidator("").Cues(strings.NewReader("WEBVTT\n"), FormatSCC), 0); err == nil {
+		t.Error("expected a file without the SCC header to be rejected")
+	}
+}
+
+func TestValidateSCCFile(t *testing.T) {
+	content := strings.Join([]string{
+		sccHeader,
+		"",
+		sccLine("00:00:00:00", 0x1420, 0x1420, 0x1470, 0x1470, 0x4865, 0x6c6c, 0x6f00, 0x142f, 0x142f),
+		sccLine("00:00:05:00", 0x142c, 0x142c),
+		// Left on screen at the end of the file
+		sccLine("00:00:06:00", 0x1425, 0x1425, 0x4279, 0x6521),
+	}, "\r\n") + "\r\n"
+	path := filepath.Join(t.TempDir(), "master.scc")
+	os.WriteFile(path, []byte(content), 0o644)
+
+	report, err := NewCaptionValidator("").Validate(path, Window{End: 10}, 45)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Format != FormatSCC || report.Cues != 2 || len(report.Errors) != 0 {
+		t.Errorf("unexpected report %+v, errors %v", report, report.Errors)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// SegmentationQualityWarning reports cue segmentation metrics that exceed configured thresholds
+type SegmentationQualityWarning struct {
+	Type               string        `json:"type"`
+	Rule               string        `json:"rule"`
+	Severity           string        `json:"severity"`
+	TotalCues          int           `json:"total_cues"`
+	MidSentencePercent float64       `json:"mid_sentence_percent"`
+	OneWordPercent     float64       `json:"one_word_percent"`
+	ClauseBreakPercent float64       `json:"clause_break_percent"`
+	Violations         []string      `json:"violations"`
+	Description        string        `json:"description"`
+	SuggestedFix       *SuggestedFix `json:"suggested_fix,omitempty"`
+}
+
+// SegmentationThresholds holds the maximum allowed percentage for each metric (0 disables a metric)
+type SegmentationThresholds struct {
+	MidSentence float64
+	OneWord     float64
+	ClauseBreak float64
+}
+
+// enabled reports whether any segmentation threshold is configured
+func (st SegmentationThresholds) enabled() bool {
+	return st.MidSentence > 0 || st.OneWord > 0 || st.ClauseBreak > 0
+}
+
+// SegmentationMetrics summarizes how cleanly cue boundaries follow sentence structure
+type SegmentationMetrics struct {
+	TotalCues          int
+	MidSentencePercent float64
+	OneWordPercent     float64
+	ClauseBreakPercent float64
+
+	// 1-based numbers of the cues behind each metric
+	MidSentenceCues []int
+	OneWordCues     []int
+	ClauseBreakCues []int
+}
+
+const (
+	sentenceEndPunctuation = ".!?…♪"
+	clausePunctuation      = ",;:" + sentenceEndPunctuation
+)
+
+// measureSegmentation computes segmentation metrics using simple punctuation heuristics
+func measureSegmentation(captions []Caption) SegmentationMetrics {
+	var metrics SegmentationMetrics
+	total := 0
+	for i, caption := range captions {
+		text := strings.TrimSpace(caption.Text)
+		if text == "" {
+			continue
+		}
+		total++
+
+		body, last := splitLastRune(text)
+		if !strings.ContainsRune(sentenceEndPunctuation, last) {
+			metrics.MidSentenceCues = append(metrics.MidSentenceCues, i+1)
+		}
+		if len(captionWords(text)) == 1 {
+			metrics.OneWordCues = append(metrics.OneWordCues, i+1)
+		}
+		// A cue that ends without any punctuation but contains a clause boundary
+		// earlier was split at the wrong place; the internal boundary was a better break
+		if !strings.ContainsRune(clausePunctuation, last) && strings.ContainsAny(body, clausePunctuation) {
+			metrics.ClauseBreakCues = append(metrics.ClauseBreakCues, i+1)
+		}
+	}
+
+	metrics.TotalCues = total
+	if total > 0 {
+		metrics.MidSentencePercent = float64(len(metrics.MidSentenceCues)) / float64(total) * 100
+		metrics.OneWordPercent = float64(len(metrics.OneWordCues)) / float64(total) * 100
+		metrics.ClauseBreakPercent = float64(len(metrics.ClauseBreakCues)) / float64(total) * 100
+	}
+	return metrics
+}
+
+// splitLastRune returns text without its final rune, and that rune, ignoring closing quotes and brackets
+func splitLastRune(text string) (string, rune) {
+	trimmed := strings.TrimRight(text, "\"'”’)] ")
+	last, size := utf8.DecodeLastRuneInString(trimmed)
+	if size == 0 {
+		return "", 0
+	}
+	return trimmed[:len(trimmed)-size], last
+}
+
+// validateSegmentation compares segmentation metrics against thresholds
+func (cv *CaptionValidator) validateSegmentation(captions []Caption, thresholds SegmentationThresholds) *SegmentationQualityWarning {
+	metrics := measureSegmentation(captions)
+	if metrics.TotalCues == 0 {
+		return nil
+	}
+
+	var violations, details []string
+	flagged := make(map[int]bool)
+	check := func(name, label string, actual, limit float64, cues []int) {
+		if limit > 0 && actual > limit {
+			violations = append(violations, name)
+			details = append(details, fmt.Sprintf("%.2f%% %s (max %.2f%%)", actual, label, limit))
+			for _, cue := range cues {
+				flagged[cue] = true
+			}
+		}
+	}
+	check("mid_sentence", "of cues end mid-sentence", metrics.MidSentencePercent, thresholds.MidSentence, metrics.MidSentenceCues)
+	check("one_word", "of cues are one word", metrics.OneWordPercent, thresholds.OneWord, metrics.OneWordCues)
+	check("clause_break", "of cues break across clause boundaries", metrics.ClauseBreakPercent, thresholds.ClauseBreak, metrics.ClauseBreakCues)
+
+	if len(violations) == 0 {
+		return nil
+	}
+	cues := make([]int, 0, len(flagged))
+	for cue := range flagged {
+		cues = append(cues, cue)
+	}
+	sort.Ints(cues)
+
+	return &SegmentationQualityWarning{
+		Type:               "segmentation_quality",
+		Severity:           SeverityWarning,
+		TotalCues:          metrics.TotalCues,
+		MidSentencePercent: metrics.MidSentencePercent,
+		OneWordPercent:     metrics.OneWordPercent,
+		ClauseBreakPercent: metrics.ClauseBreakPercent,
+		Violations:         violations,
+		Description:        "Segmentation quality issues: " + strings.Join(details, ", "),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixResegmentCues,
+			Cues:        cues,
+			Description: fmt.Sprintf("Re-segment %s at sentence or clause boundaries", cueRange(cues)),
+		},
+	}
+}
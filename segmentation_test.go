@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestMeasureSegmentation(t *testing.T) {
+	captions := []Caption{
+		{StartTime: 1.0, EndTime: 2.0, Text: "Hello there."},
+		{StartTime: 2.0, EndTime: 3.0, Text: "I went to the store, and then"},
+		{StartTime: 3.0, EndTime: 4.0, Text: "home"},
+		{StartTime: 4.0, EndTime: 5.0, Text: "\"Really?\""},
+	}
+
+	metrics := measureSegmentation(captions)
+	if metrics.TotalCues != 4 {
+		t.Errorf("expected 4 cues, got %d", metrics.TotalCues)
+	}
+	if metrics.MidSentencePercent != 50 {
+		t.Errorf("expected 50%% mid-sentence, got %f", metrics.MidSentencePercent)
+	}
+	if metrics.OneWordPercent != 50 {
+		t.Errorf("expected 50%% one-word, got %f", metrics.OneWordPercent)
+	}
+	if metrics.ClauseBreakPercent != 25 {
+		t.Errorf("expected 25%% clause breaks, got %f", metrics.ClauseBreakPercent)
+	}
+}
+
+func TestValidateSegmentation(t *testing.T) {
+	cv := NewCaptionValidator("http://test.com")
+
+	captions := []Caption{
+		{StartTime: 1.0, EndTime: 2.0, Text: "Hello there."},
+		{StartTime: 2.0, EndTime: 3.0, Text: "Okay"},
+	}
+
+	if warn := cv.validateSegmentation(captions, SegmentationThresholds{OneWord: 60}); warn != nil {
+		t.Errorf("unexpected segmentation warning: %v", warn)
+	}
+
+	warn := cv.validateSegmentation(captions, SegmentationThresholds{OneWord: 40, MidSentence: 80})
+	if warn == nil {
+		t.Fatal("expected segmentation warning, got none")
+	}
+	if warn.Type != "segmentation_quality" {
+		t.Errorf("expected type 'segmentation_quality', got '%s'", warn.Type)
+	}
+	if len(warn.Violations) != 1 || warn.Violations[0] != "one_word" {
+		t.Errorf("expected only one_word violation, got %v", warn.Violations)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SegmentMap is a -segment_map: the content segments of a program, such as recap,
+// body and credits, and the coverage each type of segment must reach, so one run
+// checks them all
+type SegmentMap struct {
+	Thresholds map[string]float64 // required coverage per segment type
+	Segments   []MappedSegment    // in timeline order
+}
+
+// MappedSegment is one segment of a -segment_map
+type MappedSegment struct {
+	Type string
+	Window
+}
+
+// SegmentTypeCoverage is the coverage of all the segments of one type together
+type SegmentTypeCoverage struct {
+	Type             string  `json:"type"`
+	Segments         int     `json:"segments"`
+	RequiredCoverage float64 `json:"required_coverage"` // 0 when the type is only measured
+	Coverage         float64 `json:"coverage"`          // gating metric
+	CoveredSeconds   float64 `json:"covered_seconds"`
+	WindowSeconds    float64 `json:"window_seconds"`
+	Passed           bool    `json:"passed"`
+}
+
+// segmentMapFile is a -segment_map as written. Times are seconds, or strings in any
+// form -t_end takes or SMPTE timecode.
+type segmentMapFile struct {
+	Thresholds map[string]float64 `json:"thresholds"`
+	Segments   []struct {
+		Type  string          `json:"type"`
+		Start json.RawMessage `json:"start"`
+		End   json.RawMessage `json:"end"`
+	} `json:"segments"`
+}
+
+// loadSegmentMap reads a -segment_map JSON file, reading timecode at rate. Segment
+// types are case-insensitive, segments may not overlap, and every threshold must
+// name a type some segment has, so a misspelled type is not silently ungated.
+func loadSegmentMap(path string, rate FrameRate) (*SegmentMap, error) {
+	content, err := os.ReadFile(longPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read segment map: %w", err)
+	}
+	var file segmentMapFile
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	segmentMap := &SegmentMap{Thresholds: map[string]float64{}}
+	for name, threshold := range file.Thresholds {
+		if threshold < 0 || threshold > 100 {
+			return nil, fmt.Errorf("%s: threshold of %q must be a percentage between 0 and 100, got %g", path, name, threshold)
+		}
+		segmentMap.Thresholds[strings.ToLower(name)] = threshold
+	}
+	for i, segment := range file.Segments {
+		mapped := MappedSegment{Type: strings.ToLower(strings.TrimSpace(segment.Type))}
+		if mapped.Type == "" {
+			return nil, fmt.Errorf("%s: segment %d has no type", path, i+1)
+		}
+		if mapped.Start, err = parseSegmentTime(segment.Start, rate); err != nil {
+			return nil, fmt.Errorf("%s: segment %d start: %w", path, i+1, err)
+		}
+		if mapped.End, err = parseSegmentTime(segment.End, rate); err != nil {
+			return nil, fmt.Errorf("%s: segment %d end: %w", path, i+1, err)
+		}
+		if err := mapped.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: segment %d: %w", path, i+1, err)
+		}
+		segmentMap.Segments = append(segmentMap.Segments, mapped)
+	}
+	if len(segmentMap.Segments) == 0 {
+		return nil, fmt.Errorf("%s: no segments", path)
+	}
+	types := segmentMap.types()
+	for _, name := range slices.Sorted(maps.Keys(segmentMap.Thresholds)) {
+		if !slices.Contains(types, name) {
+			return nil, fmt.Errorf("%s: threshold for %q matches no segment type (%s)", path, name, strings.Join(types, ", "))
+		}
+	}
+	slices.SortStableFunc(segmentMap.Segments, func(a, b MappedSegment) int { return cmp.Compare(a.Start, b.Start) })
+	for i := 1; i < len(segmentMap.Segments); i++ {
+		if previous, segment := segmentMap.Segments[i-1], segmentMap.Segments[i]; segment.Start < previous.End {
+			return nil, fmt.Errorf("%s: %s segment %s overlaps %s segment %s", path, segment.Type, segment.Window, previous.Type, previous.Window)
+		}
+	}
+	return segmentMap, nil
+}
+
+// parseSegmentTime reads a segment map time: seconds, a timestamp or SMPTE timecode
+func parseSegmentTime(raw json.RawMessage, rate FrameRate) (float64, error) {
+	if len(raw) == 0 {
+		return 0, fmt.Errorf("missing")
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		value = string(raw)
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return 0, fmt.Errorf("invalid time %s", raw)
+		}
+	}
+	seconds, err := parseTimestamp(value)
+	if err != nil {
+		frames, tcErr := timecodeFrames(value, rate, false)
+		if tcErr != nil {
+			return 0, fmt.Errorf("invalid time %q: expected seconds, HH:MM:SS.mmm, HH:MM:SS:FF or a duration like 44m", value)
+		}
+		seconds = rate.seconds(frames)
+	}
+	return seconds, nil
+}
+
+// span is the window from the start of the first segment to the end of the last
+func (segmentMap *SegmentMap) span() Window {
+	return Window{Start: segmentMap.Segments[0].Start, End: segmentMap.Segments[len(segmentMap.Segments)-1].End}
+}
+
+// types returns the segment types in the order they first appear
+func (segmentMap *SegmentMap) types() []string {
+	var types []string
+	for _, segment := range segmentMap.Segments {
+		if !slices.Contains(types, segment.Type) {
+			types = append(types, segment.Type)
+		}
+	}
+	return types
+}
+
+// validateSegmentMap measures the coverage of each segment type of the -segment_map
+// over its segments together, against the type's threshold, or requiredCoverage for
+// types the map gives none. A type with a threshold of 0 is measured but never fails.
+// Each type below its threshold is a caption_coverage error of its own.
+func (cv *CaptionValidator) validateSegmentMap(captions []Caption, requiredCoverage float64) ([]SegmentTypeCoverage, []*CaptionCoverageError) {
+	var results []SegmentTypeCoverage
+	var errs []*CaptionCoverageError
+	for _, segmentType := range cv.segmentMap.types() {
+		threshold, ok := cv.segmentMap.Thresholds[segmentType]
+		if !ok {
+			threshold = requiredCoverage
+		}
+		var windows []Window
+		var parts []CoverageMetrics
+		for _, segment := range cv.segmentMap.Segments {
+			if segment.Type == segmentType {
+				windows = append(windows, segment.Window)
+				parts = append(parts, measureCoverage(captions, segment.Window, cv.minReadable, cv.coverageMetric))
+			}
+		}
+		span := Window{Start: windows[0].Start, End: windows[len(windows)-1].End}
+		metrics := cv.sumCoverage(parts, span)
+		actual := metrics.gatingValue()
+		passed := threshold == 0 || coveragePasses(actual, threshold, cv.tolerance)
+		results = append(results, SegmentTypeCoverage{
+			Type:             segmentType,
+			Segments:         len(windows),
+			RequiredCoverage: threshold,
+			Coverage:         actual,
+			CoveredSeconds:   metrics.CoveredSeconds,
+			WindowSeconds:    metrics.WindowSeconds,
+			Passed:           passed,
+		})
+		if passed {
+			continue
+		}
+		errs = append(errs, &CaptionCoverageError{
+			Type:             "caption_coverage",
+			Severity:         SeverityError,
+			RequiredCoverage: threshold,
+			ActualCoverage:   actual,
+			GatingMetric:     metrics.Gating,
+			WallClock:        metrics.WallClock,
+			DialogueWeighted: metrics.DialogueWeighted,
+			Tolerance:        cv.tolerance,
+			EndPadding:       cv.endPadding,
+			CoveredMs:        metrics.CoveredMs,
+			CoveredSeconds:   metrics.CoveredSeconds,
+			StartTime:        span.Start,
+			EndTime:          span.End,
+			Window:           span.String(),
+			SegmentType:      segmentType,
+			Description:      fmt.Sprintf("Caption coverage of %.2f%% in %s segments is below required %.2f%%", actual, segmentType, threshold),
+			SuggestedFix:     windowsCoverageFix(captions, windows),
+		})
+	}
+	return results, errs
+}
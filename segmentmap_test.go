@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSegmentMap(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "segments.json")
+		os.WriteFile(path, []byte(content), 0o644)
+		return path
+	}
+
+	segmentMap, err := loadSegmentMap(write(`{
+		"thresholds": {"Program": 95, "recap": 80},
+		"segments": [
+			{"type": "program", "start": "1m30s", "end": "00:10:00:00"},
+			{"type": "recap", "start": 0, "end": "00:01:30.000"},
+			{"type": "Credits", "start": 600, "end": 660.5}
+		]
+	}`), FrameRate{Num: 25, Den: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segmentMap.Segments) != 3 || segmentMap.Segments[0].Type != "recap" || segmentMap.Segments[1].End != 600 || segmentMap.Segments[2].Type != "credits" {
+		t.Errorf("unexpected segments %+v", segmentMap.Segments)
+	}
+	if segmentMap.Thresholds["program"] != 95 || segmentMap.span() != (Window{Start: 0, End: 660.5}) {
+		t.Errorf("unexpected map %+v", segmentMap)
+	}
+
+	for content, message := range map[string]string{
+		`{"segments": []}`: "no segments",
+		`{"segments": [{"type": "recap", "start": 0, "end": 90}, {"type": "program", "start": 60, "end": 120}]}`: "overlaps",
+		`{"segments": [{"start": 0, "end": 90}]}`:                                                  "has no type",
+		`{"segments": [{"type": "recap", "start": "soon", "end": 90}]}`:                            "invalid time",
+		`{"segments": [{"type": "recap", "start": 90, "end": 30}]}`:                                "window end must be greater",
+		`{"thresholds": {"credit": 90}, "segments": [{"type": "credits", "start": 0, "end": 90}]}`: `threshold for "credit" matches no segment type (credits)`,
+		`{"segments": [{"type": "recap", "start": 0, "end": "Inf"}]}`:                              "invalid time \"Inf\"",
+		`{"thresholds": {"recap": 120}, "segments": []}`:                                           "between 0 and 100",
+		`{"segment": [{"type": "recap", "start": 0, "end": 90}]}`:                                  "unknown field",
+	} {
+		if _, err := loadSegmentMap(write(content), FrameRate{Num: 24, Den: 1}); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("%s: expected an error containing %q, got %v", content, message, err)
+		}
+	}
+}
+
+func TestValidateSegmentMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ep1.srt")
+	os.WriteFile(path, []byte("1\n00:00:00,000 --> 00:00:09,000\nPreviously\n\n2\n00:00:10,000 --> 00:00:15,000\nHello\n\n3\n00:00:25,000 --> 00:00:30,000\nGoodbye\n"), 0o644)
+
+	cv := NewCaptionValidator("")
+	cv.segmentMap = &SegmentMap{
+		Thresholds: map[string]float64{"recap": 80, "credits": 0},
+		Segments: []MappedSegment{
+			{Type: "recap", Window: Window{Start: 0, End: 10}},
+			{Type: "program", Window: Window{Start: 10, End: 20}},
+			{Type: "program", Window: Window{Start: 25, End: 35}},
+			{Type: "credits", Window: Window{Start: 35, End: 40}},
+		},
+	}
+	report, err := cv.Validate(path, Window{End: 40}, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// recap meets its own threshold, program misses -coverage and credits are only measured
+	expected := []SegmentTypeCoverage{
+		{Type: "recap", Segments: 1, RequiredCoverage: 80, Coverage: 90, CoveredSeconds: 9, WindowSeconds: 10, Passed: true},
+		{Type: "program", Segments: 2, RequiredCoverage: 60, Coverage: 50, CoveredSeconds: 10, WindowSeconds: 20, Passed: false},
+		{Type: "credits", Segments: 1, RequiredCoverage: 0, Coverage: 0, CoveredSeconds: 0, WindowSeconds: 5, Passed: true},
+	}
+	if len(report.SegmentTypes) != len(expected) {
+		t.Fatalf("expected %d segment types, got %+v", len(expected), report.SegmentTypes)
+	}
+	for i := range expected {
+		if report.SegmentTypes[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], report.SegmentTypes[i])
+		}
+	}
+	if len(report.Errors) != 1 {
+		t.Fatalf("expected one coverage error, got %+v", report.Errors)
+	}
+	coverageErr, ok := report.Errors[0].(*CaptionCoverageError)
+	if !ok || coverageErr.SegmentType != "program" || coverageErr.Window != "00:00:10.000-00:00:35.000" || len(coverageErr.SuggestedFix.Gaps) != 2 {
+		t.Errorf("unexpected error %+v", report.Errors[0])
+	}
+	if gaps := coverageErr.SuggestedFix.Gaps; gaps[0] != (Window{Start: 15, End: 20}) || gaps[1] != (Window{Start: 30, End: 35}) {
+		t.Errorf("expected gaps inside the program segments only, got %+v", gaps)
+	}
+}
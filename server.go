@@ -0,0 +1,463 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Job states
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// openAPISpec documents the HTTP API; keep it in step with Handler
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// Job submission errors
+var (
+	ErrQueueFull    = errors.New("job queue is full")
+	ErrShuttingDown = errors.New("server is shutting down")
+)
+
+// Job is an asynchronous validation and, once finished, its report
+type Job struct {
+	ID       string      `json:"id"`
+	Status   string      `json:"status"`
+	Created  time.Time   `json:"created"`
+	Finished *time.Time  `json:"finished,omitempty"`
+	Report   *FileReport `json:"report,omitempty"`
+	Error    string      `json:"error,omitempty"`
+
+	request *validationRequest
+	tenant  *tenant // only the submitting tenant can poll the job
+}
+
+// validationRequest is an uploaded caption file and the settings to validate it with
+type validationRequest struct {
+	name     string // uploaded file name, reported as the report's file
+	path     string // temporary copy of the upload
+	format   string // format to read the upload as when it cannot be sniffed, from its Content-Type
+	window   Window
+	coverage float64
+	tenant   *tenant
+}
+
+// ServerOptions configures server mode
+type ServerOptions struct {
+	Workers   int            // concurrent job validations
+	QueueSize int            // jobs waiting beyond those running
+	JobTTL    time.Duration  // how long finished jobs stay available for polling
+	MaxUpload int64          // maximum request body size in bytes
+	Coverage  float64        // required coverage when a request does not set one
+	Tenants   []TenantConfig // API keys and per-tenant defaults; none disables authentication
+}
+
+// Server serves validation over HTTP, synchronously or through a bounded in-memory job queue
+type Server struct {
+	validator *CaptionValidator
+	opts      ServerOptions
+	queue     chan *Job
+
+	defaultTenant *tenant
+	tenants       map[[sha256.Size]byte]*tenant // by API key hash; nil when authentication is off
+
+	workers sync.WaitGroup
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	closing bool // set by Drain; no new jobs are accepted
+}
+
+// NewServer creates a server and starts its job workers
+func NewServer(cv *CaptionValidator, opts ServerOptions) (*Server, error) {
+	s := &Server{
+		validator:     cv,
+		opts:          opts,
+		queue:         make(chan *Job, max(opts.QueueSize, 0)),
+		defaultTenant: &tenant{name: "default", validator: cv, coverage: opts.Coverage},
+		jobs:          make(map[string]*Job),
+	}
+	if len(opts.Tenants) > 0 {
+		tenants, err := newTenants(cv, opts.Coverage, opts.Tenants)
+		if err != nil {
+			return nil, err
+		}
+		s.tenants = tenants
+	}
+
+	for range max(opts.Workers, 1) {
+		s.workers.Go(s.work)
+	}
+	go s.expireJobs()
+	return s, nil
+}
+
+// Handler routes the HTTP API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /validate", s.authenticate(s.handleValidate))
+	mux.HandleFunc("POST /jobs", s.authenticate(s.handleSubmit))
+	mux.HandleFunc("GET /jobs/{id}", s.authenticate(s.handleJob))
+	mux.HandleFunc("GET /usage", s.authenticate(s.handleUsage))
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPISpec)
+	})
+	return mux
+}
+
+// runServe implements the serve subcommand. When ctx is cancelled the server stops
+// taking jobs, lets queued and running jobs finish while results can still be
+// polled, then closes the listener and exits with exitInterrupted.
+func runServe(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "Address to listen on")
+	endpoint := fs.String("endpoint", "", "Language detection endpoint URL")
+	coverage := fs.Float64("coverage", 80, "Required coverage percentage when a request does not set one")
+	tolerance := fs.Float64("coverage_tolerance", 0, "Percentage points below the required coverage that still pass")
+	locale := fs.String("locale", "en", "Language of the human-readable descriptions in reports: en, es or pt")
+	disable := fs.String("disable", "", "Comma-separated rule IDs or issue types never to report")
+	workers := fs.Int("workers", runtime.NumCPU(), "Concurrent job validations")
+	queueSize := fs.Int("queue_size", 64, "Maximum jobs waiting to run; further submissions get 503")
+	jobTTL := fs.Duration("job_ttl", time.Hour, "How long finished jobs can be polled")
+	maxUpload := fs.Int64("max_upload_mb", 64, "Maximum upload size in MB")
+	tenantsFile := fs.String("tenants", "", "JSON file of tenants with API keys and defaults (enables authentication)")
+	detectorHeaders := headerFlag{}
+	fs.Var(detectorHeaders, "detector_header", "Header sent with every language detection call as \"Name: value\" (repeatable)")
+	drainTimeout := fs.Duration("drain_timeout", 30*time.Second, "How long shutdown waits for queued and running jobs")
+	fs.Usage = commandUsage(fs, "serve", "serve [flags]")
+	fs.Parse(args)
+
+	if *endpoint == "" {
+		log.Fatal("Language detection endpoint is required (use -endpoint flag)")
+	}
+	var tenants []TenantConfig
+	if *tenantsFile != "" {
+		var err error
+		if tenants, err = loadTenants(*tenantsFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	localizer, err := newLocalizer(*locale)
+	if err != nil {
+		log.Fatal(err)
+	}
+	disabled, err := parseRuleList(*disable)
+	if err != nil {
+		log.Fatal(err)
+	}
+	validator := NewCaptionValidator(*endpoint)
+	validator.tolerance = *tolerance
+	validator.locale = localizer
+	validator.disabled = disabled
+	validator.headers = http.Header(detectorHeaders)
+	server, err := NewServer(validator, ServerOptions{
+		Workers:   *workers,
+		QueueSize: *queueSize,
+		JobTTL:    *jobTTL,
+		MaxUpload: *maxUpload << 20,
+		Coverage:  *coverage,
+		Tenants:   tenants,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	httpServer := &http.Server{Addr: *addr, Handler: server.Handler()}
+	go func() {
+		<-ctx.Done()
+		drainCtx, cancel := context.WithTimeout(context.Background(), *drainTimeout)
+		defer cancel()
+		if err := server.Drain(drainCtx); err != nil {
+			log.Printf("Jobs still running at shutdown: %v", err)
+		}
+		httpServer.Shutdown(drainCtx)
+	}()
+
+	log.Printf("Listening on %s", *addr)
+	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	os.Exit(exitInterrupted)
+}
+
+// Drain stops accepting jobs and waits until every queued and running job has
+// finished, or ctx is done. Job status can still be polled while draining.
+func (s *Server) Drain(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closing {
+		s.closing = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// handleValidate validates an upload and responds with its report
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	req, err := s.parseRequest(w, r)
+	if err != nil {
+		writeError(w, requestErrorStatus(err), err)
+		return
+	}
+	defer os.Remove(req.path)
+
+	report, err := s.validate(req)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleSubmit queues an upload for validation and responds with the job ID immediately
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	req, err := s.parseRequest(w, r)
+	if err != nil {
+		writeError(w, requestErrorStatus(err), err)
+		return
+	}
+
+	job, err := s.submit(req)
+	if err != nil {
+		os.Remove(req.path)
+		if err == ErrQueueFull {
+			w.Header().Set("Retry-After", "5")
+		}
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// handleJob reports a job's status, and its report once finished
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.job(r.PathValue("id"), requestTenant(r))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// parseRequest reads an upload, either a multipart form with the caption file in
+// "file" or the raw caption bytes as the request body, plus optional "window" or
+// "t_start"/"t_end" and "coverage" fields (form fields or query parameters). The
+// file is streamed to a temporary path the caller must remove.
+func (s *Server) parseRequest(w http.ResponseWriter, r *http.Request) (*validationRequest, error) {
+	if s.opts.MaxUpload > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, s.opts.MaxUpload)
+	}
+	upload, err := readUpload(r)
+	if err != nil {
+		return nil, err
+	}
+	req, err := s.uploadRequest(upload, requestTenant(r))
+	if err != nil {
+		os.Remove(upload.path)
+		return nil, err
+	}
+	return req, nil
+}
+
+// uploadRequest applies the upload's fields on top of the tenant's defaults
+func (s *Server) uploadRequest(upload *upload, t *tenant) (*validationRequest, error) {
+	window, err := requestWindow(upload.fields)
+	if err != nil {
+		return nil, err
+	}
+	coverage := t.coverage
+	if value := upload.fields.Get("coverage"); value != "" {
+		if coverage, err = strconv.ParseFloat(value, 64); err != nil {
+			return nil, fmt.Errorf("invalid coverage %q", value)
+		}
+	}
+	t.bytesIn.Add(upload.size)
+
+	return &validationRequest{
+		name:     upload.name,
+		path:     upload.path,
+		format:   upload.format,
+		window:   window,
+		coverage: coverage,
+		tenant:   t,
+	}, nil
+}
+
+// requestWindow reads the time window from the "window" or "t_start"/"t_end" fields
+func requestWindow(fields url.Values) (Window, error) {
+	if value := fields.Get("window"); value != "" {
+		return ParseWindow(value)
+	}
+
+	var window Window
+	for _, field := range []struct {
+		name  string
+		value *float64
+	}{{"t_start", &window.Start}, {"t_end", &window.End}} {
+		if value := fields.Get(field.name); value != "" {
+			seconds, err := parseTimestamp(value)
+			if err != nil {
+				return Window{}, fmt.Errorf("invalid %s: %w", field.name, err)
+			}
+			*field.value = seconds
+		}
+	}
+	return window, window.Validate()
+}
+
+// requestErrorStatus maps an upload error to its HTTP status
+func requestErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// validate runs one request with its tenant's settings, reporting the upload under its original name
+func (s *Server) validate(req *validationRequest) (*FileReport, error) {
+	validator := req.tenant.validator
+	if req.format != "" {
+		hinted := *validator
+		hinted.formatHint = req.format
+		validator = &hinted
+	}
+	report, err := validator.Validate(req.path, req.window, req.coverage)
+	req.tenant.record(report, err)
+	if err != nil {
+		return nil, err
+	}
+	report.File = req.name
+	return report, nil
+}
+
+// submit queues a job, failing fast when the queue is full
+func (s *Server) submit(req *validationRequest) (*Job, error) {
+	job := &Job{ID: newJobID(), Status: JobQueued, Created: time.Now().UTC(), request: req, tenant: req.tenant}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing {
+		return nil, ErrShuttingDown
+	}
+	select {
+	case s.queue <- job:
+	default:
+		return nil, ErrQueueFull
+	}
+	s.jobs[job.ID] = job
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// job returns a snapshot of one of the tenant's jobs so it can be encoded without holding the lock
+func (s *Server) job(id string, t *tenant) (*Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok || job.tenant != t {
+		return nil, false
+	}
+	snapshot := *job
+	return &snapshot, true
+}
+
+// work runs queued jobs until the queue is closed
+func (s *Server) work() {
+	for job := range s.queue {
+		s.setStatus(job, JobRunning, nil, nil)
+		report, err := s.validate(job.request)
+		os.Remove(job.request.path)
+		if err != nil {
+			s.setStatus(job, JobFailed, nil, err)
+		} else {
+			s.setStatus(job, JobDone, report, nil)
+		}
+	}
+}
+
+func (s *Server) setStatus(job *Job, status string, report *FileReport, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.Status = status
+	job.Report = report
+	if err != nil {
+		job.Error = err.Error()
+	}
+	if status == JobDone || status == JobFailed {
+		finished := time.Now().UTC()
+		job.Finished = &finished
+	}
+}
+
+// expireJobs forgets finished jobs once they are older than the job TTL, keeping memory bounded
+func (s *Server) expireJobs() {
+	if s.opts.JobTTL <= 0 {
+		return
+	}
+	ticker := time.NewTicker(min(s.opts.JobTTL, time.Minute))
+	defer ticker.Stop()
+	for now := range ticker.C {
+		s.mu.Lock()
+		for id, job := range s.jobs {
+			if job.Finished != nil && now.Sub(*job.Finished) > s.opts.JobTTL {
+				delete(s.jobs, id)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func newJobID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
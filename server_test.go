@@ -0,0 +1,371 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"caption-validator/client"
+)
+
+// uploadRequest builds a multipart validation request for content
+func uploadRequest(t *testing.T, url, name, content string, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for key, value := range fields {
+		writer.WriteField(key, value)
+	}
+	part, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(content))
+	writer.Close()
+
+	req, err := http.NewRequest(http.MethodPost, url, &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func newTestServer(t *testing.T, opts ServerOptions) *httptest.Server {
+	t.Helper()
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	t.Cleanup(detector.Close)
+
+	server, err := NewServer(NewCaptionValidator(detector.URL), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := httptest.NewServer(server.Handler())
+	t.Cleanup(api.Close)
+	return api
+}
+
+const serverTestCaptions = "WEBVTT\n\n00:00:00.000 --> 00:00:10.000\nHello there\n"
+
+func TestServerJobLifecycle(t *testing.T) {
+	api := newTestServer(t, ServerOptions{Workers: 1, QueueSize: 4, Coverage: 80})
+
+	resp, err := http.DefaultClient.Do(uploadRequest(t, api.URL+"/jobs", "episode.vtt", serverTestCaptions, map[string]string{"t_end": "20"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var job Job
+	json.NewDecoder(resp.Body).Decode(&job)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted || job.ID == "" || resp.Header.Get("Location") != "/jobs/"+job.ID {
+		t.Fatalf("expected 202 with job ID, got %d %+v", resp.StatusCode, job)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status != JobDone && job.Status != JobFailed {
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not finish, last status %q", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+		resp, err := http.Get(api.URL + "/jobs/" + job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		json.NewDecoder(resp.Body).Decode(&job)
+		resp.Body.Close()
+	}
+
+	if job.Status != JobDone || job.Report == nil || job.Report.File != "episode.vtt" {
+		t.Fatalf("expected finished job with report, got %+v", job)
+	}
+	if len(job.Report.Errors) != 1 {
+		t.Errorf("expected one coverage error at 50%%, got %+v", job.Report.Errors)
+	}
+
+	resp, err = http.Get(api.URL + "/jobs/unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown job, got %d", resp.StatusCode)
+	}
+}
+
+func TestServerQueueFull(t *testing.T) {
+	cv := NewCaptionValidator("http://test.com")
+	server := &Server{validator: cv, queue: make(chan *Job), jobs: map[string]*Job{}}
+
+	if _, err := server.submit(&validationRequest{}); err != ErrQueueFull {
+		t.Errorf("expected ErrQueueFull with no room in the queue, got %v", err)
+	}
+}
+
+func TestServerValidateSync(t *testing.T) {
+	api := newTestServer(t, ServerOptions{Workers: 1, Coverage: 80})
+
+	resp, err := http.DefaultClient.Do(uploadRequest(t, api.URL+"/validate", "episode.vtt", serverTestCaptions, map[string]string{"window": "0-10"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var report FileReport
+	json.NewDecoder(resp.Body).Decode(&report)
+	if resp.StatusCode != http.StatusOK || len(report.Errors) != 0 {
+		t.Errorf("expected passing report, got %d %+v", resp.StatusCode, report)
+	}
+
+	resp, err = http.DefaultClient.Do(uploadRequest(t, api.URL+"/validate", "episode.vtt", serverTestCaptions, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 without a window, got %d", resp.StatusCode)
+	}
+}
+
+func TestServerRawUpload(t *testing.T) {
+	api := newTestServer(t, ServerOptions{Workers: 1, Coverage: 80, MaxUpload: 1024})
+
+	post := func(query, contentType, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, api.URL+"/validate"+query, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	resp := post("?t_end=10&name=episode.vtt", "text/vtt", serverTestCaptions)
+	var report FileReport
+	json.NewDecoder(resp.Body).Decode(&report)
+	if resp.StatusCode != http.StatusOK || report.File != "episode.vtt" || len(report.Errors) != 0 {
+		t.Errorf("expected passing report for raw body, got %d %+v", resp.StatusCode, report)
+	}
+
+	if resp := post("?t_end=10", "", serverTestCaptions); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected sniffed raw body to validate, got %d", resp.StatusCode)
+	}
+	if resp := post("?t_end=10", "application/x-subrip", serverTestCaptions); resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415 when Content-Type contradicts the content, got %d", resp.StatusCode)
+	}
+	// An SRT body that does not open with a cue index is read as the format it declares
+	unsniffable := "\n1\n00:00:00,000 --> 00:00:10,000\nHello there\n"
+	resp = post("?t_end=10", "application/x-subrip", unsniffable)
+	report = FileReport{}
+	json.NewDecoder(resp.Body).Decode(&report)
+	if resp.StatusCode != http.StatusOK || report.Format != "srt" || report.Cues != 1 {
+		t.Errorf("expected the declared format to be used, got %d %+v", resp.StatusCode, report)
+	}
+	if resp := post("?t_end=10", "", unsniffable); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("expected an undeclared unsniffable body to be refused, got %d", resp.StatusCode)
+	}
+	cpl := `<?xml version="1.0"?><CompositionPlaylist xmlns="http://www.smpte-ra.org/schemas/2067-3/2016">`
+	if resp := post("?t_end=10", "", cpl); resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415 for an IMF composition, got %d", resp.StatusCode)
+	}
+	if resp := post("?t_end=10", "image/png", serverTestCaptions); resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415 for a non-caption Content-Type, got %d", resp.StatusCode)
+	}
+	if resp := post("?t_end=10", "text/vtt", serverTestCaptions+strings.Repeat("x", 2048)); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 above the upload limit, got %d", resp.StatusCode)
+	}
+}
+
+func TestServerCaptionMediaTypes(t *testing.T) {
+	api := newTestServer(t, ServerOptions{Workers: 1, Coverage: 80})
+	ttml := `<tt xmlns="http://www.w3.org/ns/ttml" xml:lang="en-US"><body><div><p begin="00:00:00.000" end="00:00:09.000">Hello there</p></div></body></tt>`
+	scc := sccHeader + "\n\n" + sccLine("00:00:00:00", 0x1420, 0x1420, 0x4865, 0x6c6c, 0x6f20, 0x142f, 0x142f) + "\n\n" + sccLine("00:00:09:00", 0x142c, 0x142c) + "\n"
+	tests := []struct {
+		contentType, body, format string
+		status                    int
+	}{
+		{"application/ttml+xml", ttml, FormatTTML, http.StatusOK},
+		{"text/x-scc", scc, FormatSCC, http.StatusOK},
+		{"application/x-scc", scc, FormatSCC, http.StatusOK},
+		{"application/ttml+xml", serverTestCaptions, "", http.StatusUnsupportedMediaType},
+		{"text/x-scc", ttml, "", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodPost, api.URL+"/validate?t_end=10", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", tt.contentType)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var report FileReport
+		json.NewDecoder(resp.Body).Decode(&report)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status || report.Format != tt.format {
+			t.Errorf("%s: expected %d and format %q, got %d %+v", tt.contentType, tt.status, tt.format, resp.StatusCode, report)
+		}
+	}
+}
+
+func TestServerClientRoundTrip(t *testing.T) {
+	api := newTestServer(t, ServerOptions{Workers: 1, QueueSize: 4, Coverage: 80})
+	c := client.New(api.URL, "")
+	ctx := context.Background()
+
+	job, err := c.SubmitJob(ctx, "episode.vtt", strings.NewReader(serverTestCaptions), client.Options{TEnd: "20"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err = c.WaitJob(ctx, job.ID, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != client.JobDone || len(job.Report.Errors) != 1 || job.Report.Errors[0].Type != "caption_coverage" {
+		t.Fatalf("expected finished job with a coverage error, got %+v", job)
+	}
+	if job.Report.Errors[0].SuggestedFix.Action != FixCaptionGaps || len(job.Report.Errors[0].Raw) == 0 {
+		t.Errorf("expected decoded suggested fix and raw error, got %+v", job.Report.Errors[0])
+	}
+
+	_, err = c.Validate(ctx, "episode.vtt", strings.NewReader(serverTestCaptions), client.Options{})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 APIError without a window, got %v", err)
+	}
+}
+
+func TestOpenAPISpecCoversRoutes(t *testing.T) {
+	api := newTestServer(t, ServerOptions{})
+	resp, err := http.Get(api.URL + "/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var spec struct {
+		OpenAPI string                            `json:"openapi"`
+		Paths   map[string]map[string]interface{} `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatalf("spec is not valid JSON: %v", err)
+	}
+	for _, route := range []struct{ method, path string }{
+		{"post", "/validate"},
+		{"post", "/jobs"},
+		{"get", "/jobs/{id}"},
+		{"get", "/usage"},
+		{"get", "/openapi.json"},
+	} {
+		if _, ok := spec.Paths[route.path][route.method]; !ok {
+			t.Errorf("spec is missing %s %s", route.method, route.path)
+		}
+	}
+}
+
+func TestServerTenants(t *testing.T) {
+	api := newTestServer(t, ServerOptions{Workers: 1, QueueSize: 4, Coverage: 80, Tenants: []TenantConfig{
+		{Name: "news", APIKeys: []string{"news-key"}, Coverage: 40},
+		{Name: "sport", APIKeys: []string{"sport-key"}, ExpectedLanguage: "es-ES"},
+	}})
+	ctx := context.Background()
+
+	_, err := client.New(api.URL, "").Validate(ctx, "episode.vtt", strings.NewReader(serverTestCaptions), client.Options{TEnd: "20"})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without an API key, got %v", err)
+	}
+
+	// The news tenant's 40% default coverage passes a file that is 50% captioned
+	news := client.New(api.URL, "news-key")
+	report, err := news.Validate(ctx, "episode.vtt", strings.NewReader(serverTestCaptions), client.Options{TEnd: "20"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Errors) != 0 {
+		t.Errorf("expected news report to pass at tenant coverage, got %+v", report.Errors)
+	}
+
+	// The sport tenant expects Spanish, so the English detector result fails
+	sport := client.New(api.URL, "sport-key")
+	job, err := sport.SubmitJob(ctx, "match.vtt", strings.NewReader(serverTestCaptions), client.Options{TEnd: "10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job, err = sport.WaitJob(ctx, job.ID, 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if len(job.Report.Errors) != 1 || job.Report.Errors[0].Type != "incorrect_language" {
+		t.Errorf("expected incorrect_language for sport tenant, got %+v", job.Report.Errors)
+	}
+	if _, err := news.GetJob(ctx, job.ID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected another tenant's job to be hidden, got %v", err)
+	}
+
+	usage, err := news.Usage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Tenant != "news" || usage.Validations != 1 || usage.Passed != 1 || usage.BytesIn != int64(len(serverTestCaptions)) {
+		t.Errorf("unexpected news usage: %+v", usage)
+	}
+}
+
+func TestNewTenantsRejectsDuplicateKeys(t *testing.T) {
+	_, err := newTenants(NewCaptionValidator("http://test.com"), 80, []TenantConfig{
+		{Name: "a", APIKeys: []string{"shared"}},
+		{Name: "b", APIKeys: []string{"shared"}},
+	})
+	if err == nil {
+		t.Error("expected error for an API key shared by two tenants")
+	}
+}
+
+func TestServerDrain(t *testing.T) {
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	defer detector.Close()
+	server, err := NewServer(NewCaptionValidator(detector.URL), ServerOptions{Workers: 1, QueueSize: 4, Coverage: 80})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "episode.vtt")
+	if err := os.WriteFile(path, []byte(serverTestCaptions), 0644); err != nil {
+		t.Fatal(err)
+	}
+	job, err := server.submit(&validationRequest{name: "episode.vtt", path: path, window: Window{End: 10}, coverage: 80, tenant: server.defaultTenant})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Drain(ctx); err != nil {
+		t.Fatalf("drain did not finish: %v", err)
+	}
+	if finished, _ := server.job(job.ID, server.defaultTenant); finished.Status != JobDone {
+		t.Errorf("expected queued job to finish during drain, got %q", finished.Status)
+	}
+	if _, err := server.submit(&validationRequest{tenant: server.defaultTenant}); err != ErrShuttingDown {
+		t.Errorf("expected ErrShuttingDown after drain, got %v", err)
+	}
+}
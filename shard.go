@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Shard is one of Count disjoint slices of a batch, numbered from 1. Files are
+// assigned by a hash of their path, so pods given the same inputs agree on which
+// pod validates which file without talking to each other.
+type Shard struct {
+	Index int
+	Count int // 0 means the batch is not sharded
+}
+
+// ParseShard parses a shard as INDEX/COUNT, e.g. 3/10
+func ParseShard(s string) (Shard, error) {
+	index, count, found := strings.Cut(s, "/")
+	i, err1 := strconv.Atoi(strings.TrimSpace(index))
+	n, err2 := strconv.Atoi(strings.TrimSpace(count))
+	if !found || err1 != nil || err2 != nil {
+		return Shard{}, fmt.Errorf("invalid shard %q: expected INDEX/COUNT, e.g. 3/10", s)
+	}
+	if n < 1 || i < 1 || i > n {
+		return Shard{}, fmt.Errorf("invalid shard %q: the index must be between 1 and the count", s)
+	}
+	return Shard{Index: i, Count: n}, nil
+}
+
+func (s Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// includes reports whether path belongs to the shard. Paths are normalized and use
+// forward slashes before hashing, so Windows and Linux pods split a share alike.
+func (s Shard) includes(path string) bool {
+	if s.Count == 0 {
+		return true
+	}
+	h := fnv.New64a()
+	h.Write([]byte(filepath.ToSlash(normalizePath(path))))
+	return int(h.Sum64()%uint64(s.Count)) == s.Index-1
+}
+
+// filter returns the files that belong to the shard
+func (s Shard) filter(files []string) []string {
+	if s.Count == 0 {
+		return files
+	}
+	var kept []string
+	for _, file := range files {
+		if s.includes(file) {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
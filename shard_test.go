@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseShard(t *testing.T) {
+	if shard, err := ParseShard("3/10"); err != nil || shard != (Shard{Index: 3, Count: 10}) || shard.String() != "3/10" {
+		t.Errorf("expected shard 3/10, got %+v (%v)", shard, err)
+	}
+	for _, s := range []string{"0/10", "11/10", "1/0", "3", "a/b", "-1/4"} {
+		if _, err := ParseShard(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
+
+func TestShardPartitionsFiles(t *testing.T) {
+	var files []string
+	for i := range 1000 {
+		files = append(files, fmt.Sprintf("catalog/show-%d/ep%d.srt", i%37, i))
+	}
+	seen := map[string]int{}
+	for index := 1; index <= 4; index++ {
+		kept := Shard{Index: index, Count: 4}.filter(files)
+		if len(kept) < 150 || len(kept) > 350 {
+			t.Errorf("shard %d/4 got %d of 1000 files", index, len(kept))
+		}
+		for _, file := range kept {
+			seen[file]++
+		}
+	}
+	for _, file := range files {
+		if seen[file] != 1 {
+			t.Fatalf("%s is in %d shards", file, seen[file])
+		}
+	}
+
+	shard := Shard{Index: 2, Count: 3}
+	if shard.includes("catalog/show-1/ep1.srt") != shard.includes("catalog/./show-1/ep1.srt") {
+		t.Error("expected equivalent paths to land in the same shard")
+	}
+	if len(Shard{}.filter(files)) != len(files) {
+		t.Error("expected the zero shard to keep every file")
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+var (
+	// voiceTagPattern matches WebVTT voice spans such as <v Alice> or <v.loud Bob Smith>
+	voiceTagPattern = regexp.MustCompile(`<v(?:\.[\w.-]+)*\s+([^>]+)>`)
+	// speakerPrefixPattern matches upper-case SRT speaker labels such as "ALICE:" or "- DR. SMITH:"
+	speakerPrefixPattern = regexp.MustCompile(`(?:^|[-–]\s*)([A-Z][A-Z0-9.' ]*[A-Z0-9.]):\s`)
+)
+
+// SpeakerStats is the captioned share of the window for one identified speaker
+type SpeakerStats struct {
+	Speaker          string  `json:"speaker"`
+	Cues             int     `json:"cues"`
+	CaptionedSeconds float64 `json:"captioned_seconds"`
+}
+
+// SpeakerCoverageWarning reports identified speakers with no captions in the window
+type SpeakerCoverageWarning struct {
+	Type         string         `json:"type"`
+	Rule         string         `json:"rule"`
+	Severity     string         `json:"severity"`
+	Speakers     []SpeakerStats `json:"speakers"`
+	Missing      []string       `json:"missing"`
+	Description  string         `json:"description"`
+	SuggestedFix *SuggestedFix  `json:"suggested_fix,omitempty"`
+}
+
+// captionSpeakers returns the distinct speakers labeled in a cue
+func captionSpeakers(text string) []string {
+	var speakers []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		name = strings.TrimSpace(name)
+		if key := strings.ToLower(name); name != "" && !seen[key] {
+			seen[key] = true
+			speakers = append(speakers, name)
+		}
+	}
+
+	for _, match := range voiceTagPattern.FindAllStringSubmatch(text, -1) {
+		add(match[1])
+	}
+	for _, match := range speakerPrefixPattern.FindAllStringSubmatch(text, -1) {
+		add(match[1])
+	}
+	return speakers
+}
+
+// measureSpeakers totals captioned time per speaker within the window, in order of
+// first appearance. Speakers labeled only outside the window are listed with zero cues.
+// Returns nil when the file has no speaker labels.
+func measureSpeakers(captions []Caption, window Window) []SpeakerStats {
+	var stats []SpeakerStats
+	index := map[string]int{}
+	for _, caption := range captions {
+		overlap, inWindow := window.Intersect(Window{Start: caption.StartTime, End: caption.EndTime})
+		for _, speaker := range captionSpeakers(caption.Text) {
+			key := strings.ToLower(speaker)
+			i, ok := index[key]
+			if !ok {
+				i = len(stats)
+				index[key] = i
+				stats = append(stats, SpeakerStats{Speaker: speaker})
+			}
+			if inWindow {
+				stats[i].Cues++
+				stats[i].CaptionedSeconds += overlap.Duration()
+			}
+		}
+	}
+
+	for i := range stats {
+		stats[i].CaptionedSeconds = math.Round(stats[i].CaptionedSeconds*1000) / 1000
+	}
+	return stats
+}
+
+// validateSpeakerCoverage warns when an identified speaker has no captions within the window
+func (cv *CaptionValidator) validateSpeakerCoverage(stats []SpeakerStats, window Window) *SpeakerCoverageWarning {
+	var missing []string
+	for _, speaker := range stats {
+		if speaker.Cues == 0 {
+			missing = append(missing, speaker.Speaker)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return &SpeakerCoverageWarning{
+		Type:        "speaker_coverage",
+		Severity:    SeverityWarning,
+		Speakers:    stats,
+		Missing:     missing,
+		Description: fmt.Sprintf("%d of %d identified speaker(s) have no captions in %s: %s", len(missing), len(stats), window, strings.Join(missing, ", ")),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixCaptionSpeakers,
+			Speakers:    missing,
+			Description: fmt.Sprintf("Caption the dialogue of %s within the window", strings.Join(missing, ", ")),
+		},
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCaptionSpeakers(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{"<v Alice>Hello there", []string{"Alice"}},
+		{"<v.loud Bob Smith>Hi <v Alice>Hey <v Bob Smith>Again", []string{"Bob Smith", "Alice"}},
+		{"- DR. SMITH: Come in. - JANE: Thanks.", []string{"DR. SMITH", "JANE"}},
+		{"Meet me at 10:30 sharp", nil},
+		{"No speaker", nil},
+	}
+
+	for _, tt := range tests {
+		if result := captionSpeakers(tt.text); !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("captionSpeakers(%q) = %q, expected %q", tt.text, result, tt.expected)
+		}
+	}
+}
+
+func TestSpeakerCoverage(t *testing.T) {
+	captions := []Caption{
+		{StartTime: 0, EndTime: 4, Text: "<v Moderator>Welcome to the debate"},
+		{StartTime: 5, EndTime: 8, Text: "<v Candidate A>Thank you"},
+		{StartTime: 40, EndTime: 45, Text: "<v Candidate B>My turn at last"},
+		{StartTime: 9, EndTime: 12, Text: "<v moderator>First question"},
+	}
+	window := Window{Start: 0, End: 30}
+
+	stats := measureSpeakers(captions, window)
+	expected := []SpeakerStats{
+		{Speaker: "Moderator", Cues: 2, CaptionedSeconds: 7},
+		{Speaker: "Candidate A", Cues: 1, CaptionedSeconds: 3},
+		{Speaker: "Candidate B", Cues: 0, CaptionedSeconds: 0},
+	}
+	if !reflect.DeepEqual(stats, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, stats)
+	}
+
+	cv := NewCaptionValidator("http://test.com")
+	warning := cv.validateSpeakerCoverage(stats, window)
+	if warning == nil {
+		t.Fatal("expected speaker_coverage warning, got none")
+	}
+	if !reflect.DeepEqual(warning.Missing, []string{"Candidate B"}) || warning.SuggestedFix.Action != FixCaptionSpeakers {
+		t.Errorf("unexpected speaker_coverage warning: %+v", warning)
+	}
+
+	if measureSpeakers([]Caption{{StartTime: 0, EndTime: 1, Text: "Unlabeled"}}, window) != nil {
+		t.Error("expected no speaker stats without labels")
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// speechRates are the syllables per second of natural speech, keyed by primary
+// language subtag, as measured across languages by Pellegrino, Coupé and Marsico
+// (2011). Languages that pack less into each syllable are spoken faster.
+var speechRates = map[string]float64{
+	"en": 6.19,
+	"de": 5.97,
+	"es": 7.82,
+	"fr": 7.18,
+	"it": 6.99,
+	"ja": 7.84,
+	"zh": 5.18,
+}
+
+// defaultSpeechRate applies to languages without a measured rate
+const defaultSpeechRate = 6.5
+
+// Pauses a speaker takes at punctuation inside a cue, in seconds
+const (
+	clausePause   = 0.15 // , ; :
+	sentencePause = 0.3  // . ! ? …
+)
+
+// speechRateFor returns the speaking rate for a language tag such as es-MX
+func speechRateFor(language string) float64 {
+	base, _, _ := strings.Cut(strings.ToLower(language), "-")
+	if rate, ok := speechRates[base]; ok {
+		return rate
+	}
+	return defaultSpeechRate
+}
+
+// ImplausibleCue is a cue shown for far less time than its text takes to say
+type ImplausibleCue struct {
+	Cue           int     `json:"cue"`
+	StartTime     float64 `json:"start_time"`
+	EndTime       float64 `json:"end_time"`
+	Syllables     int     `json:"syllables"`
+	SpeechSeconds float64 `json:"speech_seconds"` // estimated time to say the dialogue
+	Ratio         float64 `json:"ratio"`          // seconds shown over speech_seconds
+}
+
+// ImplausibleDurationWarning reports cues whose dialogue could not have been spoken
+// in the time they are on screen, which CPS limits miss when the text is short but
+// dense, such as numbers, or the cue was cut short by a timing error
+type ImplausibleDurationWarning struct {
+	Type         string           `json:"type"`
+	Rule         string           `json:"rule"`
+	Severity     string           `json:"severity"`
+	Language     string           `json:"language"`
+	SpeechRate   float64          `json:"syllables_per_second"`
+	MinRatio     float64          `json:"min_ratio"`
+	Cues         []ImplausibleCue `json:"cues"`
+	Description  string           `json:"description"`
+	SuggestedFix *SuggestedFix    `json:"suggested_fix,omitempty"`
+}
+
+// countSyllables estimates the syllables it takes to say text in a language.
+// Words in alphabetic scripts count their vowel groups, with a silent final "e" in
+// English; each kana or Hangul block is one syllable, and each Han character one in
+// Chinese and two in Japanese, where it is mostly read with two morae. Digits are
+// spelled out when spoken, so each counts as two.
+func countSyllables(text, language string) int {
+	base, _, _ := strings.Cut(strings.ToLower(language), "-")
+	syllables := 0
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' }) {
+		groups, vowel := 0, false
+		for _, r := range word {
+			switch {
+			case unicode.IsDigit(r):
+				syllables += 2
+				vowel = false
+			case unicode.In(r, unicode.Han):
+				syllables++
+				if base == "ja" {
+					syllables++
+				}
+				vowel = false
+			case unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+				syllables++
+				vowel = false
+			case unicode.IsLetter(r):
+				isVowel := strings.ContainsRune("aeiouyáéíóúàèìòùâêîôûäëïöüãõåæøœ", unicode.ToLower(r))
+				if isVowel && !vowel {
+					groups++
+				}
+				vowel = isVowel
+			}
+		}
+		lower := strings.ToLower(word)
+		if base == "en" && groups > 1 && strings.HasSuffix(lower, "e") && !strings.HasSuffix(lower, "le") && !strings.HasSuffix(lower, "ee") {
+			groups--
+		}
+		if groups == 0 && strings.IndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) && r < unicode.MaxLatin1 }) >= 0 {
+			groups = 1 // "hmm", "shh"
+		}
+		syllables += groups
+	}
+	return syllables
+}
+
+// speechPauses totals the pauses at punctuation inside text; the cue's last mark
+// is left out, since the next cue's timing covers it
+func speechPauses(text string) float64 {
+	text = strings.TrimRightFunc(text, func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSpace(r) })
+	pauses := 0.0
+	for _, r := range text {
+		switch r {
+		case ',', ';', ':', '、', '，':
+			pauses += clausePause
+		case '.', '!', '?', '…', '。', '！', '？':
+			pauses += sentencePause
+		}
+	}
+	return pauses
+}
+
+// speechSeconds estimates how long the dialogue of a cue, annotations left out,
+// takes to say
+func speechSeconds(text, language string) (int, float64) {
+	dialogue, _ := splitAnnotations(text)
+	syllables := countSyllables(dialogue, language)
+	if syllables == 0 {
+		return 0, 0
+	}
+	return syllables, float64(syllables)/speechRateFor(language) + speechPauses(dialogue)
+}
+
+// validateSpeechDuration warns about cues shown for less than minRatio of the time
+// their dialogue takes to say at the expected language's speaking rate
+func (cv *CaptionValidator) validateSpeechDuration(captions []Caption, minRatio float64) *ImplausibleDurationWarning {
+	var flagged []ImplausibleCue
+	var cues []int
+	for i, caption := range captions {
+		syllables, seconds := speechSeconds(caption.Text, cv.expectedLanguage)
+		if seconds == 0 {
+			continue
+		}
+		shown := caption.EndTime - caption.StartTime
+		if ratio := shown / seconds; ratio < minRatio {
+			flagged = append(flagged, ImplausibleCue{
+				Cue:           i + 1,
+				StartTime:     caption.StartTime,
+				EndTime:       caption.EndTime,
+				Syllables:     syllables,
+				SpeechSeconds: math.Round(seconds*100) / 100,
+				Ratio:         math.Round(ratio*100) / 100,
+			})
+			cues = append(cues, i+1)
+		}
+	}
+	if len(flagged) == 0 {
+		return nil
+	}
+	return &ImplausibleDurationWarning{
+		Type:        "implausible_duration",
+		Severity:    SeverityWarning,
+		Language:    cv.expectedLanguage,
+		SpeechRate:  speechRateFor(cv.expectedLanguage),
+		MinRatio:    minRatio,
+		Cues:        flagged,
+		Description: fmt.Sprintf("%d cue(s) are shown for less than %g of the time their text takes to say", len(flagged), minRatio),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixRetimeCues,
+			Cues:        cues,
+			Description: fmt.Sprintf("Extend %s or condense the text so it can be said in the time it is shown", cueRange(cues)),
+		},
+	}
+}
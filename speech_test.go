@@ -0,0 +1,67 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCountSyllables(t *testing.T) {
+	tests := []struct {
+		text, language string
+		expected       int
+	}{
+		{"Hello there", "en-US", 3},
+		{"Make sure you close the gate", "en-US", 6},
+		{"Little table", "en-US", 4},
+		{"Hmm.", "en-US", 1},
+		{"In 1999", "en-US", 9},
+		{"¿Dónde está la biblioteca?", "es-ES", 9},
+		{"ありがとう", "ja-JP", 5},
+		{"日本語", "ja-JP", 6},
+		{"你好吗", "zh-CN", 3},
+		{"안녕하세요", "ko-KR", 5},
+	}
+	for _, tt := range tests {
+		if got := countSyllables(tt.text, tt.language); got != tt.expected {
+			t.Errorf("%q (%s): expected %d syllables, got %d", tt.text, tt.language, tt.expected, got)
+		}
+	}
+}
+
+func TestSpeechSeconds(t *testing.T) {
+	// Annotations are not spoken; the final mark adds no pause, the inner ones do
+	syllables, seconds := speechSeconds("[DOOR SLAMS] Wait, stop. Please.", "en-US")
+	if syllables != 3 || math.Abs(seconds-(3/6.19+clausePause+sentencePause)) > 1e-9 {
+		t.Errorf("expected 3 syllables in %.3fs, got %d in %.3fs", 3/6.19+clausePause+sentencePause, syllables, seconds)
+	}
+	if syllables, seconds := speechSeconds("(MUSIC)", "en-US"); syllables != 0 || seconds != 0 {
+		t.Errorf("expected nothing to say, got %d in %gs", syllables, seconds)
+	}
+	if speechRateFor("es-MX") != 7.82 || speechRateFor("pt-BR") != defaultSpeechRate {
+		t.Error("unexpected speaking rates")
+	}
+}
+
+func TestValidateSpeechDuration(t *testing.T) {
+	captions := []Caption{
+		{StartTime: 1, EndTime: 1.8, Text: "In 1999, we moved to the other side of the river."},
+		{StartTime: 2, EndTime: 6, Text: "[MUSIC] Yes."},
+		{StartTime: 6.5, EndTime: 7, Text: "Did you call the insurance company yesterday?"},
+		{StartTime: 7, EndTime: 7.2, Text: "[GUNSHOT]"},
+	}
+	cv := NewCaptionValidator("")
+	cv.expectedLanguage = "en-US"
+	issue := cv.validateSpeechDuration(captions, 0.5)
+	if issue == nil {
+		t.Fatal("expected implausible_duration")
+	}
+	if len(issue.Cues) != 2 || issue.Cues[0].Cue != 1 || issue.Cues[1].Cue != 3 || issue.Severity != SeverityWarning {
+		t.Errorf("expected cues 1 and 3 as a warning, got %+v", issue)
+	}
+	if fix := issue.SuggestedFix; fix.Action != FixRetimeCues || len(fix.Cues) != 2 {
+		t.Errorf("unexpected fix %+v", fix)
+	}
+	if cv.validateSpeechDuration(captions, 0.2) != nil {
+		t.Error("expected no warning below every cue's ratio")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StuckRun is a stretch of consecutive cues that all show the same text
+type StuckRun struct {
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+	Window    string  `json:"window"`
+	Cues      []int   `json:"cues"`
+	Text      string  `json:"text"`
+}
+
+// StuckCaptionWarning reports captions stuck on one text across many cues, which
+// encoders do when their input stalls. The repeats still count towards coverage.
+type StuckCaptionWarning struct {
+	Type         string        `json:"type"`
+	Rule         string        `json:"rule"`
+	Severity     string        `json:"severity"`
+	MaxSeconds   float64       `json:"max_seconds"`
+	Runs         []StuckRun    `json:"runs"`
+	Description  string        `json:"description"`
+	SuggestedFix *SuggestedFix `json:"suggested_fix,omitempty"`
+}
+
+// findStuckRuns returns runs of two or more consecutive cues with the same text,
+// whitespace and case aside, lasting longer than maxSeconds
+func findStuckRuns(captions []Caption, maxSeconds float64) []StuckRun {
+	var runs []StuckRun
+	for start := 0; start < len(captions); {
+		text := strings.ToLower(strings.Join(strings.Fields(captions[start].Text), " "))
+		end := start + 1
+		for end < len(captions) && strings.ToLower(strings.Join(strings.Fields(captions[end].Text), " ")) == text {
+			end++
+		}
+		run := StuckRun{StartTime: captions[start].StartTime, Text: captions[start].Text}
+		for i := start; i < end; i++ {
+			run.EndTime = max(run.EndTime, captions[i].EndTime)
+			run.Cues = append(run.Cues, i+1)
+		}
+		if text != "" && end-start > 1 && run.EndTime-run.StartTime > maxSeconds {
+			run.Window = Window{Start: run.StartTime, End: run.EndTime}.String()
+			runs = append(runs, run)
+		}
+		start = end
+	}
+	return runs
+}
+
+// validateStuckCaptions reports runs of repeated cue text lasting over maxSeconds
+func (cv *CaptionValidator) validateStuckCaptions(captions []Caption, maxSeconds float64) *StuckCaptionWarning {
+	runs := findStuckRuns(captions, maxSeconds)
+	if len(runs) == 0 {
+		return nil
+	}
+
+	// Every cue after the first of a run is a repeat to replace
+	var repeats []int
+	for _, run := range runs {
+		repeats = append(repeats, run.Cues[1:]...)
+	}
+	return &StuckCaptionWarning{
+		Type:        "stuck_caption",
+		Severity:    SeverityWarning,
+		MaxSeconds:  maxSeconds,
+		Runs:        runs,
+		Description: fmt.Sprintf("%d run(s) of consecutive cues repeat the same text for more than %gs", len(runs), maxSeconds),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixReplaceStuck,
+			Cues:        repeats,
+			Description: fmt.Sprintf("Replace the repeated text in %s with the dialogue it is stuck over", cueRange(repeats)),
+		},
+	}
+}
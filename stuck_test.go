@@ -0,0 +1,41 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStuckCaptionFindsLongRepeatedRuns(t *testing.T) {
+	captions := []Caption{
+		{StartTime: 0, EndTime: 2, Text: "Previously on..."},
+		{StartTime: 2, EndTime: 4, Text: "Where were you?"},
+	}
+	// The encoder stalls and repeats one line every 4 seconds for two minutes
+	for start := 4.0; start < 124; start += 4 {
+		captions = append(captions, Caption{StartTime: start, EndTime: start + 4, Text: "I waited  all NIGHT."})
+	}
+	captions = append(captions,
+		Caption{StartTime: 124, EndTime: 126, Text: "Yes."},
+		Caption{StartTime: 126, EndTime: 128, Text: "Yes."})
+
+	cv := NewCaptionValidator("")
+	warn := cv.validateStuckCaptions(captions, 60)
+	if warn == nil || len(warn.Runs) != 1 {
+		t.Fatalf("expected one stuck run, got %+v", warn)
+	}
+	if warn.Severity != SeverityWarning || issuesResult([]interface{}{warn}) != SummaryWarn {
+		t.Errorf("expected a stuck caption to warn without failing the file, got severity %q", warn.Severity)
+	}
+	run := warn.Runs[0]
+	if run.StartTime != 4 || run.EndTime != 124 || len(run.Cues) != 30 || run.Cues[0] != 3 || run.Window != "00:00:04.000-00:02:04.000" {
+		t.Errorf("unexpected run %+v", run)
+	}
+	if warn.SuggestedFix.Action != FixReplaceStuck || !slices.Equal(warn.SuggestedFix.Cues, run.Cues[1:]) {
+		t.Errorf("expected the repeats after the first cue to be replaced, got %+v", warn.SuggestedFix)
+	}
+
+	// A short repeat like "Yes." "Yes." is dialogue, not a stuck caption
+	if warn := cv.validateStuckCaptions(captions[len(captions)-2:], 60); warn != nil {
+		t.Errorf("expected no warning for a short repeat, got %+v", warn)
+	}
+}
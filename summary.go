@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Summary results
+const (
+	SummaryPass  = "pass"
+	SummaryWarn  = "warn" // every issue is a warning
+	SummaryFail  = "fail"
+	SummaryError = "error"
+)
+
+// Issue severities; issues without a "severity" field are errors
+const (
+	SeverityError   = "error"   // blocks delivery
+	SeverityWarning = "warning" // needs attention, but the file passes
+	SeverityInfo    = "info"    // for the record; does not change the result
+)
+
+// summaryLogger receives one line per validation at a severity matching its result;
+// *syslog.Writer implements it
+type summaryLogger interface {
+	Info(m string) error
+	Warning(m string) error
+	Err(m string) error
+}
+
+// ValidationSummary is the one-line record of a finished validation
+type ValidationSummary struct {
+	File     string
+	Result   string
+	Errors   int
+	Types    []string // distinct error types in report order
+	Coverage float64  // gating coverage percentage; not set when the file could not be validated
+	Elapsed  time.Duration
+	Reason   string // why the file could not be validated
+}
+
+// summarize condenses a report, or the error that prevented one, into a summary
+func summarize(filepath string, report *FileReport, err error, elapsed time.Duration) ValidationSummary {
+	summary := ValidationSummary{File: filepath, Result: SummaryPass, Elapsed: elapsed}
+	if err != nil {
+		summary.Result, summary.Reason = SummaryError, err.Error()
+		return summary
+	}
+	if report.Coverage != nil {
+		summary.Coverage = report.Coverage.gatingValue()
+	}
+	summary.Errors = len(report.Errors)
+	summary.Result = issuesResult(report.Errors)
+	seen := map[string]bool{}
+	for _, issue := range report.Errors {
+		if errType := issueType(issue); !seen[errType] {
+			seen[errType] = true
+			summary.Types = append(summary.Types, errType)
+		}
+	}
+	return summary
+}
+
+// issuesResult is fail when any issue is an error, warn when any is a warning and
+// pass otherwise
+func issuesResult(issues []interface{}) string {
+	result := SummaryPass
+	for _, issue := range issues {
+		switch issueSeverity(issue) {
+		case SeverityError:
+			return SummaryFail
+		case SeverityWarning:
+			result = SummaryWarn
+		}
+	}
+	return result
+}
+
+// issueType returns the "type" of a validation error, whatever its concrete type
+func issueType(issue interface{}) string {
+	var typed struct {
+		Type string `json:"type"`
+	}
+	if data, err := json.Marshal(issue); err == nil {
+		json.Unmarshal(data, &typed)
+	}
+	return typed.Type
+}
+
+// issueSeverity returns the "severity" of a validation error, error when it has none
+func issueSeverity(issue interface{}) string {
+	var typed struct {
+		Severity string `json:"severity"`
+	}
+	if data, err := json.Marshal(issue); err == nil {
+		json.Unmarshal(data, &typed)
+	}
+	if typed.Severity == "" {
+		return SeverityError
+	}
+	return typed.Severity
+}
+
+// String formats the summary as logfmt key=value pairs, e.g.
+// result=fail file="ep1.srt" errors=2 types=caption_coverage,incorrect_language coverage=70.00 elapsed_ms=12
+// followed by any -meta pairs as meta.asset_id="ABC123"
+func (s ValidationSummary) String() string {
+	fields := []string{"result=" + s.Result, "file=" + strconv.Quote(s.File)}
+	if s.Result == SummaryError {
+		fields = append(fields, "reason="+strconv.Quote(s.Reason))
+	} else {
+		fields = append(fields, fmt.Sprintf("errors=%d", s.Errors))
+		if len(s.Types) > 0 {
+			fields = append(fields, "types="+strings.Join(s.Types, ","))
+		}
+		fields = append(fields, fmt.Sprintf("coverage=%.2f", s.Coverage))
+	}
+	fields = append(fields, fmt.Sprintf("elapsed_ms=%d", s.Elapsed.Milliseconds()))
+	for _, key := range slices.Sorted(maps.Keys(resultMeta)) {
+		fields = append(fields, "meta."+key+"="+strconv.Quote(resultMeta[key]))
+	}
+	return strings.Join(fields, " ")
+}
+
+// logSummary sends a validation's summary to the summary logger, if one is configured.
+// Delivery failures are ignored so monitoring can never fail a validation.
+func (cv *CaptionValidator) logSummary(summary ValidationSummary) {
+	if cv.summaries == nil {
+		return
+	}
+	switch summary.Result {
+	case SummaryPass:
+		cv.summaries.Info(summary.String())
+	case SummaryWarn, SummaryFail:
+		cv.summaries.Warning(summary.String())
+	default:
+		cv.summaries.Err(summary.String())
+	}
+}
+
+// validateAndSummarize runs Validate, isolated from panics and bounded by
+// -file_timeout, then logs its summary and adds it to the digest
+func (cv *CaptionValidator) validateAndSummarize(filepath string, window Window, requiredCoverage float64) (*FileReport, error) {
+	start := time.Now()
+	report, err := isolate(filepath, cv.deadline, func(ctx context.Context) (*FileReport, error) {
+		return cv.validate(ctx, filepath, window, requiredCoverage)
+	})
+	elapsed := time.Since(start)
+	cv.stats.recordValidation(elapsed)
+	summary := summarize(filepath, report, err, elapsed)
+	cv.logSummary(summary)
+	cv.digest.record(summary)
+	return report, err
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingLogger keeps summary lines prefixed with their severity
+type recordingLogger struct{ lines []string }
+
+func (r *recordingLogger) Info(m string) error    { return r.record("info", m) }
+func (r *recordingLogger) Warning(m string) error { return r.record("warning", m) }
+func (r *recordingLogger) Err(m string) error     { return r.record("err", m) }
+
+func (r *recordingLogger) record(severity, m string) error {
+	r.lines = append(r.lines, severity+" "+m)
+	return nil
+}
+
+func TestValidationSummaryString(t *testing.T) {
+	report := &FileReport{
+		Coverage: &CoverageMetrics{WallClock: 70, Gating: CoverageWallClock},
+		Errors: []interface{}{
+			&CaptionCoverageError{Type: "caption_coverage"},
+			&IncorrectLanguageError{Type: "incorrect_language"},
+			&CaptionCoverageError{Type: "caption_coverage"},
+		},
+	}
+	summary := summarize("ep 1.srt", report, nil, 12*time.Millisecond)
+	expected := `result=fail file="ep 1.srt" errors=3 types=caption_coverage,incorrect_language coverage=70.00 elapsed_ms=12`
+	if summary.String() != expected {
+		t.Errorf("expected %s, got %s", expected, summary)
+	}
+
+	summary = summarize("notes.txt", nil, errors.New("unsupported caption format: unknown"), 0)
+	expected = `result=error file="notes.txt" reason="unsupported caption format: unknown" elapsed_ms=0`
+	if summary.String() != expected {
+		t.Errorf("expected %s, got %s", expected, summary)
+	}
+}
+
+func TestSummaryWarnsOnWarningsOnly(t *testing.T) {
+	warning := &CaptionCoverageError{Type: "caption_coverage", Severity: SeverityWarning}
+	report := &FileReport{Errors: []interface{}{warning}}
+	if summary := summarize("ep 1.srt", report, nil, 0); summary.Result != SummaryWarn {
+		t.Errorf("expected warn for warnings only, got %s", summary.Result)
+	}
+	if entry := manifestEntry(*report); entry.Status != ManifestWarned {
+		t.Errorf("expected warned manifest status, got %s", entry.Status)
+	}
+
+	report.Errors = append(report.Errors, newDuplicateCueWarning([]DuplicateCue{{Cue: 2, DuplicateOf: 1}}))
+	if summary := summarize("ep 1.srt", report, nil, 0); summary.Result != SummaryWarn {
+		t.Errorf("expected duplicate_cue to be a warning, got %s", summary.Result)
+	}
+
+	report.Errors = append(report.Errors, &IncorrectLanguageError{Type: "incorrect_language"})
+	if summary := summarize("ep 1.srt", report, nil, 0); summary.Result != SummaryFail {
+		t.Errorf("expected fail once an error is reported, got %s", summary.Result)
+	}
+	if entry := manifestEntry(*report); entry.Status != ManifestFailed {
+		t.Errorf("expected failed manifest status, got %s", entry.Status)
+	}
+}
+
+func TestFailOnExitCode(t *testing.T) {
+	for _, tc := range []struct {
+		policy string
+		counts DigestCounts
+		code   int
+	}{
+		{SummaryFail, DigestCounts{Passed: 2, Warned: 1}, 0},
+		{SummaryFail, DigestCounts{Warned: 1, Failed: 1}, exitFailed},
+		{SummaryWarn, DigestCounts{Passed: 2, Warned: 1}, exitWarned},
+		{SummaryWarn, DigestCounts{Warned: 1, Failed: 1}, exitFailed},
+		{SummaryWarn, DigestCounts{Passed: 3}, 0},
+	} {
+		if code := failOnExitCode(tc.policy, tc.counts); code != tc.code {
+			t.Errorf("-fail_on %s with %+v: expected exit %d, got %d", tc.policy, tc.counts, tc.code, code)
+		}
+	}
+}
+
+func TestValidateFileLogsSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("not captions"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	logger := &recordingLogger{}
+	cv := NewCaptionValidator("http://test.com")
+	cv.summaries = logger
+
+	report := cv.validateForReport(path, Window{End: 10}, 80)
+	if report.ProgramError == "" {
+		t.Fatal("expected a program error for an unsupported file")
+	}
+	if len(logger.lines) != 1 || !strings.HasPrefix(logger.lines[0], "err result=error") {
+		t.Errorf("expected one error-level summary, got %q", logger.lines)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// CaptionSyncError reports captions that lag (or lead) the spoken audio
+type CaptionSyncError struct {
+	Type            string        `json:"type"`
+	Rule            string        `json:"rule"`
+	MaxLatency      float64       `json:"max_latency"`
+	AverageLatency  float64       `json:"average_latency"`
+	MatchedCaptions int           `json:"matched_captions"`
+	Description     string        `json:"description"`
+	SuggestedFix    *SuggestedFix `json:"suggested_fix,omitempty"`
+}
+
+// ASRWord is a single recognized word with timing from a word-level ASR transcript
+type ASRWord struct {
+	Word       string   `json:"word"`
+	Start      float64  `json:"start"`
+	End        float64  `json:"end"`
+	Confidence *float64 `json:"confidence,omitempty"` // 0 to 1; nil when the transcript has none
+}
+
+// UnmarshalJSON reads a word's confidence from "confidence", or from "probability"
+// as Whisper writes it
+func (w *ASRWord) UnmarshalJSON(data []byte) error {
+	type plain ASRWord
+	var word struct {
+		plain
+		Probability *float64 `json:"probability"`
+	}
+	if err := json.Unmarshal(data, &word); err != nil {
+		return err
+	}
+	*w = ASRWord(word.plain)
+	if w.Confidence == nil {
+		w.Confidence = word.Probability
+	}
+	return nil
+}
+
+// syncMatchWords is how many leading caption words must match the ASR sequence
+const syncMatchWords = 3
+
+// loadASRWords reads a word-level ASR JSON file, either {"words": [...]} or a bare array
+func loadASRWords(filepath string) ([]ASRWord, error) {
+	content, err := os.ReadFile(longPath(filepath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ASR reference: %w", err)
+	}
+
+	var transcript struct {
+		Words []ASRWord `json:"words"`
+	}
+	if err := json.Unmarshal(content, &transcript); err == nil {
+		return transcript.Words, nil
+	}
+
+	var words []ASRWord
+	if err := json.Unmarshal(content, &words); err != nil {
+		return nil, fmt.Errorf("failed to decode ASR reference: %w", err)
+	}
+	return words, nil
+}
+
+// validateSync aligns caption text against ASR words and checks the average caption delay
+func (cv *CaptionValidator) validateSync(captions []Caption, words []ASRWord, maxLatency float64) *CaptionSyncError {
+	spoken := make([]string, len(words))
+	for i, word := range words {
+		spoken[i] = normalizeWord(word.Word)
+	}
+
+	// Walk captions in order, matching each one's opening words against the
+	// remaining ASR words so repeated phrases align to the right occurrence
+	var delays []float64
+	cursor := 0
+	for _, caption := range captions {
+		tokens := captionWords(caption.Text)
+		if len(tokens) == 0 {
+			continue
+		}
+		if len(tokens) > syncMatchWords {
+			tokens = tokens[:syncMatchWords]
+		}
+
+		if j := findWordSequence(spoken, tokens, cursor); j >= 0 {
+			delays = append(delays, caption.StartTime-words[j].Start)
+			cursor = j + 1
+		}
+	}
+
+	if len(delays) == 0 {
+		return nil
+	}
+
+	total := 0.0
+	for _, delay := range delays {
+		total += delay
+	}
+	averageLatency := total / float64(len(delays))
+
+	if math.Abs(averageLatency) > maxLatency {
+		return &CaptionSyncError{
+			Type:            "caption_sync",
+			MaxLatency:      maxLatency,
+			AverageLatency:  averageLatency,
+			MatchedCaptions: len(delays),
+			Description:     fmt.Sprintf("Average caption latency of %.2fs exceeds allowed %.2fs", averageLatency, maxLatency),
+			SuggestedFix: &SuggestedFix{
+				Action:      FixShiftCues,
+				Shift:       -averageLatency,
+				Description: fmt.Sprintf("Shift all cues by %.2fs to align with speech", -averageLatency),
+			},
+		}
+	}
+	return nil
+}
+
+// findWordSequence returns the index of the first occurrence of tokens in spoken at or after start
+func findWordSequence(spoken, tokens []string, start int) int {
+	for i := start; i+len(tokens) <= len(spoken); i++ {
+		matched := true
+		for k, token := range tokens {
+			if spoken[i+k] != token {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return i
+		}
+	}
+	return -1
+}
+
+// captionWords splits caption text into normalized words, dropping punctuation-only tokens
+func captionWords(text string) []string {
+	var words []string
+	for _, field := range textWords(text) {
+		if word := normalizeWord(field); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// normalizeWord lowercases a word and strips surrounding punctuation for text matching
+func normalizeWord(word string) string {
+	return strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}))
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestValidateSync(t *testing.T) {
+	cv := NewCaptionValidator("http://test.com")
+
+	words := []ASRWord{
+		{Word: "Welcome", Start: 1.0, End: 1.4},
+		{Word: "to", Start: 1.4, End: 1.5},
+		{Word: "our", Start: 1.5, End: 1.7},
+		{Word: "show.", Start: 1.7, End: 2.0},
+		{Word: "Today", Start: 5.0, End: 5.3},
+		{Word: "we", Start: 5.3, End: 5.4},
+		{Word: "talk", Start: 5.4, End: 5.8},
+	}
+
+	tests := []struct {
+		name        string
+		offset      float64
+		expectError bool
+	}{
+		{name: "captions in sync", offset: 0.5, expectError: false},
+		{name: "captions trail audio by 6 seconds", offset: 6.0, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captions := []Caption{
+				{StartTime: 1.0 + tt.offset, EndTime: 3.0 + tt.offset, Text: "Welcome to our show!"},
+				{StartTime: 5.0 + tt.offset, EndTime: 7.0 + tt.offset, Text: "Today we talk"},
+			}
+
+			err := cv.validateSync(captions, words, 2.0)
+			if tt.expectError && err == nil {
+				t.Fatal("expected sync error, got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Fatalf("unexpected sync error: %v", err)
+			}
+			if err != nil {
+				if err.Type != "caption_sync" {
+					t.Errorf("expected type 'caption_sync', got '%s'", err.Type)
+				}
+				if err.AverageLatency != tt.offset {
+					t.Errorf("expected average latency %f, got %f", tt.offset, err.AverageLatency)
+				}
+				if err.MatchedCaptions != 2 {
+					t.Errorf("expected 2 matched captions, got %d", err.MatchedCaptions)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadASRWords(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "words object", content: `{"words": [{"word": "hello", "start": 1.0, "end": 1.5}]}`},
+		{name: "bare array", content: `[{"word": "hello", "start": 1.0, "end": 1.5}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile, err := os.CreateTemp("", "test_asr_*.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpFile.Name())
+
+			if _, err := tmpFile.WriteString(tt.content); err != nil {
+				t.Fatal(err)
+			}
+			tmpFile.Close()
+
+			words, err := loadASRWords(tmpFile.Name())
+			if err != nil {
+				t.Fatalf("loadASRWords failed: %v", err)
+			}
+			if len(words) != 1 || words[0].Word != "hello" || words[0].Start != 1.0 {
+				t.Errorf("unexpected words: %+v", words)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows || plan9
+
+package main
+
+import "errors"
+
+// newSyslogSummaries is unavailable where Go has no syslog support
+func newSyslogSummaries(target string) (summaryLogger, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
@@ -0,0 +1,26 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"fmt"
+	"log/syslog"
+	"strings"
+)
+
+// newSyslogSummaries connects to syslog: "local" uses the host's syslog socket,
+// which journald also reads; "udp://host:514" or "tcp://host:514" a remote server
+func newSyslogSummaries(target string) (summaryLogger, error) {
+	network, addr := "", ""
+	if target != "local" {
+		var ok bool
+		if network, addr, ok = strings.Cut(target, "://"); !ok || (network != "udp" && network != "tcp") {
+			return nil, fmt.Errorf("invalid syslog target %q: expected local, udp://host:port or tcp://host:port", target)
+		}
+	}
+	writer, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_USER, "caption-validator")
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return writer, nil
+}
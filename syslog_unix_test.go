@@ -0,0 +1,42 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogSummariesUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	summaries, err := newSyslogSummaries("udp://" + conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cv := NewCaptionValidator("http://test.com")
+	cv.summaries = summaries
+	cv.logSummary(ValidationSummary{File: "ep1.srt", Result: SummaryFail, Errors: 1})
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Priority 12 is facility user (1) at severity warning (4)
+	message := string(buf[:n])
+	if !strings.HasPrefix(message, "<12>") || !strings.Contains(message, `caption-validator`) || !strings.Contains(message, `result=fail file="ep1.srt" errors=1`) {
+		t.Errorf("unexpected syslog message %q", message)
+	}
+
+	if _, err := newSyslogSummaries("http://example.com"); err == nil {
+		t.Error("expected error for an unsupported syslog target")
+	}
+}
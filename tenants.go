@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// TenantConfig is one content team's entry in the -tenants file
+type TenantConfig struct {
+	Name             string   `json:"name"`
+	APIKeys          []string `json:"api_keys"`
+	ExpectedLanguage string   `json:"expected_language,omitempty"` // defaults to en-US
+	Coverage         float64  `json:"coverage,omitempty"`          // defaults to the server's -coverage
+}
+
+// Usage counts a tenant's server activity since startup
+type Usage struct {
+	Tenant      string `json:"tenant"`
+	Requests    int64  `json:"requests"`
+	Validations int64  `json:"validations"`
+	Passed      int64  `json:"passed"`
+	Failed      int64  `json:"failed"`
+	Errors      int64  `json:"errors"` // files that could not be validated
+	BytesIn     int64  `json:"bytes_in"`
+}
+
+// tenant is a configured tenant with its own validator settings and usage counters
+type tenant struct {
+	name      string
+	validator *CaptionValidator
+	coverage  float64
+
+	requests, validations, passed, failed, errors, bytesIn atomic.Int64
+}
+
+func (t *tenant) usage() Usage {
+	return Usage{
+		Tenant:      t.name,
+		Requests:    t.requests.Load(),
+		Validations: t.validations.Load(),
+		Passed:      t.passed.Load(),
+		Failed:      t.failed.Load(),
+		Errors:      t.errors.Load(),
+		BytesIn:     t.bytesIn.Load(),
+	}
+}
+
+// record counts one finished validation
+func (t *tenant) record(report *FileReport, err error) {
+	switch {
+	case err != nil:
+		t.errors.Add(1)
+	case issuesResult(report.Errors) == SummaryFail:
+		t.validations.Add(1)
+		t.failed.Add(1)
+	default:
+		t.validations.Add(1)
+		t.passed.Add(1)
+	}
+}
+
+// loadTenants reads a JSON array of TenantConfig
+func loadTenants(path string) ([]TenantConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenants file: %w", err)
+	}
+	var configs []TenantConfig
+	if err := json.Unmarshal(content, &configs); err != nil {
+		return nil, fmt.Errorf("failed to decode tenants file: %w", err)
+	}
+	return configs, nil
+}
+
+// newTenants builds tenants on top of base, indexed by the SHA-256 of each API key
+// so keys are not kept in plain text after startup
+func newTenants(base *CaptionValidator, coverage float64, configs []TenantConfig) (map[[sha256.Size]byte]*tenant, error) {
+	keys := make(map[[sha256.Size]byte]*tenant)
+	names := make(map[string]bool)
+	for _, config := range configs {
+		if config.Name == "" || names[config.Name] {
+			return nil, fmt.Errorf("tenant names must be unique and non-empty: %q", config.Name)
+		}
+		names[config.Name] = true
+
+		t := &tenant{name: config.Name, validator: base, coverage: coverage}
+		if config.ExpectedLanguage != "" {
+			cv := *base
+			cv.expectedLanguage = config.ExpectedLanguage
+			t.validator = &cv
+		}
+		if config.Coverage > 0 {
+			t.coverage = config.Coverage
+		}
+
+		for _, key := range config.APIKeys {
+			hash := sha256.Sum256([]byte(key))
+			if key == "" || keys[hash] != nil {
+				return nil, fmt.Errorf("tenant %s: API keys must be unique and non-empty", config.Name)
+			}
+			keys[hash] = t
+		}
+	}
+	return keys, nil
+}
+
+type tenantKey struct{}
+
+// authenticate resolves the caller's tenant from the X-API-Key header or a bearer
+// token. Without configured tenants every request uses the default tenant.
+func (s *Server) authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		t := s.defaultTenant
+		if s.tenants != nil {
+			key := r.Header.Get("X-API-Key")
+			if key == "" {
+				key, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			}
+			if t = s.tenants[sha256.Sum256([]byte(key))]; key == "" || t == nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or unknown API key"))
+				return
+			}
+		}
+		t.requests.Add(1)
+		next(w, r.WithContext(context.WithValue(r.Context(), tenantKey{}, t)))
+	}
+}
+
+// requestTenant returns the tenant resolved by authenticate
+func requestTenant(r *http.Request) *tenant {
+	return r.Context().Value(tenantKey{}).(*tenant)
+}
+
+// handleUsage reports the caller's usage counters
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, requestTenant(r).usage())
+}
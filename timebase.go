@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Time bases -time_base reads the numeric cue times of JSON cue documents in
+const (
+	TimeBaseSeconds = "seconds" // seconds, as OCR tools write them
+	TimeBase90kHz   = "90khz"   // ticks of the 90 kHz MPEG clock, counted from 0
+	TimeBasePTS     = "pts"     // MPEG-TS presentation timestamps, which wrap at 2^33 ticks
+)
+
+// MPEG system clock constants: PTS count 90 kHz ticks in 33 bits, wrapping about
+// every 26.5 hours
+const (
+	mpegClock = 90000
+	ptsWrap   = int64(1) << 33
+)
+
+// parseTimeBase checks the -time_base flag
+func parseTimeBase(base string) (string, error) {
+	switch base {
+	case "", TimeBaseSeconds:
+		return TimeBaseSeconds, nil
+	case TimeBase90kHz, TimeBasePTS:
+		return base, nil
+	}
+	return "", fmt.Errorf("invalid -time_base %q: use %s, %s or %s", base, TimeBaseSeconds, TimeBase90kHz, TimeBasePTS)
+}
+
+// cueClock converts one document's cue times to seconds. PTS are unwrapped as they
+// are read, in document order: a value more than half the PTS range below the one
+// before it has wrapped past 2^33, and one more than half the range above it was
+// taken before a wrap already seen; the first is compared with -pts_origin when one
+// is set, so cues just past a wrap count on from an origin just before it. Each is
+// then counted from the origin.
+type cueClock struct {
+	base   string
+	origin int64 // PTS of program time 0 (-pts_origin)
+	epoch  int64 // wraps seen, in ticks
+	last   int64
+	read   bool
+}
+
+func (cv *CaptionValidator) newCueClock() *cueClock {
+	return &cueClock{base: cv.timeBase, origin: cv.ptsOrigin, last: cv.ptsOrigin, read: cv.ptsOriginSet}
+}
+
+// seconds converts a cue time as written to seconds
+func (c *cueClock) seconds(value float64) float64 {
+	switch c.base {
+	case TimeBase90kHz:
+		return value / mpegClock
+	case TimeBasePTS:
+		ticks := int64(math.Round(value))
+		if c.read {
+			switch {
+			case ticks < c.last-ptsWrap/2:
+				c.epoch += ptsWrap
+			case ticks > c.last+ptsWrap/2:
+				c.epoch -= ptsWrap
+			}
+		}
+		c.last, c.read = ticks, true
+		return float64(ticks+c.epoch-c.origin) / mpegClock
+	}
+	return value
+}
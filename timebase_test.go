@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCueClockUnwrapsPTS(t *testing.T) {
+	cv := NewCaptionValidator("")
+	cv.timeBase = TimeBasePTS
+	cv.ptsOrigin, cv.ptsOriginSet = ptsWrap-10*mpegClock, true // 10s before the wrap
+	clock := cv.newCueClock()
+	for _, tt := range []struct {
+		pts  int64
+		want float64
+	}{
+		{ptsWrap - 5*mpegClock, 5},
+		{2 * mpegClock, 12}, // past the wrap
+		{ptsWrap - mpegClock, 9},
+		{3 * mpegClock, 13},
+	} {
+		if got := clock.seconds(float64(tt.pts)); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("seconds(%d) = %v, want %v", tt.pts, got, tt.want)
+		}
+	}
+
+	// An explicit origin of 0 still places a first PTS just before the wrap before it
+	cv.ptsOrigin = 0
+	if got := cv.newCueClock().seconds(float64(ptsWrap - mpegClock)); got != -1 {
+		t.Errorf("expected a PTS 1s before the wrap to be -1s from -pts_origin 0, got %v", got)
+	}
+	cv.ptsOriginSet = false
+	if got := cv.newCueClock().seconds(float64(ptsWrap - mpegClock)); got != float64(ptsWrap-mpegClock)/mpegClock {
+		t.Errorf("expected the first PTS to be taken as it is without -pts_origin, got %v", got)
+	}
+
+	cv.timeBase = TimeBase90kHz
+	if got := cv.newCueClock().seconds(135000); got != 1.5 {
+		t.Errorf("expected 135000 ticks to be 1.5s, got %v", got)
+	}
+	if _, err := parseTimeBase("27mhz"); err == nil {
+		t.Error("expected an unknown time base to be rejected")
+	}
+}
+
+func TestJSONCuesInPTS(t *testing.T) {
+	start := ptsWrap - 2*mpegClock
+	doc := fmt.Sprintf(`{"cues": [
+		{"start_time": %d, "end_time": %d, "text": "Across the wrap"},
+		{"start_time": %d, "end_time": %d, "text": "After it"}
+	]}`, start, mpegClock, 2*mpegClock, 4*mpegClock)
+
+	cv := NewCaptionValidator("")
+	cv.timeBase = TimeBasePTS
+	cv.ptsOrigin, cv.ptsOriginSet = start, true
+	captions, failures, err := collectCues(cv.Cues(strings.NewReader(doc), FormatOCRJSON), 0)
+	if err != nil || len(failures) != 0 {
+		t.Fatalf("unexpected error %v or failures %+v", err, failures)
+	}
+	if len(captions) != 2 || captions[0].StartTime != 0 || captions[0].EndTime != 3 || captions[1].StartTime != 4 || captions[1].EndTime != 6 {
+		t.Errorf("expected cues at 0-3s and 4-6s, got %+v", captions)
+	}
+}
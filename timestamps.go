@@ -0,0 +1,71 @@
+package main
+
+import "fmt"
+
+// TimestampRangeError reports a cue whose timing cannot be used: unparseable,
+// out of range, or negative once an offset is applied
+type TimestampRangeError struct {
+	Type         string        `json:"type"`
+	Rule         string        `json:"rule"`
+	Line         int           `json:"line,omitempty"`
+	Timestamp    string        `json:"timestamp"`
+	Offset       float64       `json:"offset,omitempty"`
+	Description  string        `json:"description"`
+	SuggestedFix *SuggestedFix `json:"suggested_fix,omitempty"`
+}
+
+// newTimestampRangeError reports a timing line the parser had to skip
+func newTimestampRangeError(line int, timing, reason string) *TimestampRangeError {
+	return &TimestampRangeError{
+		Type:        "timestamp_range",
+		Line:        line,
+		Timestamp:   timing,
+		Description: fmt.Sprintf("Cue on line %d skipped: %s", line, reason),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixCorrectTimestamp,
+			Lines:       []int{line},
+			Description: fmt.Sprintf("Correct the cue timing on line %d", line),
+		},
+	}
+}
+
+// applyOffset shifts every cue by offset seconds. Cues that would start before zero
+// are reported; they are clamped to zero, or dropped if they end before zero too.
+// A clamped cue is numbered among the shifted cues; a dropped one has no number and
+// is named by its time.
+func applyOffset(captions []Caption, offset float64) ([]Caption, []*TimestampRangeError) {
+	var shifted []Caption
+	var rangeErrs []*TimestampRangeError
+	for _, caption := range captions {
+		start, end := caption.StartTime+offset, caption.EndTime+offset
+		if start < 0 {
+			action := "clamped to 00:00:00.000"
+			if end <= 0 {
+				action = "dropped"
+			}
+			var cues []int
+			if end > 0 {
+				cues = []int{len(shifted) + 1}
+			}
+			rangeErrs = append(rangeErrs, &TimestampRangeError{
+				Type:        "timestamp_range",
+				Timestamp:   formatTimestamp(caption.StartTime),
+				Offset:      offset,
+				Description: fmt.Sprintf("Cue at %s becomes negative (%s) after offset of %.3fs and was %s", formatTimestamp(caption.StartTime), formatTimestamp(start), offset, action),
+				SuggestedFix: &SuggestedFix{
+					Action:      FixAdjustOffset,
+					Cues:        cues,
+					Description: fmt.Sprintf("Use an offset of at least %.3fs or remove the cue at %s", -caption.StartTime, formatTimestamp(caption.StartTime)),
+				},
+			})
+			if end <= 0 {
+				continue
+			}
+			start = 0
+		}
+
+		caption.StartTime, caption.EndTime = start, end
+		shifted = append(shifted, caption)
+	}
+	return shifted, rangeErrs
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseLongTimestamps(t *testing.T) {
+	cv := NewCaptionValidator("http://test.com")
+
+	result, err := cv.parseWebVTTTime("25:00:01.500")
+	if err != nil || result != 90001.5 {
+		t.Errorf("expected 90001.5, got %f (err %v)", result, err)
+	}
+	result, err = cv.parseSRTTime("100:00:00,000")
+	if err != nil || result != 360000 {
+		t.Errorf("expected 360000, got %f (err %v)", result, err)
+	}
+	// WebVTT allows the hours component to be omitted
+	result, err = cv.parseWebVTTTime("01:30.250 line:0")
+	if err != nil || result != 90.25 {
+		t.Errorf("expected 90.25, got %f (err %v)", result, err)
+	}
+}
+
+func TestParseReportsTimestampRangeErrors(t *testing.T) {
+	cv := NewCaptionValidator("http://test.com")
+
+	vtt := "WEBVTT\n\n00:00:01.000 --> 00:00:05.000\nFine\n\n00:61:00.000 --> 00:62:00.000\nBad minutes\n"
+	captions, failures, err := cv.parseWebVTT(vtt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(captions) != 1 {
+		t.Errorf("expected 1 caption, got %d", len(captions))
+	}
+	rangeErrs := timestampRangeErrors(failures)
+	if len(rangeErrs) != 1 || rangeErrs[0].Type != "timestamp_range" || rangeErrs[0].Line != 6 {
+		t.Fatalf("expected one timestamp_range error on line 6, got %+v", rangeErrs)
+	}
+
+	srt := "1\n00:00:01,000 --> 00:00:05,000\nFine\n\n2\n00:00:61,000 --> 00:00:62,000\nBad seconds\n"
+	captions, failures, err = cv.parseSRT(srt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(captions) != 1 {
+		t.Errorf("expected 1 caption, got %d", len(captions))
+	}
+	if len(failures) != 1 || failures[0].Kind != FailureTimestamp || failures[0].Line != 6 {
+		t.Fatalf("expected one timestamp failure on line 6, got %+v", failures)
+	}
+}
+
+func TestApplyOffset(t *testing.T) {
+	captions := []Caption{
+		{StartTime: 1.0, EndTime: 2.0, Text: "Dropped"},
+		{StartTime: 2.0, EndTime: 6.0, Text: "Clamped"},
+		{StartTime: 10.0, EndTime: 12.0, Text: "Shifted"},
+	}
+
+	shifted, rangeErrs := applyOffset(captions, -3)
+	if len(rangeErrs) != 2 {
+		t.Errorf("expected 2 timestamp_range errors, got %d", len(rangeErrs))
+	}
+	if rangeErrs[0].SuggestedFix.Cues != nil || !slices.Equal(rangeErrs[1].SuggestedFix.Cues, []int{1}) {
+		t.Errorf("expected the dropped cue unnumbered and the clamped one as shifted cue 1, got %v and %v", rangeErrs[0].SuggestedFix.Cues, rangeErrs[1].SuggestedFix.Cues)
+	}
+	if len(shifted) != 2 {
+		t.Fatalf("expected 2 captions after offset, got %d", len(shifted))
+	}
+	if shifted[0].StartTime != 0 || shifted[0].EndTime != 3 {
+		t.Errorf("expected clamped cue 0-3, got %f-%f", shifted[0].StartTime, shifted[0].EndTime)
+	}
+	if shifted[1].StartTime != 7 || shifted[1].EndTime != 9 {
+		t.Errorf("expected shifted cue 7-9, got %f-%f", shifted[1].StartTime, shifted[1].EndTime)
+	}
+}
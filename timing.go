@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DetectorTimeouts bounds calls to the language detection endpoint. Zero values mean no limit.
+type DetectorTimeouts struct {
+	Connect    time.Duration // establishing the connection
+	Request    time.Duration // one detection call, from connecting to reading the response
+	Validation time.Duration // all detection calls for one file together
+}
+
+// defaultDetectorTimeouts keeps the former 30s per-call limit
+var defaultDetectorTimeouts = DetectorTimeouts{Connect: 10 * time.Second, Request: 30 * time.Second}
+
+// errValidationDeadline reports that a file's detection calls ran past the validation deadline
+var errValidationDeadline = errors.New("validation deadline exceeded")
+
+// detectionContext returns the context shared by one file's detection calls
+func (cv *CaptionValidator) detectionContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if cv.timeouts.Validation <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, cv.timeouts.Validation, errValidationDeadline)
+}
+
+// maxIdleDetectorConns is how many kept-alive connections to the detector are pooled,
+// enough for every batch worker or server job to reuse one instead of redialing
+const maxIdleDetectorConns = 64
+
+// setTimeouts sets the detector timeouts and builds the one HTTP client all detection
+// calls share, across files and goroutines, so connections are pooled and kept alive
+func (cv *CaptionValidator) setTimeouts(timeouts DetectorTimeouts) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: timeouts.Connect, KeepAlive: 30 * time.Second}).DialContext
+	transport.MaxIdleConnsPerHost = maxIdleDetectorConns
+	cv.timeouts = timeouts
+	cv.client = &http.Client{Timeout: timeouts.Request, Transport: transport}
+}
+
+// drainClose reads what is left of a response body, such as the newline after a JSON
+// answer, before closing it; a connection is only reused once its body is read to EOF
+func drainClose(body io.ReadCloser) {
+	io.CopyN(io.Discard, body, 4<<10)
+	body.Close()
+}
+
+// LatencyStats summarizes a set of durations in milliseconds
+type LatencyStats struct {
+	Count int     `json:"count"`
+	P50   float64 `json:"p50_ms"`
+	P90   float64 `json:"p90_ms"`
+	P99   float64 `json:"p99_ms"`
+	Max   float64 `json:"max_ms"`
+}
+
+// RunStats is printed to stderr with -stats after a run, so time spent waiting on the
+// detector can be told apart from time spent validating
+type RunStats struct {
+	Type       string        `json:"type"`
+	Validation LatencyStats  `json:"validation"` // wall time per file, detection included
+	Detector   DetectorStats `json:"detector"`
+}
+
+// DetectorStats counts detection calls and summarizes their latency
+type DetectorStats struct {
+	Errors   int          `json:"errors"`   // failed calls, timeouts included
+	Timeouts int          `json:"timeouts"` // calls cut off by a timeout or the validation deadline
+	Latency  LatencyStats `json:"latency"`
+}
+
+// runStats records latencies across a run; a nil *runStats records nothing
+type runStats struct {
+	mu          sync.Mutex
+	validations []time.Duration
+	detections  []time.Duration
+	errors      int
+	timeouts    int
+}
+
+func (rs *runStats) recordValidation(elapsed time.Duration) {
+	if rs == nil {
+		return
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.validations = append(rs.validations, elapsed)
+}
+
+func (rs *runStats) recordDetection(elapsed time.Duration, err error) {
+	if rs == nil {
+		return
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.detections = append(rs.detections, elapsed)
+	if err != nil {
+		rs.errors++
+		if isTimeout(err) {
+			rs.timeouts++
+		}
+	}
+}
+
+// isTimeout reports whether a detection call was cut off by a timeout or the
+// validation deadline
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, errValidationDeadline) || errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// snapshot summarizes everything recorded so far
+func (rs *runStats) snapshot() RunStats {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return RunStats{
+		Type:       "stats",
+		Validation: latencyStats(rs.validations),
+		Detector: DetectorStats{
+			Errors:   rs.errors,
+			Timeouts: rs.timeouts,
+			Latency:  latencyStats(rs.detections),
+		},
+	}
+}
+
+// latencyStats computes nearest-rank percentiles of durations
+func latencyStats(durations []time.Duration) LatencyStats {
+	stats := LatencyStats{Count: len(durations)}
+	if len(durations) == 0 {
+		return stats
+	}
+	sorted := slices.Sorted(slices.Values(durations))
+	rank := func(p float64) float64 {
+		i := int(math.Ceil(p*float64(len(sorted)))) - 1
+		return milliseconds(sorted[max(i, 0)])
+	}
+	stats.P50, stats.P90, stats.P99 = rank(0.50), rank(0.90), rank(0.99)
+	stats.Max = milliseconds(sorted[len(sorted)-1])
+	return stats
+}
+
+// String formats the stats as a JSON line
+func (s RunStats) String() string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// PhaseDurations is where one file's validation spent its time, in milliseconds, so
+// a slow detector shows in the reports themselves
+type PhaseDurations struct {
+	Parse    float64 `json:"parse_ms"`    // reading and parsing the file, duplicates removed
+	Coverage float64 `json:"coverage_ms"` // the coverage check and metrics
+	Language float64 `json:"language_ms"` // language detection calls, retries included
+	Total    float64 `json:"total_ms"`    // the whole validation, every other check included
+}
+
+// milliseconds converts a duration to milliseconds with microsecond precision
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// reportTime is a report timestamp: UTC, to the millisecond
+func reportTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Millisecond)
+}
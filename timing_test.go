@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLatencyStats(t *testing.T) {
+	var durations []time.Duration
+	for i := 100; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+	stats := latencyStats(durations)
+	expected := LatencyStats{Count: 100, P50: 50, P90: 90, P99: 99, Max: 100}
+	if stats != expected {
+		t.Errorf("expected %+v, got %+v", expected, stats)
+	}
+	if stats := latencyStats(nil); stats != (LatencyStats{}) {
+		t.Errorf("expected empty stats, got %+v", stats)
+	}
+}
+
+func TestValidationDeadline(t *testing.T) {
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(300 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	defer detector.Close()
+
+	cv := NewCaptionValidator(detector.URL)
+	cv.timeouts.Validation = 50 * time.Millisecond
+	cv.stats = &runStats{}
+
+	ctx, cancel := cv.detectionContext(context.Background())
+	defer cancel()
+	start := time.Now()
+	_, err := cv.detectLanguage(ctx, "Hello there")
+	if !errors.Is(err, errValidationDeadline) {
+		t.Fatalf("expected validation deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Errorf("deadline did not cut the call short: %s", elapsed)
+	}
+
+	stats := cv.stats.snapshot()
+	if stats.Detector.Latency.Count != 1 || stats.Detector.Errors != 1 || stats.Detector.Timeouts != 1 {
+		t.Errorf("expected one timed out call in stats, got %+v", stats.Detector)
+	}
+}
+
+func TestReportTiming(t *testing.T) {
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	defer detector.Close()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ep1.srt")
+	os.WriteFile(path, []byte("1\n00:00:01,000 --> 00:00:09,000\nHello there\n"), 0o644)
+
+	cv := NewCaptionValidator(detector.URL)
+	before := time.Now()
+	report, err := cv.Validate(path, Window{End: 10}, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.StartedAt.Before(before.Truncate(time.Millisecond)) || report.FinishedAt.Before(report.StartedAt) || report.StartedAt.Location() != time.UTC {
+		t.Errorf("unexpected timestamps %v, %v", report.StartedAt, report.FinishedAt)
+	}
+	durations := report.Durations
+	if durations == nil || durations.Language < 50 || durations.Parse <= 0 || durations.Total < durations.Parse+durations.Coverage+durations.Language {
+		t.Errorf("unexpected durations %+v", durations)
+	}
+
+	// A file that could not be validated has timestamps but no phases
+	notes := filepath.Join(dir, "notes.txt")
+	os.WriteFile(notes, []byte("not captions"), 0o644)
+	failed := cv.validateForReport(notes, Window{End: 10}, 80)
+	if failed.ProgramError == "" || failed.StartedAt.IsZero() || failed.FinishedAt.IsZero() || failed.Durations != nil {
+		t.Errorf("unexpected report %+v", failed)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(300 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+	}))
+	defer detector.Close()
+
+	cv := NewCaptionValidator(detector.URL)
+	cv.setTimeouts(DetectorTimeouts{Request: 50 * time.Millisecond})
+	if _, err := cv.detectLanguage(context.Background(), "Hello there"); err == nil || errors.Is(err, errValidationDeadline) {
+		t.Errorf("expected a request timeout, got %v", err)
+	}
+}
+
+func TestDetectionCallsReuseConnections(t *testing.T) {
+	var dials atomic.Int32
+	detector := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	detector.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			dials.Add(1)
+		}
+	}
+	detector.Start()
+	defer detector.Close()
+
+	cv := NewCaptionValidator(detector.URL)
+	for range 5 {
+		if _, err := cv.detectLanguage(context.Background(), "Hello there"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := dials.Load(); n != 1 {
+		t.Fatalf("expected consecutive calls to reuse one connection, got %d", n)
+	}
+
+	// Concurrent callers share one validator, as batch workers and server jobs do
+	const workers, calls = 8, 5
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range calls {
+				if lang, err := cv.detectLanguage(context.Background(), "Hello there"); err != nil || lang != "en-US" {
+					t.Errorf("unexpected detection %q, %v", lang, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := dials.Load(); n >= workers*calls/2 {
+		t.Errorf("expected pooled connections for %d concurrent calls, got %d", workers*calls, n)
+	}
+}
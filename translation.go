@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// QualitySuspectWarning reports caption text that scores as likely machine translated
+type QualitySuspectWarning struct {
+	Type             string             `json:"type"`
+	Rule             string             `json:"rule"`
+	Severity         string             `json:"severity"`
+	Score            float64            `json:"score"`
+	Threshold        float64            `json:"threshold"`
+	Scorer           string             `json:"scorer"` // "heuristic" or the model command's name
+	Signals          TranslationSignals `json:"signals"`
+	UntranslatedCues []int              `json:"untranslated_cues,omitempty"`
+	Description      string             `json:"description"`
+	SuggestedFix     *SuggestedFix      `json:"suggested_fix,omitempty"`
+}
+
+// TranslationSignals are the heuristic measurements behind a translation quality score
+type TranslationSignals struct {
+	Untranslated float64 `json:"untranslated_share"` // share of cues still in English
+	Repetition   float64 `json:"trigram_repetition"` // share of word trigrams that repeat an earlier one
+}
+
+// TranslationModelInput is the JSON document written to the -mt_model command's stdin
+type TranslationModelInput struct {
+	Language string    `json:"language"`
+	Cues     []Caption `json:"cues"`
+}
+
+// translationModelOutput is what the -mt_model command prints: a score from 0
+// (human) to 1 (machine translated)
+type translationModelOutput struct {
+	Score *float64 `json:"score"`
+}
+
+// englishFunctionWords are frequent English words that rarely survive translation
+var englishFunctionWords = map[string]bool{
+	"the": true, "and": true, "is": true, "are": true, "was": true, "you": true,
+	"to": true, "of": true, "it": true, "that": true, "this": true, "what": true,
+	"have": true, "with": true, "for": true, "not": true, "we": true, "they": true,
+	"he": true, "she": true, "be": true, "do": true, "don't": true, "i'm": true,
+}
+
+const (
+	// untranslatedWordShare is the share of English function words that marks a cue untranslated
+	untranslatedWordShare = 0.4
+	// minRepetitionTrigrams is how many trigrams a file needs before repetition is meaningful
+	minRepetitionTrigrams = 30
+	// Trigram repetition below the floor is normal dialogue; at the ceiling it counts fully
+	repetitionFloor, repetitionCeiling = 0.1, 0.5
+)
+
+// measureTranslation computes the heuristic signals and returns the untranslated cues.
+// Untranslated stretches are only looked for when the expected language is not English.
+func measureTranslation(captions []Caption, language string) (TranslationSignals, []int) {
+	var signals TranslationSignals
+	var untranslated []int
+	checkEnglish := !strings.EqualFold(strings.SplitN(language, "-", 2)[0], "en")
+
+	seen := map[string]bool{}
+	trigrams, repeats, counted := 0, 0, 0
+	for i, caption := range captions {
+		words := captionWords(caption.Text)
+		if len(words) == 0 {
+			continue
+		}
+		counted++
+
+		if checkEnglish && len(words) >= 3 {
+			english := 0
+			for _, word := range words {
+				if englishFunctionWords[word] {
+					english++
+				}
+			}
+			if float64(english)/float64(len(words)) >= untranslatedWordShare {
+				untranslated = append(untranslated, i+1)
+			}
+		}
+
+		for j := 0; j+2 < len(words); j++ {
+			trigram := words[j] + " " + words[j+1] + " " + words[j+2]
+			if seen[trigram] {
+				repeats++
+			}
+			seen[trigram] = true
+			trigrams++
+		}
+	}
+
+	if counted > 0 {
+		signals.Untranslated = float64(len(untranslated)) / float64(counted)
+	}
+	if trigrams >= minRepetitionTrigrams {
+		signals.Repetition = float64(repeats) / float64(trigrams)
+	}
+	return signals, untranslated
+}
+
+// heuristicScore combines the signals as independent evidence into a 0-1 score
+func (s TranslationSignals) heuristicScore() float64 {
+	repetition := min(max((s.Repetition-repetitionFloor)/(repetitionCeiling-repetitionFloor), 0), 1)
+	return 1 - (1-s.Untranslated)*(1-repetition)
+}
+
+// scoreWithModel runs the -mt_model command on the cues and returns its score
+func (cv *CaptionValidator) scoreWithModel(captions []Caption) (float64, error) {
+	input := TranslationModelInput{Language: cv.expectedLanguage, Cues: captions}
+	if input.Cues == nil {
+		input.Cues = []Caption{}
+	}
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pluginTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, cv.mtModel[0], cv.mtModel[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, err
+	}
+
+	var result translationModelOutput
+	if err := json.Unmarshal(out, &result); err != nil {
+		return 0, fmt.Errorf("invalid JSON output: %w", err)
+	}
+	if result.Score == nil || *result.Score < 0 || *result.Score > 1 {
+		return 0, fmt.Errorf("output needs a \"score\" between 0 and 1: %s", bytes.TrimSpace(out))
+	}
+	return *result.Score, nil
+}
+
+// validateTranslationQuality scores the captions for machine translation artifacts,
+// with the -mt_model command when one is configured, and warns above the threshold.
+// A failing model is reported as a plugin_error.
+func (cv *CaptionValidator) validateTranslationQuality(captions []Caption) interface{} {
+	signals, untranslated := measureTranslation(captions, cv.expectedLanguage)
+	score, scorer := signals.heuristicScore(), "heuristic"
+	if len(cv.mtModel) > 0 {
+		name := filepath.Base(cv.mtModel[0])
+		modelScore, err := cv.scoreWithModel(captions)
+		if err != nil {
+			return &PluginError{
+				Type:        "plugin_error",
+				Plugin:      name,
+				Description: fmt.Sprintf("Translation quality model %s failed: %v", name, err),
+				SuggestedFix: &SuggestedFix{
+					Action:      FixCheckPlugin,
+					Description: fmt.Sprintf("Run %s by hand to see why it fails", name),
+				},
+			}
+		}
+		score, scorer = modelScore, name
+	}
+	if score < cv.mtThreshold {
+		return nil
+	}
+
+	return &QualitySuspectWarning{
+		Type:             "quality_suspect",
+		Severity:         SeverityWarning,
+		Score:            score,
+		Threshold:        cv.mtThreshold,
+		Scorer:           scorer,
+		Signals:          signals,
+		UntranslatedCues: untranslated,
+		Description:      fmt.Sprintf("Machine translation score %.2f is at or above %.2f (%.0f%% of cues untranslated, %.0f%% repeated trigrams)", score, cv.mtThreshold, signals.Untranslated*100, signals.Repetition*100),
+		SuggestedFix: &SuggestedFix{
+			Action:      FixReviewTranslation,
+			Cues:        untranslated,
+			Description: "Have a translator review the track before accepting it",
+		},
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestMeasureTranslation(t *testing.T) {
+	captions := []Caption{
+		{Text: "¿Dónde está la estación?"},
+		{Text: "What is this for?"},
+		{Text: "No lo sé."},
+		{Text: "OK"},
+	}
+
+	signals, untranslated := measureTranslation(captions, "es-ES")
+	if !reflect.DeepEqual(untranslated, []int{2}) || signals.Untranslated != 0.25 {
+		t.Errorf("expected cue 2 untranslated out of 4, got %v %+v", untranslated, signals)
+	}
+	if _, untranslated := measureTranslation(captions, "en-US"); len(untranslated) != 0 {
+		t.Errorf("expected no untranslated cues for English, got %v", untranslated)
+	}
+
+	// The same sentence over and over is a machine translation loop
+	looping := make([]Caption, 12)
+	for i := range looping {
+		looping[i] = Caption{Text: "Ich gehe jetzt nach Hause"}
+	}
+	signals, _ = measureTranslation(looping, "de-DE")
+	if signals.Repetition < 0.9 || signals.heuristicScore() != 1 {
+		t.Errorf("expected high repetition and full score, got %+v score %v", signals, signals.heuristicScore())
+	}
+}
+
+func TestValidateTranslationQuality(t *testing.T) {
+	cv := NewCaptionValidator("http://test.com")
+	cv.expectedLanguage = "es-ES"
+	cv.mtThreshold = 0.5
+	captions := []Caption{
+		{Text: "What is this for?"},
+		{Text: "No lo sé."},
+	}
+
+	warn, ok := cv.validateTranslationQuality(captions).(*QualitySuspectWarning)
+	if !ok || warn.Score != 0.5 || warn.Scorer != "heuristic" || !reflect.DeepEqual(warn.UntranslatedCues, []int{1}) {
+		t.Fatalf("expected heuristic quality_suspect at 0.5, got %+v", warn)
+	}
+	if warn.SuggestedFix.Action != FixReviewTranslation {
+		t.Errorf("unexpected suggested fix %+v", warn.SuggestedFix)
+	}
+
+	cv.mtThreshold = 0.6
+	if issue := cv.validateTranslationQuality(captions); issue != nil {
+		t.Errorf("expected no warning below threshold, got %+v", issue)
+	}
+}
+
+func TestTranslationModelHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("model scripts use sh")
+	}
+	dir := t.TempDir()
+	scripts := map[string]string{
+		// Scores by whether it received the expected language
+		"model":  "#!/bin/sh\nif grep -q '\"language\":\"fr-FR\"'; then echo '{\"score\": 0.9}'; else echo '{\"score\": 0}'; fi\n",
+		"broken": "#!/bin/sh\necho '{\"score\": 7}'\n",
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cv := NewCaptionValidator("http://test.com")
+	cv.expectedLanguage = "fr-FR"
+	cv.mtThreshold = 0.8
+	cv.mtModel = []string{filepath.Join(dir, "model")}
+	captions := []Caption{{Text: "Bonjour"}}
+
+	warn, ok := cv.validateTranslationQuality(captions).(*QualitySuspectWarning)
+	if !ok || warn.Score != 0.9 || warn.Scorer != "model" {
+		t.Fatalf("expected model score 0.9, got %+v", warn)
+	}
+
+	cv.mtModel = []string{filepath.Join(dir, "broken")}
+	pluginErr, ok := cv.validateTranslationQuality(captions).(*PluginError)
+	if !ok || !strings.Contains(pluginErr.Description, "between 0 and 1") {
+		t.Errorf("expected plugin_error for out-of-range score, got %+v", pluginErr)
+	}
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FormatTTML is a standalone TTML, DFXP or IMSC document
+const FormatTTML = "ttml"
+
+// ttmlTiming holds the ttp: parameters that frame and tick time expressions depend on
+type ttmlTiming struct {
+	frameRate    float64 // effective frames per second, multiplier applied
+	subFrameRate float64
+	tickRate     float64
+}
+
+// newTTMLTiming reads the timing parameters from the root tt element's attributes
+func newTTMLTiming(attrs []xml.Attr) (ttmlTiming, error) {
+	timing := ttmlTiming{frameRate: 30, subFrameRate: 1}
+	frameRateSet, tickRateSet := false, false
+	multiplier := 1.0
+	for _, attr := range attrs {
+		var err error
+		switch attr.Name.Local {
+		case "frameRate":
+			timing.frameRate, err = strconv.ParseFloat(strings.TrimSpace(attr.Value), 64)
+			frameRateSet = true
+		case "subFrameRate":
+			timing.subFrameRate, err = strconv.ParseFloat(strings.TrimSpace(attr.Value), 64)
+		case "tickRate":
+			timing.tickRate, err = strconv.ParseFloat(strings.TrimSpace(attr.Value), 64)
+			tickRateSet = true
+		case "frameRateMultiplier":
+			fields := strings.Fields(attr.Value)
+			if len(fields) != 2 {
+				return timing, fmt.Errorf("invalid ttp:frameRateMultiplier %q", attr.Value)
+			}
+			num, err1 := strconv.ParseFloat(fields[0], 64)
+			den, err2 := strconv.ParseFloat(fields[1], 64)
+			if err1 != nil || err2 != nil || num <= 0 || den <= 0 {
+				return timing, fmt.Errorf("invalid ttp:frameRateMultiplier %q", attr.Value)
+			}
+			multiplier = num / den
+		}
+		if err != nil {
+			return timing, fmt.Errorf("invalid ttp:%s %q", attr.Name.Local, attr.Value)
+		}
+	}
+	if timing.frameRate <= 0 || timing.subFrameRate <= 0 || tickRateSet && timing.tickRate <= 0 {
+		return timing, fmt.Errorf("TTML frame, sub-frame and tick rates must be positive")
+	}
+	// Ticks default to frames when a frame rate is given, and to seconds otherwise
+	if !tickRateSet {
+		timing.tickRate = 1
+		if frameRateSet {
+			timing.tickRate = timing.frameRate * timing.subFrameRate
+		}
+	}
+	timing.frameRate *= multiplier
+	return timing, nil
+}
+
+var (
+	ttmlClockPattern  = regexp.MustCompile(`^(\d{2,}):(\d{2}):(\d{2})(?:(\.\d+)|:(\d{2,})(?:\.(\d+))?)?$`)
+	ttmlOffsetPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)(h|ms|m|s|f|t)$`)
+)
+
+// seconds converts a TTML clock time (HH:MM:SS.fff or HH:MM:SS:FF.sub) or offset time
+// (10.5s, 250ms, 48f, 900000t) to seconds
+func (timing ttmlTiming) seconds(expr string) (float64, error) {
+	expr = strings.TrimSpace(expr)
+	if m := ttmlClockPattern.FindStringSubmatch(expr); m != nil {
+		hours, _ := strconv.ParseFloat(m[1], 64)
+		minutes, _ := strconv.ParseFloat(m[2], 64)
+		seconds, _ := strconv.ParseFloat(m[3], 64)
+		total := hours*3600 + minutes*60 + seconds
+		if m[4] != "" {
+			fraction, _ := strconv.ParseFloat(m[4], 64)
+			total += fraction
+		}
+		if m[5] != "" {
+			frames, _ := strconv.ParseFloat(m[5], 64)
+			if m[6] != "" {
+				subFrames, _ := strconv.ParseFloat(m[6], 64)
+				frames += subFrames / timing.subFrameRate
+			}
+			total += frames / timing.frameRate
+		}
+		return total, nil
+	}
+	if m := ttmlOffsetPattern.FindStringSubmatch(expr); m != nil {
+		value, _ := strconv.ParseFloat(m[1], 64)
+		switch m[2] {
+		case "h":
+			return value * 3600, nil
+		case "m":
+			return value * 60, nil
+		case "s":
+			return value, nil
+		case "ms":
+			return value / 1000, nil
+		case "f":
+			return value / timing.frameRate, nil
+		default:
+			return value / timing.tickRate, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid TTML time expression %q", expr)
+}
+
+// ttmlScope is the active interval of a timed element; a missing end is +Inf
+type ttmlScope struct {
+	begin, end float64
+}
+
+// ttmlWhitespace collapses runs of whitespace, as xml:space="default" renders them
+var ttmlWhitespace = regexp.MustCompile(`\s+`)
+
+// TTMLDocument is the text of a TTML or IMSC document, timed on its own timeline
+type TTMLDocument struct {
+	Lang     string // xml:lang of the root element
+	Cues     []Caption
+	Failures []ParseFailure
+}
+
+// parseTTML reads the paragraphs of a TTML document as cues. Each p is active from
+// its begin to its end (or begin plus dur), relative to the enclosing body and divs
+// in parallel time containment; span and br content is part of its paragraph.
+// Paragraphs left without an end are returned as missing_timing failures.
+func (cv *CaptionValidator) parseTTML(content []byte) (*TTMLDocument, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	doc := &TTMLDocument{}
+	var timing ttmlTiming
+	scopes := []ttmlScope{{begin: 0, end: math.Inf(1)}}
+	var (
+		inParagraph bool
+		paragraph   ttmlScope
+		lines       []string
+		line        strings.Builder
+		lineNo      int
+	)
+	sawRoot := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode TTML: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "tt":
+				sawRoot = true
+				if timing, err = newTTMLTiming(t.Attr); err != nil {
+					return nil, err
+				}
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "lang" {
+						doc.Lang = attr.Value
+					}
+				}
+			case "body", "div", "p", "span":
+				scope, err := timing.scope(t.Attr, scopes[len(scopes)-1])
+				if err != nil {
+					line, _ := decoder.InputPos()
+					return nil, fmt.Errorf("TTML line %d: %w", line, err)
+				}
+				scopes = append(scopes, scope)
+				if t.Name.Local == "p" {
+					inParagraph, paragraph, lines = true, scope, nil
+					line.Reset()
+					lineNo, _ = decoder.InputPos()
+				}
+			case "br":
+				if inParagraph {
+					lines = append(lines, line.String())
+					line.Reset()
+				}
+			}
+		case xml.CharData:
+			if inParagraph {
+				line.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "body", "div", "span":
+				scopes = scopes[:len(scopes)-1]
+			case "p":
+				scopes = scopes[:len(scopes)-1]
+				inParagraph = false
+				lines = append(lines, line.String())
+				var text []string
+				for _, l := range lines {
+					if l = strings.TrimSpace(ttmlWhitespace.ReplaceAllString(l, " ")); l != "" {
+						text = append(text, l)
+					}
+				}
+				switch {
+				case len(text) == 0:
+					continue
+				case math.IsInf(paragraph.end, 1):
+					doc.Failures = append(doc.Failures, ParseFailure{Line: lineNo, Kind: FailureMissingTiming, Text: strings.Join(text, " "), Reason: "paragraph has no end or dur"})
+				case paragraph.end > paragraph.begin:
+					doc.Cues = append(doc.Cues, Caption{
+						StartTime: paragraph.begin,
+						EndTime:   paragraph.end,
+						Text:      strings.Join(text, " "),
+						Lines:     len(text),
+						Layout:    cv.cueLayout(text),
+					})
+				}
+			}
+		}
+	}
+	if !sawRoot {
+		return nil, fmt.Errorf("not a TTML document: no tt element")
+	}
+	return doc, nil
+}
+
+// scope resolves an element's begin, end and dur against its parent's interval
+func (timing ttmlTiming) scope(attrs []xml.Attr, parent ttmlScope) (ttmlScope, error) {
+	scope := ttmlScope{begin: parent.begin, end: math.Inf(1)}
+	var end, dur *float64
+	for _, attr := range attrs {
+		if attr.Name.Local != "begin" && attr.Name.Local != "end" && attr.Name.Local != "dur" {
+			continue
+		}
+		value, err := timing.seconds(attr.Value)
+		if err != nil {
+			return scope, err
+		}
+		switch attr.Name.Local {
+		case "begin":
+			scope.begin = parent.begin + value
+		case "end":
+			end = &value
+		case "dur":
+			dur = &value
+		}
+	}
+	switch {
+	case end != nil && dur != nil:
+		scope.end = min(parent.begin+*end, scope.begin+*dur)
+	case end != nil:
+		scope.end = parent.begin + *end
+	case dur != nil:
+		scope.end = scope.begin + *dur
+	}
+	scope.end = min(scope.end, parent.end)
+	return scope, nil
+}
+
+// ttmlCues decodes a standalone TTML document into cues. The whole document is read
+// first, as paragraph timing depends on the elements around it; paragraphs without an
+// end are returned as parse failures.
+func (cv *CaptionValidator) ttmlCues(source io.Reader) iter.Seq2[Caption, error] {
+	return func(yield func(Caption, error) bool) {
+		content, err := io.ReadAll(source)
+		if err != nil {
+			yield(Caption{}, fmt.Errorf("failed to read file: %w", err))
+			return
+		}
+		doc, err := cv.parseTTML(content)
+		if err != nil {
+			yield(Caption{}, err)
+			return
+		}
+		for i := range doc.Failures {
+			if !yield(Caption{}, &doc.Failures[i]) {
+				return
+			}
+		}
+		for _, caption := range doc.Cues {
+			if !yield(caption, nil) {
+				return
+			}
+		}
+	}
+}
+
+// parseTTMLHead reads the metadata of a TTML document from its root element and head:
+// xml:lang is the language, ttp:frameRate the frame rate and ttm:title the title.
+// Reading stops at the body.
+func parseTTMLHead(source io.Reader) *FileMetadata {
+	decoder := xml.NewDecoder(source)
+	metadata := &FileMetadata{}
+	inTitle := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "tt":
+				for _, attr := range t.Attr {
+					switch attr.Name.Local {
+					case "lang":
+						metadata.Language = attr.Value
+					case "frameRate":
+						metadata.FrameRate, _ = strconv.ParseFloat(strings.TrimSpace(attr.Value), 64)
+					}
+				}
+			case "title":
+				inTitle = true
+			case "body":
+				return ttmlMetadataOrNil(metadata)
+			}
+		case xml.CharData:
+			if inTitle {
+				metadata.Title += strings.TrimSpace(string(t))
+			}
+		case xml.EndElement:
+			if t.Name.Local == "title" {
+				inTitle = false
+			}
+		}
+	}
+	return ttmlMetadataOrNil(metadata)
+}
+
+// ttmlMetadataOrNil returns nil for a document that declares nothing about itself
+func ttmlMetadataOrNil(metadata *FileMetadata) *FileMetadata {
+	if metadata.Title == "" && metadata.Language == "" && metadata.FrameRate == 0 {
+		return nil
+	}
+	return metadata
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/xml"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTTMLTimeExpressions(t *testing.T) {
+	timing, err := newTTMLTiming([]xml.Attr{
+		{Name: xml.Name{Local: "frameRate"}, Value: "30"},
+		{Name: xml.Name{Local: "frameRateMultiplier"}, Value: "1000 1001"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"00:00:01.500", 1.5},
+		{"01:00:00", 3600},
+		{"00:00:01:15", 1 + 15/29.97002997},
+		{"2.5s", 2.5},
+		{"250ms", 0.25},
+		{"1.5m", 90},
+		{"30f", 30 / 29.97002997},
+		{"60t", 2}, // ticks default to nominal frames
+	}
+	for _, tt := range tests {
+		got, err := timing.seconds(tt.expr)
+		if err != nil || math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("seconds(%q) = %v, %v; want %v", tt.expr, got, err, tt.want)
+		}
+	}
+	if _, err := timing.seconds("1:2"); err == nil {
+		t.Error("expected an invalid time expression to be rejected")
+	}
+}
+
+func TestParseTTML(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttp="http://www.w3.org/ns/ttml#parameter" ttp:tickRate="10000000" xml:lang="es">
+  <body>
+    <div begin="10s">
+      <p begin="1s" end="3s">Hola,
+        <span>¿qué tal?</span><br/>Bien.</p>
+      <p begin="20000000t" dur="1s">Adiós</p>
+      <p begin="5s">Sin final</p>
+    </div>
+    <div begin="00:01:00.000" end="00:01:02.000">
+      <p begin="1s" end="5s">Recortado</p>
+    </div>
+  </body>
+</tt>`
+	parsed, err := NewCaptionValidator("").parseTTML([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Lang != "es" {
+		t.Errorf("expected xml:lang es, got %q", parsed.Lang)
+	}
+	want := []Caption{
+		{StartTime: 11, EndTime: 13, Text: "Hola, ¿qué tal? Bien.", Lines: 2},
+		{StartTime: 12, EndTime: 13, Text: "Adiós", Lines: 1},
+		{StartTime: 61, EndTime: 62, Text: "Recortado", Lines: 1},
+	}
+	if len(parsed.Cues) != len(want) {
+		t.Fatalf("expected %d cues, got %+v", len(want), parsed.Cues)
+	}
+	for i := range want {
+		if parsed.Cues[i] != want[i] {
+			t.Errorf("cue %d = %+v, want %+v", i, parsed.Cues[i], want[i])
+		}
+	}
+	if len(parsed.Failures) != 1 || parsed.Failures[0].Kind != FailureMissingTiming || parsed.Failures[0].Line != 8 {
+		t.Errorf("expected one missing_timing failure on line 8, got %+v", parsed.Failures)
+	}
+
+	if _, err := NewCaptionValidator("").parseTTML([]byte("<html></html>")); err == nil {
+		t.Error("expected a document without tt to be rejected")
+	}
+}
+
+func TestValidateTTMLFile(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<!-- Delivered by the broadcast captioning house; do not edit by hand -->
+<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttp="http://www.w3.org/ns/ttml#parameter" xmlns:ttm="http://www.w3.org/ns/ttml#metadata" ttp:frameRate="25" xml:lang="es-ES">
+  <head><metadata><ttm:title>Episodio 1</ttm:title></metadata></head>
+  <body>
+    <div>
+      <p begin="00:00:01:00" end="00:00:05:00">Hola, <span>¿qué tal?</span></p>
+      <p begin="6s" dur="3s">Muy bien</p>
+      <p begin="9s">Sin final</p>
+    </div>
+  </body>
+</tt>`
+	path := filepath.Join(t.TempDir(), "ep1.dfxp")
+	os.WriteFile(path, []byte(doc), 0o644)
+
+	cv := NewCaptionValidator("")
+	report, err := cv.Validate(path, Window{End: 10}, 70)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Format != FormatTTML || report.Cues != 2 || report.Coverage.WallClock != 70 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if len(report.ParseFailures) != 1 || report.ParseFailures[0].Kind != FailureMissingTiming {
+		t.Errorf("expected the paragraph without an end as a parse failure, got %+v", report.ParseFailures)
+	}
+	if metadata := report.Metadata; metadata == nil || metadata.Title != "Episodio 1" || metadata.Language != "es-ES" || metadata.FrameRate != 25 {
+		t.Errorf("expected the document's head metadata, got %+v", report.Metadata)
+	}
+
+	if format := sniffFormat([]byte(`<tt xmlns="http://www.w3.org/ns/ttml">`)); format != FormatTTML {
+		t.Errorf("expected a bare tt root to be sniffed as TTML, got %s", format)
+	}
+}
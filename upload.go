@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// ErrUnsupportedMediaType is returned for uploads whose Content-Type is not a caption
+// format, or names a different format than the content has
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// maxFieldBytes bounds each non-file multipart field, which are read into memory
+const maxFieldBytes = 4096
+
+// captionMediaTypes maps Content-Type values to the caption format they declare.
+// Generic types map to "" so the format is sniffed from the content.
+var captionMediaTypes = map[string]string{
+	"text/vtt":                 "webvtt",
+	"application/x-subrip":     "srt",
+	"application/srt":          "srt",
+	"text/srt":                 "srt",
+	"text/x-srt":               "srt",
+	"application/ttml+xml":     FormatTTML,
+	"text/x-scc":               FormatSCC,
+	"application/x-scc":        FormatSCC,
+	"":                         "",
+	"text/plain":               "",
+	"application/octet-stream": "",
+}
+
+// upload is a caption file streamed to a temporary path, with its request fields
+type upload struct {
+	name   string // file name reported in the report
+	path   string
+	size   int64
+	format string // format the Content-Type declares, when the content could not be sniffed
+	fields url.Values
+}
+
+// readUpload streams the request's caption file to disk. Multipart forms are read
+// part by part, so neither form carries the file in memory; any other body is
+// the raw caption file, named by the "name" query parameter.
+func readUpload(r *http.Request) (*upload, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil && r.Header.Get("Content-Type") != "" {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedMediaType, err)
+	}
+	fields := r.URL.Query()
+
+	if mediaType != "multipart/form-data" {
+		name := fields.Get("name")
+		if name == "" {
+			name = "upload"
+		}
+		return storeUpload(r.Body, name, mediaType, fields)
+	}
+
+	parts, err := r.MultipartReader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	var stored *upload
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if stored != nil {
+				os.Remove(stored.path)
+			}
+			return nil, fmt.Errorf("failed to read upload: %w", err)
+		}
+
+		if part.FormName() == "file" && stored == nil {
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			if stored, err = storeUpload(part, part.FileName(), partType, fields); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		value, err := io.ReadAll(io.LimitReader(part, maxFieldBytes+1))
+		if err == nil && len(value) > maxFieldBytes {
+			err = fmt.Errorf("field %q is longer than %d bytes", part.FormName(), maxFieldBytes)
+		}
+		if err != nil {
+			if stored != nil {
+				os.Remove(stored.path)
+			}
+			return nil, err
+		}
+		fields.Set(part.FormName(), string(value))
+	}
+	if stored == nil {
+		return nil, fmt.Errorf("missing caption file: no \"file\" part in the form")
+	}
+	return stored, nil
+}
+
+// storeUpload copies src to a temporary file after checking that the content
+// matches the format its media type declares. Content that cannot be sniffed is
+// read as the declared format instead.
+func storeUpload(src io.Reader, name, mediaType string, fields url.Values) (*upload, error) {
+	declared, ok := captionMediaTypes[mediaType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s (send text/vtt, application/x-subrip, application/ttml+xml, text/x-scc or text/plain)", ErrUnsupportedMediaType, mediaType)
+	}
+	content := bufio.NewReader(src)
+	header, err := content.Peek(formatHeaderSize)
+	if err != nil && err != io.EOF && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	hint := ""
+	switch sniffed := sniffFormat(header); {
+	case sniffed == FormatIMF:
+		// A CPL's track files and asset map would be looked up next to the upload,
+		// in the temporary directory every request shares
+		return nil, fmt.Errorf("%w: IMF compositions cannot be uploaded, as their cues come from the package's other files", ErrUnsupportedMediaType)
+	case sniffed == "unknown":
+		hint = declared
+	case declared != "" && sniffed != declared:
+		return nil, fmt.Errorf("%w: Content-Type %s declares %s but the content is %s", ErrUnsupportedMediaType, mediaType, declared, sniffed)
+	}
+
+	tmp, err := os.CreateTemp("", "caption-*"+filepath.Ext(name))
+	if err != nil {
+		return nil, err
+	}
+	defer tmp.Close()
+	n, err := io.Copy(tmp, content)
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+	return &upload{name: name, path: tmp.Name(), size: n, format: hint, fields: fields}, nil
+}
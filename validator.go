@@ -1,93 +1,590 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"regexp"
-	"strconv"
+	"slices"
 	"strings"
 	"time"
 )
 
 // Error types for validation failures
 type CaptionCoverageError struct {
-	Type             string  `json:"type"`
-	RequiredCoverage float64 `json:"required_coverage"`
-	ActualCoverage   float64 `json:"actual_coverage"`
-	StartTime        float64 `json:"start_time"`
-	EndTime          float64 `json:"end_time"`
-	Description      string  `json:"description"`
+	Type             string        `json:"type"`
+	Rule             string        `json:"rule"`
+	Severity         string        `json:"severity"` // warning below -coverage_warn, error below -coverage
+	RequiredCoverage float64       `json:"required_coverage"`
+	ActualCoverage   float64       `json:"actual_coverage"`
+	GatingMetric     string        `json:"gating_metric"`
+	WallClock        float64       `json:"wall_clock_coverage"`
+	DialogueWeighted float64       `json:"dialogue_weighted_coverage"`
+	Tolerance        float64       `json:"tolerance"`
+	EndPadding       float64       `json:"end_padding_seconds,omitempty"`
+	CoveredMs        int64         `json:"covered_ms"`
+	CoveredSeconds   float64       `json:"covered_seconds"`
+	StartTime        float64       `json:"start_time"`
+	EndTime          float64       `json:"end_time"`
+	Window           string        `json:"window"`
+	SegmentType      string        `json:"segment_type,omitempty"` // the -segment_map type measured, if any
+	Description      string        `json:"description"`
+	SuggestedFix     *SuggestedFix `json:"suggested_fix,omitempty"`
 }
 
 type IncorrectLanguageError struct {
-	Type         string `json:"type"`
-	DetectedLang string `json:"detected_language"`
-	ExpectedLang string `json:"expected_language"`
-	Description  string `json:"description"`
+	Type         string             `json:"type"`
+	Rule         string             `json:"rule"`
+	DetectedLang string             `json:"detected_language"`
+	ExpectedLang string             `json:"expected_language"`
+	Breakdown    map[string]float64 `json:"language_breakdown,omitempty"` // percent of captioned time per language, with -detect_chunk
+	Description  string             `json:"description"`
+	SuggestedFix *SuggestedFix      `json:"suggested_fix,omitempty"`
 }
 
 // Core types
+
+// CaptionValidator validates caption files. Set its options before the first call;
+// from then on one validator is safe for concurrent use by any number of goroutines,
+// as batch mode's workers and the server's jobs share it. Validation only reads its
+// options, shared state (stats, digests, baselines, limits) is locked, and every
+// detection call goes through one pooled, keep-alive HTTP client.
 type CaptionValidator struct {
-	endpoint string
+	endpoint         string
+	expectedLanguage string // language the captions must be in, e.g. en-US
+
+	asrPath             string  // optional word-level ASR reference for sync checks
+	maxLatency          float64 // allowed average caption delay in seconds
+	minASRConfidence    float64 // ASR confidence under which a cue counts as low-confidence; 0 disables low_asr_confidence
+	asrConfidenceWindow float64 // seconds of the window low_asr_confidence is measured over at a time
+
+	offset       float64      // seconds added to every cue time before validation
+	timeBase     string       // unit of JSON cue times: seconds, 90khz or pts (-time_base)
+	ptsOrigin    int64        // PTS of program time 0 with -time_base pts
+	ptsOriginSet bool         // -pts_origin was given, 0 included
+	redactMode   string       // how proper nouns and numbers are redacted before detection
+	sampleChars  int          // max characters sent for detection (0 sends all text)
+	smartJoin    bool         // rebuild hyphenated words and sentences across cues for detection
+	plugins      []string     // external validator executables
+	classifiers  []Classifier // text classification endpoints from -classifier, such as toxicity or PII
+	ocrCommand   []string     // command that turns bitmap subtitles into OCR JSON
+
+	coverageMetric string           // metric that gates coverage: wall_clock or dialogue_weighted
+	minReadable    float64          // cues shorter than this (seconds) are discounted in dialogue-weighted coverage
+	endPadding     float64          // seconds added to each cue's end when measuring coverage
+	clampWindow    string           // -clamp_window: media, last_cue or "" to keep the window as given
+	tolerance      float64          // percentage points of coverage shortfall that still pass
+	coverageWarn   float64          // coverage under this but over the required one is a warning; 0 disables
+	program        []ProgramSegment // program content from -program; coverage is measured over it instead of the window
+	segmentMap     *SegmentMap      // content segments from -segment_map, each type with its own coverage threshold
+	coverageImage  string           // path pattern the coverage timeline is drawn to; empty draws nothing
+	segmentation   SegmentationThresholds
+	percentiles    []PercentileTarget // cue statistic percentile targets from -profile and -percentile
+	flash          FlashLimits
+	minWPM         float64 // words per minute under which the track is flagged as implausibly sparse; 0 disables
+	maxStuck       float64 // seconds consecutive cues may repeat one text before stuck_caption; 0 disables
+	minSpeechRatio float64 // share of a cue's estimated speaking time it must be shown for; 0 disables
+	detectChunk    float64 // seconds of captions detected at a time for the language breakdown; 0 disables
+	punctuation    PunctuationStyle
+	allowPartial   bool // partly parsed files may pass; failures are still listed in the report
+	markupErrors   bool // report unbalanced SRT formatting tags
+	repairHybrids  bool // accept SRT/WebVTT hybrid timestamps without reporting format_mismatch
+	speakerCheck   bool // warn when a labeled speaker has no captions in the window
+	invisibleCheck bool // warn about zero-width, control and bidi characters and non-NFC text
+	metadataCheck  bool // warn when the header's declared language differs from the expected one
+	sdhCheck       bool // detect SDH annotations' language apart from the dialogue's
+	directives     bool // honor the cv- NOTE directives of WebVTT files (-directives)
+	inlineRules    bool // honor the cv-disable NOTE comments of WebVTT files (-inline_disable)
+
+	safeArea  SafeArea        // title-safe margins for positioned cues; zero disables the check
+	graphics  []GraphicRegion // on-screen graphics cues must not cover
+	rendering *LineRendering  // font lines are measured in for line_overflow; nil disables the check
+	locale    *localizer      // translates descriptions; nil leaves them in English
+	disabled  map[string]bool // rule IDs never reported (-disable)
+	baseline  *Baseline       // known violations to drop, or to record with -update_baseline
+	assets    AssetList       // per-file parameters from -asset_list, matched by file name
+
+	mtThreshold float64  // machine translation score that triggers quality_suspect (0 disables)
+	mtModel     []string // optional command that scores machine translation instead of the heuristic
+
+	timeouts        DetectorTimeouts     // connect, request and per-file limits on detection calls; set with setTimeouts
+	retries         int                  // times a detection call that timed out or got a 429 or 5xx is retried
+	deadline        time.Duration        // limit on each file's whole validation (-file_timeout); 0 for none
+	client          *http.Client         // shared by every detection call, built by setTimeouts
+	runID           string               // identifies the run in detector requests and reports
+	headers         http.Header          // extra headers sent with every detector request (-detector_header)
+	classifications *classificationCache // classifier scores by text for the run; nil caches nothing
+	stats           *runStats            // records latencies for -stats; nil records nothing
+	digest          *runDigest           // collects results for -email and -notify; nil collects nothing
+
+	openFiles  semaphore     // bounds concurrently open file handles
+	memory     *memoryBudget // bounds caption bytes held in memory
+	summaries  summaryLogger // receives a one-line summary of each validation
+	content    []byte        // caption file ValidateBytes holds in memory; nil reads it from disk
+	formatHint string        // format assumed when the content cannot be sniffed, as an upload's Content-Type declares
+	legacy     string        // per-error lines emitted with or instead of reports (-legacy_output)
 }
 
 type Caption struct {
-	StartTime float64
-	EndTime   float64
-	Text      string
+	StartTime  float64    `json:"start_time"`
+	EndTime    float64    `json:"end_time"`
+	Text       string     `json:"text"`
+	Markup     string     `json:"-"`                    // SRT cue text as written, before tags were stripped
+	Settings   string     `json:"-"`                    // WebVTT cue settings from the timing line, e.g. "line:90% align:start"
+	Lines      int        `json:"-"`                    // text lines as written
+	Suppressed string     `json:"-"`                    // space-separated rule IDs a cv-disable comment turns off for this cue
+	Layout     string     `json:"-"`                    // text lines with tags stripped, joined by "\n"; only kept for line_overflow
+	Source     SourceSpan `json:"-"`                    // where the cue sits in a WebVTT or SRT file; zero for other formats
+	Confidence *float64   `json:"confidence,omitempty"` // mean confidence of the -asr words spoken during the cue; nil without one
+}
+
+// FileReport is the structured result for a single caption file; batch mode prints one per file
+type FileReport struct {
+	File          string                `json:"file"`
+	Window        string                `json:"window"`
+	WindowClamped *WindowClamp          `json:"window_clamped,omitempty"` // the window as requested, when -clamp_window cut it short
+	SHA256        string                `json:"sha256,omitempty"`
+	Size          int64                 `json:"size,omitempty"` // bytes
+	Format        string                `json:"format,omitempty"`
+	Cues          int                   `json:"cues,omitempty"` // cues parsed, duplicates included
+	Coverage      *CoverageMetrics      `json:"coverage,omitempty"`
+	Segments      []SegmentCoverage     `json:"program_segments,omitempty"`
+	SegmentTypes  []SegmentTypeCoverage `json:"segment_coverage,omitempty"`
+	Speakers      []SpeakerStats        `json:"speakers,omitempty"`
+	Metadata      *FileMetadata         `json:"metadata,omitempty"`
+	ParseFailures []ParseFailure        `json:"parse_failures,omitempty"`
+	Errors        []interface{}         `json:"errors"`
+	Suppressed    []string              `json:"suppressed,omitempty"` // rule IDs of dropped issues, one per issue
+	Baselined     []string              `json:"baselined,omitempty"`  // rule IDs of issues already in the -baseline file
+	Directives    []string              `json:"directives,omitempty"` // the file's cv- NOTE directives that overrode run parameters
+	Asset         *Asset                `json:"asset,omitempty"`      // the -asset_list entry the file matched
+	ProgramError  string                `json:"program_error,omitempty"`
+	ErrorType     string                `json:"program_error_type,omitempty"` // validation_timeout or internal_error when the file was given up on
+	Version       string                `json:"validator_version"`            // build of the validator that wrote the report
+	RunID         string                `json:"run_id"`
+	StartedAt     time.Time             `json:"started_at,omitzero"`
+	FinishedAt    time.Time             `json:"finished_at,omitzero"`
+	Durations     *PhaseDurations       `json:"durations,omitempty"` // parse, coverage and language time; none when the file could not be validated
 }
 
+// LanguageResponse is the canonical detector answer; parseDetectorResponse also
+// accepts the other shapes detectors use
 type LanguageResponse struct {
 	Lang string `json:"lang"`
 }
 
 func NewCaptionValidator(endpoint string) *CaptionValidator {
-	return &CaptionValidator{
-		endpoint: endpoint,
+	cv := &CaptionValidator{
+		endpoint:         endpoint,
+		expectedLanguage: "en-US",
+		coverageMetric:   CoverageWallClock,
+		minReadable:      1.0,
+		timeBase:         TimeBaseSeconds,
+		runID:            newRunID(),
 	}
+	cv.setTimeouts(defaultDetectorTimeouts)
+	return cv
 }
 
-func (cv *CaptionValidator) ValidateFile(filepath string, tStart, tEnd, requiredCoverage float64) error {
-	format, err := cv.detectFormat(filepath)
+// setLimits applies resource limits shared by all validations run through this validator
+func (cv *CaptionValidator) setLimits(limits ResourceLimits) {
+	cv.openFiles = newSemaphore(limits.MaxOpenFiles)
+	cv.memory = newMemoryBudget(limits.MemoryBudget)
+}
+
+// ValidateFile validates a caption file and prints each validation error as a JSON line,
+// followed by the file's report with -legacy_output alongside
+func (cv *CaptionValidator) ValidateFile(filepath string, window Window, requiredCoverage float64) error {
+	report, err := cv.validateAndSummarize(filepath, window, requiredCoverage)
 	if err != nil {
 		return err
 	}
+	for _, issue := range report.Errors {
+		printJSON(issue)
+	}
+	if cv.legacy == LegacyAlongside {
+		printJSON(report)
+	}
+	return nil
+}
+
+// Validate runs all validations on a caption file and returns its report.
+// A non-nil error means the file could not be validated at all (e.g. unsupported format).
+func (cv *CaptionValidator) Validate(filepath string, window Window, requiredCoverage float64) (*FileReport, error) {
+	return cv.validate(context.Background(), filepath, window, requiredCoverage)
+}
+
+// validate is Validate with a context that bounds the file's detection calls
+func (cv *CaptionValidator) validate(ctx context.Context, filepath string, window Window, requiredCoverage float64) (*FileReport, error) {
+	started := time.Now()
+	var parseTime, coverageTime, languageTime time.Duration
+	// The file's -asset_list entry overrides run parameters, and its directives both
+	cv, window, requiredCoverage, asset := cv.withAsset(filepath, window, requiredCoverage)
+	if window.End == 0 && cv.assets != nil {
+		return nil, errors.New("no window: the -asset_list gives no duration for this file; set -t_end or -window")
+	}
+	if err := window.Validate(); err != nil {
+		return nil, err
+	}
 
-	// Exit with code 1 for unsupported formats
-	if format != "webvtt" && format != "srt" {
-		os.Exit(1)
+	format, err := cv.detectFormat(filepath)
+	if err != nil {
+		return nil, err
 	}
 
-	captions, err := cv.parseFile(filepath, format)
+	// Unsupported formats are program errors, not validation errors
+	if !isTextFormat(format) && !isBitmapFormat(format) && format != FormatOCRJSON && format != FormatTTML && format != FormatSCC && format != FormatIMF {
+		return nil, fmt.Errorf("unsupported caption format: %s", format)
+	}
+	if cv.content != nil && (isBitmapFormat(format) || format == FormatIMF) {
+		return nil, fmt.Errorf("%s captions cannot be validated in memory: their cues come from other files", format)
+	}
+
+	// With -directives, a WebVTT file's cv- directives override run parameters for this file only
+	directives, err := cv.readDirectives(filepath, format)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if cv, requiredCoverage, err = cv.withDirectives(directives, window, requiredCoverage); err != nil {
+		return nil, err
+	}
+
+	// Hold the file size against the memory budget while its captions are in memory
+	if size, err := cv.captionSize(filepath); err == nil {
+		cv.memory.acquire(size)
+		defer cv.memory.release(size)
+	}
+	// A validation abandoned at -file_timeout stops at each of these checks, and
+	// waiting for the budget may have taken that long
+	if err := abandoned(ctx); err != nil {
+		return nil, err
 	}
 
-	// Run validations and output errors as JSON
-	coverageErr := cv.validateCoverage(captions, tStart, tEnd, requiredCoverage)
-	if coverageErr != nil {
-		if errorJSON, _ := json.Marshal(coverageErr); errorJSON != nil {
-			fmt.Println(string(errorJSON))
+	parseStart := time.Now()
+	digest := newFileDigest()
+	captions, failures, err := cv.parseFileDigest(ctx, filepath, format, digest)
+	if err != nil {
+		return nil, err
+	}
+	// A mislabeled file reads as no cues; another parser may still make sense of it
+	var redetected *FormatRedetectedInfo
+	if len(captions) == 0 && isTextFormat(format) {
+		if info, alternate, alternateFailures := cv.reparseAlternate(filepath, format); info != nil {
+			redetected, captions, failures = info, alternate, alternateFailures
+			format = info.ParsedFormat
 		}
 	}
+	parsedCues := len(captions)
+	rangeErrs := timestampRangeErrors(failures)
+	var offsetErrs []*TimestampRangeError
+	if cv.offset != 0 {
+		captions, offsetErrs = applyOffset(captions, cv.offset)
+		rangeErrs = append(rangeErrs, offsetErrs...)
+	}
 	
-	languageErr := cv.validateLanguage(captions)
-	if languageErr != nil {
-		if errorJSON, _ := json.Marshal(languageErr); errorJSON != nil {
-			fmt.Println(string(errorJSON))
+	// Invisible characters are checked in the text as written, before it is normalized
+	var written []Caption
+	if cv.invisibleCheck {
+		written = slices.Clone(captions)
+	}
+	normalizeCaptions(captions)
+
+	// Exact duplicates are reported and left out of every other check, so cue
+	// numbers found before they were removed are mapped to the cues that remain
+	duplicates := findDuplicates(captions)
+	parsed := captions
+	captions = removeDuplicates(captions, duplicates)
+	for _, offsetErr := range offsetErrs {
+		for i, cue := range offsetErr.SuggestedFix.Cues {
+			offsetErr.SuggestedFix.Cues[i] = keptCueNumber(cue, duplicates)
 		}
 	}
+	var invisibleWarn *InvisibleCharacterWarning
+	if cv.invisibleCheck {
+		invisibleWarn = cv.validateInvisibleCharacters(removeDuplicates(written, duplicates))
+	}
+	parseTime = time.Since(parseStart)
 
-	return nil
+	// Run validations and collect errors
+	issues := []interface{}{}
+	if redetected != nil {
+		issues = append(issues, redetected)
+	}
+	for _, rangeErr := range rangeErrs {
+		issues = append(issues, rangeErr)
+	}
+	if !cv.repairHybrids {
+		if mismatchErr := newFormatMismatchError(format, failures); mismatchErr != nil {
+			issues = append(issues, mismatchErr)
+		}
+	}
+	if mixedErr := cv.validateMixedFormat(filepath, format); mixedErr != nil {
+		issues = append(issues, mixedErr)
+	}
+	// Damaged files fail unless partial results are explicitly allowed
+	if !cv.allowPartial {
+		if partialErr := newPartialParseError(len(captions), failures); partialErr != nil {
+			issues = append(issues, partialErr)
+		}
+	}
+	if duplicateWarn := newDuplicateCueWarning(duplicates); duplicateWarn != nil {
+		issues = append(issues, duplicateWarn)
+	}
+	metadata := cv.readMetadata(filepath, format)
+
+	// Coverage counts each cue as on screen until its end plus -end_padding
+	coverageStart := time.Now()
+	covered := padCueEnds(captions, cv.endPadding)
+	// A window past the end of the media is cut back to it with -clamp_window, rather
+	// than failing coverage for a tail nothing could caption
+	var clamped *WindowClamp
+	if cv.clampWindow != "" && len(cv.program) == 0 {
+		window, clamped = clampWindow(window, cv.clampWindow, covered, metadata)
+	}
+	// A -segment_map replaces the window's threshold with one per segment type
+	var segmentTypes []SegmentTypeCoverage
+	if cv.segmentMap != nil {
+		var coverageErrs []*CaptionCoverageError
+		segmentTypes, coverageErrs = cv.validateSegmentMap(covered, requiredCoverage)
+		for _, coverageErr := range coverageErrs {
+			issues = append(issues, coverageErr)
+		}
+	} else if coverageErr := cv.validateCoverage(covered, window, requiredCoverage); coverageErr != nil {
+		issues = append(issues, coverageErr)
+	}
+	coverageTime = time.Since(coverageStart)
+	if err := abandoned(ctx); err != nil {
+		return nil, err
+	}
+
+	// All detection calls for the file share the validation deadline. Without an
+	// endpoint, as in the lsp command, the language is not checked.
+	detectCtx, cancel := cv.detectionContext(ctx)
+	defer cancel()
+	languageStart := time.Now()
+	if cv.endpoint != "" {
+		if languageErr := cv.validateLanguage(detectCtx, captions); languageErr != nil {
+			issues = append(issues, languageErr)
+		}
+		if tagWarn := cv.validateLanguageTags(detectCtx, captions); tagWarn != nil {
+			issues = append(issues, tagWarn)
+		}
+		if cv.sdhCheck {
+			if annotationWarn := cv.validateAnnotationLanguage(detectCtx, captions); annotationWarn != nil {
+				issues = append(issues, annotationWarn)
+			}
+		}
+	}
+	languageTime = time.Since(languageStart)
+	if len(cv.classifiers) > 0 {
+		issues = append(issues, cv.runClassifiers(detectCtx, captions)...)
+	}
+	if err := abandoned(ctx); err != nil {
+		return nil, err
+	}
+	if cv.metadataCheck {
+		if metadataWarn := cv.validateMetadataLanguage(metadata); metadataWarn != nil {
+			issues = append(issues, metadataWarn)
+		}
+	}
+
+	if cv.markupErrors {
+		if markupErr := cv.validateMarkup(captions); markupErr != nil {
+			issues = append(issues, markupErr)
+		}
+	}
+
+	if cv.safeArea.enabled() {
+		if positionErr := cv.validatePositions(captions); positionErr != nil {
+			issues = append(issues, positionErr)
+		}
+	}
+
+	if len(cv.graphics) > 0 {
+		if graphicWarn := cv.validateGraphics(captions); graphicWarn != nil {
+			issues = append(issues, graphicWarn)
+		}
+	}
+	if cv.rendering != nil {
+		if overflowErr := cv.validateLineWidths(captions); overflowErr != nil {
+			issues = append(issues, overflowErr)
+		}
+	}
+
+	if invisibleWarn != nil {
+		issues = append(issues, invisibleWarn)
+	}
+	
+	if cv.punctuation.enabled() {
+		if styleWarn := cv.validatePunctuationStyle(captions, cv.punctuation); styleWarn != nil {
+			issues = append(issues, styleWarn)
+		}
+	}
+	if localeWarn := cv.validateLocaleFormat(captions); localeWarn != nil {
+		issues = append(issues, localeWarn)
+	}
+	if cv.mtThreshold > 0 {
+		if qualityWarn := cv.validateTranslationQuality(captions); qualityWarn != nil {
+			issues = append(issues, qualityWarn)
+		}
+	}
+	
+	speakers := measureSpeakers(captions, window)
+	if cv.speakerCheck {
+		if speakerWarn := cv.validateSpeakerCoverage(speakers, window); speakerWarn != nil {
+			issues = append(issues, speakerWarn)
+		}
+	}
+
+	if cv.segmentation.enabled() {
+		if segmentationWarn := cv.validateSegmentation(captions, cv.segmentation); segmentationWarn != nil {
+			issues = append(issues, segmentationWarn)
+		}
+	}
+	if len(cv.percentiles) > 0 {
+		if percentileErr := cv.validatePercentiles(captions, cv.percentiles); percentileErr != nil {
+			issues = append(issues, percentileErr)
+		}
+	}
+	if cv.flash.enabled() {
+		if flashWarn := cv.validateCueFlash(captions, cv.flash); flashWarn != nil {
+			issues = append(issues, flashWarn)
+		}
+	}
+	if cv.minWPM > 0 {
+		if densityWarn := cv.validateDialogueDensity(captions, window, cv.minWPM); densityWarn != nil {
+			issues = append(issues, densityWarn)
+		}
+	}
+	if cv.maxStuck > 0 {
+		if stuckWarn := cv.validateStuckCaptions(captions, cv.maxStuck); stuckWarn != nil {
+			issues = append(issues, stuckWarn)
+		}
+	}
+	if cv.minSpeechRatio > 0 {
+		if speechWarn := cv.validateSpeechDuration(captions, cv.minSpeechRatio); speechWarn != nil {
+			issues = append(issues, speechWarn)
+		}
+	}
+
+	// Sync check only runs when an ASR reference is supplied
+	if cv.asrPath != "" {
+		cv.openFiles.acquire()
+		words, err := loadASRWords(cv.asrPath)
+		cv.openFiles.release()
+		if err != nil {
+			return nil, err
+		}
+		if syncErr := cv.validateSync(captions, words, cv.maxLatency); syncErr != nil {
+			issues = append(issues, syncErr)
+		}
+		applyASRConfidence(captions, words)
+		if cv.minASRConfidence > 0 {
+			if confidenceWarn := cv.validateASRConfidence(captions, window, cv.minASRConfidence, cv.asrConfidenceWindow); confidenceWarn != nil {
+				issues = append(issues, confidenceWarn)
+			}
+		}
+	}
+
+	if len(cv.plugins) > 0 {
+		issues = append(issues, cv.runPlugins(ctx, filepath, format, window, captions)...)
+	}
+
+	// An abandoned validation must not touch the baseline other files share
+	if !commitResult(ctx) {
+		return nil, ctx.Err()
+	}
+	issues, suppressed := cv.suppressIssues(issues, captions, parsed, cv.readFileSuppressions(filepath, format))
+	issues, baselined := cv.baseline.apply(filepath, issues)
+	sortIssues(issues)
+	addSpans(issues, captions, parsed)
+	cv.locale.localize(issues)
+	cv.locale.localize(failures)
+
+	coverageStart = time.Now()
+	coverage := measureCoverage(covered, window, cv.minReadable, cv.coverageMetric)
+	var segments []SegmentCoverage
+	if len(cv.program) > 0 {
+		coverage, segments = cv.measureProgramCoverage(covered, requiredCoverage)
+	}
+	coverage.EndPadding = cv.endPadding
+	coverageTime += time.Since(coverageStart)
+	if cv.coverageImage != "" {
+		// A failed image is logged and never changes the result
+		if err := cv.writeCoverageImage(filepath, covered, window, coverage); err != nil {
+			log.Print(err)
+		}
+	}
+	return &FileReport{
+		File:          filepath,
+		Window:        window.String(),
+		WindowClamped: clamped,
+		SHA256:        digest.sum(),
+		Size:          digest.size,
+		Format:        format,
+		Cues:          parsedCues,
+		Coverage:      &coverage,
+		Segments:      segments,
+		SegmentTypes:  segmentTypes,
+		Speakers:      speakers,
+		Metadata:      metadata,
+		ParseFailures: failures,
+		Suppressed:    suppressed,
+		Baselined:     baselined,
+		Directives:    directives.list(),
+		Asset:         asset,
+		Errors:        issues,
+		Version:       buildVersion(),
+		RunID:         cv.runID,
+		StartedAt:     reportTime(started),
+		FinishedAt:    reportTime(time.Now()),
+		Durations: &PhaseDurations{
+			Parse:    milliseconds(parseTime),
+			Coverage: milliseconds(coverageTime),
+			Language: milliseconds(languageTime),
+			Total:    milliseconds(time.Since(started)),
+		},
+	}, nil
+}
+
+// resultOutput receives validation results; logs always go to stderr
+var resultOutput io.Writer = os.Stdout
+
+// resultMeta is the -meta pass-through metadata added to every result line as "meta"
+var resultMeta map[string]string
+
+// printJSON writes a validation result as a single JSON line
+func printJSON(v interface{}) {
+	if errorJSON, _ := json.Marshal(v); errorJSON != nil {
+		fmt.Fprintln(resultOutput, string(withMeta(errorJSON)))
+	}
+}
+
+// withMeta appends the "meta" object to a marshaled JSON object
+func withMeta(object []byte) []byte {
+	if len(resultMeta) == 0 || len(object) < 2 || object[0] != '{' {
+		return object
+	}
+	meta, _ := json.Marshal(resultMeta)
+	separator := ","
+	if len(object) == 2 {
+		separator = ""
+	}
+	return append(append(object[:len(object)-1], separator+`"meta":`...), append(meta, '}')...)
 }
 
-// detectFormat determines if file is WebVTT or SRT by examining header
+// detectFormat determines the caption format by examining the header
 func (cv *CaptionValidator) detectFormat(filepath string) (string, error) {
-	header := make([]byte, 100)
-	file, err := os.Open(filepath)
+	header := make([]byte, formatHeaderSize)
+	cv.openFiles.acquire()
+	defer cv.openFiles.release()
+	file, err := cv.openCaption(filepath)
 	if err != nil {
 		return "", fmt.Errorf("failed to open file: %w", err)
 	}
@@ -98,216 +595,421 @@ func (cv *CaptionValidator) detectFormat(filepath string) (string, error) {
 		return "", fmt.Errorf("failed to read file header: %w", err)
 	}
 
-	headerStr := string(header[:n])
+	format := sniffFormat(header[:n])
+	if format == "unknown" && cv.formatHint != "" {
+		return cv.formatHint, nil
+	}
+	if format == "unknown" {
+		return format, fmt.Errorf("unsupported caption format")
+	}
+	return format, nil
+}
+
+// formatHeaderSize is how many leading bytes sniffFormat needs
+const formatHeaderSize = 100
+
+// srtIndexPattern matches the numeric cue index that starts an SRT file
+var srtIndexPattern = regexp.MustCompile(`^\d+\s*$`)
+
+// sniffFormat identifies WebVTT, SRT, SCC, an IMF CPL, TTML, bitmap subtitles or OCR JSON
+// from the start of a file, or returns "unknown"
+func sniffFormat(header []byte) string {
+	headerStr := strings.TrimPrefix(string(header), "\ufeff")
 	if strings.Contains(headerStr, "WEBVTT") {
-		return "webvtt", nil
+		return "webvtt"
+	}
+	if srtIndexPattern.MatchString(strings.TrimSpace(strings.Split(headerStr, "\n")[0])) {
+		return "srt"
+	}
+	if strings.HasPrefix(headerStr, sccHeader) {
+		return FormatSCC
+	}
+	if strings.Contains(headerStr, "<CompositionPlaylist") {
+		return FormatIMF
+	}
+	// TTML roots may follow an XML declaration and comments past the header
+	if strings.Contains(headerStr, "<tt") || strings.HasPrefix(strings.TrimSpace(headerStr), "<?xml") {
+		return FormatTTML
+	}
+	if format := sniffBitmapFormat(header); format != "" {
+		return format
+	}
+	return "unknown"
+}
+
+func (cv *CaptionValidator) parseFile(filepath, format string) ([]Caption, []ParseFailure, error) {
+	return cv.parseFileDigest(context.Background(), filepath, format, io.Discard)
+}
+
+// parseFileDigest parses like parseFile and copies every byte read to digest. The
+// parse stops once the isolated validation running under ctx is abandoned.
+func (cv *CaptionValidator) parseFileDigest(ctx context.Context, filepath, format string, digest io.Writer) ([]Caption, []ParseFailure, error) {
+	// A CPL's cues come from its package's files, each read under an open-file slot of
+	// its own, so the CPL is not held open while they are
+	if format == FormatIMF {
+		return cv.assembleIMF(filepath, digest)
+	}
+	cv.openFiles.acquire()
+	defer cv.openFiles.release()
+	file, err := cv.openCaption(filepath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer file.Close()
+	
+	// Bitmaps are hashed as they are; their cues come from the OCR tool
+	if isBitmapFormat(format) {
+		if _, err := io.Copy(digest, file); err != nil {
+			return nil, nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		text, err := cv.runOCR(ctx, filepath, format)
+		if err != nil {
+			return nil, nil, err
+		}
+		return collectCues(cv.Cues(bytes.NewReader(text), FormatOCRJSON), 0)
 	}
-	if regexp.MustCompile(`^\d+\s*$`).MatchString(strings.TrimSpace(strings.Split(headerStr, "\n")[0])) {
-		return "srt", nil
+	
+	expected := 0
+	if size, err := cv.captionSize(filepath); err == nil {
+		expected = int(size / estimatedCueBytes)
 	}
-	return "unknown", fmt.Errorf("unsupported caption format")
+	return collectCues(cv.Cues(contextReader{ctx, io.TeeReader(file, digest)}, format), expected)
 }
 
-func (cv *CaptionValidator) parseFile(filepath, format string) ([]Caption, error) {
-	content, err := os.ReadFile(filepath)
+// parseWebVTT extracts captions from WebVTT format. Blocks that cannot be turned
+// into cues are skipped and returned as parse failures alongside the parsed cues.
+func (cv *CaptionValidator) parseWebVTT(content string) ([]Caption, []ParseFailure, error) {
+	return collectCues(cv.Cues(strings.NewReader(content), "webvtt"), strings.Count(content, "-->"))
+}
+
+// webVTTCue decodes one WebVTT block. Both results are nil for blocks that carry no
+// cue; both are set when the cue was recovered from an SRT-style timestamp.
+func (cv *CaptionValidator) webVTTCue(block cueBlock) (*Caption, *ParseFailure) {
+	// Header, comments, and style/region definitions carry no cues
+	if block.First && strings.Contains(block.Lines[0], "WEBVTT") || isWebVTTMetadataBlock(block.Lines[0]) {
+		return nil, nil
+	}
+	
+	// The timing line is first, or second after an optional cue identifier
+	timingIdx := -1
+	for j := 0; j < len(block.Lines) && j < 2; j++ {
+		if strings.Contains(block.Lines[j], "-->") {
+			timingIdx = j
+			break
+		}
+	}
+	if timingIdx < 0 {
+		return nil, blockFailure(block.Line, block.Lines[0], block.Truncated)
+	}
+	
+	line := block.Lines[timingIdx]
+	lineNo := block.Line + timingIdx
+	times, ok := splitTiming(line)
+	if !ok {
+		return nil, timingFailure(lineNo, line, fmt.Errorf("invalid WebVTT timing line: %s", line), block.Truncated)
+	}
+	
+	startTime, endTime, mismatch, err := parseCueTimes(times, cv.parseWebVTTTime, cv.parseSRTTime)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+		return nil, timingFailure(lineNo, line, err, block.Truncated)
+	}
+	
+	// WebVTT allows empty cues, unless the file was cut off right after the timing
+	textParts := block.Lines[timingIdx+1:]
+	if len(textParts) == 0 && block.Truncated {
+		return nil, &ParseFailure{Line: lineNo, Kind: FailureTruncatedCue, Text: line, Reason: "file ends before cue text"}
 	}
+	
+	return &Caption{
+		StartTime: startTime,
+		EndTime:   endTime,
+		Text:      strings.Join(textParts, " "),
+		Settings:  cueSettingsText(times[1]),
+		Lines:     len(textParts),
+		Layout:    cv.cueLayout(textParts),
+		Source:    block.span(),
+	}, mismatchNote(mismatch, lineNo, line, "SRT comma timestamp in WebVTT file")
+}
 
-	switch format {
-	case "webvtt":
-		return cv.parseWebVTT(string(content))
-	case "srt":
-		return cv.parseSRT(string(content))
-	default:
-		return nil, fmt.Errorf("unsupported format: %s", format)
+// isWebVTTMetadataBlock matches NOTE, STYLE and REGION blocks
+func isWebVTTMetadataBlock(first string) bool {
+	for _, keyword := range []string{"NOTE", "STYLE", "REGION"} {
+		if first == keyword || strings.HasPrefix(first, keyword+" ") || strings.HasPrefix(first, keyword+"\t") {
+			return true
+		}
 	}
+	return false
 }
 
-// parseWebVTT extracts captions from WebVTT format
-func (cv *CaptionValidator) parseWebVTT(content string) ([]Caption, error) {
-	var captions []Caption
-	lines := strings.Split(content, "\n")
+// parseSRT extracts captions from SRT format. Blocks that cannot be turned into
+// cues are skipped and returned as parse failures alongside the parsed cues.
+func (cv *CaptionValidator) parseSRT(content string) ([]Caption, []ParseFailure, error) {
+	return collectCues(cv.Cues(strings.NewReader(content), "srt"), strings.Count(content, "-->"))
+}
+
+// srtCue decodes one SRT block into a cue or a parse failure. Like webVTTCue, it may
+// return both when a hybrid timestamp was repaired.
+func (cv *CaptionValidator) srtCue(block cueBlock) (*Caption, *ParseFailure) {
+	// Timing follows the numeric index; tolerate blocks where the index is missing
+	timingIdx := -1
+	if strings.Contains(block.Lines[0], "-->") {
+		timingIdx = 0
+	} else if len(block.Lines) > 1 && strings.Contains(block.Lines[1], "-->") {
+		timingIdx = 1
+	}
+	if timingIdx < 0 {
+		return nil, blockFailure(block.Line, block.Lines[0], block.Truncated)
+	}
 	
-	for i := 0; i < len(lines); i++ {
-		line := strings.TrimSpace(lines[i])
-		if !strings.Contains(line, "-->") {
-			continue
-		}
-		
-		times := strings.Split(line, "-->")
-		if len(times) != 2 {
-			continue
-		}
-		
-		startTime, err1 := cv.parseWebVTTTime(strings.TrimSpace(times[0]))
-		endTime, err2 := cv.parseWebVTTTime(strings.TrimSpace(times[1]))
-		if err1 != nil || err2 != nil {
-			continue
-		}
-		
-		// Collect caption text
-		var textParts []string
-		i++
-		for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
-			textParts = append(textParts, strings.TrimSpace(lines[i]))
-			i++
+	line := block.Lines[timingIdx]
+	lineNo := block.Line + timingIdx
+	times, ok := splitTiming(line)
+	if !ok {
+		return nil, timingFailure(lineNo, line, fmt.Errorf("invalid SRT timing line: %s", line), block.Truncated)
+	}
+	
+	startTime, endTime, mismatch, err := parseCueTimes(times, cv.parseSRTTime, cv.parseWebVTTTime)
+	if err != nil {
+		return nil, timingFailure(lineNo, line, err, block.Truncated)
+	}
+	
+	textParts := block.Lines[timingIdx+1:]
+	if len(textParts) == 0 {
+		if block.Truncated {
+			return nil, &ParseFailure{Line: lineNo, Kind: FailureTruncatedCue, Text: line, Reason: "file ends before cue text"}
 		}
-		
-		captions = append(captions, Caption{
-			StartTime: startTime,
-			EndTime:   endTime,
-			Text:      strings.Join(textParts, " "),
-		})
+		return nil, &ParseFailure{Line: lineNo, Kind: FailureEmptyCue, Text: line, Reason: "cue has no text"}
 	}
-	return captions, nil
+	
+	// Tags may span lines (multi-line italics), so strip after joining
+	markup := strings.Join(textParts, " ")
+	return &Caption{
+		StartTime: startTime,
+		EndTime:   endTime,
+		Text:      stripMarkup(markup),
+		Markup:    markup,
+		Layout:    cv.cueLayout(textParts),
+		Source:    block.span(),
+	}, mismatchNote(mismatch, lineNo, line, "WebVTT dot timestamp in SRT file")
 }
 
-// parseSRT extracts captions from SRT format  
-func (cv *CaptionValidator) parseSRT(content string) ([]Caption, error) {
-	var captions []Caption
-	for _, block := range strings.Split(content, "\n\n") {
-		lines := strings.Split(strings.TrimSpace(block), "\n")
-		if len(lines) < 3 || !strings.Contains(lines[1], "-->") {
-			continue
-		}
-		
-		times := strings.Split(lines[1], "-->")
-		if len(times) != 2 {
-			continue
-		}
-		
-		startTime, err1 := cv.parseSRTTime(strings.TrimSpace(times[0]))
-		endTime, err2 := cv.parseSRTTime(strings.TrimSpace(times[1]))
-		if err1 != nil || err2 != nil {
-			continue
-		}
-		
-		captions = append(captions, Caption{
-			StartTime: startTime,
-			EndTime:   endTime,
-			Text:      strings.Join(lines[2:], " "),
-		})
-	}
-	return captions, nil
+// blockFailure reports a block without a timing line
+func blockFailure(line int, text string, truncated bool) *ParseFailure {
+	if truncated {
+		return &ParseFailure{Line: line, Kind: FailureTruncatedCue, Text: text, Reason: "file ends before cue timing"}
+	}
+	return &ParseFailure{Line: line, Kind: FailureMissingTiming, Text: text, Reason: "block has no timing line"}
 }
 
-// Time parsing functions for WebVTT (uses .) and SRT (uses ,) formats
+// Time parsing functions for WebVTT (uses .) and SRT (uses ,) formats. Hours may
+// exceed 24 (long live events) and are optional in WebVTT.
 func (cv *CaptionValidator) parseWebVTTTime(timeStr string) (float64, error) {
-	return cv.parseTime(timeStr, `(\d{2}):(\d{2}):(\d{2})\.(\d{3})`, "WebVTT")
+	return cv.parseTime(timeStr, '.', true, "WebVTT")
 }
 
 func (cv *CaptionValidator) parseSRTTime(timeStr string) (float64, error) {
-	return cv.parseTime(timeStr, `(\d{2}):(\d{2}):(\d{2}),(\d{3})`, "SRT")
+	return cv.parseTime(timeStr, ',', false, "SRT")
 }
 
-// parseTime converts time string to seconds using provided regex pattern
-func (cv *CaptionValidator) parseTime(timeStr, pattern, format string) (float64, error) {
-	matches := regexp.MustCompile(pattern).FindStringSubmatch(timeStr)
-	if len(matches) != 5 {
+// parseTime converts a time string starting with [H+:]MM:SS<separator>mmm to seconds;
+// anything after the milliseconds, such as WebVTT cue settings, is ignored. It scans
+// by hand because it runs twice per cue and a regexp match allocates.
+func (cv *CaptionValidator) parseTime(timeStr string, separator byte, optionalHours bool, format string) (float64, error) {
+	var fields [3]int
+	var widths [3]int
+	n, i := 0, 0
+	for {
+		start := i
+		value := 0
+		for i < len(timeStr) && timeStr[i] >= '0' && timeStr[i] <= '9' {
+			value = value*10 + int(timeStr[i]-'0')
+			i++
+		}
+		if i == start || n == len(fields) {
+			return 0, fmt.Errorf("invalid %s time format: %s", format, timeStr)
+		}
+		fields[n], widths[n] = value, i-start
+		n++
+		if i >= len(timeStr) || timeStr[i] != ':' {
+			break
+		}
+		i++
+	}
+	
+	// Milliseconds are exactly three digits after the separator
+	if i+4 > len(timeStr) || timeStr[i] != separator {
 		return 0, fmt.Errorf("invalid %s time format: %s", format, timeStr)
 	}
+	milliseconds := 0
+	for _, digit := range []byte(timeStr[i+1 : i+4]) {
+		if digit < '0' || digit > '9' {
+			return 0, fmt.Errorf("invalid %s time format: %s", format, timeStr)
+		}
+		milliseconds = milliseconds*10 + int(digit-'0')
+	}
 	
-	hours, _ := strconv.Atoi(matches[1])
-	minutes, _ := strconv.Atoi(matches[2])
-	seconds, _ := strconv.Atoi(matches[3])
-	milliseconds, _ := strconv.Atoi(matches[4])
+	hours := 0
+	switch {
+	case n == 3:
+		hours = fields[0]
+		fields[0], fields[1], widths[0], widths[1] = fields[1], fields[2], widths[1], widths[2]
+	case n != 2 || !optionalHours:
+		return 0, fmt.Errorf("invalid %s time format: %s", format, timeStr)
+	}
+	minutes, seconds := fields[0], fields[1]
+	if widths[0] != 2 || widths[1] != 2 {
+		return 0, fmt.Errorf("invalid %s time format: %s", format, timeStr)
+	}
+	if minutes > 59 || seconds > 59 {
+		return 0, fmt.Errorf("%s time out of range: %s", format, timeStr)
+	}
 	
 	return float64(hours*3600+minutes*60+seconds) + float64(milliseconds)/1000.0, nil
 }
 
 // validateCoverage checks if captions cover required percentage of time window
-func (cv *CaptionValidator) validateCoverage(captions []Caption, tStart, tEnd, requiredCoverage float64) *CaptionCoverageError {
-	totalDuration := tEnd - tStart
-	coveredDuration := 0.0
-	
-	// Calculate overlapping duration for each caption
-	for _, caption := range captions {
-		if caption.EndTime <= tStart || caption.StartTime >= tEnd {
-			continue // No overlap
-		}
-		
-		// Calculate effective overlap within time window
-		start := caption.StartTime
-		if start < tStart {
-			start = tStart
-		}
-		end := caption.EndTime
-		if end > tEnd {
-			end = tEnd
-		}
-		
-		if end > start {
-			coveredDuration += end - start
-		}
+func (cv *CaptionValidator) validateCoverage(captions []Caption, window Window, requiredCoverage float64) *CaptionCoverageError {
+	metrics := measureCoverage(captions, window, cv.minReadable, cv.coverageMetric)
+	if len(cv.program) > 0 {
+		metrics, _ = cv.measureProgramCoverage(captions, requiredCoverage)
+		window = programSpan(cv.program)
 	}
 	
-	actualCoverage := (coveredDuration / totalDuration) * 100
-	if actualCoverage < requiredCoverage {
-		return &CaptionCoverageError{
-			Type:             "caption_coverage",
-			RequiredCoverage: requiredCoverage,
-			ActualCoverage:   actualCoverage,
-			StartTime:        tStart,
-			EndTime:          tEnd,
-			Description:      fmt.Sprintf("Caption coverage of %.2f%% is below required %.2f%%", actualCoverage, requiredCoverage),
+	// The gating metric decides pass/fail; both metrics are always reported
+	actualCoverage := metrics.gatingValue()
+	severity, description := SeverityError, "Caption coverage of %.2f%% is below required %.2f%%"
+	if coveragePasses(actualCoverage, requiredCoverage, cv.tolerance) {
+		// Between the thresholds the file needs attention but still passes
+		if cv.coverageWarn <= requiredCoverage || coveragePasses(actualCoverage, cv.coverageWarn, cv.tolerance) {
+			return nil
 		}
+		requiredCoverage = cv.coverageWarn
+		severity, description = SeverityWarning, "Caption coverage of %.2f%% is below the %.2f%% warning threshold"
+	}
+	fix := coverageFix(captions, window)
+	if len(cv.program) > 0 {
+		fix = cv.programCoverageFix(captions)
+	}
+	return &CaptionCoverageError{
+		Type:             "caption_coverage",
+		Severity:         severity,
+		RequiredCoverage: requiredCoverage,
+		ActualCoverage:   actualCoverage,
+		GatingMetric:     metrics.Gating,
+		WallClock:        metrics.WallClock,
+		DialogueWeighted: metrics.DialogueWeighted,
+		Tolerance:        cv.tolerance,
+		EndPadding:       cv.endPadding,
+		CoveredMs:        metrics.CoveredMs,
+		CoveredSeconds:   metrics.CoveredSeconds,
+		StartTime:        window.Start,
+		EndTime:          window.End,
+		Window:           window.String(),
+		Description:      fmt.Sprintf(description, actualCoverage, requiredCoverage),
+		SuggestedFix:     fix,
 	}
-	return nil
 }
 
-// validateLanguage sends caption text to endpoint and validates en-US response
-func (cv *CaptionValidator) validateLanguage(captions []Caption) *IncorrectLanguageError {
-	// Combine all caption text
-	var textParts []string
-	for _, caption := range captions {
-		if caption.Text != "" {
-			textParts = append(textParts, caption.Text)
-		}
-	}
-	
-	text := strings.Join(textParts, " ")
+// validateLanguage sends caption text to endpoint and validates the detected language.
+// It returns an *IncorrectLanguageError, a *DetectorProtocolError when the detector's
+// answer cannot be read, an *UnknownLanguageError when it names no known language,
+// a *LanguageDetectionFailedError when it cannot be reached, or nil.
+func (cv *CaptionValidator) validateLanguage(ctx context.Context, captions []Caption) interface{} {
+	text := cv.detectionText(captions)
 	if text == "" {
 		return nil
 	}
 	
-	detectedLang, err := cv.detectLanguage(text)
+	detectedLang, err := cv.detectLanguage(ctx, text)
+	var protocolErr *DetectorProtocolError
+	if errors.As(err, &protocolErr) {
+		return protocolErr
+	}
+	var unknownErr *UnknownLanguageError
+	if errors.As(err, &unknownErr) {
+		return unknownErr
+	}
 	if err != nil {
-		return &IncorrectLanguageError{
-			Type:         "incorrect_language",
-			DetectedLang: "unknown",
-			ExpectedLang: "en-US",
-			Description:  fmt.Sprintf("Failed to detect language: %v", err),
-		}
+		return cv.detectionFailed(err)
 	}
 	
-	if detectedLang != "en-US" {
+	if !sameLanguage(detectedLang, cv.expectedLanguage) {
 		return &IncorrectLanguageError{
 			Type:         "incorrect_language",
 			DetectedLang: detectedLang,
-			ExpectedLang: "en-US",
-			Description:  fmt.Sprintf("Detected language '%s' does not match expected 'en-US'", detectedLang),
+			ExpectedLang: cv.expectedLanguage,
+			Breakdown:    cv.languageBreakdown(ctx, captions),
+			Description:  fmt.Sprintf("Detected language '%s' does not match expected '%s'", detectedLang, cv.expectedLanguage),
+			SuggestedFix: &SuggestedFix{
+				Action:      FixReplaceTrack,
+				Language:    cv.expectedLanguage,
+				Description: fmt.Sprintf("Replace the %s track with an %s caption track", detectedLang, cv.expectedLanguage),
+			},
 		}
 	}
 	return nil
 }
 
-// detectLanguage sends text to HTTP endpoint and returns detected language
-func (cv *CaptionValidator) detectLanguage(text string) (string, error) {
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Post(cv.endpoint, "text/plain", strings.NewReader(text))
-	if err != nil {
-		return "", fmt.Errorf("failed to call language detection endpoint: %w", err)
+// detectionText is the caption text sent for language detection
+func (cv *CaptionValidator) detectionText(captions []Caption) string {
+	return cv.sampledDetectionText(captions, cv.sampleChars)
+}
+
+// sampledDetectionText is detectionText with a sample of at most limit characters
+// (0 sends all text), for callers that share -sample_chars across several requests
+func (cv *CaptionValidator) sampledDetectionText(captions []Caption, limit int) string {
+	// Combine all caption text
+	var textParts []string
+	for _, caption := range captions {
+		text := withoutForeignSpans(caption.Text, cv.expectedLanguage)
+		if cv.sdhCheck {
+			text, _ = splitAnnotations(text)
+		}
+		if text != "" {
+			textParts = append(textParts, text)
+		}
 	}
-	defer resp.Body.Close()
 	
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("language detection endpoint returned status: %d", resp.StatusCode)
+	if cv.smartJoin {
+		textParts = smartJoin(textParts)
 	}
 	
-	var langResp LanguageResponse
-	if err := json.NewDecoder(resp.Body).Decode(&langResp); err != nil {
-		return "", fmt.Errorf("failed to decode language response: %w", err)
+	// Only a bounded, redacted sample leaves the host when privacy options are set
+	return redactText(sampleText(textParts, limit), cv.redactMode)
+}
+
+// detectLanguage sends text to HTTP endpoint and returns detected language as a
+// canonical tag, or an *UnknownLanguageError when the answer names none. Calls that
+// time out, fail to connect or get a 429 or 5xx are retried up to cv.retries times;
+// once every attempt has failed the error is a *DetectionError.
+func (cv *CaptionValidator) detectLanguage(ctx context.Context, text string) (string, error) {
+	var lang string
+	retries, status, err := cv.retryCall(ctx, func() (status int, err error) {
+		lang, status, err = cv.detectAttempt(ctx, text)
+		return status, err
+	})
+	var protocolErr *DetectorProtocolError
+	if errors.As(err, &protocolErr) {
+		return "", err
+	}
+	if err != nil {
+		return "", &DetectionError{StatusCode: status, Retries: retries, Err: err}
+	}
+	return cv.canonicalDetection(lang)
+}
+
+// detectAttempt makes one detection call; status is the HTTP status when one came back
+func (cv *CaptionValidator) detectAttempt(ctx context.Context, text string) (lang string, status int, err error) {
+	start := time.Now()
+	defer func() { cv.stats.recordDetection(time.Since(start), err) }()
+	
+	body, contentType, status, err := cv.postText(ctx, cv.endpoint, "language detection", text)
+	if err != nil {
+		return "", status, err
+	}
+	lang, ok := parseDetectorResponse(body)
+	if !ok {
+		return "", status, cv.protocolError(contentType, body)
 	}
-	return langResp.Lang, nil
+	return lang, status, nil
 }
\ No newline at end of file
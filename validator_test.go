@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -84,7 +85,7 @@ Hello world
 00:00:06.000 --> 00:00:10.000
 This is a test`
 
-	captions, err := cv.parseWebVTT(content)
+	captions, _, err := cv.parseWebVTT(content)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -117,7 +118,7 @@ Hello world
 00:00:06,000 --> 00:00:10,000
 This is a test`
 
-	captions, err := cv.parseSRT(content)
+	captions, _, err := cv.parseSRT(content)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -149,22 +150,19 @@ func TestValidateCoverage(t *testing.T) {
 
 	tests := []struct {
 		name             string
-		tStart           float64
-		tEnd             float64
+		window           Window
 		requiredCoverage float64
 		expectError      bool
 	}{
 		{
 			name:             "sufficient coverage",
-			tStart:           0.0,
-			tEnd:             10.0,
+			window:           Window{Start: 0.0, End: 10.0},
 			requiredCoverage: 40.0,
 			expectError:      false,
 		},
 		{
 			name:             "insufficient coverage",
-			tStart:           0.0,
-			tEnd:             10.0,
+			window:           Window{Start: 0.0, End: 10.0},
 			requiredCoverage: 50.0,
 			expectError:      true,
 		},
@@ -172,7 +170,7 @@ func TestValidateCoverage(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := cv.validateCoverage(captions, tt.tStart, tt.tEnd, tt.requiredCoverage)
+			err := cv.validateCoverage(captions, tt.window, tt.requiredCoverage)
 			if tt.expectError && err == nil {
 				t.Error("expected coverage error, got none")
 			}
@@ -197,7 +195,7 @@ func TestValidateLanguage(t *testing.T) {
 		{StartTime: 5.0, EndTime: 7.0, Text: "This is English"},
 	}
 
-	err := cv.validateLanguage(captions)
+	err := cv.validateLanguage(context.Background(), captions)
 	if err != nil {
 		t.Errorf("unexpected language validation error: %v", err)
 	}
@@ -216,9 +214,9 @@ func TestValidateLanguageIncorrect(t *testing.T) {
 		{StartTime: 1.0, EndTime: 3.0, Text: "Hola mundo"},
 	}
 
-	err := cv.validateLanguage(captions)
-	if err == nil {
-		t.Error("expected language validation error, got none")
+	err, ok := cv.validateLanguage(context.Background(), captions).(*IncorrectLanguageError)
+	if !ok {
+		t.Fatal("expected language validation error, got none")
 	}
 
 	if err.DetectedLang != "es-ES" {
@@ -346,7 +344,7 @@ func TestJSONErrorOutputFormat(t *testing.T) {
 	}
 	
 	// Test coverage error
-	coverageErr := cv.validateCoverage(captions, 0, 10, 80)
+	coverageErr := cv.validateCoverage(captions, Window{Start: 0, End: 10}, 80)
 	if coverageErr == nil {
 		t.Fatal("expected coverage error, got none")
 	}
@@ -375,6 +373,9 @@ func TestJSONErrorOutputFormat(t *testing.T) {
 	if _, ok := parsed["actual_coverage"]; !ok {
 		t.Error("missing 'actual_coverage' field in JSON output")
 	}
+	if parsed["window"] != "00:00:00.000-00:00:10.000" {
+		t.Errorf("expected window '00:00:00.000-00:00:10.000', got '%v'", parsed["window"])
+	}
 }
 
 func TestLanguageErrorJSONFormat(t *testing.T) {
@@ -390,7 +391,7 @@ func TestLanguageErrorJSONFormat(t *testing.T) {
 		{StartTime: 1.0, EndTime: 3.0, Text: "Hola mundo"},
 	}
 
-	langErr := cv.validateLanguage(captions)
+	langErr := cv.validateLanguage(context.Background(), captions)
 	if langErr == nil {
 		t.Fatal("expected language error, got none")
 	}
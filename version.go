@@ -0,0 +1,72 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+// version is the release the binary was built as, set with
+// -ldflags "-X main.version=1.8.0"; without it the module version Go stamped in
+// from version control is used
+var version string
+
+// buildVersion returns the validator's version: the release, the version control
+// revision of a source build, or "dev"
+func buildVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// newRunID returns a random ID for one run, sent to the detector and written to
+// every report so a report can be matched to the detector's logs
+func newRunID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// userAgent identifies the validator build and run in detector requests
+func (cv *CaptionValidator) userAgent() string {
+	return fmt.Sprintf("caption-validator/%s (run %s)", buildVersion(), cv.runID)
+}
+
+// setDetectorHeaders adds the User-Agent and the -detector_header headers to a
+// request to the detector; a -detector_header User-Agent replaces the default
+func (cv *CaptionValidator) setDetectorHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", cv.userAgent())
+	for name, values := range cv.headers {
+		req.Header[name] = values
+	}
+}
+
+// headerFlag collects repeatable "Name: value" headers, e.g. -detector_header "X-Team: captions"
+type headerFlag http.Header
+
+func (h headerFlag) String() string {
+	var headers []string
+	for name, values := range h {
+		for _, value := range values {
+			headers = append(headers, name+": "+value)
+		}
+	}
+	return strings.Join(headers, ", ")
+}
+
+func (h headerFlag) Set(value string) error {
+	name, val, ok := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("invalid header %q: expected \"Name: value\"", value)
+	}
+	http.Header(h).Add(name, strings.TrimSpace(val))
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHeaderFlag(t *testing.T) {
+	headers := headerFlag{}
+	for _, value := range []string{"X-Team: captions", "X-Trace:abc", "X-Team: qa"} {
+		if err := headers.Set(value); err != nil {
+			t.Fatalf("%q: %v", value, err)
+		}
+	}
+	if got := http.Header(headers).Values("X-Team"); len(got) != 2 || got[0] != "captions" || got[1] != "qa" {
+		t.Errorf("expected both X-Team values, got %v", got)
+	}
+	if got := http.Header(headers).Get("X-Trace"); got != "abc" {
+		t.Errorf("expected X-Trace abc, got %q", got)
+	}
+	for _, value := range []string{"X-Team", ": captions", "X Team: captions"} {
+		if err := headers.Set(value); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
+
+func TestDetectorRequestIdentification(t *testing.T) {
+	var userAgent, team string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent, team = r.UserAgent(), r.Header.Get("X-Team")
+		json.NewEncoder(w).Encode(map[string]string{"lang": "en-US"})
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "a.srt")
+	os.WriteFile(path, []byte("1\n00:00:01,000 --> 00:00:09,000\nHello world\n"), 0o644)
+	cv := NewCaptionValidator(server.URL)
+	cv.runID = "run42"
+	cv.headers = http.Header{"X-Team": {"captions"}}
+	report, err := cv.Validate(path, Window{Start: 0, End: 10}, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "caption-validator/" + buildVersion() + " (run run42)"; userAgent != want {
+		t.Errorf("expected User-Agent %q, got %q", want, userAgent)
+	}
+	if team != "captions" {
+		t.Errorf("expected the custom header, got %q", team)
+	}
+	if report.Version != buildVersion() || report.RunID != "run42" {
+		t.Errorf("expected the report to carry the version and run, got %q and %q", report.Version, report.RunID)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// wideRanges are the East Asian Wide and Fullwidth blocks, which take two columns
+var wideRanges = []struct{ lo, hi rune }{
+	{0x1100, 0x115F},   // Hangul Jamo initials
+	{0x2E80, 0x303E},   // CJK radicals, Kangxi, ideographic description, CJK symbols and punctuation
+	{0x3041, 0x33FF},   // Hiragana, Katakana, Bopomofo, Hangul compatibility Jamo, CJK compatibility
+	{0x3400, 0x4DBF},   // CJK Extension A
+	{0x4E00, 0x9FFF},   // CJK Unified Ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE30, 0xFE4F},   // CJK compatibility forms
+	{0xFF00, 0xFF60},   // Fullwidth forms
+	{0xFFE0, 0xFFE6},   // Fullwidth signs
+	{0x20000, 0x3FFFD}, // CJK Extensions B and later
+}
+
+// runeWidth is the columns a character takes: two for wide and fullwidth
+// characters, none for combining marks and format characters, otherwise one
+func runeWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	for _, wide := range wideRanges {
+		if r >= wide.lo && r <= wide.hi {
+			return 2
+		}
+	}
+	return 1
+}
+
+// clusterWidth is the columns a grapheme cluster takes: that of its widest
+// character, so marks and joined emoji add nothing to their base
+func clusterWidth(cluster string) int {
+	width := 0
+	for _, r := range cluster {
+		width = max(width, runeWidth(r))
+	}
+	return width
+}
+
+// textWidth is the columns text takes on screen. Line lengths and reading speeds are
+// measured in columns, so a Japanese line of 13 characters is 26 columns wide.
+func textWidth(text string) int {
+	width := 0
+	for _, cluster := range graphemes(text) {
+		width += clusterWidth(cluster)
+	}
+	return width
+}
+
+// isUnspaced reports whether r belongs to a script written without spaces between
+// words, where every character may start a new word or line
+func isUnspaced(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// noLineStart are the Japanese small kana and marks that may not begin a line
+const noLineStart = "ぁぃぅぇぉっゃゅょゎゕゖァィゥェォッャュョヮヵヶー々ゝゞヽヾ"
+
+// textToken is a run of text that lines may break around. Spaced tokens were
+// separated from the previous one by whitespace; the others follow it directly.
+type textToken struct {
+	text   string
+	spaced bool
+}
+
+// breakTokens splits text at whitespace and between the characters of unspaced
+// scripts. Closing punctuation stays with the character before it and opening
+// punctuation with the one after, so lines never start with 。 or end with 「, and
+// small kana such as っ stay with the character before them.
+// Markup tags are never split from the text they touch, and breaks fall only
+// between grapheme clusters so combining marks stay on their base.
+func breakTokens(text string) []textToken {
+	var tokens []textToken
+	for _, field := range strings.Fields(text) {
+		start, i, previous := 0, 0, rune(0)
+		for _, cluster := range graphemes(field) {
+			r := firstRune(cluster)
+			opening := unicode.In(r, unicode.Ps, unicode.Pi)
+			tag := r == '<' || previous == '>'
+			if i > start && !tag && !strings.ContainsRune(noLineStart, r) && (isUnspaced(r) || isUnspaced(previous)) && (opening || !unicode.IsPunct(r)) && !unicode.In(previous, unicode.Ps, unicode.Pi) {
+				tokens = append(tokens, textToken{text: field[start:i], spaced: start == 0})
+				start = i
+			}
+			previous = r
+			i += len(cluster)
+		}
+		tokens = append(tokens, textToken{text: field[start:], spaced: start == 0})
+	}
+	return tokens
+}
+
+// joinTokens rebuilds text from tokens
+func joinTokens(tokens []textToken) string {
+	var b strings.Builder
+	for i, token := range tokens {
+		if i > 0 && token.spaced {
+			b.WriteByte(' ')
+		}
+		b.WriteString(token.text)
+	}
+	return b.String()
+}
+
+// textWords splits text into words for counting: whitespace-separated, except that
+// every Han, Hiragana and Katakana character counts as a word, as word counts of
+// Chinese and Japanese text conventionally do
+func textWords(text string) []string {
+	var words []string
+	for _, token := range breakTokens(text) {
+		words = append(words, token.text)
+	}
+	return words
+}
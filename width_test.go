@@ -0,0 +1,55 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTextWidth(t *testing.T) {
+	for text, expected := range map[string]int{
+		"Hello":     5,
+		"こんにちは":     10,
+		"안녕하세요 친구":  15,
+		"ＡＢＣ":       6,
+		"e\u0301":   1, // combining acute
+		"私はJohnです。": 14,
+	} {
+		if width := textWidth(text); width != expected {
+			t.Errorf("%q: expected width %d, got %d", text, expected, width)
+		}
+	}
+}
+
+func TestTextWords(t *testing.T) {
+	if words := textWords("I waited all night."); len(words) != 4 {
+		t.Errorf("expected 4 English words, got %q", words)
+	}
+	if words := textWords("駅で待った。"); !slices.Equal(words, []string{"駅", "で", "待っ", "た。"}) {
+		t.Errorf("unexpected Japanese words %q", words)
+	}
+	// Korean separates words with spaces
+	if words := textWords("역에서 계속 기다렸어요"); len(words) != 3 {
+		t.Errorf("expected 3 Korean words, got %q", words)
+	}
+}
+
+func TestBreakTokensKeepsPunctuationAndTags(t *testing.T) {
+	tokens := breakTokens("彼は「<i>本</i>」と言った。 OK")
+	var texts []string
+	for _, token := range tokens {
+		texts = append(texts, token.text)
+	}
+	expected := []string{"彼", "は", "「<i>本</i>」", "と", "言っ", "た。", "OK"}
+	if !slices.Equal(texts, expected) {
+		t.Errorf("expected %q, got %q", expected, texts)
+	}
+	if joinTokens(tokens) != "彼は「<i>本</i>」と言った。 OK" {
+		t.Errorf("expected tokens to rejoin to the original, got %q", joinTokens(tokens))
+	}
+}
+
+func TestWrapTextCJK(t *testing.T) {
+	if wrapped := wrapText("私は昨日の夜ずっと駅であなたを待っていました。", 26); wrapped != "私は昨日の夜ずっと駅であな\nたを待っていました。" {
+		t.Errorf("unexpected wrap %q", wrapped)
+	}
+}
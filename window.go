@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Window validation errors
+var (
+	ErrWindowNegative  = errors.New("window start must not be negative")
+	ErrWindowEmpty     = errors.New("window end must be greater than start")
+	ErrWindowNotFinite = errors.New("window bounds must be finite")
+	ErrWindowTooShort  = errors.New("window must be at least 1ms long")
+)
+
+// minWindowSeconds is the shortest window coverage can be measured over, as it is
+// counted in whole milliseconds
+const minWindowSeconds = 0.001
+
+// Window is a half-open time range [Start, End) in seconds
+type Window struct {
+	Start float64 `json:"start_time"`
+	End   float64 `json:"end_time"`
+}
+
+// Validate checks that the window is finite, non-negative and at least 1ms long
+func (w Window) Validate() error {
+	if !isFinite(w.Start) || !isFinite(w.End) {
+		return fmt.Errorf("%w: %g-%g", ErrWindowNotFinite, w.Start, w.End)
+	}
+	if w.Start < 0 {
+		return fmt.Errorf("%w: %s", ErrWindowNegative, w)
+	}
+	if w.End <= w.Start {
+		return fmt.Errorf("%w: %s", ErrWindowEmpty, w)
+	}
+	if w.Duration() < minWindowSeconds {
+		return fmt.Errorf("%w: %s", ErrWindowTooShort, w)
+	}
+	return nil
+}
+
+// Duration returns the window length in seconds
+func (w Window) Duration() float64 {
+	return w.End - w.Start
+}
+
+// Intersect returns the overlap of two windows and whether they overlap at all
+func (w Window) Intersect(other Window) (Window, bool) {
+	overlap := Window{Start: max(w.Start, other.Start), End: min(w.End, other.End)}
+	if overlap.End <= overlap.Start {
+		return Window{}, false
+	}
+	return overlap, true
+}
+
+// String formats the window as HH:MM:SS.mmm-HH:MM:SS.mmm
+func (w Window) String() string {
+	return formatTimestamp(w.Start) + "-" + formatTimestamp(w.End)
+}
+
+// ParseWindow parses "START-END" where each bound is HH:MM:SS[.mmm], a Go duration
+// ("5m", "1h30m") or raw seconds, e.g. "00:05:00-01:30:00" or "5m-90m"
+func ParseWindow(s string) (Window, error) {
+	startStr, endStr, ok := strings.Cut(s, "-")
+	if !ok {
+		return Window{}, fmt.Errorf("invalid window %q: expected START-END", s)
+	}
+	start, err := parseTimestamp(startStr)
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid window %q: %w", s, err)
+	}
+	end, err := parseTimestamp(endStr)
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid window %q: %w", s, err)
+	}
+
+	w := Window{Start: start, End: end}
+	if err := w.Validate(); err != nil {
+		return Window{}, err
+	}
+	return w, nil
+}
+
+var clockTimestampPattern = regexp.MustCompile(`^(?:(\d+):)?(\d{1,2}):(\d{1,2}(?:\.\d+)?)$`)
+
+// parseTimestamp converts HH:MM:SS[.mmm] or MM:SS, with minutes and seconds below 60,
+// a Go duration or raw seconds to seconds
+func parseTimestamp(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		if !isFinite(seconds) {
+			return 0, fmt.Errorf("invalid timestamp %q: must be a finite number of seconds", s)
+		}
+		return seconds, nil
+	}
+	if matches := clockTimestampPattern.FindStringSubmatch(s); matches != nil {
+		hours, _ := strconv.Atoi(matches[1])
+		minutes, _ := strconv.Atoi(matches[2])
+		seconds, _ := strconv.ParseFloat(matches[3], 64)
+		if minutes > 59 || seconds >= 60 {
+			return 0, fmt.Errorf("invalid timestamp %q: minutes and seconds must be below 60", s)
+		}
+		return float64(hours*3600+minutes*60) + seconds, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d.Seconds(), nil
+	}
+	return 0, fmt.Errorf("invalid timestamp %q: expected seconds, HH:MM:SS.mmm or a duration like 1h30m", s)
+}
+
+// isFinite reports whether seconds is neither NaN nor infinite
+func isFinite(seconds float64) bool {
+	return !math.IsNaN(seconds) && !math.IsInf(seconds, 0)
+}
+
+// formatTimestamp formats seconds as HH:MM:SS.mmm
+func formatTimestamp(seconds float64) string {
+	sign := ""
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	ms := int64(seconds*1000 + 0.5)
+	return fmt.Sprintf("%s%02d:%02d:%02d.%03d", sign, ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    Window
+		expectedErr error
+	}{
+		{input: "00:05:00-01:30:00", expected: Window{Start: 300, End: 5400}},
+		{input: "5m-90m", expected: Window{Start: 300, End: 5400}},
+		{input: "0-30", expected: Window{Start: 0, End: 30}},
+		{input: "00:00:01.500-1h", expected: Window{Start: 1.5, End: 3600}},
+		{input: "90m-5m", expectedErr: ErrWindowEmpty},
+		{input: "10-10.0005", expectedErr: ErrWindowTooShort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			window, err := ParseWindow(tt.input)
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWindow failed: %v", err)
+			}
+			if window != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, window)
+			}
+		})
+	}
+
+	for _, input := range []string{"5m", "abc-10", "10-xyz", "0-NaN", "0-Inf", "0-+Inf", "NaN-10"} {
+		if _, err := ParseWindow(input); err == nil {
+			t.Errorf("expected error for %q, got none", input)
+		}
+	}
+}
+
+func TestWindowValidate(t *testing.T) {
+	if err := (Window{Start: -1, End: 10}).Validate(); !errors.Is(err, ErrWindowNegative) {
+		t.Errorf("expected ErrWindowNegative, got %v", err)
+	}
+	if err := (Window{Start: 10, End: 10}).Validate(); !errors.Is(err, ErrWindowEmpty) {
+		t.Errorf("expected ErrWindowEmpty, got %v", err)
+	}
+	if err := (Window{Start: 10, End: 10.0004}).Validate(); !errors.Is(err, ErrWindowTooShort) {
+		t.Errorf("expected ErrWindowTooShort, got %v", err)
+	}
+	if err := (Window{Start: 0, End: math.Inf(1)}).Validate(); !errors.Is(err, ErrWindowNotFinite) {
+		t.Errorf("expected ErrWindowNotFinite, got %v", err)
+	}
+	if err := (Window{Start: 0, End: 0.001}).Validate(); err != nil {
+		t.Errorf("unexpected error for a 1ms window: %v", err)
+	}
+	if err := (Window{Start: 0, End: 10}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWindowIntersect(t *testing.T) {
+	w := Window{Start: 10, End: 20}
+
+	overlap, ok := w.Intersect(Window{Start: 5, End: 15})
+	if !ok || overlap != (Window{Start: 10, End: 15}) {
+		t.Errorf("expected overlap 10-15, got %+v (ok=%v)", overlap, ok)
+	}
+	if overlap.Duration() != 5 {
+		t.Errorf("expected duration 5, got %f", overlap.Duration())
+	}
+	if _, ok := w.Intersect(Window{Start: 20, End: 30}); ok {
+		t.Error("expected adjacent windows not to overlap")
+	}
+	if w.String() != "00:00:10.000-00:00:20.000" {
+		t.Errorf("unexpected string form %s", w.String())
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"90", 90},
+		{"12.5", 12.5},
+		{"01:30:00.250", 5400.25},
+		{"05:00", 300},
+		{"1h30m", 5400},
+		{"45s", 45},
+	}
+
+	for _, tt := range tests {
+		result, err := parseTimestamp(tt.input)
+		if err != nil {
+			t.Errorf("failed to parse %s: %v", tt.input, err)
+		}
+		if result != tt.expected {
+			t.Errorf("for %s, expected %f, got %f", tt.input, tt.expected, result)
+		}
+	}
+}
+
+func TestParseTimestampOutOfRange(t *testing.T) {
+	tests := []struct {
+		input string
+		valid bool
+	}{
+		{"00:59:59.999", true},
+		{"59:59", true},
+		{"100:00:00", true},
+		{"00:75:99", false},
+		{"00:60:00", false},
+		{"00:00:60", false},
+		{"00:00:60.000", false},
+		{"00:00:99.5", false},
+		{"60:00", false},
+		{"05:75", false},
+	}
+	for _, tt := range tests {
+		_, err := parseTimestamp(tt.input)
+		if tt.valid && err != nil {
+			t.Errorf("expected %s to parse, got %v", tt.input, err)
+		}
+		if !tt.valid && (err == nil || !strings.Contains(err.Error(), "below 60")) {
+			t.Errorf("expected %s to be rejected as out of range, got %v", tt.input, err)
+		}
+	}
+}
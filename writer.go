@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strings"
+)
+
+// DeliveryProfile is the formatting spec cues are conformed to when written back out
+type DeliveryProfile struct {
+	Name          string
+	MaxLineLength int     // characters per line before text is wrapped
+	MinGap        float64 // seconds required between the end of a cue and the next start
+	FrameRate     float64 // cue times are rounded to frame boundaries (0 keeps milliseconds)
+	Punctuation   PunctuationStyle
+	Font          string             // typeface lines are measured in for line_overflow
+	FontSize      float64            // pixels on a 1920x1080 frame; a typical MaxLineLength line fits the safe area
+	Percentiles   []PercentileTarget // cue statistic targets validation checks files against
+}
+
+// deliveryProfiles are the built-in profiles selectable with conform -profile
+var deliveryProfiles = map[string]DeliveryProfile{
+	"netflix": {Name: "netflix", MaxLineLength: 42, MinGap: 2.0 / 24, FrameRate: 24,
+		Punctuation: PunctuationStyle{Quotes: QuotesStraight, Dash: DashEm, Ellipsis: EllipsisCharacter},
+		Font:        "arial", FontSize: 66,
+		Percentiles: []PercentileTarget{{"cps", 95, "<=", 20}, {"duration", 50, ">=", 1.5}}},
+	"bbc": {Name: "bbc", MaxLineLength: 37, MinGap: 1.0 / 25, FrameRate: 25,
+		Punctuation: PunctuationStyle{Quotes: QuotesCurly, Dash: DashEm, Ellipsis: EllipsisDots},
+		Font:        "arial", FontSize: 75},
+	// Line 21 has no dash or ellipsis characters, so 608 text sticks to ASCII. Its
+	// 32 monospaced columns fill the 80% safe width exactly at 80px Courier.
+	"cea608": {Name: "cea608", MaxLineLength: 32, MinGap: 2 * 1001.0 / 30000, FrameRate: 30000.0 / 1001,
+		Punctuation: PunctuationStyle{Quotes: QuotesStraight, Dash: DashDoubleHyphen, Ellipsis: EllipsisDots},
+		Font:        "courier", FontSize: 80},
+}
+
+// runConform implements the conform subcommand, writing a file's cues back out in a
+// delivery profile's format
+func runConform(args []string) {
+	fs := flag.NewFlagSet("conform", flag.ExitOnError)
+	profileName := fs.String("profile", "netflix", "Delivery profile: "+strings.Join(slices.Sorted(maps.Keys(deliveryProfiles)), ", "))
+	format := fs.String("format", "", "Output format: srt or webvtt (defaults to the input format)")
+	output := fs.String("o", "", "Output file (defaults to stdout)")
+	dedupe := fs.Bool("dedupe", false, "Drop cues that repeat an earlier cue's times and text exactly")
+	language := fs.String("language", "", "Caption language, e.g. ja-JP; languages with shorter lines override the profile's line length")
+	maxLineLength := fs.Int("max_line_length", 0, "Characters per line before text is wrapped (overrides -profile and -language)")
+	fs.Usage = commandUsage(fs, "conform", "conform [-profile name] [-language tag] [-format srt|webvtt] [-dedupe] [-o output] captions-filepath")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	profile, ok := deliveryProfiles[*profileName]
+	if !ok {
+		log.Fatalf("unknown delivery profile %q", *profileName)
+	}
+	if rules := languageRulesFor(*language); rules.MaxLineLength > 0 {
+		profile.MaxLineLength = rules.MaxLineLength
+	}
+	if *maxLineLength > 0 {
+		profile.MaxLineLength = *maxLineLength
+	}
+
+	cv := NewCaptionValidator("")
+	inputFormat, err := cv.detectFormat(fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *format == "" {
+		*format = inputFormat
+	}
+	if *format != "srt" && *format != "webvtt" {
+		log.Fatalf("unsupported output format %q", *format)
+	}
+
+	captions, failures, err := cv.parseFile(fs.Arg(0), inputFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(failures) > 0 {
+		log.Printf("Dropped %d blocks that could not be parsed", len(failures))
+	}
+	if *dedupe {
+		duplicates := findDuplicates(captions)
+		captions = removeDuplicates(captions, duplicates)
+		if len(duplicates) > 0 {
+			log.Printf("Dropped %d duplicate cues", len(duplicates))
+		}
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			log.Fatal(err)
+		}
+	}
+	err = WriteCues(out, Conform(captions, profile, *format), *format)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Conform returns a copy of captions formatted for profile: times rounded to frame
+// boundaries, each cue ended early enough to leave the minimum gap before the next,
+// and text wrapped at the maximum line length. SRT output keeps formatting tags.
+func Conform(captions []Caption, profile DeliveryProfile, format string) []Caption {
+	conformed := make([]Caption, len(captions))
+	for i, caption := range captions {
+		text := caption.Text
+		if format == "srt" && caption.Markup != "" {
+			text = caption.Markup
+		}
+		conformed[i] = Caption{
+			StartTime: roundToFrame(caption.StartTime, profile.FrameRate),
+			EndTime:   roundToFrame(caption.EndTime, profile.FrameRate),
+			Text:      wrapText(text, profile.MaxLineLength),
+		}
+	}
+
+	for i := 0; i+1 < len(conformed); i++ {
+		cue, next := &conformed[i], conformed[i+1]
+		if next.StartTime < cue.StartTime || next.StartTime-cue.EndTime >= profile.MinGap-1e-9 {
+			continue
+		}
+		// Never shorten a cue to nothing; overlapping cues are left for review
+		if end := roundToFrame(next.StartTime-profile.MinGap, profile.FrameRate); end > cue.StartTime {
+			cue.EndTime = end
+		}
+	}
+	return conformed
+}
+
+// roundToFrame rounds seconds to the nearest frame boundary
+func roundToFrame(seconds, frameRate float64) float64 {
+	if frameRate <= 0 {
+		return seconds
+	}
+	return math.Round(seconds*frameRate) / frameRate
+}
+
+// wrapText greedily wraps words into lines of at most width visible columns, with
+// fullwidth characters taking two; markup tags do not count towards the width.
+// Unspaced scripts wrap between characters. Words longer than width get a line of
+// their own.
+func wrapText(text string, width int) string {
+	words := breakTokens(text)
+	if width <= 0 {
+		return joinTokens(words)
+	}
+
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0
+	for _, word := range words {
+		wordWidth, space := textWidth(stripMarkup(word.text)), 0
+		if word.spaced {
+			space = 1
+		}
+		if lineWidth > 0 && lineWidth+space+wordWidth > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineWidth = 0
+		}
+		if lineWidth > 0 && word.spaced {
+			line.WriteByte(' ')
+			lineWidth++
+		}
+		line.WriteString(word.text)
+		lineWidth += wordWidth
+	}
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WriteCues writes captions as an SRT or WebVTT file
+func WriteCues(w io.Writer, captions []Caption, format string) error {
+	buf := bufio.NewWriter(w)
+	separator := "."
+	switch format {
+	case "webvtt":
+		buf.WriteString("WEBVTT\n\n")
+	case "srt":
+		separator = ","
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+
+	for i, caption := range captions {
+		if format == "srt" {
+			fmt.Fprintf(buf, "%d\n", i+1)
+		}
+		fmt.Fprintf(buf, "%s --> %s\n%s\n\n",
+			strings.Replace(formatTimestamp(caption.StartTime), ".", separator, 1),
+			strings.Replace(formatTimestamp(caption.EndTime), ".", separator, 1),
+			caption.Text)
+	}
+	return buf.Flush()
+}
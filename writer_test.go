@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConform(t *testing.T) {
+	profile := DeliveryProfile{MaxLineLength: 20, MinGap: 2.0 / 25, FrameRate: 25}
+	captions := []Caption{
+		{StartTime: 1.013, EndTime: 3.99, Text: "This line is far too long for the profile", Markup: "This line is far too <i>long</i> for the profile"},
+		{StartTime: 4.0, EndTime: 6.0, Text: "Short"},
+	}
+
+	conformed := Conform(captions, profile, "srt")
+	if conformed[0].StartTime != 1.0 {
+		t.Errorf("expected start rounded to frame 1.0, got %v", conformed[0].StartTime)
+	}
+	// 3.99 rounds to 4.0, leaving no gap before 4.0; the end moves back two frames
+	if conformed[0].EndTime != 3.92 {
+		t.Errorf("expected end 3.92 for a two-frame gap, got %v", conformed[0].EndTime)
+	}
+	if expected := "This line is far too\n<i>long</i> for the profile"; conformed[0].Text != expected {
+		t.Errorf("expected wrapped markup %q, got %q", expected, conformed[0].Text)
+	}
+	if captions[0].EndTime != 3.99 {
+		t.Error("Conform must not modify its input")
+	}
+
+	if conformed := Conform(captions, profile, "webvtt"); strings.Contains(conformed[0].Text, "<i>") {
+		t.Errorf("expected WebVTT output to use plain text, got %q", conformed[0].Text)
+	}
+}
+
+func TestWriteCuesRoundTrip(t *testing.T) {
+	captions := []Caption{
+		{StartTime: 1, EndTime: 2.5, Text: "Hello\nthere"},
+		{StartTime: 3, EndTime: 4, Text: "Bye"},
+	}
+	cv := NewCaptionValidator("")
+
+	for _, format := range []string{"srt", "webvtt"} {
+		var out strings.Builder
+		if err := WriteCues(&out, captions, format); err != nil {
+			t.Fatal(err)
+		}
+		parsed, failures, err := collectCues(cv.Cues(strings.NewReader(out.String()), format), len(captions))
+		if err != nil || len(failures) != 0 {
+			t.Fatalf("%s: failed to parse written cues: %v %+v\n%s", format, err, failures, out.String())
+		}
+		if len(parsed) != 2 || parsed[0].EndTime != 2.5 || parsed[0].Text != "Hello there" {
+			t.Errorf("%s: unexpected round trip %+v", format, parsed)
+		}
+	}
+
+	if err := WriteCues(&strings.Builder{}, captions, "ttml"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}